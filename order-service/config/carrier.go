@@ -0,0 +1,9 @@
+package config
+
+import "os"
+
+// CarrierWebhookSecret is the secret carriers send in the X-Webhook-Secret header of their
+// tracking updates. Without it every update is rejected.
+func CarrierWebhookSecret() string {
+	return os.Getenv("CARRIER_WEBHOOK_SECRET")
+}
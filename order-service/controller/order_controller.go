@@ -8,4 +8,6 @@ type OrderController interface {
 	Delete(c *fiber.Ctx) error
 	FindById(c *fiber.Ctx) error
 	FindAll(c *fiber.Ctx) error
+	CancelSubOrder(c *fiber.Ctx) error
+	UpdateShippingAddress(c *fiber.Ctx) error
 }
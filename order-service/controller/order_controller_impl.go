@@ -25,17 +25,20 @@ func (controller *OrderControllerImpl) Create(c *fiber.Ctx) error {
 		return helper.BadRequest(c, err.Error())
 	}
 
-	// Basic validation at controller level to avoid calling service with invalid input
-	if request.ItemName == "" {
-		return helper.BadRequest(c, "item name required")
-	}
-
-	if request.Quantity <= 0 {
-		return helper.BadRequest(c, "quantity must be greater than 0")
-	}
-
-	if request.Price <= 0 {
-		return helper.BadRequest(c, "price must be greater than 0")
+	// Basic validation at controller level to avoid calling service with invalid input. Line
+	// items of a multi-seller cart are checked by the service.
+	if len(request.Items) == 0 {
+		if request.ItemName == "" {
+			return helper.BadRequest(c, "item name required")
+		}
+
+		if request.Quantity <= 0 {
+			return helper.BadRequest(c, "quantity must be greater than 0")
+		}
+
+		if request.Price <= 0 {
+			return helper.BadRequest(c, "price must be greater than 0")
+		}
 	}
 
 	order, err := controller.orderService.Create(c.Context(), request)
@@ -113,3 +116,36 @@ func (controller *OrderControllerImpl) FindAll(c *fiber.Ctx) error {
 
 	return c.JSON(fiber.Map{"data": response})
 }
+
+func (controller *OrderControllerImpl) CancelSubOrder(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid UUID")
+	}
+
+	order, err := controller.orderService.CancelSubOrder(c.Context(), orderId)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToOrderResponse(order))
+}
+
+func (controller *OrderControllerImpl) UpdateShippingAddress(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid UUID")
+	}
+
+	request := web.AddressRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	order, err := controller.orderService.UpdateShippingAddress(c.Context(), orderId, request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToOrderResponse(order))
+}
@@ -0,0 +1,12 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type ShipmentController interface {
+	Create(c *fiber.Ctx) error
+	UpdateStatus(c *fiber.Ctx) error
+	FindById(c *fiber.Ctx) error
+	FindByOrderId(c *fiber.Ctx) error
+	UpdateFulfillment(c *fiber.Ctx) error
+	HandleCarrierWebhook(c *fiber.Ctx) error
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"errors"
+	"order-service/exception"
+	"order-service/helper"
+	"order-service/models/web"
+	"order-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ShipmentControllerImpl struct {
+	shipmentService service.ShipmentService
+}
+
+func NewShipmentController(shipmentService service.ShipmentService) ShipmentController {
+	return &ShipmentControllerImpl{
+		shipmentService: shipmentService,
+	}
+}
+
+func (controller *ShipmentControllerImpl) Create(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid UUID")
+	}
+
+	request := web.ShipmentCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	shipment, err := controller.shipmentService.Create(c.Context(), orderId, request)
+	return shipmentResponse(c, shipment, err)
+}
+
+func (controller *ShipmentControllerImpl) UpdateStatus(c *fiber.Ctx) error {
+	shipmentId := c.Params("shipmentId")
+	if _, err := uuid.Parse(shipmentId); err != nil {
+		return helper.BadRequest(c, "invalid UUID")
+	}
+
+	request := web.ShipmentUpdateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	shipment, err := controller.shipmentService.UpdateStatus(c.Context(), shipmentId, request)
+	return shipmentResponse(c, shipment, err)
+}
+
+func (controller *ShipmentControllerImpl) FindById(c *fiber.Ctx) error {
+	shipmentId := c.Params("shipmentId")
+	if _, err := uuid.Parse(shipmentId); err != nil {
+		return helper.BadRequest(c, "invalid UUID")
+	}
+
+	shipment, err := controller.shipmentService.FindById(c.Context(), shipmentId)
+	return shipmentResponse(c, shipment, err)
+}
+
+func (controller *ShipmentControllerImpl) FindByOrderId(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid UUID")
+	}
+
+	shipments, err := controller.shipmentService.FindByOrderId(c.Context(), orderId)
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, shipments)
+}
+
+func (controller *ShipmentControllerImpl) UpdateFulfillment(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid UUID")
+	}
+
+	request := web.OrderFulfillmentRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	order, err := controller.shipmentService.UpdateFulfillment(c.Context(), orderId, request)
+	if err != nil {
+		return shipmentResponse(c, nil, err)
+	}
+
+	return helper.ResponseSuccess(c, helper.ToOrderResponse(order))
+}
+
+// HandleCarrierWebhook receives tracking updates pushed by a carrier. The route checks the
+// carrier's shared secret before it gets here.
+func (controller *ShipmentControllerImpl) HandleCarrierWebhook(c *fiber.Ctx) error {
+	request := web.CarrierWebhookRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, "invalid payload")
+	}
+
+	shipment, err := controller.shipmentService.HandleCarrierWebhook(c.Context(), c.Params("carrier"), request)
+	return shipmentResponse(c, shipment, err)
+}
+
+func shipmentResponse(c *fiber.Ctx, data interface{}, err error) error {
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, data)
+}
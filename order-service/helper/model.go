@@ -3,18 +3,28 @@ package helper
 import (
 	"order-service/models/domain"
 	"order-service/models/web"
+	"strings"
 )
 
 func ToOrderResponse(order domain.Order) web.OrderResponse {
 	return web.OrderResponse{
-		Id:          order.ID,
-		ItemName:    order.ItemName,
-		Quantity:    order.Quantity,
-		Price:       order.Price,
-		TotalAmount: order.TotalAmount,
-		Status:      order.Status,
-		CreatedAt:   order.CreatedAt,
-		UpdatedAt:   order.UpdatedAt,
+		Id:              order.ID,
+		ItemName:        order.ItemName,
+		Quantity:        order.Quantity,
+		Price:           order.Price,
+		TotalAmount:     order.TotalAmount,
+		Currency:        order.Currency,
+		Status:          order.Status,
+		SubscriptionID:  order.SubscriptionID,
+		SplitBySeller:   order.SplitBySeller,
+		ParentID:        order.ParentID,
+		SellerID:        order.SellerID,
+		ShippingAddress: order.ShippingAddress,
+		RefundedAmount:  order.RefundedAmount,
+		CreatedAt:       order.CreatedAt,
+		UpdatedAt:       order.UpdatedAt,
+		Items:           order.Items,
+		SubOrders:       ToOrderResponses(order.SubOrders),
 	}
 }
 
@@ -26,3 +36,21 @@ func ToOrderResponses(orders []domain.Order) []web.OrderResponse {
 
 	return orderResponses
 }
+
+// ToAddress normalizes a shipping address; addresses without a country are in Indonesia.
+func ToAddress(request web.AddressRequest) domain.Address {
+	country := strings.ToUpper(request.Country)
+	if country == "" {
+		country = "ID"
+	}
+
+	return domain.Address{
+		RecipientName: request.RecipientName,
+		Phone:         request.Phone,
+		Street:        request.Street,
+		City:          request.City,
+		Province:      request.Province,
+		PostalCode:    request.PostalCode,
+		Country:       country,
+	}
+}
@@ -14,6 +14,14 @@ func BadRequest(c *fiber.Ctx, message string) error {
 	})
 }
 
+func NotFound(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusNotFound).JSON(web.WebResponse{
+		Code:   fiber.StatusNotFound,
+		Status: "NOT FOUND",
+		Data:   message,
+	})
+}
+
 func ResponseSuccess(c *fiber.Ctx, data interface{}) error {
 	return c.Status(fiber.StatusOK).JSON(web.WebResponse{
 		Code:   fiber.StatusOK,
@@ -29,3 +37,11 @@ func InternalServerError(c *fiber.Ctx, message string) error {
 		Data:   message,
 	})
 }
+
+func Unauthorized(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(web.WebResponse{
+		Code:   fiber.StatusUnauthorized,
+		Status: "UNAUTHORIZED",
+		Data:   message,
+	})
+}
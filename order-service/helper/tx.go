@@ -2,10 +2,18 @@ package helper
 
 import "gorm.io/gorm"
 
-func CommitOrRollback(tx *gorm.DB) {
+// CommitOrRollback ends tx when the caller returns. A panic rolls it back; so does a non-nil
+// error in err, which callers that write inside tx pass as a pointer to their named error result.
+func CommitOrRollback(tx *gorm.DB, err ...*error) {
 	if r := recover(); r != nil {
 		tx.Rollback()
 		panic(r)
 	}
+	for _, e := range err {
+		if *e != nil {
+			tx.Rollback()
+			return
+		}
+	}
 	tx.Commit()
 }
@@ -26,16 +26,20 @@ func main() {
 	})
 
 	db := config.NewDB()
-	db.AutoMigrate(&domain.Order{})
+	db.AutoMigrate(&domain.Order{}, &domain.OrderItem{}, &domain.Shipment{})
 	validate := validator.New()
 
 	orderRepository := repository.NewOrderRepository(db)
 	orderService := service.NewOrderService(orderRepository, db, validate)
 	orderController := controller.NewOrderController(orderService)
 	paymentCallbackController := controller.NewPaymentCallbackController(orderService)
+	shipmentRepository := repository.NewShipmentRepository(db)
+	shipmentService := service.NewShipmentService(shipmentRepository, orderRepository, db, validate)
+	shipmentController := controller.NewShipmentController(shipmentService)
 
 	routes.OrderRoutes(app, orderController)
 	routes.PaymentCallbackRoutes(app, *paymentCallbackController)
+	routes.ShipmentRoutes(app, shipmentController, config.CarrierWebhookSecret())
 
 	app.Listen(":3000")
 }
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"order-service/helper"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookSecretHeader carries the secret shared with a carrier on each of its updates.
+const WebhookSecretHeader = "X-Webhook-Secret"
+
+// WebhookSecret only lets through requests that carry secret. Without a configured secret every
+// request is rejected, so a deployment that was never given one cannot be driven by anyone who
+// finds the URL.
+func WebhookSecret(secret string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if secret == "" || subtle.ConstantTimeCompare([]byte(c.Get(WebhookSecretHeader)), []byte(secret)) != 1 {
+			return helper.Unauthorized(c, "invalid webhook secret")
+		}
+
+		return c.Next()
+	}
+}
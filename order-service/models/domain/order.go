@@ -8,14 +8,27 @@ import (
 )
 
 type Order struct {
-	ID          uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	ItemName    string         `json:"item_name"`
-	Quantity    int            `json:"quantity"`
-	Price       int64          `json:"price"`
-	TotalAmount int64          `json:"total_amount"`
-	Status      string         `gorm:"type:varchar(50);default:'pending'" json:"status"`
-	PaymentID   *uuid.UUID     `gorm:"type:uuid" json:"payment_id"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ItemName    string     `json:"item_name"`
+	Quantity    int        `json:"quantity"`
+	Price       int64      `json:"price"`
+	TotalAmount int64      `json:"total_amount"`
+	Currency    string     `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+	Status      string     `gorm:"type:varchar(50);default:'pending'" json:"status"`
+	PaymentID   *uuid.UUID `gorm:"type:uuid" json:"payment_id"`
+	// SubscriptionID links the orders generated by payment-service's subscription billing.
+	SubscriptionID *uuid.UUID `gorm:"type:uuid;index" json:"subscription_id"`
+	// SplitBySeller marks an order whose line items were split into one sub-order per seller.
+	// The customer pays the parent; the sellers fulfil the sub-orders.
+	SplitBySeller   bool       `gorm:"not null;default:false" json:"split_by_seller"`
+	ParentID        *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
+	SellerID        string     `gorm:"type:varchar(100);index" json:"seller_id"`
+	ShippingAddress *Address   `gorm:"embedded;embeddedPrefix:shipping_" json:"shipping_address"`
+	// RefundedAmount is what was given back for cancelled sub-orders.
+	RefundedAmount int64          `gorm:"not null;default:0" json:"refunded_amount"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+	Items          []OrderItem    `gorm:"-" json:"items,omitempty"`
+	SubOrders      []Order        `gorm:"-" json:"sub_orders,omitempty"`
 }
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderItem is a line item of a seller's sub-order.
+type OrderItem struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID   uuid.UUID `gorm:"type:uuid;index;not null" json:"order_id"`
+	SellerID  string    `gorm:"type:varchar(100);not null" json:"seller_id"`
+	ItemName  string    `gorm:"not null" json:"item_name"`
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	Price     int64     `gorm:"not null" json:"price"`
+	Subtotal  int64     `gorm:"not null" json:"subtotal"`
+	CreatedAt time.Time `json:"created_at"`
+}
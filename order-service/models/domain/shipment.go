@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Shipment statuses, in the order a parcel moves through them. The order being shipped takes the
+// same status.
+const (
+	ShipmentPacked    = "packed"
+	ShipmentShipped   = "shipped"
+	ShipmentDelivered = "delivered"
+	ShipmentReturned  = "returned"
+)
+
+// Address is where an order is delivered.
+type Address struct {
+	RecipientName string `gorm:"type:varchar(100)" json:"recipient_name"`
+	Phone         string `gorm:"type:varchar(20)" json:"phone"`
+	Street        string `json:"street"`
+	City          string `gorm:"type:varchar(100)" json:"city"`
+	Province      string `gorm:"type:varchar(100)" json:"province"`
+	PostalCode    string `gorm:"type:varchar(10)" json:"postal_code"`
+	Country       string `gorm:"type:varchar(2)" json:"country"`
+}
+
+// Shipment is the parcel a paid order, or a seller's sub-order, is sent in.
+type Shipment struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID        uuid.UUID  `gorm:"type:uuid;index;not null" json:"order_id"`
+	Carrier        string     `gorm:"type:varchar(50);not null;index:idx_shipments_tracking" json:"carrier"`
+	TrackingNumber string     `gorm:"type:varchar(100);index:idx_shipments_tracking" json:"tracking_number"`
+	Status         string     `gorm:"type:varchar(20);not null" json:"status"`
+	PackedAt       *time.Time `json:"packed_at"`
+	ShippedAt      *time.Time `json:"shipped_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	ReturnedAt     *time.Time `json:"returned_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
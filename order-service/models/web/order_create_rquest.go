@@ -1,7 +1,21 @@
 package web
 
+// OrderCreateRequest places a single-item order, or with Items a multi-seller cart that is split
+// into one sub-order per seller.
 type OrderCreateRequest struct {
+	ItemName string `validate:"required_without=Items"`
+	Quantity int    `validate:"required_without=Items,omitempty,gt=0"`
+	Price    int64  `validate:"required_without=Items,omitempty,gt=0"`
+	Currency string `validate:"omitempty,len=3"`
+	// SubscriptionID is set by payment-service for orders generated by a subscription cycle.
+	SubscriptionID  string             `validate:"omitempty,uuid"`
+	Items           []OrderItemRequest `validate:"omitempty,dive"`
+	ShippingAddress *AddressRequest
+}
+
+type OrderItemRequest struct {
 	ItemName string `validate:"required"`
 	Quantity int    `validate:"required,gt=0"`
 	Price    int64  `validate:"required,gt=0"`
+	SellerID string `validate:"required,max=100"`
 }
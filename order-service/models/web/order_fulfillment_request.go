@@ -0,0 +1,9 @@
+package web
+
+// OrderFulfillmentRequest is the body of the older fulfillment endpoint, kept on top of
+// shipments. Carrier and TrackingNumber are needed when the order has not been packed yet.
+type OrderFulfillmentRequest struct {
+	Status         string `validate:"required,oneof=shipped delivered"`
+	Carrier        string `validate:"max=50"`
+	TrackingNumber string `validate:"max=100"`
+}
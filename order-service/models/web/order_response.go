@@ -1,18 +1,28 @@
 package web
 
 import (
+	"order-service/models/domain"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type OrderResponse struct {
-	Id          uuid.UUID `json:"id"`
-	ItemName    string    `json:"item_name"`
-	Quantity    int       `json:"quantity"`
-	Price       int64     `json:"price"`
-	TotalAmount int64     `json:"total_amount"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Id              uuid.UUID          `json:"id"`
+	ItemName        string             `json:"item_name"`
+	Quantity        int                `json:"quantity"`
+	Price           int64              `json:"price"`
+	TotalAmount     int64              `json:"total_amount"`
+	Currency        string             `json:"currency"`
+	Status          string             `json:"status"`
+	SubscriptionID  *uuid.UUID         `json:"subscription_id,omitempty"`
+	SplitBySeller   bool               `json:"split_by_seller,omitempty"`
+	ParentID        *uuid.UUID         `json:"parent_id,omitempty"`
+	SellerID        string             `json:"seller_id,omitempty"`
+	ShippingAddress *domain.Address    `json:"shipping_address,omitempty"`
+	RefundedAmount  int64              `json:"refunded_amount,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	Items           []domain.OrderItem `json:"items,omitempty"`
+	SubOrders       []OrderResponse    `json:"sub_orders,omitempty"`
 }
@@ -0,0 +1,28 @@
+package web
+
+type AddressRequest struct {
+	RecipientName string `validate:"required,max=100"`
+	Phone         string `validate:"required,max=20"`
+	Street        string `validate:"required,max=255"`
+	City          string `validate:"required,max=100"`
+	Province      string `validate:"max=100"`
+	PostalCode    string `validate:"required,max=10"`
+	Country       string `validate:"omitempty,len=2"`
+}
+
+type ShipmentCreateRequest struct {
+	Carrier        string `validate:"required,max=50"`
+	TrackingNumber string `validate:"max=100"`
+}
+
+type ShipmentUpdateRequest struct {
+	Status         string `validate:"required,oneof=shipped delivered returned"`
+	TrackingNumber string `validate:"max=100"`
+}
+
+// CarrierWebhookRequest is a tracking update pushed by a carrier. Status is the carrier's own
+// word for it.
+type CarrierWebhookRequest struct {
+	TrackingNumber string `json:"tracking_number" validate:"required,max=100"`
+	Status         string `json:"status" validate:"required"`
+}
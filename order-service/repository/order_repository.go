@@ -13,4 +13,8 @@ type OrderRepository interface {
 	Delete(ctx context.Context, tx *gorm.DB, orderId string) error
 	FindById(ctx context.Context, tx *gorm.DB, orderId string) (domain.Order, error)
 	FindByAll(ctx context.Context, tx *gorm.DB) ([]domain.Order, error)
+	UpdateShippingAddress(ctx context.Context, tx *gorm.DB, order domain.Order) (domain.Order, error)
+	FindByParentId(ctx context.Context, tx *gorm.DB, parentId string) ([]domain.Order, error)
+	SaveItems(ctx context.Context, tx *gorm.DB, items []domain.OrderItem) error
+	FindItems(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.OrderItem, error)
 }
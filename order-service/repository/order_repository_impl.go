@@ -25,13 +25,29 @@ func (repository *OrderRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, or
 
 func (repository *OrderRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, order domain.Order) (domain.Order, error) {
 	err := tx.WithContext(ctx).Model(domain.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
-		"item_name":    order.ItemName,
-		"quantity":     order.Quantity,
-		"price":        order.Price,
-		"total_amount": order.TotalAmount,
-		"status":       order.Status,
-		"payment_id":   order.PaymentID,
-		"updated_at":   time.Now(),
+		"item_name":       order.ItemName,
+		"quantity":        order.Quantity,
+		"price":           order.Price,
+		"total_amount":    order.TotalAmount,
+		"status":          order.Status,
+		"payment_id":      order.PaymentID,
+		"refunded_amount": order.RefundedAmount,
+		"updated_at":      time.Now(),
+	}).Error
+	return order, err
+}
+
+func (repository *OrderRepositoryImpl) UpdateShippingAddress(ctx context.Context, tx *gorm.DB, order domain.Order) (domain.Order, error) {
+	address := order.ShippingAddress
+	err := tx.WithContext(ctx).Model(domain.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"shipping_recipient_name": address.RecipientName,
+		"shipping_phone":          address.Phone,
+		"shipping_street":         address.Street,
+		"shipping_city":           address.City,
+		"shipping_province":       address.Province,
+		"shipping_postal_code":    address.PostalCode,
+		"shipping_country":        address.Country,
+		"updated_at":              time.Now(),
 	}).Error
 	return order, err
 }
@@ -57,3 +73,22 @@ func (repository *OrderRepositoryImpl) FindByAll(ctx context.Context, tx *gorm.D
 
 	return orders, err
 }
+
+// FindByParentId returns the sub-orders of a split order, one per seller.
+func (repository *OrderRepositoryImpl) FindByParentId(ctx context.Context, tx *gorm.DB, parentId string) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := tx.WithContext(ctx).Where("parent_id = ?", parentId).Order("seller_id").Find(&orders).Error
+
+	return orders, err
+}
+
+func (repository *OrderRepositoryImpl) SaveItems(ctx context.Context, tx *gorm.DB, items []domain.OrderItem) error {
+	return tx.WithContext(ctx).Create(&items).Error
+}
+
+func (repository *OrderRepositoryImpl) FindItems(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.OrderItem, error) {
+	var items []domain.OrderItem
+	err := tx.WithContext(ctx).Where("order_id = ?", orderId).Order("created_at, item_name").Find(&items).Error
+
+	return items, err
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"order-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type ShipmentRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, shipment domain.Shipment) (domain.Shipment, error)
+	Update(ctx context.Context, tx *gorm.DB, shipment domain.Shipment) (domain.Shipment, error)
+	FindById(ctx context.Context, tx *gorm.DB, shipmentId string) (domain.Shipment, error)
+	FindByOrderId(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.Shipment, error)
+	FindByTracking(ctx context.Context, tx *gorm.DB, carrier string, trackingNumber string) (domain.Shipment, error)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"order-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ShipmentRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewShipmentRepository(db *gorm.DB) ShipmentRepository {
+	return &ShipmentRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *ShipmentRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, shipment domain.Shipment) (domain.Shipment, error) {
+	err := tx.WithContext(ctx).Create(&shipment).Error
+	return shipment, err
+}
+
+func (repository *ShipmentRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, shipment domain.Shipment) (domain.Shipment, error) {
+	shipment.UpdatedAt = time.Now()
+	err := tx.WithContext(ctx).Model(domain.Shipment{}).Where("id = ?", shipment.ID).Updates(map[string]interface{}{
+		"tracking_number": shipment.TrackingNumber,
+		"status":          shipment.Status,
+		"shipped_at":      shipment.ShippedAt,
+		"delivered_at":    shipment.DeliveredAt,
+		"returned_at":     shipment.ReturnedAt,
+		"updated_at":      shipment.UpdatedAt,
+	}).Error
+	return shipment, err
+}
+
+func (repository *ShipmentRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, shipmentId string) (domain.Shipment, error) {
+	var shipment domain.Shipment
+	err := tx.WithContext(ctx).Where("id = ?", shipmentId).First(&shipment).Error
+
+	return shipment, err
+}
+
+func (repository *ShipmentRepositoryImpl) FindByOrderId(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.Shipment, error) {
+	var shipments []domain.Shipment
+	err := tx.WithContext(ctx).Where("order_id = ?", orderId).Order("created_at").Find(&shipments).Error
+
+	return shipments, err
+}
+
+// FindByTracking finds the shipment a carrier knows by trackingNumber.
+func (repository *ShipmentRepositoryImpl) FindByTracking(ctx context.Context, tx *gorm.DB, carrier string, trackingNumber string) (domain.Shipment, error) {
+	var shipment domain.Shipment
+	err := tx.WithContext(ctx).Where("carrier = ? AND tracking_number = ?", carrier, trackingNumber).First(&shipment).Error
+
+	return shipment, err
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"order-service/controller"
+	"order-service/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -14,6 +15,22 @@ func OrderRoutes(app *fiber.App, orderController controller.OrderController) {
 	order.Post("/", orderController.Create)
 	order.Put("/:orderId", orderController.Update)
 	order.Delete("/:orderId", orderController.Delete)
+	order.Post("/:orderId/cancel", orderController.CancelSubOrder)
+	order.Put("/:orderId/shipping-address", orderController.UpdateShippingAddress)
+}
+
+// ShipmentRoutes registers the shipment endpoints. The carrier webhook needs webhookSecret.
+func ShipmentRoutes(app *fiber.App, shipmentController controller.ShipmentController, webhookSecret string) {
+	app.Post("/orders/:orderId/shipments", shipmentController.Create)
+	app.Get("/orders/:orderId/shipments", shipmentController.FindByOrderId)
+	app.Put("/orders/:orderId/fulfillment", shipmentController.UpdateFulfillment)
+
+	shipment := app.Group("/shipments")
+
+	shipment.Get("/:shipmentId", shipmentController.FindById)
+	shipment.Put("/:shipmentId/status", shipmentController.UpdateStatus)
+
+	app.Post("/webhooks/carriers/:carrier", middleware.WebhookSecret(webhookSecret), shipmentController.HandleCarrierWebhook)
 }
 
 func PaymentCallbackRoutes(app *fiber.App, callbackController controller.PaymentCallbackController) {
@@ -13,4 +13,6 @@ type OrderService interface {
 	FindById(ctx context.Context, orderId string) (domain.Order, error)
 	FindAll(ctx context.Context) ([]domain.Order, error)
 	ProcessPaymentCallback(ctx context.Context, request web.PaymentCallbackRequest) (domain.Order, error)
+	CancelSubOrder(ctx context.Context, orderId string) (domain.Order, error)
+	UpdateShippingAddress(ctx context.Context, orderId string, request web.AddressRequest) (domain.Order, error)
 }
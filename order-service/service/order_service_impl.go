@@ -3,15 +3,21 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"order-service/helper"
 	"order-service/models/domain"
 	"order-service/models/web"
 	"order-service/repository"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// defaultCurrency prices orders created without an explicit currency.
+const defaultCurrency = "IDR"
+
 type OrderServiceImpl struct {
 	OrderRepository repository.OrderRepository
 	DB              *gorm.DB
@@ -26,19 +32,41 @@ func NewOrderService(orderRepository repository.OrderRepository, DB *gorm.DB, va
 	}
 }
 
-func (service *OrderServiceImpl) Create(ctx context.Context, request web.OrderCreateRequest) (domain.Order, error) {
-	err := service.Validate.Struct(request)
+func (service *OrderServiceImpl) Create(ctx context.Context, request web.OrderCreateRequest) (_ domain.Order, err error) {
+	err = service.Validate.Struct(request)
 	helper.PanicIfError(err)
 
 	tx := service.DB.Begin()
-	defer helper.CommitOrRollback(tx)
+	defer helper.CommitOrRollback(tx, &err)
+
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	var address *domain.Address
+	if request.ShippingAddress != nil {
+		shippingAddress := helper.ToAddress(*request.ShippingAddress)
+		address = &shippingAddress
+	}
+
+	if len(request.Items) > 0 {
+		return service.createSplit(ctx, tx, request, currency, address)
+	}
 
 	order := domain.Order{
-		ItemName:    request.ItemName,
-		Quantity:    request.Quantity,
-		Price:       request.Price,
-		TotalAmount: request.Price * int64(request.Quantity),
-		Status:      "pending",
+		ItemName:        request.ItemName,
+		Quantity:        request.Quantity,
+		Price:           request.Price,
+		TotalAmount:     request.Price * int64(request.Quantity),
+		Currency:        currency,
+		Status:          "pending",
+		ShippingAddress: address,
+	}
+
+	if request.SubscriptionID != "" {
+		subscriptionId := uuid.MustParse(request.SubscriptionID)
+		order.SubscriptionID = &subscriptionId
 	}
 
 	created, err := service.OrderRepository.Save(ctx, tx, order)
@@ -49,13 +77,13 @@ func (service *OrderServiceImpl) Create(ctx context.Context, request web.OrderCr
 	return created, nil
 }
 
-func (service *OrderServiceImpl) Update(ctx context.Context, request web.OrderUpdateRequest) (domain.Order, error) {
+func (service *OrderServiceImpl) Update(ctx context.Context, request web.OrderUpdateRequest) (_ domain.Order, err error) {
 	if err := service.Validate.Struct(request); err != nil {
 		return domain.Order{}, err
 	}
 
 	tx := service.DB.Begin()
-	defer helper.CommitOrRollback(tx)
+	defer helper.CommitOrRollback(tx, &err)
 
 	order, err := service.OrderRepository.FindById(ctx, tx, request.ID.String())
 	if err != nil {
@@ -66,6 +94,10 @@ func (service *OrderServiceImpl) Update(ctx context.Context, request web.OrderUp
 		return domain.Order{}, errors.New("paid order cannot be updated")
 	}
 
+	if order.SplitBySeller || order.ParentID != nil {
+		return domain.Order{}, errors.New("orders split by seller cannot be updated")
+	}
+
 	if request.Quantity <= 0 {
 		return domain.Order{}, errors.New("quantity must be greater than 0")
 	}
@@ -87,9 +119,9 @@ func (service *OrderServiceImpl) Update(ctx context.Context, request web.OrderUp
 	return updated, err
 }
 
-func (service *OrderServiceImpl) Delete(ctx context.Context, orderId string) error {
+func (service *OrderServiceImpl) Delete(ctx context.Context, orderId string) (err error) {
 	tx := service.DB.Begin()
-	defer helper.CommitOrRollback(tx)
+	defer helper.CommitOrRollback(tx, &err)
 
 	order, err := service.OrderRepository.FindById(ctx, tx, orderId)
 	if err != nil {
@@ -100,10 +132,26 @@ func (service *OrderServiceImpl) Delete(ctx context.Context, orderId string) err
 		return errors.New("paid order cannot be deleted")
 	}
 
+	if order.ParentID != nil {
+		return errors.New("sub-orders are cancelled, not deleted")
+	}
+
 	if err := service.OrderRepository.Delete(ctx, tx, orderId); err != nil {
 		return err
 	}
 
+	if order.SplitBySeller {
+		subOrders, err := service.OrderRepository.FindByParentId(ctx, tx, orderId)
+		if err != nil {
+			return err
+		}
+		for _, subOrder := range subOrders {
+			if err := service.OrderRepository.Delete(ctx, tx, subOrder.ID.String()); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -116,6 +164,10 @@ func (service *OrderServiceImpl) FindById(ctx context.Context, orderId string) (
 		return domain.Order{}, err
 	}
 
+	if err := service.loadLines(ctx, tx, &order); err != nil {
+		return domain.Order{}, err
+	}
+
 	return order, err
 }
 
@@ -131,18 +183,352 @@ func (service *OrderServiceImpl) FindAll(ctx context.Context) ([]domain.Order, e
 	return orders, err
 }
 
-func (service *OrderServiceImpl) ProcessPaymentCallback(ctx context.Context, request web.PaymentCallbackRequest) (domain.Order, error) {
+func (service *OrderServiceImpl) ProcessPaymentCallback(ctx context.Context, request web.PaymentCallbackRequest) (_ domain.Order, err error) {
 	tx := service.DB.Begin()
-	defer helper.CommitOrRollback(tx)
+	defer helper.CommitOrRollback(tx, &err)
 
 	order, err := service.OrderRepository.FindById(ctx, tx, request.OrderID.String())
 	if err != nil {
 		return domain.Order{}, err
 	}
 
-	if request.PaymentStatus == "success" {
+	// A redelivered success callback must not undo the shipping of the order.
+	if request.PaymentStatus == "success" && !fulfillmentStatuses[order.Status] {
 		order.Status = "paid"
+		order.PaymentID = &request.PaymentID
+	}
+
+	// An installment or a split tender was paid but more is due before the order is paid in full.
+	// A late partial callback arriving after the final one leaves the order paid.
+	if request.PaymentStatus == "partially_paid" && order.Status != "paid" && !fulfillmentStatuses[order.Status] {
+		order.Status = "partially_paid"
+	}
+
+	// The courier could not collect the cash on delivery, or dunning ran out of retries, so the
+	// order will not be fulfilled. An order already on its way is settled through its shipment.
+	if request.PaymentStatus == "cancelled" && !fulfillmentStatuses[order.Status] {
+		order.Status = "cancelled"
+	}
+
+	// A lost dispute reversed the payment; flag the order for follow-up.
+	if request.PaymentStatus == "charged_back" {
+		order.Status = "charged_back"
+	}
+
+	if order.SplitBySeller {
+		return service.settleSubOrders(ctx, tx, order)
 	}
 
 	return service.OrderRepository.Update(ctx, tx, order)
 }
+
+// UpdateShippingAddress sets where an order is delivered, until it has been packed. The sub-orders
+// of a split order are shipped to the address of the order.
+func (service *OrderServiceImpl) UpdateShippingAddress(ctx context.Context, orderId string, request web.AddressRequest) (_ domain.Order, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Order{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	order, err := service.OrderRepository.FindById(ctx, tx, orderId)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if order.ParentID != nil {
+		return domain.Order{}, errors.New("sub-orders are shipped to the address of their order")
+	}
+
+	switch order.Status {
+	case "pending", "partially_paid", "paid":
+	default:
+		return domain.Order{}, fmt.Errorf("%s order cannot change its shipping address", order.Status)
+	}
+
+	address := helper.ToAddress(request)
+	order.ShippingAddress = &address
+	updated, err := service.OrderRepository.UpdateShippingAddress(ctx, tx, order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if order.SplitBySeller {
+		subOrders, err := service.OrderRepository.FindByParentId(ctx, tx, orderId)
+		if err != nil {
+			return domain.Order{}, err
+		}
+		for _, subOrder := range subOrders {
+			subOrder.ShippingAddress = &address
+			if _, err := service.OrderRepository.UpdateShippingAddress(ctx, tx, subOrder); err != nil {
+				return domain.Order{}, err
+			}
+		}
+	}
+
+	return updated, nil
+}
+
+// CancelSubOrder cancels one seller's part of a split order. Before payment the parent total
+// shrinks by the sub-order; after payment the sub-order is refunded and only its seller gives the
+// sale back. A paid sub-order is marked cancelling before payment service is asked for the
+// refund, so a refund that fails or whose answer is lost is sent again, under the same
+// idempotency key, by cancelling it again.
+func (service *OrderServiceImpl) CancelSubOrder(ctx context.Context, orderId string) (domain.Order, error) {
+	order, paymentId, err := service.startCancel(ctx, orderId)
+	if err != nil || paymentId == nil {
+		return order, err
+	}
+
+	if err := refundPayment(ctx, *paymentId, order.TotalAmount, order.SellerID, "cancel-"+order.ID.String()); err != nil {
+		return domain.Order{}, err
+	}
+
+	return service.finishCancel(ctx, orderId)
+}
+
+// startCancel cancels a pending sub-order outright. A paid one is marked cancelling instead and
+// the payment to refund it from is returned.
+func (service *OrderServiceImpl) startCancel(ctx context.Context, orderId string) (_ domain.Order, _ *uuid.UUID, err error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	order, err := service.OrderRepository.FindById(ctx, tx, orderId)
+	if err != nil {
+		return domain.Order{}, nil, err
+	}
+
+	if order.ParentID == nil {
+		return domain.Order{}, nil, errors.New("only sub-orders can be cancelled on their own")
+	}
+
+	parent, err := service.OrderRepository.FindById(ctx, tx, order.ParentID.String())
+	if err != nil {
+		return domain.Order{}, nil, err
+	}
+
+	switch order.Status {
+	case "pending":
+		// Payments already made toward the total would no longer add up to it.
+		if parent.Status == "partially_paid" {
+			return domain.Order{}, nil, errors.New("sub-orders of a partially paid order cannot be cancelled")
+		}
+	case "paid", "cancelling":
+		if parent.PaymentID == nil {
+			return domain.Order{}, nil, errors.New("order has no payment to refund")
+		}
+		if order.Status == "paid" {
+			order.Status = "cancelling"
+			if order, err = service.OrderRepository.Update(ctx, tx, order); err != nil {
+				return domain.Order{}, nil, err
+			}
+		}
+		return order, parent.PaymentID, nil
+	default:
+		return domain.Order{}, nil, fmt.Errorf("%s sub-order cannot be cancelled", order.Status)
+	}
+
+	order.Status = "cancelled"
+	updated, err := service.OrderRepository.Update(ctx, tx, order)
+	if err != nil {
+		return domain.Order{}, nil, err
+	}
+
+	parent.TotalAmount -= order.TotalAmount
+	if _, err := service.OrderRepository.Update(ctx, tx, parent); err != nil {
+		return domain.Order{}, nil, err
+	}
+	if _, err := aggregateParent(ctx, tx, service.OrderRepository, parent.ID); err != nil {
+		return domain.Order{}, nil, err
+	}
+
+	return updated, nil, nil
+}
+
+// finishCancel records the refund of a cancelling sub-order and cancels it.
+func (service *OrderServiceImpl) finishCancel(ctx context.Context, orderId string) (_ domain.Order, err error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	order, err := service.OrderRepository.FindById(ctx, tx, orderId)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	// A concurrent retry got here first.
+	if order.Status != "cancelling" {
+		return order, nil
+	}
+
+	parent, err := service.OrderRepository.FindById(ctx, tx, order.ParentID.String())
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	order.Status = "cancelled"
+	order.RefundedAmount = order.TotalAmount
+	updated, err := service.OrderRepository.Update(ctx, tx, order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	parent.RefundedAmount += order.TotalAmount
+	if _, err := service.OrderRepository.Update(ctx, tx, parent); err != nil {
+		return domain.Order{}, err
+	}
+	if _, err := aggregateParent(ctx, tx, service.OrderRepository, parent.ID); err != nil {
+		return domain.Order{}, err
+	}
+
+	return updated, nil
+}
+
+// createSplit places a multi-seller cart. The parent carries the total the customer pays and
+// each seller gets a sub-order with their line items.
+func (service *OrderServiceImpl) createSplit(ctx context.Context, tx *gorm.DB, request web.OrderCreateRequest, currency string, address *domain.Address) (domain.Order, error) {
+	parent := domain.Order{
+		ID:              uuid.New(),
+		Currency:        currency,
+		Status:          "pending",
+		SplitBySeller:   true,
+		ShippingAddress: address,
+	}
+	if request.SubscriptionID != "" {
+		subscriptionId := uuid.MustParse(request.SubscriptionID)
+		parent.SubscriptionID = &subscriptionId
+	}
+
+	items := []domain.OrderItem{}
+	subOrders := []domain.Order{}
+	for _, line := range request.Items {
+		index := -1
+		for i, subOrder := range subOrders {
+			if subOrder.SellerID == line.SellerID {
+				index = i
+			}
+		}
+		if index < 0 {
+			subOrders = append(subOrders, domain.Order{
+				ID:              uuid.New(),
+				ParentID:        &parent.ID,
+				SellerID:        line.SellerID,
+				Currency:        currency,
+				Status:          "pending",
+				ShippingAddress: address,
+			})
+			index = len(subOrders) - 1
+		}
+
+		item := domain.OrderItem{
+			ID:       uuid.New(),
+			OrderID:  subOrders[index].ID,
+			SellerID: line.SellerID,
+			ItemName: line.ItemName,
+			Quantity: line.Quantity,
+			Price:    line.Price,
+			Subtotal: line.Price * int64(line.Quantity),
+		}
+		items = append(items, item)
+		subOrders[index].Items = append(subOrders[index].Items, item)
+	}
+
+	summarize(&parent, items)
+	created, err := service.OrderRepository.Save(ctx, tx, parent)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	for i := range subOrders {
+		summarize(&subOrders[i], subOrders[i].Items)
+		lines := subOrders[i].Items
+		subOrders[i], err = service.OrderRepository.Save(ctx, tx, subOrders[i])
+		if err != nil {
+			return domain.Order{}, err
+		}
+		subOrders[i].Items = lines
+	}
+
+	if err := service.OrderRepository.SaveItems(ctx, tx, items); err != nil {
+		return domain.Order{}, err
+	}
+
+	created.SubOrders = subOrders
+	return created, nil
+}
+
+// summarize fills the single-item fields of an order from its line items: a lone item keeps its
+// name and price, several are named by their count.
+func summarize(order *domain.Order, items []domain.OrderItem) {
+	order.Quantity, order.TotalAmount = 0, 0
+	for _, item := range items {
+		order.Quantity += item.Quantity
+		order.TotalAmount += item.Subtotal
+	}
+
+	if len(items) == 1 {
+		order.ItemName = items[0].ItemName
+		order.Price = items[0].Price
+		return
+	}
+	order.ItemName = fmt.Sprintf("%d items", len(items))
+	order.Price = 0
+}
+
+// settleSubOrders applies a payment callback to a split order and its sub-orders. A completed
+// payment only moves the sub-orders still waiting for it, so a redelivered callback does not undo
+// their fulfillment.
+func (service *OrderServiceImpl) settleSubOrders(ctx context.Context, tx *gorm.DB, order domain.Order) (domain.Order, error) {
+	switch order.Status {
+	case "paid", "cancelled", "charged_back":
+	default:
+		return service.OrderRepository.Update(ctx, tx, order)
+	}
+
+	subOrders, err := service.OrderRepository.FindByParentId(ctx, tx, order.ID.String())
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	for _, subOrder := range subOrders {
+		if subOrder.Status == "cancelled" || (order.Status == "paid" && subOrder.Status != "pending") {
+			continue
+		}
+		subOrder.Status = order.Status
+		if _, err := service.OrderRepository.Update(ctx, tx, subOrder); err != nil {
+			return domain.Order{}, err
+		}
+	}
+
+	if _, err := service.OrderRepository.Update(ctx, tx, order); err != nil {
+		return domain.Order{}, err
+	}
+
+	return aggregateParent(ctx, tx, service.OrderRepository, order.ID)
+}
+
+// loadLines attaches the sub-orders of a split order, and the line items of a sub-order.
+func (service *OrderServiceImpl) loadLines(ctx context.Context, tx *gorm.DB, order *domain.Order) error {
+	if order.ParentID != nil {
+		items, err := service.OrderRepository.FindItems(ctx, tx, order.ID.String())
+		order.Items = items
+		return err
+	}
+
+	if !order.SplitBySeller {
+		return nil
+	}
+
+	subOrders, err := service.OrderRepository.FindByParentId(ctx, tx, order.ID.String())
+	if err != nil {
+		return err
+	}
+	for i := range subOrders {
+		if err := service.loadLines(ctx, tx, &subOrders[i]); err != nil {
+			return err
+		}
+	}
+	order.SubOrders = subOrders
+
+	return nil
+}
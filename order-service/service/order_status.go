@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"order-service/models/domain"
+	"order-service/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fulfillmentStatuses are the order statuses set by shipping rather than by payment.
+var fulfillmentStatuses = map[string]bool{
+	domain.ShipmentPacked:    true,
+	domain.ShipmentShipped:   true,
+	domain.ShipmentDelivered: true,
+	domain.ShipmentReturned:  true,
+	"partially_fulfilled":    true,
+}
+
+// followShipment moves an order to the status of its shipment. A sub-order moves its parent along
+// with it.
+func followShipment(ctx context.Context, tx *gorm.DB, orderRepository repository.OrderRepository, order domain.Order, status string) (domain.Order, error) {
+	order.Status = status
+	updated, err := orderRepository.Update(ctx, tx, order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if order.ParentID != nil {
+		if _, err := aggregateParent(ctx, tx, orderRepository, *order.ParentID); err != nil {
+			return domain.Order{}, err
+		}
+	}
+
+	return updated, nil
+}
+
+// aggregateParent derives a split order's status from its sub-orders and stores it.
+func aggregateParent(ctx context.Context, tx *gorm.DB, orderRepository repository.OrderRepository, parentId uuid.UUID) (domain.Order, error) {
+	parent, err := orderRepository.FindById(ctx, tx, parentId.String())
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	subOrders, err := orderRepository.FindByParentId(ctx, tx, parentId.String())
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	parent.Status = aggregateStatus(parent.Status, subOrders)
+	return orderRepository.Update(ctx, tx, parent)
+}
+
+// aggregateStatus returns the status a split order takes from its sub-orders. Cancelled
+// sub-orders do not count; while none of the others has been packed the order keeps the status its
+// payment gave it. Sub-orders on their way, delivered or returned make the order
+// partially_fulfilled until they all agree.
+func aggregateStatus(current string, subOrders []domain.Order) string {
+	counts := map[string]int{}
+	active := 0
+	for _, subOrder := range subOrders {
+		if subOrder.Status == "cancelled" {
+			continue
+		}
+		counts[subOrder.Status]++
+		active++
+	}
+
+	fulfilling := counts[domain.ShipmentPacked] + counts[domain.ShipmentShipped] + counts[domain.ShipmentDelivered] + counts[domain.ShipmentReturned]
+	switch {
+	case active == 0:
+		return "cancelled"
+	case counts[domain.ShipmentDelivered] == active:
+		return domain.ShipmentDelivered
+	case counts[domain.ShipmentReturned] == active:
+		return domain.ShipmentReturned
+	case counts[domain.ShipmentShipped]+counts[domain.ShipmentDelivered] == active:
+		return domain.ShipmentShipped
+	case counts[domain.ShipmentPacked] == active:
+		return domain.ShipmentPacked
+	case fulfilling > 0:
+		return "partially_fulfilled"
+	case counts["paid"] == active:
+		return "paid"
+	}
+	return current
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// getPaymentServiceURL returns the payment service base URL
+func getPaymentServiceURL() string {
+	url := os.Getenv("PAYMENT_SERVICE_URL")
+	return url
+}
+
+// refundPayment asks payment service to refund part of a payment. sellerId names the seller whose
+// sale is refunded, so payment service only claws back that seller. The idempotency key keeps a
+// retried cancellation from refunding twice.
+func refundPayment(ctx context.Context, paymentId uuid.UUID, amount int64, sellerId string, idempotencyKey string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"amount":    amount,
+		"seller_id": sellerId,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/payments/%s/refunds", getPaymentServiceURL(), paymentId.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("refund failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
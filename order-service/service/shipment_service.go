@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	"order-service/models/domain"
+	"order-service/models/web"
+)
+
+type ShipmentService interface {
+	Create(ctx context.Context, orderId string, request web.ShipmentCreateRequest) (domain.Shipment, error)
+	UpdateStatus(ctx context.Context, shipmentId string, request web.ShipmentUpdateRequest) (domain.Shipment, error)
+	UpdateFulfillment(ctx context.Context, orderId string, request web.OrderFulfillmentRequest) (domain.Order, error)
+	HandleCarrierWebhook(ctx context.Context, carrier string, request web.CarrierWebhookRequest) (domain.Shipment, error)
+	FindById(ctx context.Context, shipmentId string) (domain.Shipment, error)
+	FindByOrderId(ctx context.Context, orderId string) ([]domain.Shipment, error)
+}
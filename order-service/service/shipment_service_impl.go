@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"order-service/exception"
+	"order-service/helper"
+	"order-service/models/domain"
+	"order-service/models/web"
+	"order-service/repository"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// shipmentTransitions lists the statuses a shipment may move to from each status.
+var shipmentTransitions = map[string][]string{
+	domain.ShipmentPacked:    {domain.ShipmentShipped},
+	domain.ShipmentShipped:   {domain.ShipmentDelivered, domain.ShipmentReturned},
+	domain.ShipmentDelivered: {domain.ShipmentReturned},
+}
+
+// shipmentRank orders the shipment statuses so stale carrier updates can be recognised.
+var shipmentRank = map[string]int{
+	domain.ShipmentPacked:    0,
+	domain.ShipmentShipped:   1,
+	domain.ShipmentDelivered: 2,
+	domain.ShipmentReturned:  3,
+}
+
+// carrierStatuses maps the words carriers use in tracking updates to shipment statuses. Updates in
+// other words, such as arriving at a sorting hub, only inform and change nothing.
+var carrierStatuses = map[string]string{
+	"picked_up":          domain.ShipmentShipped,
+	"in_transit":         domain.ShipmentShipped,
+	"shipped":            domain.ShipmentShipped,
+	"delivered":          domain.ShipmentDelivered,
+	"returned":           domain.ShipmentReturned,
+	"returned_to_sender": domain.ShipmentReturned,
+}
+
+type ShipmentServiceImpl struct {
+	ShipmentRepository repository.ShipmentRepository
+	OrderRepository    repository.OrderRepository
+	DB                 *gorm.DB
+	Validate           *validator.Validate
+}
+
+func NewShipmentService(shipmentRepository repository.ShipmentRepository, orderRepository repository.OrderRepository, DB *gorm.DB, validate *validator.Validate) ShipmentService {
+	return &ShipmentServiceImpl{
+		ShipmentRepository: shipmentRepository,
+		OrderRepository:    orderRepository,
+		DB:                 DB,
+		Validate:           validate,
+	}
+}
+
+// Create packs a paid order, or one seller's sub-order, into a shipment. The order is packed
+// with it.
+func (service *ShipmentServiceImpl) Create(ctx context.Context, orderId string, request web.ShipmentCreateRequest) (_ domain.Shipment, err error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	order, err := service.findOrder(ctx, tx, orderId)
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	return service.pack(ctx, tx, order, request)
+}
+
+func (service *ShipmentServiceImpl) UpdateStatus(ctx context.Context, shipmentId string, request web.ShipmentUpdateRequest) (_ domain.Shipment, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	shipment, err := service.ShipmentRepository.FindById(ctx, tx, shipmentId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Shipment{}, exception.NotFoundError{Message: "shipment not found"}
+	}
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	return service.advance(ctx, tx, shipment, request.Status, request.TrackingNumber)
+}
+
+// UpdateFulfillment keeps PUT /orders/{orderId}/fulfillment working on top of shipments. A paid
+// order is packed first, then its shipment is moved through each step up to request.Status, so
+// the shipment rules still apply: shipping needs an address and a tracking number.
+func (service *ShipmentServiceImpl) UpdateFulfillment(ctx context.Context, orderId string, request web.OrderFulfillmentRequest) (_ domain.Order, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Order{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	order, err := service.findOrder(ctx, tx, orderId)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if order.SplitBySeller {
+		return domain.Order{}, errors.New("split orders are fulfilled through their sub-orders")
+	}
+
+	var shipment domain.Shipment
+	switch {
+	case order.Status == "paid":
+		shipment, err = service.pack(ctx, tx, order, web.ShipmentCreateRequest{Carrier: request.Carrier, TrackingNumber: request.TrackingNumber})
+	case order.Status == domain.ShipmentPacked, order.Status == domain.ShipmentShipped && request.Status == domain.ShipmentDelivered:
+		shipment, err = service.currentShipment(ctx, tx, order)
+	default:
+		return domain.Order{}, fmt.Errorf("%s order cannot be marked %s", order.Status, request.Status)
+	}
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	for _, status := range []string{domain.ShipmentShipped, domain.ShipmentDelivered} {
+		if shipmentRank[status] > shipmentRank[request.Status] {
+			break
+		}
+		if shipmentRank[status] <= shipmentRank[shipment.Status] {
+			continue
+		}
+		if shipment, err = service.advance(ctx, tx, shipment, status, request.TrackingNumber); err != nil {
+			return domain.Order{}, err
+		}
+	}
+
+	return service.OrderRepository.FindById(ctx, tx, orderId)
+}
+
+// HandleCarrierWebhook applies a carrier's tracking update to the shipment it knows by tracking
+// number. Carriers redeliver and reorder updates, so an update that does not move the shipment
+// forward is ignored.
+func (service *ShipmentServiceImpl) HandleCarrierWebhook(ctx context.Context, carrier string, request web.CarrierWebhookRequest) (_ domain.Shipment, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	shipment, err := service.ShipmentRepository.FindByTracking(ctx, tx, strings.ToLower(carrier), request.TrackingNumber)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Shipment{}, exception.NotFoundError{Message: "shipment not found"}
+	}
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	status, known := carrierStatuses[strings.ToLower(request.Status)]
+	if !known || shipmentRank[status] <= shipmentRank[shipment.Status] {
+		return shipment, nil
+	}
+
+	return service.advance(ctx, tx, shipment, status, "")
+}
+
+func (service *ShipmentServiceImpl) FindById(ctx context.Context, shipmentId string) (domain.Shipment, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	shipment, err := service.ShipmentRepository.FindById(ctx, tx, shipmentId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Shipment{}, exception.NotFoundError{Message: "shipment not found"}
+	}
+
+	return shipment, err
+}
+
+func (service *ShipmentServiceImpl) FindByOrderId(ctx context.Context, orderId string) ([]domain.Shipment, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.ShipmentRepository.FindByOrderId(ctx, tx, orderId)
+}
+
+func (service *ShipmentServiceImpl) findOrder(ctx context.Context, tx *gorm.DB, orderId string) (domain.Order, error) {
+	order, err := service.OrderRepository.FindById(ctx, tx, orderId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Order{}, exception.NotFoundError{Message: "order not found"}
+	}
+
+	return order, err
+}
+
+// pack creates the shipment of a paid order and packs the order.
+func (service *ShipmentServiceImpl) pack(ctx context.Context, tx *gorm.DB, order domain.Order, request web.ShipmentCreateRequest) (domain.Shipment, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	if order.SplitBySeller {
+		return domain.Shipment{}, errors.New("split orders are shipped through their sub-orders")
+	}
+	if order.Status != "paid" {
+		return domain.Shipment{}, fmt.Errorf("%s order cannot be shipped", order.Status)
+	}
+	if order.ShippingAddress == nil {
+		return domain.Shipment{}, errors.New("order has no shipping address")
+	}
+
+	now := time.Now()
+	shipment := domain.Shipment{
+		ID:       uuid.New(),
+		OrderID:  order.ID,
+		Carrier:  strings.ToLower(strings.TrimSpace(request.Carrier)),
+		Status:   domain.ShipmentPacked,
+		PackedAt: &now,
+	}
+	if err := service.track(ctx, tx, &shipment, request.TrackingNumber); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	created, err := service.ShipmentRepository.Save(ctx, tx, shipment)
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	if _, err := followShipment(ctx, tx, service.OrderRepository, order, domain.ShipmentPacked); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	return created, nil
+}
+
+// currentShipment returns the latest shipment of an order, the one its status follows.
+func (service *ShipmentServiceImpl) currentShipment(ctx context.Context, tx *gorm.DB, order domain.Order) (domain.Shipment, error) {
+	shipments, err := service.ShipmentRepository.FindByOrderId(ctx, tx, order.ID.String())
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+	if len(shipments) == 0 {
+		return domain.Shipment{}, exception.NotFoundError{Message: "shipment not found"}
+	}
+
+	return shipments[len(shipments)-1], nil
+}
+
+// advance moves a shipment to status and its order along with it. A shipment only leaves the
+// warehouse with a tracking number.
+func (service *ShipmentServiceImpl) advance(ctx context.Context, tx *gorm.DB, shipment domain.Shipment, status string, trackingNumber string) (domain.Shipment, error) {
+	allowed := false
+	for _, next := range shipmentTransitions[shipment.Status] {
+		allowed = allowed || next == status
+	}
+	if !allowed {
+		return domain.Shipment{}, fmt.Errorf("%s shipment cannot be marked %s", shipment.Status, status)
+	}
+
+	if err := service.track(ctx, tx, &shipment, trackingNumber); err != nil {
+		return domain.Shipment{}, err
+	}
+	if status == domain.ShipmentShipped && shipment.TrackingNumber == "" {
+		return domain.Shipment{}, errors.New("tracking number is required to ship")
+	}
+
+	now := time.Now()
+	switch status {
+	case domain.ShipmentShipped:
+		shipment.ShippedAt = &now
+	case domain.ShipmentDelivered:
+		shipment.DeliveredAt = &now
+	case domain.ShipmentReturned:
+		shipment.ReturnedAt = &now
+	}
+	shipment.Status = status
+
+	updated, err := service.ShipmentRepository.Update(ctx, tx, shipment)
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	order, err := service.OrderRepository.FindById(ctx, tx, shipment.OrderID.String())
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+	if _, err := followShipment(ctx, tx, service.OrderRepository, order, status); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	return updated, nil
+}
+
+// track sets the tracking number of a shipment, which must not belong to another shipment of the
+// same carrier. An empty trackingNumber keeps the current one.
+func (service *ShipmentServiceImpl) track(ctx context.Context, tx *gorm.DB, shipment *domain.Shipment, trackingNumber string) error {
+	trackingNumber = strings.TrimSpace(trackingNumber)
+	if trackingNumber == "" || trackingNumber == shipment.TrackingNumber {
+		return nil
+	}
+
+	existing, err := service.ShipmentRepository.FindByTracking(ctx, tx, shipment.Carrier, trackingNumber)
+	if err == nil && existing.ID != shipment.ID {
+		return fmt.Errorf("tracking number %s is already used by shipment %s", trackingNumber, existing.ID)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	shipment.TrackingNumber = trackingNumber
+	return nil
+}
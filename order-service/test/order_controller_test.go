@@ -59,6 +59,16 @@ func (m *MockOrderService) ProcessPaymentCallback(ctx context.Context, request w
 	return args.Get(0).(domain.Order), args.Error(1)
 }
 
+func (m *MockOrderService) CancelSubOrder(ctx context.Context, orderId string) (domain.Order, error) {
+	args := m.Called(ctx, orderId)
+	return args.Get(0).(domain.Order), args.Error(1)
+}
+
+func (m *MockOrderService) UpdateShippingAddress(ctx context.Context, orderId string, request web.AddressRequest) (domain.Order, error) {
+	args := m.Called(ctx, orderId, request)
+	return args.Get(0).(domain.Order), args.Error(1)
+}
+
 // SUCCESS CONDITION TESTS
 
 // Test Create endpoint
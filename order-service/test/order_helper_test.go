@@ -3,6 +3,7 @@ package test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -244,3 +245,25 @@ func TestPanicIfErrorWithDifferentErrors(t *testing.T) {
 		})
 	}
 }
+
+// TestCommitOrRollbackOnReturnedError tests that a returned error rolls the transaction back
+func TestCommitOrRollbackOnReturnedError(t *testing.T) {
+	db := openOrderDB(t)
+
+	write := func(fail bool) (err error) {
+		tx := db.Begin()
+		defer helper.CommitOrRollback(tx, &err)
+		tx.Create(&domain.OrderItem{ID: uuid.New(), OrderID: uuid.New(), ItemName: "Kopi", Quantity: 1, Price: 1000})
+		if fail {
+			return errors.New("later write failed")
+		}
+		return nil
+	}
+
+	assert.Error(t, write(true))
+	assert.NoError(t, write(false))
+
+	var count int64
+	db.Model(&domain.OrderItem{}).Count(&count)
+	assert.EqualValues(t, 1, count)
+}
@@ -25,8 +25,21 @@ func TestOrderRepositoryCRUD(t *testing.T) {
         quantity INTEGER,
         price INTEGER,
         total_amount INTEGER,
+        currency TEXT,
         status TEXT,
         payment_id TEXT,
+        subscription_id TEXT,
+        split_by_seller NUMERIC DEFAULT false,
+        parent_id TEXT,
+        seller_id TEXT,
+        shipping_recipient_name TEXT,
+        shipping_phone TEXT,
+        shipping_street TEXT,
+        shipping_city TEXT,
+        shipping_province TEXT,
+        shipping_postal_code TEXT,
+        shipping_country TEXT,
+        refunded_amount INTEGER DEFAULT 0,
         created_at DATETIME,
         updated_at DATETIME,
         deleted_at DATETIME
@@ -54,6 +54,31 @@ func (m *MockOrderRepository) FindByAll(ctx context.Context, tx *gorm.DB) ([]dom
 	}
 	return args.Get(0).([]domain.Order), args.Error(1)
 }
+func (m *MockOrderRepository) UpdateShippingAddress(ctx context.Context, tx *gorm.DB, order domain.Order) (domain.Order, error) {
+	args := m.Called(ctx, tx, order)
+	if args.Get(0) == nil {
+		return domain.Order{}, args.Error(1)
+	}
+	return args.Get(0).(domain.Order), args.Error(1)
+}
+func (m *MockOrderRepository) FindByParentId(ctx context.Context, tx *gorm.DB, parentId string) ([]domain.Order, error) {
+	args := m.Called(ctx, tx, parentId)
+	if args.Get(0) == nil {
+		return []domain.Order{}, args.Error(1)
+	}
+	return args.Get(0).([]domain.Order), args.Error(1)
+}
+func (m *MockOrderRepository) SaveItems(ctx context.Context, tx *gorm.DB, items []domain.OrderItem) error {
+	args := m.Called(ctx, tx, items)
+	return args.Error(0)
+}
+func (m *MockOrderRepository) FindItems(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.OrderItem, error) {
+	args := m.Called(ctx, tx, orderId)
+	if args.Get(0) == nil {
+		return []domain.OrderItem{}, args.Error(1)
+	}
+	return args.Get(0).([]domain.OrderItem), args.Error(1)
+}
 
 // SUCCESS CONDITION TESTS
 
@@ -75,6 +100,29 @@ func TestCreateSuccess(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateSubscriptionOrder(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	validate := validator.New()
+	svc := service.NewOrderService(mockRepo, db, validate)
+
+	subscriptionId := uuid.New()
+	req := web.OrderCreateRequest{ItemName: "Pro plan", Quantity: 1, Price: 99_000, SubscriptionID: subscriptionId.String()}
+
+	mockRepo.On("Save", mock.Anything, mock.Anything, mock.MatchedBy(func(o domain.Order) bool {
+		return o.SubscriptionID != nil && *o.SubscriptionID == subscriptionId
+	})).Return(domain.Order{ID: uuid.New(), SubscriptionID: &subscriptionId}, nil)
+
+	got, err := svc.Create(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, subscriptionId, *got.SubscriptionID)
+	mockRepo.AssertExpectations(t)
+
+	assert.Panics(t, func() {
+		svc.Create(context.Background(), web.OrderCreateRequest{ItemName: "Pro plan", Quantity: 1, Price: 99_000, SubscriptionID: "not-a-uuid"})
+	})
+}
+
 // Test Update Endpoint
 func TestUpdateSuccess(t *testing.T) {
 	mockRepo := new(MockOrderRepository)
@@ -181,6 +229,57 @@ func TestProcessPaymentCallback_Success(t *testing.T) {
 	assert.Equal(t, "paid", got.Status)
 }
 
+func TestProcessPaymentCallback_ChargedBack(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	validate := validator.New()
+	svc := service.NewOrderService(mockRepo, db, validate)
+
+	id := uuid.New()
+	o := domain.Order{ID: id, Status: "paid"}
+	mockRepo.On("FindById", mock.Anything, mock.Anything, id.String()).Return(o, nil)
+	mockRepo.On("Update", mock.Anything, mock.Anything, mock.MatchedBy(func(ord domain.Order) bool { return ord.Status == "charged_back" })).Return(domain.Order{ID: id, Status: "charged_back"}, nil)
+
+	cbReq := web.PaymentCallbackRequest{OrderID: id, PaymentID: uuid.New(), PaymentStatus: "charged_back"}
+	got, err := svc.ProcessPaymentCallback(context.Background(), cbReq)
+	assert.NoError(t, err)
+	assert.Equal(t, "charged_back", got.Status)
+}
+
+func TestProcessPaymentCallback_Cancelled(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	validate := validator.New()
+	svc := service.NewOrderService(mockRepo, db, validate)
+
+	id := uuid.New()
+	o := domain.Order{ID: id, Status: "pending"}
+	mockRepo.On("FindById", mock.Anything, mock.Anything, id.String()).Return(o, nil)
+	mockRepo.On("Update", mock.Anything, mock.Anything, mock.MatchedBy(func(ord domain.Order) bool { return ord.Status == "cancelled" })).Return(domain.Order{ID: id, Status: "cancelled"}, nil)
+
+	cbReq := web.PaymentCallbackRequest{OrderID: id, PaymentID: uuid.New(), PaymentStatus: "cancelled"}
+	got, err := svc.ProcessPaymentCallback(context.Background(), cbReq)
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", got.Status)
+}
+
+func TestProcessPaymentCallback_PartiallyPaid(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	validate := validator.New()
+	svc := service.NewOrderService(mockRepo, db, validate)
+
+	id := uuid.New()
+	o := domain.Order{ID: id, Status: "pending"}
+	mockRepo.On("FindById", mock.Anything, mock.Anything, id.String()).Return(o, nil)
+	mockRepo.On("Update", mock.Anything, mock.Anything, mock.MatchedBy(func(ord domain.Order) bool { return ord.Status == "partially_paid" })).Return(domain.Order{ID: id, Status: "partially_paid"}, nil)
+
+	cbReq := web.PaymentCallbackRequest{OrderID: id, PaymentID: uuid.New(), PaymentStatus: "partially_paid"}
+	got, err := svc.ProcessPaymentCallback(context.Background(), cbReq)
+	assert.NoError(t, err)
+	assert.Equal(t, "partially_paid", got.Status)
+}
+
 // ERROR CONDITION TESTS
 
 // Test Create Endpoint with Validation Error
@@ -374,3 +473,25 @@ func TestProcessPaymentCallback_FindOrderError(t *testing.T) {
 	_, err := svc.ProcessPaymentCallback(context.Background(), cbReq)
 	assert.Error(t, err)
 }
+
+func TestCreateDefaultsCurrency(t *testing.T) {
+	mockRepo := new(MockOrderRepository)
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	svc := service.NewOrderService(mockRepo, db, validator.New())
+
+	mockRepo.On("Save", mock.Anything, mock.Anything, mock.MatchedBy(func(o domain.Order) bool { return o.Currency == "IDR" })).Return(domain.Order{Currency: "IDR"}, nil).Once()
+	mockRepo.On("Save", mock.Anything, mock.Anything, mock.MatchedBy(func(o domain.Order) bool { return o.Currency == "USD" })).Return(domain.Order{Currency: "USD"}, nil).Once()
+
+	got, err := svc.Create(context.Background(), web.OrderCreateRequest{ItemName: "x", Quantity: 1, Price: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, "IDR", got.Currency)
+
+	got, err = svc.Create(context.Background(), web.OrderCreateRequest{ItemName: "x", Quantity: 1, Price: 100, Currency: "usd"})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", got.Currency)
+
+	assert.Panics(t, func() {
+		svc.Create(context.Background(), web.OrderCreateRequest{ItemName: "x", Quantity: 1, Price: 100, Currency: "RUPIAH"})
+	})
+	mockRepo.AssertExpectations(t)
+}
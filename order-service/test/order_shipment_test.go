@@ -0,0 +1,264 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"order-service/controller"
+	"order-service/middleware"
+	"order-service/models/domain"
+	"order-service/models/web"
+	"order-service/repository"
+	"order-service/routes"
+	"order-service/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type shipmentFixture struct {
+	db              *gorm.DB
+	orderService    service.OrderService
+	shipmentService service.ShipmentService
+	app             *fiber.App
+}
+
+func setupShipments(t *testing.T) shipmentFixture {
+	db := openOrderDB(t)
+	validate := validator.New()
+
+	orderRepository := repository.NewOrderRepository(db)
+	orderService := service.NewOrderService(orderRepository, db, validate)
+	shipmentService := service.NewShipmentService(repository.NewShipmentRepository(db), orderRepository, db, validate)
+
+	app := fiber.New()
+	routes.OrderRoutes(app, controller.NewOrderController(orderService))
+	routes.ShipmentRoutes(app, controller.NewShipmentController(shipmentService), testWebhookSecret)
+
+	return shipmentFixture{db, orderService, shipmentService, app}
+}
+
+// testWebhookSecret is the secret the carrier webhook is registered with in tests; send passes it
+// on every request.
+const testWebhookSecret = "whsec_test"
+
+func (fixture shipmentFixture) send(t *testing.T, method string, path string, payload interface{}) (int, json.RawMessage) {
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.WebhookSecretHeader, testWebhookSecret)
+	resp, err := fixture.app.Test(req)
+	assert.NoError(t, err)
+
+	var response struct {
+		Data json.RawMessage `json:"Data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&response)
+	return resp.StatusCode, response.Data
+}
+
+// paidOrder seeds a paid single-item order; sqlite cannot generate its id. address may be nil.
+func (fixture shipmentFixture) paidOrder(t *testing.T, address *domain.Address) uuid.UUID {
+	order := domain.Order{ID: uuid.New(), ItemName: "Sepatu", Quantity: 1, Price: 250_000, TotalAmount: 250_000, Currency: "IDR", Status: "paid", ShippingAddress: address}
+	assert.NoError(t, fixture.db.Create(&order).Error)
+	return order.ID
+}
+
+var testAddress = map[string]interface{}{"RecipientName": "Siti", "Phone": "0812000111", "Street": "Jl. Sudirman 5", "City": "Jakarta", "PostalCode": "10220"}
+
+func TestShipmentLifecycleDrivesOrderStatus(t *testing.T) {
+	fixture := setupShipments(t)
+	ctx := context.Background()
+	orderId := fixture.paidOrder(t, nil)
+
+	status, data := fixture.send(t, http.MethodPut, "/orders/"+orderId.String()+"/shipping-address", testAddress)
+	assert.Equal(t, http.StatusOK, status)
+
+	orderStatus := func() string {
+		order, err := fixture.orderService.FindById(ctx, orderId.String())
+		assert.NoError(t, err)
+		return order.Status
+	}
+
+	order, err := fixture.orderService.FindById(ctx, orderId.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "ID", order.ShippingAddress.Country)
+	assert.Equal(t, "Jakarta", order.ShippingAddress.City)
+
+	status, data = fixture.send(t, http.MethodPost, "/orders/"+orderId.String()+"/shipments", map[string]interface{}{"Carrier": "JNE"})
+	assert.Equal(t, http.StatusOK, status)
+	var shipment domain.Shipment
+	json.Unmarshal(data, &shipment)
+	assert.Equal(t, "jne", shipment.Carrier)
+	assert.Equal(t, domain.ShipmentPacked, shipment.Status)
+	assert.NotNil(t, shipment.PackedAt)
+	assert.Equal(t, "packed", orderStatus())
+
+	path := "/shipments/" + shipment.ID.String() + "/status"
+	status, data = fixture.send(t, http.MethodPut, path, map[string]interface{}{"Status": "shipped"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.JSONEq(t, `"tracking number is required to ship"`, string(data))
+
+	status, _ = fixture.send(t, http.MethodPut, path, map[string]interface{}{"Status": "shipped", "TrackingNumber": "JNE123"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "shipped", orderStatus())
+
+	webhook := func(carrierStatus string) int {
+		status, _ := fixture.send(t, http.MethodPost, "/webhooks/carriers/JNE", map[string]interface{}{"tracking_number": "JNE123", "status": carrierStatus})
+		return status
+	}
+	assert.Equal(t, http.StatusOK, webhook("in_transit"))
+	assert.Equal(t, http.StatusOK, webhook("arrived_at_hub"))
+	assert.Equal(t, "shipped", orderStatus())
+	assert.Equal(t, http.StatusOK, webhook("DELIVERED"))
+	assert.Equal(t, "delivered", orderStatus())
+
+	// Late or redelivered updates do not move the shipment back.
+	assert.Equal(t, http.StatusOK, webhook("in_transit"))
+	assert.Equal(t, "delivered", orderStatus())
+
+	assert.Equal(t, http.StatusOK, webhook("returned_to_sender"))
+	assert.Equal(t, "returned", orderStatus())
+
+	found, err := fixture.shipmentService.FindById(ctx, shipment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ShipmentReturned, found.Status)
+	assert.NotNil(t, found.ShippedAt)
+	assert.NotNil(t, found.DeliveredAt)
+	assert.NotNil(t, found.ReturnedAt)
+
+	// A redelivered or late payment callback does not undo the shipping either.
+	for _, paymentStatus := range []string{"success", "partially_paid", "cancelled"} {
+		_, err = fixture.orderService.ProcessPaymentCallback(ctx, web.PaymentCallbackRequest{OrderID: orderId, PaymentID: uuid.New(), PaymentStatus: paymentStatus})
+		assert.NoError(t, err)
+		assert.Equal(t, "returned", orderStatus(), paymentStatus)
+	}
+
+	resp, _ := fixture.app.Test(httptest.NewRequest(http.MethodGet, "/orders/"+orderId.String()+"/shipments", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestShipmentRules(t *testing.T) {
+	fixture := setupShipments(t)
+	ctx := context.Background()
+
+	noAddress := fixture.paidOrder(t, nil)
+	_, err := fixture.shipmentService.Create(ctx, noAddress.String(), web.ShipmentCreateRequest{Carrier: "jne"})
+	assert.EqualError(t, err, "order has no shipping address")
+
+	status, _ := fixture.send(t, http.MethodPut, "/orders/"+noAddress.String()+"/shipping-address", testAddress)
+	assert.Equal(t, http.StatusOK, status)
+	shipment, err := fixture.shipmentService.Create(ctx, noAddress.String(), web.ShipmentCreateRequest{Carrier: "jne", TrackingNumber: "JNE-1"})
+	assert.NoError(t, err)
+
+	_, err = fixture.orderService.UpdateShippingAddress(ctx, noAddress.String(), web.AddressRequest{RecipientName: "Siti", Phone: "1", Street: "Jl. Lain", City: "Bogor", PostalCode: "16111"})
+	assert.EqualError(t, err, "packed order cannot change its shipping address")
+	_, err = fixture.shipmentService.Create(ctx, noAddress.String(), web.ShipmentCreateRequest{Carrier: "jne"})
+	assert.EqualError(t, err, "packed order cannot be shipped")
+	_, err = fixture.shipmentService.UpdateStatus(ctx, shipment.ID.String(), web.ShipmentUpdateRequest{Status: "delivered"})
+	assert.EqualError(t, err, "packed shipment cannot be marked delivered")
+
+	other := fixture.paidOrder(t, &domain.Address{RecipientName: "Budi", Phone: "2", Street: "Jl. Asia Afrika 8", City: "Bandung", PostalCode: "40111", Country: "ID"})
+	_, err = fixture.shipmentService.Create(ctx, other.String(), web.ShipmentCreateRequest{Carrier: "JNE", TrackingNumber: "JNE-1"})
+	assert.EqualError(t, err, "tracking number JNE-1 is already used by shipment "+shipment.ID.String())
+
+	pending := domain.Order{ID: uuid.New(), ItemName: "Tas", Quantity: 1, Price: 100_000, TotalAmount: 100_000, Status: "pending"}
+	assert.NoError(t, fixture.db.Create(&pending).Error)
+	_, err = fixture.shipmentService.Create(ctx, pending.ID.String(), web.ShipmentCreateRequest{Carrier: "jne"})
+	assert.EqualError(t, err, "pending order cannot be shipped")
+
+	status, _ = fixture.send(t, http.MethodPost, "/orders/"+uuid.NewString()+"/shipments", map[string]interface{}{"Carrier": "jne"})
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = fixture.send(t, http.MethodPost, "/webhooks/carriers/jne", map[string]interface{}{"tracking_number": "NOPE", "status": "delivered"})
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestLatePartialPaymentCallbackKeepsOrderPaid(t *testing.T) {
+	fixture := setupShipments(t)
+	ctx := context.Background()
+
+	order := domain.Order{ID: uuid.New(), ItemName: "Kulkas", Quantity: 1, Price: 3_000_000, TotalAmount: 3_000_000, Currency: "IDR", Status: "pending"}
+	assert.NoError(t, fixture.db.Create(&order).Error)
+
+	callback := func(paymentStatus string) string {
+		updated, err := fixture.orderService.ProcessPaymentCallback(ctx, web.PaymentCallbackRequest{OrderID: order.ID, PaymentID: uuid.New(), PaymentStatus: paymentStatus})
+		assert.NoError(t, err)
+		return updated.Status
+	}
+
+	assert.Equal(t, "partially_paid", callback("partially_paid"))
+	assert.Equal(t, "paid", callback("success"))
+	assert.Equal(t, "paid", callback("partially_paid"))
+}
+
+func TestFulfillmentEndpointDrivesShipments(t *testing.T) {
+	fixture := setupShipments(t)
+	ctx := context.Background()
+	orderId := fixture.paidOrder(t, &domain.Address{RecipientName: "Siti", Phone: "1", Street: "Jl. Sudirman 5", City: "Jakarta", PostalCode: "10220", Country: "ID"})
+	path := "/orders/" + orderId.String() + "/fulfillment"
+
+	status, data := fixture.send(t, http.MethodPut, path, map[string]interface{}{"Status": "shipped", "Carrier": "JNE"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.JSONEq(t, `"tracking number is required to ship"`, string(data))
+
+	// The failed request does not leave the order packed.
+	unchanged, err := fixture.orderService.FindById(ctx, orderId.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "paid", unchanged.Status)
+
+	status, data = fixture.send(t, http.MethodPut, path, map[string]interface{}{"Status": "shipped", "Carrier": "JNE", "TrackingNumber": "JNE-9"})
+	assert.Equal(t, http.StatusOK, status)
+	var order web.OrderResponse
+	json.Unmarshal(data, &order)
+	assert.Equal(t, "shipped", order.Status)
+
+	shipments, err := fixture.shipmentService.FindByOrderId(ctx, orderId.String())
+	assert.NoError(t, err)
+	assert.Len(t, shipments, 1)
+	assert.Equal(t, domain.ShipmentShipped, shipments[0].Status)
+	assert.Equal(t, "JNE-9", shipments[0].TrackingNumber)
+
+	status, data = fixture.send(t, http.MethodPut, path, map[string]interface{}{"Status": "delivered"})
+	assert.Equal(t, http.StatusOK, status)
+	json.Unmarshal(data, &order)
+	assert.Equal(t, "delivered", order.Status)
+
+	status, data = fixture.send(t, http.MethodPut, path, map[string]interface{}{"Status": "shipped"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.JSONEq(t, `"delivered order cannot be marked shipped"`, string(data))
+
+	status, _ = fixture.send(t, http.MethodPut, "/orders/"+uuid.NewString()+"/fulfillment", map[string]interface{}{"Status": "shipped"})
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestCarrierWebhookRequiresSecret(t *testing.T) {
+	fixture := setupShipments(t)
+	update, _ := json.Marshal(map[string]interface{}{"tracking_number": "JNE123", "status": "delivered"})
+
+	for _, secret := range []string{"", "whsec_wrong"} {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/carriers/jne", bytes.NewReader(update))
+		req.Header.Set("Content-Type", "application/json")
+		if secret != "" {
+			req.Header.Set(middleware.WebhookSecretHeader, secret)
+		}
+		resp, err := fixture.app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	// Without a configured secret the webhook accepts nothing.
+	app := fiber.New()
+	routes.ShipmentRoutes(app, controller.NewShipmentController(fixture.shipmentService), "")
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/carriers/jne", bytes.NewReader(update))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
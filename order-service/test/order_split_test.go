@@ -0,0 +1,294 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"order-service/controller"
+	"order-service/models/domain"
+	"order-service/models/web"
+	"order-service/repository"
+	"order-service/routes"
+	"order-service/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type refundCall struct {
+	path           string
+	idempotencyKey string
+	body           map[string]interface{}
+}
+
+// openOrderDB returns a sqlite database with the order-service tables.
+func openOrderDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+
+	// AutoMigrate would generate Postgres-specific SQL (gen_random_uuid()) for orders.
+	err = db.Exec(`CREATE TABLE orders (
+        id TEXT PRIMARY KEY,
+        item_name TEXT,
+        quantity INTEGER,
+        price INTEGER,
+        total_amount INTEGER,
+        currency TEXT,
+        status TEXT,
+        payment_id TEXT,
+        subscription_id TEXT,
+        split_by_seller NUMERIC DEFAULT false,
+        parent_id TEXT,
+        seller_id TEXT,
+        shipping_recipient_name TEXT,
+        shipping_phone TEXT,
+        shipping_street TEXT,
+        shipping_city TEXT,
+        shipping_province TEXT,
+        shipping_postal_code TEXT,
+        shipping_country TEXT,
+        refunded_amount INTEGER DEFAULT 0,
+        created_at DATETIME,
+        updated_at DATETIME,
+        deleted_at DATETIME
+    );`).Error
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&domain.OrderItem{}, &domain.Shipment{}))
+
+	return db
+}
+
+// setupSplitOrders returns the order and shipment services over sqlite and records the refunds
+// asked of payment service. Refunds answer with refundStatus.
+func setupSplitOrders(t *testing.T, refundStatus int) (service.OrderService, service.ShipmentService, *fiber.App, *[]refundCall) {
+	db := openOrderDB(t)
+
+	refunds := []refundCall{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := refundCall{path: r.URL.Path, idempotencyKey: r.Header.Get("Idempotency-Key")}
+		json.NewDecoder(r.Body).Decode(&call.body)
+		refunds = append(refunds, call)
+		w.WriteHeader(refundStatus)
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("PAYMENT_SERVICE_URL", srv.URL)
+	t.Cleanup(func() { os.Setenv("PAYMENT_SERVICE_URL", "") })
+
+	orderRepository := repository.NewOrderRepository(db)
+	orderService := service.NewOrderService(orderRepository, db, validator.New())
+	shipmentService := service.NewShipmentService(repository.NewShipmentRepository(db), orderRepository, db, validator.New())
+	app := fiber.New()
+	routes.OrderRoutes(app, controller.NewOrderController(orderService))
+
+	return orderService, shipmentService, app, &refunds
+}
+
+func placeSplitOrder(t *testing.T, app *fiber.App) web.OrderResponse {
+	body, _ := json.Marshal(map[string]interface{}{
+		"Items": []map[string]interface{}{
+			{"ItemName": "Kopi", "Quantity": 2, "Price": 50_000, "SellerID": "seller-a"},
+			{"ItemName": "Teh", "Quantity": 1, "Price": 30_000, "SellerID": "seller-b"},
+			{"ItemName": "Gula", "Quantity": 1, "Price": 20_000, "SellerID": "seller-a"},
+		},
+		"ShippingAddress": map[string]interface{}{"RecipientName": "Budi", "Phone": "08123456789", "Street": "Jl. Merdeka 1", "City": "Bandung", "PostalCode": "40111"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, _ := app.Test(req)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var created struct {
+		Data web.OrderResponse `json:"Data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	return created.Data
+}
+
+func TestSplitOrderFulfilmentAggregatesIntoParent(t *testing.T) {
+	orderService, shipmentService, app, _ := setupSplitOrders(t, http.StatusOK)
+	ctx := context.Background()
+
+	parent := placeSplitOrder(t, app)
+	assert.True(t, parent.SplitBySeller)
+	assert.Equal(t, int64(150_000), parent.TotalAmount)
+	assert.Equal(t, "3 items", parent.ItemName)
+	assert.Len(t, parent.SubOrders, 2)
+
+	found, err := orderService.FindById(ctx, parent.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, found.SubOrders, 2)
+	sellerA, sellerB := found.SubOrders[0], found.SubOrders[1]
+	assert.Equal(t, "seller-a", sellerA.SellerID)
+	assert.Equal(t, int64(120_000), sellerA.TotalAmount)
+	assert.Len(t, sellerA.Items, 2)
+	assert.Equal(t, "Teh", sellerB.ItemName)
+	assert.Equal(t, parent.Id, *sellerB.ParentID)
+
+	paymentId := uuid.New()
+	paid, err := orderService.ProcessPaymentCallback(ctx, web.PaymentCallbackRequest{OrderID: parent.Id, PaymentID: paymentId, PaymentStatus: "success"})
+	assert.NoError(t, err)
+	assert.Equal(t, "paid", paid.Status)
+	assert.Equal(t, paymentId, *paid.PaymentID)
+
+	status := func() string {
+		order, err := orderService.FindById(ctx, parent.Id.String())
+		assert.NoError(t, err)
+		return order.Status
+	}
+
+	_, err = shipmentService.Create(ctx, parent.Id.String(), web.ShipmentCreateRequest{Carrier: "jne"})
+	assert.EqualError(t, err, "split orders are shipped through their sub-orders")
+
+	shipA, err := shipmentService.Create(ctx, sellerA.ID.String(), web.ShipmentCreateRequest{Carrier: "jne", TrackingNumber: "JNE-A"})
+	assert.NoError(t, err)
+	assert.Equal(t, "partially_fulfilled", status())
+	shipB, err := shipmentService.Create(ctx, sellerB.ID.String(), web.ShipmentCreateRequest{Carrier: "sicepat", TrackingNumber: "SC-B"})
+	assert.NoError(t, err)
+	assert.Equal(t, "packed", status())
+
+	advance := func(shipment domain.Shipment, to string) {
+		_, err := shipmentService.UpdateStatus(ctx, shipment.ID.String(), web.ShipmentUpdateRequest{Status: to})
+		assert.NoError(t, err)
+	}
+	advance(shipA, "shipped")
+	assert.Equal(t, "partially_fulfilled", status())
+	advance(shipB, "shipped")
+	assert.Equal(t, "shipped", status())
+	advance(shipA, "delivered")
+	assert.Equal(t, "shipped", status())
+	advance(shipB, "delivered")
+	assert.Equal(t, "delivered", status())
+
+	fulfil := func(orderId uuid.UUID, status string) error {
+		_, err := shipmentService.UpdateFulfillment(ctx, orderId.String(), web.OrderFulfillmentRequest{Status: status})
+		return err
+	}
+	assert.EqualError(t, fulfil(parent.Id, "delivered"), "split orders are fulfilled through their sub-orders")
+	assert.Error(t, fulfil(sellerA.ID, "bogus"))
+	assert.EqualError(t, fulfil(sellerB.ID, "shipped"), "delivered order cannot be marked shipped")
+
+	// A redelivered payment callback does not undo the fulfilment.
+	_, err = orderService.ProcessPaymentCallback(ctx, web.PaymentCallbackRequest{OrderID: parent.Id, PaymentID: paymentId, PaymentStatus: "success"})
+	assert.NoError(t, err)
+	assert.Equal(t, "delivered", status())
+}
+
+func TestCancelPaidSubOrderRefundsItsSeller(t *testing.T) {
+	orderService, shipmentService, app, refunds := setupSplitOrders(t, http.StatusOK)
+	ctx := context.Background()
+
+	parent := placeSplitOrder(t, app)
+	paymentId := uuid.New()
+	_, err := orderService.ProcessPaymentCallback(ctx, web.PaymentCallbackRequest{OrderID: parent.Id, PaymentID: paymentId, PaymentStatus: "success"})
+	assert.NoError(t, err)
+
+	sellerA, sellerB := parent.SubOrders[0], parent.SubOrders[1]
+	shipment, err := shipmentService.Create(ctx, sellerA.Id.String(), web.ShipmentCreateRequest{Carrier: "jne", TrackingNumber: "JNE-A"})
+	assert.NoError(t, err)
+	_, err = shipmentService.UpdateStatus(ctx, shipment.ID.String(), web.ShipmentUpdateRequest{Status: "shipped"})
+	assert.NoError(t, err)
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodPost, "/orders/"+sellerB.Id.String()+"/cancel", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Len(t, *refunds, 1)
+	assert.Equal(t, "/payments/"+paymentId.String()+"/refunds", (*refunds)[0].path)
+	assert.Equal(t, "cancel-"+sellerB.Id.String(), (*refunds)[0].idempotencyKey)
+	assert.Equal(t, map[string]interface{}{"amount": float64(30_000), "seller_id": "seller-b"}, (*refunds)[0].body)
+
+	cancelled, err := orderService.FindById(ctx, sellerB.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+	assert.Equal(t, int64(30_000), cancelled.RefundedAmount)
+
+	// The only sub-order left is shipped, so the whole order is.
+	found, err := orderService.FindById(ctx, parent.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "shipped", found.Status)
+	assert.Equal(t, int64(30_000), found.RefundedAmount)
+	assert.Equal(t, int64(150_000), found.TotalAmount)
+
+	_, err = orderService.CancelSubOrder(ctx, sellerB.Id.String())
+	assert.EqualError(t, err, "cancelled sub-order cannot be cancelled")
+	_, err = orderService.CancelSubOrder(ctx, sellerA.Id.String())
+	assert.EqualError(t, err, "shipped sub-order cannot be cancelled")
+	_, err = orderService.CancelSubOrder(ctx, parent.Id.String())
+	assert.EqualError(t, err, "only sub-orders can be cancelled on their own")
+	assert.Len(t, *refunds, 1)
+}
+
+func TestCancelSubOrderBeforePaymentShrinksTheTotal(t *testing.T) {
+	orderService, _, app, refunds := setupSplitOrders(t, http.StatusOK)
+	ctx := context.Background()
+
+	parent := placeSplitOrder(t, app)
+	_, err := orderService.CancelSubOrder(ctx, parent.SubOrders[1].Id.String())
+	assert.NoError(t, err)
+	assert.Empty(t, *refunds)
+
+	found, err := orderService.FindById(ctx, parent.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(120_000), found.TotalAmount)
+	assert.Equal(t, "pending", found.Status)
+
+	_, err = orderService.CancelSubOrder(ctx, parent.SubOrders[0].Id.String())
+	assert.NoError(t, err)
+	found, err = orderService.FindById(ctx, parent.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), found.TotalAmount)
+	assert.Equal(t, "cancelled", found.Status)
+}
+
+func TestCancelSubOrderRetriesAFailedRefund(t *testing.T) {
+	orderService, _, app, refunds := setupSplitOrders(t, http.StatusBadGateway)
+	ctx := context.Background()
+
+	parent := placeSplitOrder(t, app)
+	_, err := orderService.ProcessPaymentCallback(ctx, web.PaymentCallbackRequest{OrderID: parent.Id, PaymentID: uuid.New(), PaymentStatus: "success"})
+	assert.NoError(t, err)
+
+	subOrderId := parent.SubOrders[0].Id.String()
+	_, err = orderService.CancelSubOrder(ctx, subOrderId)
+	assert.ErrorContains(t, err, "refund failed with status 502")
+
+	// The cancellation is recorded, but nothing counts as refunded yet.
+	pending, err := orderService.FindById(ctx, subOrderId)
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelling", pending.Status)
+	assert.Equal(t, int64(0), pending.RefundedAmount)
+	found, err := orderService.FindById(ctx, parent.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "paid", found.Status)
+	assert.Equal(t, int64(0), found.RefundedAmount)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*refunds = append(*refunds, refundCall{path: r.URL.Path, idempotencyKey: r.Header.Get("Idempotency-Key")})
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("PAYMENT_SERVICE_URL", srv.URL)
+
+	cancelled, err := orderService.CancelSubOrder(ctx, subOrderId)
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+	assert.Equal(t, int64(120_000), cancelled.RefundedAmount)
+
+	// The retry asks for the same refund, so payment service refunds it once.
+	assert.Len(t, *refunds, 2)
+	assert.Equal(t, (*refunds)[0].idempotencyKey, (*refunds)[1].idempotencyKey)
+
+	found, err = orderService.FindById(ctx, parent.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(120_000), found.RefundedAmount)
+
+	_, err = orderService.CancelSubOrder(ctx, subOrderId)
+	assert.EqualError(t, err, "cancelled sub-order cannot be cancelled")
+}
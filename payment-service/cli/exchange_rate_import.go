@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"payment-service/models/domain"
+	"payment-service/service"
+)
+
+// RunExchangeRateImport implements `payment-service import-rates <file.csv>`.
+func RunExchangeRateImport(args []string, exchangeRateService service.ExchangeRateService, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: import-rates <file.csv>")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	result, err := exchangeRateService.Import(context.Background(), domain.RateSourceFile, file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "exchange rates: %d imported, %d already on file\n", result.Imported, result.Skipped)
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/service"
+)
+
+// RunSettlementImport implements `payment-service import-settlement -provider <name> -date <YYYY-MM-DD> <file.csv>`.
+func RunSettlementImport(args []string, settlementService service.SettlementService, out io.Writer) error {
+	flags := flag.NewFlagSet("import-settlement", flag.ContinueOnError)
+	flags.SetOutput(out)
+	provider := flags.String("provider", "", "provider that issued the settlement file")
+	date := flags.String("date", "", "settlement date (YYYY-MM-DD)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: import-settlement -provider <name> -date <YYYY-MM-DD> <file.csv>")
+	}
+
+	path := flags.Arg(0)
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	report, err := settlementService.Import(context.Background(), web.SettlementImportRequest{
+		Provider:       *provider,
+		SettlementDate: *date,
+		FileName:       filepath.Base(path),
+	}, file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "settlement report %s: %d lines, %d matched, %d exceptions\n",
+		report.ID, report.TotalLines, report.MatchedCount, report.ExceptionCount)
+
+	for _, item := range report.Items {
+		if item.Result != domain.SettlementMatched {
+			fmt.Fprintf(out, "  %-16s %s\n", item.Result, item.ProviderReference)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// BalanceConfig controls gift cards, store credit and customer wallets.
+type BalanceConfig struct {
+	// GiftCardValidity is how long a gift card issued without an explicit expiry stays
+	// redeemable. Store credit does not expire unless an expiry is given.
+	GiftCardValidity time.Duration
+	// WalletMinTopUp is the smallest amount a wallet can be topped up with.
+	WalletMinTopUp int64
+	// WalletMaxBalance caps what a wallet may hold; a top-up that would take the balance above it
+	// is refused. Zero means no cap.
+	WalletMaxBalance int64
+}
+
+func NewBalanceConfig() BalanceConfig {
+	return BalanceConfig{
+		GiftCardValidity: time.Duration(envInt("GIFT_CARD_VALIDITY_DAYS", 365)) * 24 * time.Hour,
+		WalletMinTopUp:   envInt("WALLET_MIN_TOP_UP", 10_000),
+		WalletMaxBalance: envInt("WALLET_MAX_BALANCE", 20_000_000),
+	}
+}
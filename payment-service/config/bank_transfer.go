@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"payment-service/models/domain"
+	"slices"
+	"time"
+)
+
+// BankTransferConfig controls bank transfer payments and how incoming transfers are matched.
+type BankTransferConfig struct {
+	// VirtualAccountProviders issue a virtual account number per payment.
+	VirtualAccountProviders []string
+	// UniqueAmountProviders add a unique code to the amount of each payment.
+	UniqueAmountProviders []string
+	VirtualAccountPrefix  string
+	MaxUniqueCode         int64
+	TTL                   time.Duration
+	// Grace accepts transfers booked shortly after the payment expired, covering bank posting delay.
+	Grace time.Duration
+	// SimulatorEnabled exposes the endpoint that stands in for the bank's mutation API.
+	SimulatorEnabled bool
+}
+
+func NewBankTransferConfig() BankTransferConfig {
+	return BankTransferConfig{
+		VirtualAccountProviders: envList("BANK_VA_PROVIDERS"),
+		UniqueAmountProviders:   envList("BANK_UNIQUE_AMOUNT_PROVIDERS"),
+		VirtualAccountPrefix:    envString("BANK_VA_PREFIX", "8808"),
+		MaxUniqueCode:           envInt("BANK_UNIQUE_CODE_MAX", 999),
+		TTL:                     time.Duration(envInt("BANK_TRANSFER_TTL_HOURS", 24)) * time.Hour,
+		Grace:                   time.Duration(envInt("BANK_TRANSFER_GRACE_MINUTES", 30)) * time.Minute,
+		SimulatorEnabled:        os.Getenv("BANK_SIMULATOR_ENABLED") == "true",
+	}
+}
+
+// Mode returns how payments at provider are identified, or "" when the provider does not take
+// bank transfers.
+func (config BankTransferConfig) Mode(provider string) string {
+	switch {
+	case slices.Contains(config.VirtualAccountProviders, provider):
+		return domain.BankTransferVirtualAccount
+	case slices.Contains(config.UniqueAmountProviders, provider):
+		return domain.BankTransferUniqueAmount
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+import "slices"
+
+// CODConfig lists the providers that collect cash from the customer on delivery.
+type CODConfig struct {
+	Providers []string
+}
+
+func NewCODConfig() CODConfig {
+	return CODConfig{Providers: envList("COD_PROVIDERS")}
+}
+
+func (config CODConfig) Supports(provider string) bool {
+	return slices.Contains(config.Providers, provider)
+}
@@ -0,0 +1,17 @@
+package config
+
+import "os"
+
+// DisputeEvidenceDir is where uploaded dispute evidence is written, one directory per dispute.
+func DisputeEvidenceDir() string {
+	if dir := os.Getenv("DISPUTE_EVIDENCE_DIR"); dir != "" {
+		return dir
+	}
+	return "data/disputes"
+}
+
+// DisputeWebhookSecret is the secret providers send in the X-Webhook-Secret header of dispute
+// notifications. Without it every notification is rejected.
+func DisputeWebhookSecret() string {
+	return os.Getenv("DISPUTE_WEBHOOK_SECRET")
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// DunningConfig controls the retries of failed payments made with a saved payment method.
+type DunningConfig struct {
+	// RetrySchedule holds the delay before each retry, counted from the previous failure. The
+	// order is cancelled when the last retry fails too.
+	RetrySchedule []time.Duration
+	// SchedulerInterval is how often the scheduler looks for new failures and due retries.
+	SchedulerInterval time.Duration
+	// NotifyURL receives every dunning event as JSON. Events are only recorded when it is empty.
+	NotifyURL string
+}
+
+func NewDunningConfig() DunningConfig {
+	return DunningConfig{
+		RetrySchedule:     envHours("DUNNING_RETRY_HOURS", []time.Duration{24 * time.Hour, 72 * time.Hour, 168 * time.Hour}),
+		SchedulerInterval: time.Duration(envInt("DUNNING_SCHEDULER_MINUTES", 15)) * time.Minute,
+		NotifyURL:         os.Getenv("DUNNING_NOTIFY_URL"),
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Fee rule types.
+const (
+	FeePercentage = "percentage"
+	FeeFixed      = "fixed"
+	FeeTiered     = "tiered"
+)
+
+// FeeSchedule lists the provider fee rules. A rule with an empty method applies to every method
+// of its provider that has no rule of its own; likewise a rule with an empty currency applies to
+// every currency without one.
+type FeeSchedule struct {
+	Rules []FeeRule `json:"rules"`
+}
+
+// FeeRule prices one provider and payment method. Percentages are in basis points. A percentage
+// rule may also carry a fixed part; tiered rules pick the first tier whose up_to covers the
+// amount, with up_to 0 meaning unbounded. Fixed amounts and tier bounds are in the rule's
+// currency, so a rule that has them must name one.
+type FeeRule struct {
+	Provider          string    `json:"provider"`
+	Method            string    `json:"method"`
+	Currency          string    `json:"currency"`
+	Type              string    `json:"type"`
+	PercentBps        int64     `json:"percent_bps"`
+	Fixed             int64     `json:"fixed"`
+	Tiers             []FeeTier `json:"tiers"`
+	RefundFixed       int64     `json:"refund_fixed"`
+	ReturnFeeOnRefund bool      `json:"return_fee_on_refund"`
+}
+
+type FeeTier struct {
+	UpTo       int64 `json:"up_to"`
+	PercentBps int64 `json:"percent_bps"`
+	Fixed      int64 `json:"fixed"`
+}
+
+// LoadFeeSchedule reads the schedule from FEE_SCHEDULE_FILE (default fee_schedule.json). Without
+// a file no fees are charged.
+func LoadFeeSchedule() (FeeSchedule, error) {
+	path := os.Getenv("FEE_SCHEDULE_FILE")
+	if path == "" {
+		path = "fee_schedule.json"
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return FeeSchedule{}, nil
+	}
+	if err != nil {
+		return FeeSchedule{}, err
+	}
+
+	var schedule FeeSchedule
+	if err := json.Unmarshal(content, &schedule); err != nil {
+		return FeeSchedule{}, fmt.Errorf("parse fee schedule %s: %w", path, err)
+	}
+
+	return schedule, schedule.Validate()
+}
+
+func (schedule FeeSchedule) Validate() error {
+	for i, rule := range schedule.Rules {
+		if rule.Provider == "" {
+			return fmt.Errorf("fee rule %d: provider is required", i)
+		}
+
+		if rule.Currency == "" && rule.hasAmounts() {
+			return fmt.Errorf("fee rule %d: currency is required for fixed amounts and tiers", i)
+		}
+
+		switch rule.Type {
+		case FeePercentage, FeeFixed:
+		case FeeTiered:
+			if len(rule.Tiers) == 0 {
+				return fmt.Errorf("fee rule %d: tiered rule needs tiers", i)
+			}
+			for j, tier := range rule.Tiers {
+				if tier.UpTo == 0 && j != len(rule.Tiers)-1 {
+					return fmt.Errorf("fee rule %d: only the last tier may be unbounded", i)
+				}
+				if j > 0 && tier.UpTo != 0 && tier.UpTo <= rule.Tiers[j-1].UpTo {
+					return fmt.Errorf("fee rule %d: tiers must be in ascending order", i)
+				}
+			}
+		default:
+			return fmt.Errorf("fee rule %d: unknown type %q", i, rule.Type)
+		}
+	}
+
+	return nil
+}
+
+// hasAmounts reports whether the rule carries amounts, as opposed to only percentages.
+func (rule FeeRule) hasAmounts() bool {
+	if rule.Fixed != 0 || rule.RefundFixed != 0 {
+		return true
+	}
+	for _, tier := range rule.Tiers {
+		if tier.UpTo != 0 || tier.Fixed != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+// IdempotencyTTL is how long a stored Idempotency-Key response is replayed before the key may
+// be used for a new request.
+func IdempotencyTTL() time.Duration {
+	return time.Duration(envInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour
+}
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// InstallmentConfig controls installment plans.
+type InstallmentConfig struct {
+	// MinAmount is the smallest order total, in the order currency, that may be paid in
+	// installments.
+	MinAmount int64
+	// MaxCount caps the number of installments of a plan.
+	MaxCount int
+	// GracePeriod is how long an installment may stay unpaid after its due date before it is
+	// reported overdue.
+	GracePeriod time.Duration
+	// SchedulerInterval is how often the scheduler charges due installments and flags overdue ones.
+	SchedulerInterval time.Duration
+}
+
+func NewInstallmentConfig() InstallmentConfig {
+	return InstallmentConfig{
+		MinAmount:         envInt("INSTALLMENT_MIN_AMOUNT", 1_000_000),
+		MaxCount:          int(envInt("INSTALLMENT_MAX_COUNT", 12)),
+		GracePeriod:       time.Duration(envInt("INSTALLMENT_GRACE_HOURS", 72)) * time.Hour,
+		SchedulerInterval: time.Duration(envInt("INSTALLMENT_SCHEDULER_MINUTES", 15)) * time.Minute,
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// IPCountry places one network in a country, given as an ISO 3166-1 alpha-2 code.
+type IPCountry struct {
+	Network *net.IPNet
+	Country string
+}
+
+// IPCountries is the table the country of a client is looked up in. Clients outside every network
+// have no country.
+type IPCountries []IPCountry
+
+// LoadIPCountries reads the table from RISK_IP_COUNTRY_FILE, a CSV file of network,country rows
+// such as 203.0.113.0/24,ID. Without a file no country is known.
+func LoadIPCountries() (IPCountries, error) {
+	path := os.Getenv("RISK_IP_COUNTRY_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseIPCountries(file)
+}
+
+// ParseIPCountries reads network,country rows; lines starting with # are skipped.
+func ParseIPCountries(source io.Reader) (IPCountries, error) {
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var countries IPCountries
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return countries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ip country line %d: %w", line, err)
+		}
+
+		_, network, err := net.ParseCIDR(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("ip country line %d: %w", line, err)
+		}
+		country := strings.ToUpper(strings.TrimSpace(record[1]))
+		if len(country) != 2 {
+			return nil, fmt.Errorf("ip country line %d: country must be a two-letter code", line)
+		}
+
+		countries = append(countries, IPCountry{Network: network, Country: country})
+	}
+}
+
+// Lookup returns the country of the most specific network containing ipAddress, or "" when none
+// does.
+func (countries IPCountries) Lookup(ipAddress string) string {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return ""
+	}
+
+	country, best := "", -1
+	for _, entry := range countries {
+		if !entry.Network.Contains(ip) {
+			continue
+		}
+		if size, _ := entry.Network.Mask.Size(); size > best {
+			country, best = entry.Country, size
+		}
+	}
+	return country
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"payment-service/models/domain"
+	"payment-service/repository"
+
+	"gorm.io/gorm"
+)
+
+var ledgerAccounts = []domain.LedgerAccount{
+	{Code: domain.AccountCustomerReceivable, Name: "Customer receivable", NormalBalance: "debit"},
+	{Code: domain.AccountProviderClearing, Name: "Provider clearing", NormalBalance: "debit"},
+	{Code: domain.AccountRevenue, Name: "Revenue", NormalBalance: "credit"},
+	{Code: domain.AccountRefunds, Name: "Refunds and chargebacks", NormalBalance: "debit"},
+	{Code: domain.AccountFees, Name: "Provider fees", NormalBalance: "debit"},
+}
+
+const activePaymentIndex = "idx_payments_active_order"
+
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(
+		&domain.Payment{},
+		&domain.LedgerAccount{},
+		&domain.JournalEntry{},
+		&domain.JournalLine{},
+		&domain.SettlementReport{},
+		&domain.SettlementItem{},
+		&domain.RiskAssessment{},
+		&domain.PaymentMethod{},
+		&domain.Dispute{},
+		&domain.DisputeEvidence{},
+		&domain.ExchangeRate{},
+		&domain.PaymentEvent{},
+		&domain.CallbackAttempt{},
+		&domain.IdempotencyKey{},
+		&domain.PaymentLink{},
+		&domain.BankMutation{},
+		&domain.SubscriptionPlan{},
+		&domain.Subscription{},
+		&domain.SubscriptionCharge{},
+		&domain.DunningCase{},
+		&domain.DunningEvent{},
+		&domain.InstallmentPlan{},
+		&domain.Installment{},
+		&domain.SplitPayment{},
+		&domain.BalanceAccount{},
+		&domain.BalanceTransaction{},
+		&domain.WalletTopUp{},
+		&domain.Seller{},
+		&domain.SellerShare{},
+		&domain.SellerEntry{},
+		&domain.PayoutBatch{},
+		&domain.Payout{},
+	); err != nil {
+		return err
+	}
+
+	if err := migrateActivePaymentIndex(db); err != nil {
+		return err
+	}
+
+	// Lines posted before the ledger kept currencies were in the currency of their payment.
+	if err := db.Exec("UPDATE journal_lines SET currency = COALESCE(NULLIF((SELECT payments.currency FROM journal_entries" +
+		" JOIN payments ON payments.id = journal_entries.payment_id WHERE journal_entries.id = journal_lines.entry_id), ''), 'IDR')" +
+		" WHERE currency IS NULL OR currency = ''").Error; err != nil {
+		return err
+	}
+
+	// One wallet per customer, even when two first top-ups race to open it.
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_balance_accounts_wallet ON balance_accounts (customer_id) WHERE type = '" + domain.BalanceWallet + "'").Error; err != nil {
+		return err
+	}
+
+	for _, account := range ledgerAccounts {
+		if err := db.Where("code = ?", account.Code).FirstOrCreate(&account).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateActivePaymentIndex keeps at most one active attempt per order, per installment of an
+// order paid in installments, or per tender of an order paid with split tenders. The partial index
+// is what makes duplicate prevention hold under concurrent requests.
+func migrateActivePaymentIndex(db *gorm.DB) error {
+	return db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS " + activePaymentIndex +
+		" ON payments (order_id, installment_number, tender_number) WHERE " + repository.ActivePaymentCondition).Error
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// PaymentLinkConfig controls hosted checkout links.
+type PaymentLinkConfig struct {
+	// BaseURL is the public address of payment-service used to build link URLs.
+	BaseURL string
+	// Providers are offered on the checkout page when a link does not name its own.
+	Providers  []string
+	DefaultTTL time.Duration
+}
+
+func NewPaymentLinkConfig() PaymentLinkConfig {
+	baseURL := os.Getenv("PAYMENT_LINK_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+
+	return PaymentLinkConfig{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Providers:  envList("PAYMENT_LINK_PROVIDERS"),
+		DefaultTTL: time.Duration(envInt("PAYMENT_LINK_TTL_MINUTES", 60)) * time.Minute,
+	}
+}
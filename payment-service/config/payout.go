@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// PayoutConfig controls marketplace seller balances and payouts.
+type PayoutConfig struct {
+	// CommissionBps is the platform commission, in basis points of a seller's part of a payment,
+	// for sellers without a rate of their own.
+	CommissionBps int64
+	// MinAmount is the smallest balance paid out; smaller balances wait for a later batch.
+	MinAmount int64
+	// SchedulerInterval is how often payout batches are generated.
+	SchedulerInterval time.Duration
+}
+
+func NewPayoutConfig() PayoutConfig {
+	return PayoutConfig{
+		CommissionBps:     envInt("PLATFORM_COMMISSION_BPS", 1000),
+		MinAmount:         envInt("PAYOUT_MIN_AMOUNT", 50_000),
+		SchedulerInterval: time.Duration(envInt("PAYOUT_INTERVAL_HOURS", 24)) * time.Hour,
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "os"
+
+// TrustedProxies lists the addresses of the reverse proxies in front of the service, from
+// TRUSTED_PROXIES. Only these may name the client in TrustedProxyHeader; every other caller is
+// identified by its own connection.
+func TrustedProxies() []string {
+	return envList("TRUSTED_PROXIES")
+}
+
+// TrustedProxyHeader is the header the trusted proxies put the client address in, from
+// TRUSTED_PROXY_HEADER (default X-Real-IP). The proxy must overwrite the header, not append to it.
+func TrustedProxyHeader() string {
+	if header := os.Getenv("TRUSTED_PROXY_HEADER"); header != "" {
+		return header
+	}
+	return "X-Real-IP"
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"time"
+)
+
+// QRConfig describes the merchant encoded into QR payment payloads and which providers can
+// settle them.
+type QRConfig struct {
+	// Providers accept payments with method "qr".
+	Providers        []string
+	MerchantGUI      string
+	MerchantID       string
+	MerchantName     string
+	MerchantCity     string
+	MerchantCategory string
+	CountryCode      string
+	TTL              time.Duration
+	// Grace accepts codes paid shortly after they expired, covering notifications the provider
+	// sends late or retries.
+	Grace time.Duration
+	// WebhookSecret must accompany provider notifications in the X-Webhook-Secret header.
+	WebhookSecret string
+	// SimulatorEnabled exposes the endpoints that stand in for a customer's banking app.
+	SimulatorEnabled bool
+}
+
+func NewQRConfig() QRConfig {
+	return QRConfig{
+		Providers:        envList("QR_PROVIDERS"),
+		MerchantGUI:      envString("QR_MERCHANT_GUI", "ID.CO.QRIS.WWW"),
+		MerchantID:       envString("QR_MERCHANT_ID", "ID0000000000001"),
+		MerchantName:     envString("QR_MERCHANT_NAME", "PAYMENT SERVICE"),
+		MerchantCity:     envString("QR_MERCHANT_CITY", "JAKARTA"),
+		MerchantCategory: envString("QR_MERCHANT_CATEGORY", "5411"),
+		CountryCode:      envString("QR_COUNTRY_CODE", "ID"),
+		TTL:              time.Duration(envInt("QR_TTL_MINUTES", 15)) * time.Minute,
+		Grace:            time.Duration(envInt("QR_GRACE_MINUTES", 5)) * time.Minute,
+		WebhookSecret:    os.Getenv("QR_WEBHOOK_SECRET"),
+		SimulatorEnabled: os.Getenv("QR_SIMULATOR_ENABLED") == "true",
+	}
+}
+
+// Supports reports whether payments with method "qr" may be routed to provider.
+func (config QRConfig) Supports(provider string) bool {
+	return slices.Contains(config.Providers, provider)
+}
+
+func envString(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RiskConfig holds the thresholds of the payment risk rules. Every value can be overridden
+// through the environment; the country table is loaded separately by LoadIPCountries.
+type RiskConfig struct {
+	VelocityWindow       time.Duration
+	MaxPaymentsPerWindow int64
+	ReviewAmount         int64
+	DenyAmount           int64
+	BlockedProviders     []string
+	BlockedCountries     []string
+	FailureWindow        time.Duration
+	MaxRecentFailures    int64
+	ReviewScore          int
+	DenyScore            int
+	IPCountries          IPCountries
+}
+
+func NewRiskConfig() RiskConfig {
+	return RiskConfig{
+		VelocityWindow:       time.Duration(envInt("RISK_VELOCITY_WINDOW_MINUTES", 10)) * time.Minute,
+		MaxPaymentsPerWindow: envInt("RISK_VELOCITY_MAX_PAYMENTS", 5),
+		ReviewAmount:         envInt("RISK_REVIEW_AMOUNT", 10_000_000),
+		DenyAmount:           envInt("RISK_DENY_AMOUNT", 100_000_000),
+		BlockedProviders:     envList("RISK_BLOCKED_PROVIDERS"),
+		BlockedCountries:     envList("RISK_BLOCKED_COUNTRIES"),
+		FailureWindow:        time.Duration(envInt("RISK_FAILURE_WINDOW_HOURS", 24)) * time.Hour,
+		MaxRecentFailures:    envInt("RISK_MAX_RECENT_FAILURES", 3),
+		ReviewScore:          int(envInt("RISK_REVIEW_SCORE", 50)),
+		DenyScore:            int(envInt("RISK_DENY_SCORE", 100)),
+	}
+}
+
+func envInt(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func envList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
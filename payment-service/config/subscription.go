@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// SubscriptionConfig controls subscription billing.
+type SubscriptionConfig struct {
+	// RetrySchedule holds the delay before each retry of a failed cycle charge. The subscription
+	// is cancelled when the last retry fails too.
+	RetrySchedule []time.Duration
+	// SchedulerInterval is how often the scheduler looks for subscriptions due for billing.
+	SchedulerInterval time.Duration
+}
+
+func NewSubscriptionConfig() SubscriptionConfig {
+	return SubscriptionConfig{
+		RetrySchedule:     envHours("SUBSCRIPTION_RETRY_HOURS", []time.Duration{24 * time.Hour, 72 * time.Hour, 168 * time.Hour}),
+		SchedulerInterval: time.Duration(envInt("SUBSCRIPTION_SCHEDULER_MINUTES", 5)) * time.Minute,
+	}
+}
+
+// envHours reads a comma separated list of hours, falling back when the list is missing or
+// contains anything but positive whole hours.
+func envHours(key string, fallback []time.Duration) []time.Duration {
+	values := envList(key)
+	if len(values) == 0 {
+		return fallback
+	}
+
+	durations := make([]time.Duration, 0, len(values))
+	for _, value := range values {
+		hours, err := strconv.Atoi(value)
+		if err != nil || hours <= 0 {
+			return fallback
+		}
+		durations = append(durations, time.Duration(hours)*time.Hour)
+	}
+	return durations
+}
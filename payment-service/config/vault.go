@@ -0,0 +1,60 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// vaultKeySize is the AES-256 key length used for payment method encryption.
+const vaultKeySize = 32
+
+// LoadVaultKey returns the key that encrypts stored payment methods. VAULT_KEY takes a base64
+// encoded key; otherwise the key is read from VAULT_KEY_FILE (default vault.key). A missing file
+// is only generated when VAULT_KEY_GENERATE is true, for local setups: a key generated inside a
+// container is lost with it, and with it every stored payment method.
+func LoadVaultKey() ([]byte, error) {
+	if encoded := os.Getenv("VAULT_KEY"); encoded != "" {
+		return decodeVaultKey(encoded)
+	}
+
+	path := os.Getenv("VAULT_KEY_FILE")
+	if path == "" {
+		path = "vault.key"
+	}
+
+	content, err := os.ReadFile(path)
+	if err == nil {
+		return decodeVaultKey(strings.TrimSpace(string(content)))
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if os.Getenv("VAULT_KEY_GENERATE") != "true" {
+		return nil, fmt.Errorf("set VAULT_KEY, provide %s, or set VAULT_KEY_GENERATE=true to generate a key for development", path)
+	}
+
+	key := make([]byte, vaultKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func decodeVaultKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("vault key is not valid base64: %w", err)
+	}
+	if len(key) != vaultKeySize {
+		return nil, fmt.Errorf("vault key must be %d bytes, got %d", vaultKeySize, len(key))
+	}
+	return key, nil
+}
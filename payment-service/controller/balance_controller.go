@@ -0,0 +1,9 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type BalanceController interface {
+	Issue(c *fiber.Ctx) error
+	FindByCode(c *fiber.Ctx) error
+	FindTransactions(c *fiber.Ctx) error
+}
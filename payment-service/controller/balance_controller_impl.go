@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type BalanceControllerImpl struct {
+	balanceService service.BalanceService
+}
+
+func NewBalanceController(balanceService service.BalanceService) BalanceController {
+	return &BalanceControllerImpl{
+		balanceService: balanceService,
+	}
+}
+
+func (controller *BalanceControllerImpl) Issue(c *fiber.Ctx) error {
+	request := web.BalanceAccountIssueRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	account, err := controller.balanceService.Issue(c.Context(), request)
+	return balanceResponse(c, account, err)
+}
+
+func (controller *BalanceControllerImpl) FindByCode(c *fiber.Ctx) error {
+	account, err := controller.balanceService.FindByCode(c.Context(), c.Params("code"))
+	return balanceResponse(c, account, err)
+}
+
+func (controller *BalanceControllerImpl) FindTransactions(c *fiber.Ctx) error {
+	transactions, err := controller.balanceService.FindTransactions(c.Context(), c.Params("code"))
+	return balanceResponse(c, transactions, err)
+}
+
+func balanceResponse(c *fiber.Ctx, data interface{}, err error) error {
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, data)
+}
@@ -0,0 +1,10 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type BankTransferController interface {
+	Import(c *fiber.Ctx) error
+	FindExceptions(c *fiber.Ctx) error
+	Resolve(c *fiber.Ctx) error
+	SimulateTransfer(c *fiber.Ctx) error
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BankTransferControllerImpl struct {
+	bankTransferService service.BankTransferService
+}
+
+func NewBankTransferController(bankTransferService service.BankTransferService) BankTransferController {
+	return &BankTransferControllerImpl{
+		bankTransferService: bankTransferService,
+	}
+}
+
+// Import accepts a multipart upload with the bank statement CSV in the "file" field.
+func (controller *BankTransferControllerImpl) Import(c *fiber.Ctx) error {
+	request := web.BankMutationImportRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return helper.BadRequest(c, "bank mutation file is required")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+	defer file.Close()
+
+	result, err := controller.bankTransferService.Import(apiContext(c, ""), request, file)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, result)
+}
+
+func (controller *BankTransferControllerImpl) FindExceptions(c *fiber.Ctx) error {
+	mutations, err := controller.bankTransferService.FindExceptions(c.Context(), c.QueryBool("include_resolved"))
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, mutations)
+}
+
+func (controller *BankTransferControllerImpl) Resolve(c *fiber.Ctx) error {
+	mutationId := c.Params("mutationId")
+
+	if _, err := uuid.Parse(mutationId); err != nil {
+		return helper.BadRequest(c, "invalid mutation id")
+	}
+
+	request := web.BankMutationResolveRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	mutation, err := controller.bankTransferService.Resolve(apiContext(c, ""), mutationId, request)
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, mutation)
+}
+
+// SimulateTransfer stands in for the bank pushing one incoming transfer to us.
+func (controller *BankTransferControllerImpl) SimulateTransfer(c *fiber.Ctx) error {
+	request := web.BankMutationRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	mutation, err := controller.bankTransferService.Receive(webhookContext(c, c.Params("provider")), c.Params("provider"), request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, mutation)
+}
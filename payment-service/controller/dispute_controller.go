@@ -0,0 +1,14 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type DisputeController interface {
+	Open(c *fiber.Ctx) error
+	Webhook(c *fiber.Ctx) error
+	UpdateStatus(c *fiber.Ctx) error
+	AddEvidence(c *fiber.Ctx) error
+	DownloadEvidence(c *fiber.Ctx) error
+	FindById(c *fiber.Ctx) error
+	FindAll(c *fiber.Ctx) error
+	FindAllByPaymentId(c *fiber.Ctx) error
+}
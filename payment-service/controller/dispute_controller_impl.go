@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DisputeControllerImpl struct {
+	disputeService service.DisputeService
+}
+
+func NewDisputeController(disputeService service.DisputeService) DisputeController {
+	return &DisputeControllerImpl{
+		disputeService: disputeService,
+	}
+}
+
+func (controller *DisputeControllerImpl) Open(c *fiber.Ctx) error {
+	request := web.DisputeCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	dispute, err := controller.disputeService.Open(c.Context(), request)
+	if err != nil {
+		return disputeError(c, err)
+	}
+
+	return helper.ResponseSuccess(c, dispute)
+}
+
+// Webhook receives dispute notifications from the provider named in the path.
+func (controller *DisputeControllerImpl) Webhook(c *fiber.Ctx) error {
+	request := web.DisputeWebhookRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	dispute, err := controller.disputeService.HandleWebhook(webhookContext(c, c.Params("provider")), c.Params("provider"), request)
+	if err != nil {
+		return disputeError(c, err)
+	}
+
+	return helper.ResponseSuccess(c, dispute)
+}
+
+func (controller *DisputeControllerImpl) UpdateStatus(c *fiber.Ctx) error {
+	disputeId := c.Params("disputeId")
+
+	if _, err := uuid.Parse(disputeId); err != nil {
+		return helper.BadRequest(c, "invalid dispute id")
+	}
+
+	request := web.DisputeStatusRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	dispute, err := controller.disputeService.UpdateStatus(apiContext(c, ""), disputeId, request)
+	if err != nil {
+		return disputeError(c, err)
+	}
+
+	return helper.ResponseSuccess(c, dispute)
+}
+
+// AddEvidence accepts a multipart upload with the evidence in the "file" field.
+func (controller *DisputeControllerImpl) AddEvidence(c *fiber.Ctx) error {
+	disputeId := c.Params("disputeId")
+
+	if _, err := uuid.Parse(disputeId); err != nil {
+		return helper.BadRequest(c, "invalid dispute id")
+	}
+
+	request := web.DisputeEvidenceRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return helper.BadRequest(c, "evidence file is required")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+	defer file.Close()
+
+	request.FileName = header.Filename
+	request.ContentType = header.Header.Get(fiber.HeaderContentType)
+
+	evidence, err := controller.disputeService.AddEvidence(c.Context(), disputeId, request, file)
+	if err != nil {
+		return disputeError(c, err)
+	}
+
+	return helper.ResponseSuccess(c, evidence)
+}
+
+func (controller *DisputeControllerImpl) DownloadEvidence(c *fiber.Ctx) error {
+	disputeId := c.Params("disputeId")
+	evidenceId := c.Params("evidenceId")
+
+	if _, err := uuid.Parse(disputeId); err != nil {
+		return helper.BadRequest(c, "invalid dispute id")
+	}
+	if _, err := uuid.Parse(evidenceId); err != nil {
+		return helper.BadRequest(c, "invalid evidence id")
+	}
+
+	evidence, err := controller.disputeService.FindEvidence(c.Context(), disputeId, evidenceId)
+	if err != nil {
+		return helper.NotFound(c, "evidence not found")
+	}
+
+	if evidence.ContentType != "" {
+		c.Set(fiber.HeaderContentType, evidence.ContentType)
+	}
+	c.Attachment(evidence.FileName)
+
+	return c.SendFile(evidence.StoragePath)
+}
+
+func (controller *DisputeControllerImpl) FindById(c *fiber.Ctx) error {
+	disputeId := c.Params("disputeId")
+
+	if _, err := uuid.Parse(disputeId); err != nil {
+		return helper.BadRequest(c, "invalid dispute id")
+	}
+
+	dispute, err := controller.disputeService.FindById(c.Context(), disputeId)
+	if err != nil {
+		return helper.NotFound(c, "dispute not found")
+	}
+
+	return helper.ResponseSuccess(c, dispute)
+}
+
+func (controller *DisputeControllerImpl) FindAll(c *fiber.Ctx) error {
+	disputes, err := controller.disputeService.FindAll(c.Context(), c.Query("status"))
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, disputes)
+}
+
+func (controller *DisputeControllerImpl) FindAllByPaymentId(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	disputes, err := controller.disputeService.FindAllByPaymentId(c.Context(), paymentId)
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, disputes)
+}
+
+func disputeError(c *fiber.Ctx, err error) error {
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+
+	var conflict exception.ConflictError
+	if errors.As(err, &conflict) {
+		return helper.Conflict(c, conflict.Data)
+	}
+
+	return helper.BadRequest(c, err.Error())
+}
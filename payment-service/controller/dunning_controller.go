@@ -0,0 +1,8 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type DunningController interface {
+	FindByOrderId(c *fiber.Ctx) error
+	FindAll(c *fiber.Ctx) error
+}
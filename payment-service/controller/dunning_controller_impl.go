@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DunningControllerImpl struct {
+	dunningService service.DunningService
+}
+
+func NewDunningController(dunningService service.DunningService) DunningController {
+	return &DunningControllerImpl{
+		dunningService: dunningService,
+	}
+}
+
+func (controller *DunningControllerImpl) FindByOrderId(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid order id")
+	}
+
+	dunningCase, err := controller.dunningService.FindByOrderId(c.Context(), orderId)
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, dunningCase)
+}
+
+func (controller *DunningControllerImpl) FindAll(c *fiber.Ctx) error {
+	cases, err := controller.dunningService.FindAll(c.Context(), c.Query("status"))
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, cases)
+}
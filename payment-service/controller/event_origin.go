@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"context"
+	"payment-service/helper"
+	"payment-service/models/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiContext attributes the payment changes made by an API request to actor, or to the caller
+// named in the X-Actor header when actor is empty. API requests are not authenticated, so the
+// actor is recorded as claimed.
+func apiContext(c *fiber.Ctx, actor string) context.Context {
+	if actor == "" {
+		actor = c.Get("X-Actor")
+	}
+
+	return domain.WithEventOrigin(c.Context(), domain.EventOrigin{Source: domain.EventSourceAPI, Actor: actor, ActorClaimed: actor != ""})
+}
+
+// webhookContext attributes payment changes to a provider notification and keeps its payload,
+// with any card numbers in it masked.
+func webhookContext(c *fiber.Ctx, provider string) context.Context {
+	providerResponse := string(c.Body())
+	if helper.ContainsPAN(providerResponse) {
+		providerResponse = helper.MaskPAN(providerResponse)
+	}
+
+	return domain.WithEventOrigin(c.Context(), domain.EventOrigin{
+		Source:           domain.EventSourceWebhook,
+		Actor:            provider,
+		ProviderResponse: providerResponse,
+	})
+}
@@ -0,0 +1,10 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type ExchangeRateController interface {
+	Create(c *fiber.Ctx) error
+	Import(c *fiber.Ctx) error
+	FindAll(c *fiber.Ctx) error
+	FindEffective(c *fiber.Ctx) error
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ExchangeRateControllerImpl struct {
+	exchangeRateService service.ExchangeRateService
+}
+
+func NewExchangeRateController(exchangeRateService service.ExchangeRateService) ExchangeRateController {
+	return &ExchangeRateControllerImpl{
+		exchangeRateService: exchangeRateService,
+	}
+}
+
+func (controller *ExchangeRateControllerImpl) Create(c *fiber.Ctx) error {
+	request := web.ExchangeRateCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	rate, err := controller.exchangeRateService.Create(c.Context(), request)
+	var conflict exception.ConflictError
+	if errors.As(err, &conflict) {
+		return helper.Conflict(c, conflict.Data)
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, rate)
+}
+
+// Import accepts a CSV rate file uploaded as the "file" form field.
+func (controller *ExchangeRateControllerImpl) Import(c *fiber.Ctx) error {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return helper.BadRequest(c, "exchange rate file is required")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+	defer file.Close()
+
+	result, err := controller.exchangeRateService.Import(c.Context(), domain.RateSourceAPI, file)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, result)
+}
+
+func (controller *ExchangeRateControllerImpl) FindAll(c *fiber.Ctx) error {
+	query := web.ExchangeRateQuery{}
+	if err := c.QueryParser(&query); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	rates, err := controller.exchangeRateService.FindAll(c.Context(), query)
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, rates)
+}
+
+func (controller *ExchangeRateControllerImpl) FindEffective(c *fiber.Ctx) error {
+	query := web.ExchangeRateQuery{}
+	if err := c.QueryParser(&query); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	rate, err := controller.exchangeRateService.FindEffective(c.Context(), query)
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, rate)
+}
@@ -0,0 +1,7 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type FeeController interface {
+	Summary(c *fiber.Ctx) error
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FeeControllerImpl struct {
+	feeService service.FeeService
+}
+
+func NewFeeController(feeService service.FeeService) FeeController {
+	return &FeeControllerImpl{
+		feeService: feeService,
+	}
+}
+
+func (controller *FeeControllerImpl) Summary(c *fiber.Ctx) error {
+	request := web.FeeSummaryRequest{}
+	if err := c.QueryParser(&request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	summaries, err := controller.feeService.Summary(c.Context(), request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, summaries)
+}
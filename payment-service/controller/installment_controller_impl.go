@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type InstallmentControllerImpl struct {
+	installmentService service.InstallmentService
+}
+
+func NewInstallmentController(installmentService service.InstallmentService) InstallmentController {
+	return &InstallmentControllerImpl{
+		installmentService: installmentService,
+	}
+}
+
+func (controller *InstallmentControllerImpl) Create(c *fiber.Ctx) error {
+	request := web.InstallmentPlanCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	plan, err := controller.installmentService.Create(apiContext(c, request.CustomerID), request)
+	var conflict exception.ConflictError
+	if errors.As(err, &conflict) {
+		return helper.Conflict(c, conflict.Data)
+	}
+	return installmentResponse(c, plan, err)
+}
+
+func (controller *InstallmentControllerImpl) FindById(c *fiber.Ctx) error {
+	planId := c.Params("planId")
+
+	if _, err := uuid.Parse(planId); err != nil {
+		return helper.BadRequest(c, "invalid installment plan id")
+	}
+
+	plan, err := controller.installmentService.FindById(c.Context(), planId)
+	return installmentResponse(c, plan, err)
+}
+
+func (controller *InstallmentControllerImpl) FindByOrderId(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid order id")
+	}
+
+	plan, err := controller.installmentService.FindByOrderId(c.Context(), orderId)
+	return installmentResponse(c, plan, err)
+}
+
+func installmentResponse(c *fiber.Ctx, data interface{}, err error) error {
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, data)
+}
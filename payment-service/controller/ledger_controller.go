@@ -0,0 +1,10 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type LedgerController interface {
+	FindBalances(c *fiber.Ctx) error
+	FindBalance(c *fiber.Ctx) error
+	FindEntriesByPaymentId(c *fiber.Ctx) error
+	CheckInvariants(c *fiber.Ctx) error
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"payment-service/helper"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type LedgerControllerImpl struct {
+	ledgerService service.LedgerService
+}
+
+func NewLedgerController(ledgerService service.LedgerService) LedgerController {
+	return &LedgerControllerImpl{
+		ledgerService: ledgerService,
+	}
+}
+
+func (controller *LedgerControllerImpl) FindBalances(c *fiber.Ctx) error {
+	balances, err := controller.ledgerService.FindBalances(c.Context())
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, balances)
+}
+
+func (controller *LedgerControllerImpl) FindBalance(c *fiber.Ctx) error {
+	balance, err := controller.ledgerService.FindBalance(c.Context(), c.Params("accountCode"))
+	if err != nil {
+		return helper.NotFound(c, "account not found")
+	}
+
+	return helper.ResponseSuccess(c, balance)
+}
+
+func (controller *LedgerControllerImpl) FindEntriesByPaymentId(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	entries, err := controller.ledgerService.FindEntriesByPaymentId(c.Context(), paymentId)
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, entries)
+}
+
+func (controller *LedgerControllerImpl) CheckInvariants(c *fiber.Ctx) error {
+	result, err := controller.ledgerService.CheckInvariants(c.Context())
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, result)
+}
@@ -6,5 +6,13 @@ type PaymentController interface {
 	Create(c *fiber.Ctx) error
 	MarkAsSuccess(c *fiber.Ctx) error
 	MarkAsFailed(c *fiber.Ctx) error
+	ConfirmDelivery(c *fiber.Ctx) error
+	Refund(c *fiber.Ctx) error
+	RefundPart(c *fiber.Ctx) error
+	Chargeback(c *fiber.Ctx) error
 	FindById(c *fiber.Ctx) error
+	FindEvents(c *fiber.Ctx) error
+	FindAllByOrderId(c *fiber.Ctx) error
+	FindAll(c *fiber.Ctx) error
+	Export(c *fiber.Ctx) error
 }
@@ -1,6 +1,11 @@
 package controller
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"log"
+	"payment-service/exception"
 	"payment-service/helper"
 	"payment-service/models/web"
 	"payment-service/service"
@@ -25,17 +30,29 @@ func (controller *PaymentControllerImpl) Create(c *fiber.Ctx) error {
 		return helper.BadRequest(c, err.Error())
 	}
 
-	payment, err := controller.paymentService.Create(c.Context(), request)
+	// The app only honours the proxy header for trusted proxies, so this is never caller-supplied.
+	request.IPAddress = c.IP()
+
+	ctx := apiContext(c, "")
+	payment, err := controller.paymentService.Create(ctx, request)
+	var conflict exception.ConflictError
+	if errors.As(err, &conflict) {
+		return helper.Conflict(c, conflict.Data)
+	}
 	if err != nil {
 		return helper.BadRequest(c, err.Error())
 	}
 
-	updated, err := controller.paymentService.MarkAsSuccess(c.Context(), payment.ID.String())
+	if !payment.AwaitsCapture() {
+		return helper.ResponseSuccess(c, helper.ToPaymentResponse(payment))
+	}
+
+	updated, err := controller.paymentService.MarkAsSuccess(ctx, payment.ID.String())
 	if err != nil {
 		return helper.InternalServerError(c, err.Error())
 	}
 
-	return helper.ResponseSuccess(c, updated)
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(updated))
 }
 
 func (controller *PaymentControllerImpl) FindById(c *fiber.Ctx) error {
@@ -50,7 +67,97 @@ func (controller *PaymentControllerImpl) FindById(c *fiber.Ctx) error {
 		return helper.NotFound(c, "payment not found")
 	}
 
-	return helper.ResponseSuccess(c, result)
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(result))
+}
+
+// FindEvents returns the payment's status timeline; include_callbacks=true adds the callback
+// deliveries to order-service.
+func (controller *PaymentControllerImpl) FindEvents(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	timeline, err := controller.paymentService.FindEvents(c.Context(), paymentId, c.QueryBool("include_callbacks"))
+	if err != nil {
+		return helper.NotFound(c, "payment not found")
+	}
+
+	return helper.ResponseSuccess(c, timeline)
+}
+
+func (controller *PaymentControllerImpl) FindAllByOrderId(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid order id")
+	}
+
+	payments, err := controller.paymentService.FindAllByOrderId(c.Context(), orderId)
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponses(payments))
+}
+
+func (controller *PaymentControllerImpl) FindAll(c *fiber.Ctx) error {
+	request := web.PaymentSearchRequest{}
+	if err := c.QueryParser(&request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	payments, nextCursor, err := controller.paymentService.Search(c.Context(), request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, web.PaymentPageResponse{
+		Items:      helper.ToPaymentResponses(payments),
+		NextCursor: nextCursor,
+	})
+}
+
+func (controller *PaymentControllerImpl) Export(c *fiber.Ctx) error {
+	request := web.PaymentSearchRequest{}
+	if err := c.QueryParser(&request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		return helper.BadRequest(c, "format must be csv or ndjson")
+	}
+
+	// The stream outlives the handler, so it cannot be bound to the request context.
+	stream, err := controller.paymentService.Export(context.Background(), request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	if format == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="payments.csv"`)
+	} else {
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="payments.ndjson"`)
+	}
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var err error
+		if format == "csv" {
+			err = helper.WritePaymentsCSV(w, stream)
+		} else {
+			err = helper.WritePaymentsNDJSON(w, stream)
+		}
+
+		if err != nil {
+			log.Printf("payment export aborted: %v", err)
+		}
+	})
+
+	return nil
 }
 
 func (controller *PaymentControllerImpl) MarkAsSuccess(c *fiber.Ctx) error {
@@ -60,12 +167,12 @@ func (controller *PaymentControllerImpl) MarkAsSuccess(c *fiber.Ctx) error {
 		return helper.BadRequest(c, "invalid payment id")
 	}
 
-	result, err := controller.paymentService.MarkAsSuccess(c.Context(), paymentId)
+	result, err := controller.paymentService.MarkAsSuccess(apiContext(c, ""), paymentId)
 	if err != nil {
 		return helper.BadRequest(c, err.Error())
 	}
 
-	return helper.ResponseSuccess(c, result)
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(result))
 }
 
 func (controller *PaymentControllerImpl) MarkAsFailed(c *fiber.Ctx) error {
@@ -75,10 +182,89 @@ func (controller *PaymentControllerImpl) MarkAsFailed(c *fiber.Ctx) error {
 		return helper.BadRequest(c, "invalid payment id")
 	}
 
-	result, err := controller.paymentService.MarkAsFailed(c.Context(), paymentId)
+	result, err := controller.paymentService.MarkAsFailed(apiContext(c, ""), paymentId)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(result))
+}
+
+// ConfirmDelivery records a courier's cash-on-delivery report, attributed to the courier.
+func (controller *PaymentControllerImpl) ConfirmDelivery(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	request := web.DeliveryConfirmationRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	result, err := controller.paymentService.ConfirmDelivery(apiContext(c, request.CollectedBy), paymentId, request)
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(result))
+}
+
+func (controller *PaymentControllerImpl) Refund(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	result, err := controller.paymentService.Refund(apiContext(c, ""), paymentId)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(result))
+}
+
+func (controller *PaymentControllerImpl) RefundPart(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	request := web.PartialRefundRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	result, err := controller.paymentService.RefundPart(apiContext(c, ""), paymentId, request)
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(result))
+}
+
+func (controller *PaymentControllerImpl) Chargeback(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	result, err := controller.paymentService.Chargeback(apiContext(c, ""), paymentId)
 	if err != nil {
 		return helper.BadRequest(c, err.Error())
 	}
 
-	return helper.ResponseSuccess(c, result)
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(result))
 }
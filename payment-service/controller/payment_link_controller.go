@@ -0,0 +1,12 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type PaymentLinkController interface {
+	Create(c *fiber.Ctx) error
+	FindById(c *fiber.Ctx) error
+	Checkout(c *fiber.Ctx) error
+	Pay(c *fiber.Ctx) error
+	Success(c *fiber.Ctx) error
+	Failure(c *fiber.Ctx) error
+}
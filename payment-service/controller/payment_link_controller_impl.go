@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"errors"
+	"net/url"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+	"payment-service/views"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PaymentLinkControllerImpl struct {
+	paymentLinkService service.PaymentLinkService
+}
+
+func NewPaymentLinkController(paymentLinkService service.PaymentLinkService) PaymentLinkController {
+	return &PaymentLinkControllerImpl{
+		paymentLinkService: paymentLinkService,
+	}
+}
+
+func (controller *PaymentLinkControllerImpl) Create(c *fiber.Ctx) error {
+	request := web.PaymentLinkCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	link, err := controller.paymentLinkService.Create(c.Context(), request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, link)
+}
+
+func (controller *PaymentLinkControllerImpl) FindById(c *fiber.Ctx) error {
+	linkId := c.Params("linkId")
+
+	if _, err := uuid.Parse(linkId); err != nil {
+		return helper.BadRequest(c, "invalid payment link id")
+	}
+
+	link, err := controller.paymentLinkService.FindById(c.Context(), linkId)
+	if err != nil {
+		return helper.NotFound(c, "payment link not found")
+	}
+
+	return helper.ResponseSuccess(c, link)
+}
+
+// Checkout renders the hosted checkout page for a link token.
+func (controller *PaymentLinkControllerImpl) Checkout(c *fiber.Ctx) error {
+	page, err := controller.paymentLinkService.Checkout(c.Context(), c.Params("token"))
+	if err != nil {
+		return renderLinkError(c, err)
+	}
+
+	return render(c, fiber.StatusOK, "checkout.html", page)
+}
+
+// Pay handles the checkout form and redirects to the matching result page.
+func (controller *PaymentLinkControllerImpl) Pay(c *fiber.Ctx) error {
+	token := c.Params("token")
+
+	request := web.CheckoutRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return redirectFailure(c, token, err.Error())
+	}
+
+	payment, err := controller.paymentLinkService.Pay(c.Context(), token, request, c.IP())
+	if err != nil {
+		return redirectFailure(c, token, err.Error())
+	}
+
+	return c.Redirect("/pay/"+url.PathEscape(token)+"/success?payment_id="+payment.ID.String(), fiber.StatusSeeOther)
+}
+
+func (controller *PaymentLinkControllerImpl) Success(c *fiber.Ctx) error {
+	payment, err := controller.paymentLinkService.FindResult(c.Context(), c.Params("token"), c.Query("payment_id"))
+	if err != nil {
+		return renderLinkError(c, err)
+	}
+
+	page := views.ResultPage{Title: "Payment successful", Success: true, Message: "Thank you, your payment has been received.", PaymentID: payment.ID.String()}
+	switch payment.Status {
+	case "held":
+		page.Title = "Payment under review"
+		page.Message = "Your payment is being reviewed. The merchant will confirm your order shortly."
+	case "awaiting_collection":
+		page.Title = "Order confirmed"
+		page.Message = "Please have the exact amount ready; the courier collects the payment on delivery."
+	}
+
+	return render(c, fiber.StatusOK, "result.html", page)
+}
+
+func (controller *PaymentLinkControllerImpl) Failure(c *fiber.Ctx) error {
+	message := c.Query("message")
+	if message == "" {
+		message = "The payment could not be completed."
+	}
+
+	return render(c, fiber.StatusOK, "result.html", views.ResultPage{Title: "Payment failed", Message: message, Token: c.Params("token")})
+}
+
+func render(c *fiber.Ctx, status int, name string, data interface{}) error {
+	c.Status(status)
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return views.Templates.ExecuteTemplate(c, name, data)
+}
+
+func redirectFailure(c *fiber.Ctx, token string, message string) error {
+	return c.Redirect("/pay/"+url.PathEscape(token)+"/failure?message="+url.QueryEscape(message), fiber.StatusSeeOther)
+}
+
+// renderLinkError shows why a link cannot be used without offering a retry.
+func renderLinkError(c *fiber.Ctx, err error) error {
+	var notFound exception.NotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		return render(c, fiber.StatusNotFound, "result.html", views.ResultPage{Title: "Link not found", Message: "This payment link does not exist."})
+	case errors.Is(err, service.ErrPaymentLinkExpired), errors.Is(err, service.ErrPaymentLinkUsed):
+		return render(c, fiber.StatusGone, "result.html", views.ResultPage{Title: "Link unavailable", Message: err.Error()})
+	default:
+		return render(c, fiber.StatusBadGateway, "result.html", views.ResultPage{Title: "Checkout unavailable", Message: err.Error()})
+	}
+}
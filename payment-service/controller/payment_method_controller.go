@@ -0,0 +1,11 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type PaymentMethodController interface {
+	Create(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Delete(c *fiber.Ctx) error
+	FindById(c *fiber.Ctx) error
+	FindAllByCustomerId(c *fiber.Ctx) error
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PaymentMethodControllerImpl struct {
+	paymentMethodService service.PaymentMethodService
+}
+
+func NewPaymentMethodController(paymentMethodService service.PaymentMethodService) PaymentMethodController {
+	return &PaymentMethodControllerImpl{
+		paymentMethodService: paymentMethodService,
+	}
+}
+
+func (controller *PaymentMethodControllerImpl) Create(c *fiber.Ctx) error {
+	request := web.PaymentMethodCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	// The path owns the method; a customer id in the body cannot redirect it.
+	request.CustomerID = c.Params("customerId")
+
+	result, err := controller.paymentMethodService.Create(c.Context(), request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, result)
+}
+
+func (controller *PaymentMethodControllerImpl) Update(c *fiber.Ctx) error {
+	methodId := c.Params("paymentMethodId")
+
+	if _, err := uuid.Parse(methodId); err != nil {
+		return helper.BadRequest(c, "invalid payment method id")
+	}
+
+	request := web.PaymentMethodUpdateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	result, err := controller.paymentMethodService.Update(c.Context(), c.Params("customerId"), methodId, request)
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, result)
+}
+
+func (controller *PaymentMethodControllerImpl) Delete(c *fiber.Ctx) error {
+	methodId := c.Params("paymentMethodId")
+
+	if _, err := uuid.Parse(methodId); err != nil {
+		return helper.BadRequest(c, "invalid payment method id")
+	}
+
+	err := controller.paymentMethodService.Delete(c.Context(), c.Params("customerId"), methodId)
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "payment method deleted",
+		"id":      methodId,
+	})
+}
+
+func (controller *PaymentMethodControllerImpl) FindById(c *fiber.Ctx) error {
+	methodId := c.Params("paymentMethodId")
+
+	if _, err := uuid.Parse(methodId); err != nil {
+		return helper.BadRequest(c, "invalid payment method id")
+	}
+
+	result, err := controller.paymentMethodService.FindById(c.Context(), c.Params("customerId"), methodId)
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, result)
+}
+
+func (controller *PaymentMethodControllerImpl) FindAllByCustomerId(c *fiber.Ctx) error {
+	result, err := controller.paymentMethodService.FindAllByCustomerId(c.Context(), c.Params("customerId"))
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, result)
+}
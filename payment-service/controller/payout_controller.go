@@ -0,0 +1,14 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type PayoutController interface {
+	CreateSeller(c *fiber.Ctx) error
+	FindSeller(c *fiber.Ctx) error
+	FindEntries(c *fiber.Ctx) error
+	SetShares(c *fiber.Ctx) error
+	FindShares(c *fiber.Ctx) error
+	Generate(c *fiber.Ctx) error
+	FindBatch(c *fiber.Ctx) error
+	ExportBatch(c *fiber.Ctx) error
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PayoutControllerImpl struct {
+	payoutService service.PayoutService
+}
+
+func NewPayoutController(payoutService service.PayoutService) PayoutController {
+	return &PayoutControllerImpl{
+		payoutService: payoutService,
+	}
+}
+
+func (controller *PayoutControllerImpl) CreateSeller(c *fiber.Ctx) error {
+	request := web.SellerCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	seller, err := controller.payoutService.CreateSeller(c.Context(), request)
+	return payoutResponse(c, seller, err)
+}
+
+func (controller *PayoutControllerImpl) FindSeller(c *fiber.Ctx) error {
+	seller, err := controller.payoutService.FindSeller(c.Context(), c.Params("sellerId"))
+	return payoutResponse(c, seller, err)
+}
+
+func (controller *PayoutControllerImpl) FindEntries(c *fiber.Ctx) error {
+	entries, err := controller.payoutService.FindEntries(c.Context(), c.Params("sellerId"))
+	return payoutResponse(c, entries, err)
+}
+
+func (controller *PayoutControllerImpl) SetShares(c *fiber.Ctx) error {
+	orderId, err := uuid.Parse(c.Params("orderId"))
+	if err != nil {
+		return helper.BadRequest(c, "invalid order id")
+	}
+
+	request := web.SellerSharesRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	shares, err := controller.payoutService.SetShares(c.Context(), orderId, request)
+	return payoutResponse(c, shares, err)
+}
+
+func (controller *PayoutControllerImpl) FindShares(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid order id")
+	}
+
+	shares, err := controller.payoutService.FindShares(c.Context(), orderId)
+	return payoutResponse(c, shares, err)
+}
+
+// Generate pays out the sellers due now instead of waiting for the scheduler.
+func (controller *PayoutControllerImpl) Generate(c *fiber.Ctx) error {
+	batches, err := controller.payoutService.Generate(c.Context())
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, batches)
+}
+
+func (controller *PayoutControllerImpl) FindBatch(c *fiber.Ctx) error {
+	batchId := c.Params("batchId")
+
+	if _, err := uuid.Parse(batchId); err != nil {
+		return helper.BadRequest(c, "invalid payout batch id")
+	}
+
+	batch, err := controller.payoutService.FindBatch(c.Context(), batchId)
+	return payoutResponse(c, batch, err)
+}
+
+// ExportBatch downloads the batch as a CSV bank-transfer file.
+func (controller *PayoutControllerImpl) ExportBatch(c *fiber.Ctx) error {
+	batchId := c.Params("batchId")
+
+	if _, err := uuid.Parse(batchId); err != nil {
+		return helper.BadRequest(c, "invalid payout batch id")
+	}
+
+	batch, err := controller.payoutService.FindBatch(c.Context(), batchId)
+	if err != nil {
+		return payoutResponse(c, nil, err)
+	}
+
+	var file bytes.Buffer
+	if err := helper.WritePayoutBatchCSV(&file, batch); err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="payout-`+batchId+`.csv"`)
+	return c.Send(file.Bytes())
+}
+
+func payoutResponse(c *fiber.Ctx, data interface{}, err error) error {
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	var conflict exception.ConflictError
+	if errors.As(err, &conflict) {
+		return helper.Conflict(c, conflict.Data)
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, data)
+}
@@ -0,0 +1,10 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type QRController interface {
+	Image(c *fiber.Ctx) error
+	Webhook(c *fiber.Ctx) error
+	SimulateScan(c *fiber.Ctx) error
+	SimulatePayment(c *fiber.Ctx) error
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type QRControllerImpl struct {
+	qrService service.QRService
+}
+
+func NewQRController(qrService service.QRService) QRController {
+	return &QRControllerImpl{
+		qrService: qrService,
+	}
+}
+
+// Image renders the QR code of a pending payment; format=svg returns a vector image instead of PNG.
+func (controller *QRControllerImpl) Image(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	image, contentType, err := controller.qrService.Image(c.Context(), paymentId, c.Query("format", "png"), c.QueryInt("size"))
+	if err != nil {
+		return qrError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(image)
+}
+
+// Webhook receives QR payment notifications from the provider named in the path.
+func (controller *QRControllerImpl) Webhook(c *fiber.Ctx) error {
+	request := web.QRWebhookRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	payment, err := controller.qrService.HandleWebhook(webhookContext(c, c.Params("provider")), c.Params("provider"), request)
+	if err != nil {
+		return qrError(c, err)
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(payment))
+}
+
+func (controller *QRControllerImpl) SimulateScan(c *fiber.Ctx) error {
+	request := web.QRSimulatorRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	scan, err := controller.qrService.Scan(c.Context(), c.Params("provider"), request)
+	if err != nil {
+		return qrError(c, err)
+	}
+
+	return helper.ResponseSuccess(c, scan)
+}
+
+func (controller *QRControllerImpl) SimulatePayment(c *fiber.Ctx) error {
+	request := web.QRSimulatorRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	payment, err := controller.qrService.SimulatePayment(webhookContext(c, c.Params("provider")), c.Params("provider"), request)
+	if err != nil {
+		return qrError(c, err)
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(payment))
+}
+
+func qrError(c *fiber.Ctx, err error) error {
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+
+	if errors.Is(err, service.ErrQRCodeExpired) {
+		return helper.Gone(c, err.Error())
+	}
+
+	return helper.BadRequest(c, err.Error())
+}
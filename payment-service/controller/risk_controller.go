@@ -0,0 +1,9 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type RiskController interface {
+	FindAssessments(c *fiber.Ctx) error
+	Approve(c *fiber.Ctx) error
+	Reject(c *fiber.Ctx) error
+}
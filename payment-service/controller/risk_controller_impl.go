@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RiskControllerImpl struct {
+	riskService    service.RiskService
+	paymentService service.PaymentService
+}
+
+func NewRiskController(riskService service.RiskService, paymentService service.PaymentService) RiskController {
+	return &RiskControllerImpl{
+		riskService:    riskService,
+		paymentService: paymentService,
+	}
+}
+
+func (controller *RiskControllerImpl) FindAssessments(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	assessments, err := controller.riskService.FindAssessmentsByPaymentId(c.Context(), paymentId)
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, assessments)
+}
+
+// Approve releases a held payment and captures it, finishing what Create would have done.
+func (controller *RiskControllerImpl) Approve(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	request := web.RiskReviewRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	ctx := apiContext(c, request.Reviewer)
+	approved, err := controller.paymentService.ApproveHeld(ctx, paymentId, request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	// Cash on delivery is settled when the courier confirms the collection.
+	if approved.Status == "awaiting_collection" {
+		return helper.ResponseSuccess(c, helper.ToPaymentResponse(approved))
+	}
+
+	updated, err := controller.paymentService.MarkAsSuccess(ctx, paymentId)
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(updated))
+}
+
+func (controller *RiskControllerImpl) Reject(c *fiber.Ctx) error {
+	paymentId := c.Params("paymentId")
+
+	if _, err := uuid.Parse(paymentId); err != nil {
+		return helper.BadRequest(c, "invalid payment id")
+	}
+
+	request := web.RiskReviewRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	result, err := controller.paymentService.RejectHeld(apiContext(c, request.Reviewer), paymentId, request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, helper.ToPaymentResponse(result))
+}
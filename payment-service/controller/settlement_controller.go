@@ -0,0 +1,11 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type SettlementController interface {
+	Import(c *fiber.Ctx) error
+	FindReports(c *fiber.Ctx) error
+	FindReportById(c *fiber.Ctx) error
+	FindExceptions(c *fiber.Ctx) error
+	ResolveItem(c *fiber.Ctx) error
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SettlementControllerImpl struct {
+	settlementService service.SettlementService
+}
+
+func NewSettlementController(settlementService service.SettlementService) SettlementController {
+	return &SettlementControllerImpl{
+		settlementService: settlementService,
+	}
+}
+
+// Import accepts a multipart upload with the settlement CSV in the "file" field.
+func (controller *SettlementControllerImpl) Import(c *fiber.Ctx) error {
+	request := web.SettlementImportRequest{}
+	if err := c.BodyParser(&request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return helper.BadRequest(c, "settlement file is required")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+	defer file.Close()
+
+	request.FileName = header.Filename
+
+	report, err := controller.settlementService.Import(c.Context(), request, file)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, report)
+}
+
+func (controller *SettlementControllerImpl) FindReports(c *fiber.Ctx) error {
+	reports, err := controller.settlementService.FindReports(c.Context())
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, reports)
+}
+
+func (controller *SettlementControllerImpl) FindReportById(c *fiber.Ctx) error {
+	reportId := c.Params("reportId")
+
+	if _, err := uuid.Parse(reportId); err != nil {
+		return helper.BadRequest(c, "invalid report id")
+	}
+
+	report, err := controller.settlementService.FindReportById(c.Context(), reportId)
+	if err != nil {
+		return helper.NotFound(c, "settlement report not found")
+	}
+
+	return helper.ResponseSuccess(c, report)
+}
+
+func (controller *SettlementControllerImpl) FindExceptions(c *fiber.Ctx) error {
+	reportId := c.Params("reportId")
+
+	if _, err := uuid.Parse(reportId); err != nil {
+		return helper.BadRequest(c, "invalid report id")
+	}
+
+	items, err := controller.settlementService.FindExceptions(c.Context(), reportId, c.QueryBool("include_resolved"))
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, items)
+}
+
+func (controller *SettlementControllerImpl) ResolveItem(c *fiber.Ctx) error {
+	itemId := c.Params("itemId")
+
+	if _, err := uuid.Parse(itemId); err != nil {
+		return helper.BadRequest(c, "invalid item id")
+	}
+
+	request := web.SettlementResolveRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	item, err := controller.settlementService.ResolveItem(c.Context(), itemId, request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, item)
+}
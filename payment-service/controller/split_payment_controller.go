@@ -0,0 +1,9 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type SplitPaymentController interface {
+	Create(c *fiber.Ctx) error
+	FindById(c *fiber.Ctx) error
+	FindByOrderId(c *fiber.Ctx) error
+}
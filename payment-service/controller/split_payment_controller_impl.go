@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SplitPaymentControllerImpl struct {
+	splitPaymentService service.SplitPaymentService
+}
+
+func NewSplitPaymentController(splitPaymentService service.SplitPaymentService) SplitPaymentController {
+	return &SplitPaymentControllerImpl{
+		splitPaymentService: splitPaymentService,
+	}
+}
+
+func (controller *SplitPaymentControllerImpl) Create(c *fiber.Ctx) error {
+	request := web.SplitPaymentCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	split, err := controller.splitPaymentService.Create(c.Context(), request)
+	var conflict exception.ConflictError
+	if errors.As(err, &conflict) {
+		return helper.Conflict(c, conflict.Data)
+	}
+	return splitPaymentResponse(c, split, err)
+}
+
+func (controller *SplitPaymentControllerImpl) FindById(c *fiber.Ctx) error {
+	splitId := c.Params("splitId")
+
+	if _, err := uuid.Parse(splitId); err != nil {
+		return helper.BadRequest(c, "invalid split payment id")
+	}
+
+	split, err := controller.splitPaymentService.FindById(c.Context(), splitId)
+	return splitPaymentResponse(c, split, err)
+}
+
+func (controller *SplitPaymentControllerImpl) FindByOrderId(c *fiber.Ctx) error {
+	orderId := c.Params("orderId")
+
+	if _, err := uuid.Parse(orderId); err != nil {
+		return helper.BadRequest(c, "invalid order id")
+	}
+
+	split, err := controller.splitPaymentService.FindByOrderId(c.Context(), orderId)
+	return splitPaymentResponse(c, split, err)
+}
+
+func splitPaymentResponse(c *fiber.Ctx, data interface{}, err error) error {
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, data)
+}
@@ -0,0 +1,17 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type SubscriptionController interface {
+	CreatePlan(c *fiber.Ctx) error
+	FindPlans(c *fiber.Ctx) error
+	FindPlanById(c *fiber.Ctx) error
+	Create(c *fiber.Ctx) error
+	FindById(c *fiber.Ctx) error
+	FindAllByCustomerId(c *fiber.Ctx) error
+	FindCharges(c *fiber.Ctx) error
+	Pause(c *fiber.Ctx) error
+	Resume(c *fiber.Ctx) error
+	Cancel(c *fiber.Ctx) error
+	ChangePlan(c *fiber.Ctx) error
+}
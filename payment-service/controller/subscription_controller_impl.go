@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SubscriptionControllerImpl struct {
+	subscriptionService service.SubscriptionService
+}
+
+func NewSubscriptionController(subscriptionService service.SubscriptionService) SubscriptionController {
+	return &SubscriptionControllerImpl{
+		subscriptionService: subscriptionService,
+	}
+}
+
+func (controller *SubscriptionControllerImpl) CreatePlan(c *fiber.Ctx) error {
+	request := web.SubscriptionPlanCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	plan, err := controller.subscriptionService.CreatePlan(c.Context(), request)
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, plan)
+}
+
+func (controller *SubscriptionControllerImpl) FindPlans(c *fiber.Ctx) error {
+	plans, err := controller.subscriptionService.FindPlans(c.Context())
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, plans)
+}
+
+func (controller *SubscriptionControllerImpl) FindPlanById(c *fiber.Ctx) error {
+	planId := c.Params("planId")
+
+	if _, err := uuid.Parse(planId); err != nil {
+		return helper.BadRequest(c, "invalid plan id")
+	}
+
+	plan, err := controller.subscriptionService.FindPlanById(c.Context(), planId)
+	return subscriptionResponse(c, plan, err)
+}
+
+func (controller *SubscriptionControllerImpl) Create(c *fiber.Ctx) error {
+	request := web.SubscriptionCreateRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	subscription, err := controller.subscriptionService.Create(apiContext(c, ""), request)
+	return subscriptionResponse(c, subscription, err)
+}
+
+func (controller *SubscriptionControllerImpl) FindById(c *fiber.Ctx) error {
+	return controller.withSubscription(c, controller.subscriptionService.FindById)
+}
+
+func (controller *SubscriptionControllerImpl) FindAllByCustomerId(c *fiber.Ctx) error {
+	subscriptions, err := controller.subscriptionService.FindAllByCustomerId(c.Context(), c.Params("customerId"))
+	if err != nil {
+		return helper.InternalServerError(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, subscriptions)
+}
+
+func (controller *SubscriptionControllerImpl) FindCharges(c *fiber.Ctx) error {
+	subscriptionId := c.Params("subscriptionId")
+
+	if _, err := uuid.Parse(subscriptionId); err != nil {
+		return helper.BadRequest(c, "invalid subscription id")
+	}
+
+	charges, err := controller.subscriptionService.FindCharges(c.Context(), subscriptionId)
+	return subscriptionResponse(c, charges, err)
+}
+
+func (controller *SubscriptionControllerImpl) Pause(c *fiber.Ctx) error {
+	return controller.withSubscription(c, controller.subscriptionService.Pause)
+}
+
+func (controller *SubscriptionControllerImpl) Resume(c *fiber.Ctx) error {
+	return controller.withSubscription(c, controller.subscriptionService.Resume)
+}
+
+func (controller *SubscriptionControllerImpl) Cancel(c *fiber.Ctx) error {
+	return controller.withSubscription(c, controller.subscriptionService.Cancel)
+}
+
+func (controller *SubscriptionControllerImpl) ChangePlan(c *fiber.Ctx) error {
+	request := web.SubscriptionPlanChangeRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return controller.withSubscription(c, func(ctx context.Context, subscriptionId string) (domain.Subscription, error) {
+		return controller.subscriptionService.ChangePlan(ctx, subscriptionId, request)
+	})
+}
+
+// withSubscription runs action on the subscription named in the path.
+func (controller *SubscriptionControllerImpl) withSubscription(c *fiber.Ctx, action func(ctx context.Context, subscriptionId string) (domain.Subscription, error)) error {
+	subscriptionId := c.Params("subscriptionId")
+
+	if _, err := uuid.Parse(subscriptionId); err != nil {
+		return helper.BadRequest(c, "invalid subscription id")
+	}
+
+	subscription, err := action(apiContext(c, ""), subscriptionId)
+	return subscriptionResponse(c, subscription, err)
+}
+
+func subscriptionResponse(c *fiber.Ctx, data interface{}, err error) error {
+	var notFound exception.NotFoundError
+	if errors.As(err, &notFound) {
+		return helper.NotFound(c, notFound.Error())
+	}
+	if err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	return helper.ResponseSuccess(c, data)
+}
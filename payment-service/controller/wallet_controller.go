@@ -0,0 +1,8 @@
+package controller
+
+import "github.com/gofiber/fiber/v2"
+
+type WalletController interface {
+	CreateTopUp(c *fiber.Ctx) error
+	FindByCustomerId(c *fiber.Ctx) error
+}
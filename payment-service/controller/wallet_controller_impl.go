@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"payment-service/helper"
+	"payment-service/models/web"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WalletControllerImpl struct {
+	balanceService service.BalanceService
+}
+
+func NewWalletController(balanceService service.BalanceService) WalletController {
+	return &WalletControllerImpl{
+		balanceService: balanceService,
+	}
+}
+
+// CreateTopUp opens a top-up; the customer then pays it with POST /payments, passing the top-up
+// id as order_id and top_up=true.
+func (controller *WalletControllerImpl) CreateTopUp(c *fiber.Ctx) error {
+	request := web.WalletTopUpRequest{}
+	if err := helper.ReadFromRequestBody(c, &request); err != nil {
+		return helper.BadRequest(c, err.Error())
+	}
+
+	topUp, err := controller.balanceService.CreateTopUp(c.Context(), c.Params("customerId"), request)
+	return balanceResponse(c, topUp, err)
+}
+
+func (controller *WalletControllerImpl) FindByCustomerId(c *fiber.Ctx) error {
+	wallet, err := controller.balanceService.FindWallet(c.Context(), c.Params("customerId"))
+	return balanceResponse(c, wallet, err)
+}
@@ -0,0 +1,12 @@
+package exception
+
+// ConflictError reports that a request collides with existing state.
+// Data carries the existing resource so clients can continue with it.
+type ConflictError struct {
+	Message string
+	Data    interface{}
+}
+
+func (e ConflictError) Error() string {
+	return e.Message
+}
@@ -15,6 +15,14 @@ func NewErrorHandler(c *fiber.Ctx, err error) error {
 		})
 	}
 
+	if conflict, ok := err.(ConflictError); ok {
+		return c.Status(fiber.StatusConflict).JSON(web.WebResponse{
+			Code:   fiber.StatusConflict,
+			Status: "CONFLICT",
+			Data:   conflict.Data,
+		})
+	}
+
 	if fiberError, ok := err.(*fiber.Error); ok {
 		code := fiberError.Code
 		if code == 0 {
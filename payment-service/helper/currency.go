@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+)
+
+// currencyExponents lists currencies whose minor unit is not cents. Amounts are always stored
+// in the minor unit of their currency.
+var currencyExponents = map[string]int{
+	"IDR": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+}
+
+var decimalRate = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// CurrencyExponent returns the number of decimal places of the currency's minor unit.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// ParseRate reads a positive decimal rate such as "0.0000625" without losing precision.
+func ParseRate(rate string) (*big.Rat, error) {
+	if !decimalRate.MatchString(rate) {
+		return nil, fmt.Errorf("invalid exchange rate %q", rate)
+	}
+
+	value, _ := new(big.Rat).SetString(rate)
+	if value.Sign() <= 0 {
+		return nil, fmt.Errorf("exchange rate must be positive, got %q", rate)
+	}
+	return value, nil
+}
+
+// ConvertAmount converts an amount in minor units of one currency into minor units of another
+// at the given rate, rounding half up.
+func ConvertAmount(amount int64, fromCurrency string, toCurrency string, rate *big.Rat) int64 {
+	value := new(big.Rat).SetInt64(amount)
+	value.Mul(value, rate)
+	value.Mul(value, pow10Rat(CurrencyExponent(toCurrency)-CurrencyExponent(fromCurrency)))
+
+	return roundHalfUp(value)
+}
+
+func pow10Rat(exponent int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(absInt(exponent))), nil)
+	if exponent < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), scale)
+	}
+	return new(big.Rat).SetInt(scale)
+}
+
+func roundHalfUp(value *big.Rat) int64 {
+	doubled := new(big.Int).Mul(value.Num(), big.NewInt(2))
+	doubled.Add(doubled, value.Denom())
+	doubled.Quo(doubled, new(big.Int).Mul(value.Denom(), big.NewInt(2)))
+	return doubled.Int64()
+}
+
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// FormatAmount renders an amount in minor units for display, e.g. "1,550,000 IDR" or
+// "100.00 USD".
+func FormatAmount(amount int64, currency string) string {
+	exponent := CurrencyExponent(currency)
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	scale := int64(1)
+	for i := 0; i < exponent; i++ {
+		scale *= 10
+	}
+
+	whole := groupThousands(amount / scale)
+	if exponent == 0 {
+		return fmt.Sprintf("%s%s %s", sign, whole, currency)
+	}
+	return fmt.Sprintf("%s%s.%0*d %s", sign, whole, exponent, amount%scale, currency)
+}
+
+func groupThousands(value int64) string {
+	digits := fmt.Sprintf("%d", value)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return digits
+}
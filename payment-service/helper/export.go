@@ -0,0 +1,62 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"payment-service/models/domain"
+	"strconv"
+	"time"
+)
+
+var paymentCSVHeader = []string{"id", "order_id", "attempt", "amount", "status", "provider", "created_at", "paid_at"}
+
+// WritePaymentsCSV streams payments produced by stream as CSV rows preceded by a header row.
+func WritePaymentsCSV(w *bufio.Writer, stream func(write func(domain.Payment) error) error) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(paymentCSVHeader); err != nil {
+		return err
+	}
+
+	err := stream(func(payment domain.Payment) error {
+		paidAt := ""
+		if payment.PaidAt != nil {
+			paidAt = payment.PaidAt.Format(time.RFC3339)
+		}
+
+		return writer.Write([]string{
+			payment.ID.String(),
+			payment.OrderID.String(),
+			strconv.Itoa(payment.Attempt),
+			strconv.FormatInt(payment.Amount, 10),
+			payment.Status,
+			payment.Provider,
+			payment.CreatedAt.Format(time.RFC3339),
+			paidAt,
+		})
+	})
+
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// WritePaymentsNDJSON streams payments produced by stream as one JSON document per line.
+func WritePaymentsNDJSON(w *bufio.Writer, stream func(write func(domain.Payment) error) error) error {
+	encoder := json.NewEncoder(w)
+
+	err := stream(func(payment domain.Payment) error {
+		return encoder.Encode(ToPaymentResponse(payment))
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,19 @@
+package helper
+
+// SplitInstallments divides total into count amounts that differ by at most one unit and add
+// up to total. The leftover units go to the first installments.
+func SplitInstallments(total int64, count int) []int64 {
+	if count <= 0 {
+		return nil
+	}
+
+	amounts := make([]int64, count)
+	share, remainder := total/int64(count), total%int64(count)
+	for i := range amounts {
+		amounts[i] = share
+		if int64(i) < remainder {
+			amounts[i]++
+		}
+	}
+	return amounts
+}
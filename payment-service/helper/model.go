@@ -3,14 +3,89 @@ package helper
 import (
 	"payment-service/models/domain"
 	"payment-service/models/web"
+	"time"
 )
 
 func ToPaymentResponse(payment domain.Payment) web.PaymentResponse {
+	feeBreakdown := payment.FeeBreakdown
+	if feeBreakdown == nil {
+		feeBreakdown = []domain.FeeLine{}
+	}
+
+	qrImageURL := ""
+	if payment.QRPayload != "" {
+		qrImageURL = "/payments/" + payment.ID.String() + "/qr"
+	}
+
 	return web.PaymentResponse{
-		ID:      payment.ID,
-		OrderID: payment.OrderID,
-		Amount:  payment.Amount,
-		Status:  payment.Status,
-		PaidAt:  payment.PaidAt,
+		ID:                payment.ID,
+		OrderID:           payment.OrderID,
+		Amount:            payment.Amount,
+		Currency:          payment.Currency,
+		Status:            payment.Status,
+		Provider:          payment.Provider,
+		Method:            payment.Method,
+		ProviderReference: payment.ProviderReference,
+		Attempt:           payment.Attempt,
+		InstallmentNumber: payment.InstallmentNumber,
+		TenderNumber:      payment.TenderNumber,
+		CustomerID:        payment.CustomerID,
+		RiskScore:         payment.RiskScore,
+		RiskDecision:      payment.RiskDecision,
+		PaymentMethodID:   payment.PaymentMethodID,
+		BalanceAccountID:  payment.BalanceAccountID,
+		TopUp:             payment.TopUp,
+		FeeAmount:         payment.FeeAmount,
+		NetAmount:         payment.NetAmount,
+		RefundedAmount:    payment.RefundedAmount,
+		FeeBreakdown:      feeBreakdown,
+		Conversion:        payment.Conversion,
+		QRPayload:         payment.QRPayload,
+		QRImageURL:        qrImageURL,
+		BankTransfer:      payment.BankTransfer,
+		Collection:        payment.Collection,
+		ExpiresAt:         payment.ExpiresAt,
+		PaidAt:            payment.PaidAt,
+		CreatedAt:         payment.CreatedAt,
+		UpdatedAt:         payment.UpdatedAt,
+	}
+}
+
+func ToPaymentResponses(payments []domain.Payment) []web.PaymentResponse {
+	paymentResponses := []web.PaymentResponse{}
+	for _, payment := range payments {
+		paymentResponses = append(paymentResponses, ToPaymentResponse(payment))
+	}
+
+	return paymentResponses
+}
+
+func ToPaymentMethodResponse(method domain.PaymentMethod, details domain.CardDetails) web.PaymentMethodResponse {
+	return web.PaymentMethodResponse{
+		ID:         method.ID,
+		CustomerID: method.CustomerID,
+		Provider:   method.Provider,
+		Brand:      details.Brand,
+		Last4:      details.Last4,
+		ExpMonth:   details.ExpMonth,
+		ExpYear:    details.ExpYear,
+		CreatedAt:  method.CreatedAt,
+		UpdatedAt:  method.UpdatedAt,
+	}
+}
+
+func ToPaymentLinkResponse(link domain.PaymentLink, url string, now time.Time) web.PaymentLinkResponse {
+	return web.PaymentLinkResponse{
+		ID:         link.ID,
+		OrderID:    link.OrderID,
+		URL:        url,
+		CustomerID: link.CustomerID,
+		Providers:  link.Providers,
+		OneTime:    link.OneTime,
+		Status:     link.Status(now),
+		ExpiresAt:  link.ExpiresAt,
+		UsedAt:     link.UsedAt,
+		PaymentID:  link.PaymentID,
+		CreatedAt:  link.CreatedAt,
 	}
 }
@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"encoding/csv"
+	"io"
+	"math/big"
+	"payment-service/models/domain"
+	"strconv"
+)
+
+// SplitByShares divides amount in proportion to shares. The parts add up to amount; the last
+// share takes what rounding down leaves over, so an amount equal to the sum of the shares is
+// split into exactly the shares.
+func SplitByShares(amount int64, shares []int64) []int64 {
+	var total int64
+	for _, share := range shares {
+		total += share
+	}
+	if total == 0 {
+		return make([]int64, len(shares))
+	}
+
+	parts := make([]int64, len(shares))
+	remaining := amount
+	for i, share := range shares {
+		if i == len(shares)-1 {
+			parts[i] = remaining
+			break
+		}
+		part := new(big.Int).Mul(big.NewInt(share), big.NewInt(amount))
+		parts[i] = part.Quo(part, big.NewInt(total)).Int64()
+		remaining -= parts[i]
+	}
+	return parts
+}
+
+var payoutCSVHeader = []string{"reference", "seller_id", "bank_name", "account_number", "account_name", "amount", "currency", "description"}
+
+// WritePayoutBatchCSV writes the bank-transfer file of a payout batch: one transfer per payout,
+// referenced by the payout id.
+func WritePayoutBatchCSV(w io.Writer, batch domain.PayoutBatch) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(payoutCSVHeader); err != nil {
+		return err
+	}
+
+	description := "Payout " + batch.CreatedAt.Format("2006-01-02")
+	for _, payout := range batch.Payouts {
+		err := writer.Write([]string{
+			payout.ID.String(),
+			payout.SellerID,
+			payout.BankName,
+			payout.BankAccountNumber,
+			payout.BankAccountName,
+			strconv.FormatInt(payout.Amount, 10),
+			payout.Currency,
+			description,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -37,3 +37,27 @@ func NotFound(c *fiber.Ctx, message string) error {
 		Data:   message,
 	})
 }
+
+func Conflict(c *fiber.Ctx, data interface{}) error {
+	return c.Status(fiber.StatusConflict).JSON(web.WebResponse{
+		Code:   fiber.StatusConflict,
+		Status: "CONFLICT",
+		Data:   data,
+	})
+}
+
+func Gone(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusGone).JSON(web.WebResponse{
+		Code:   fiber.StatusGone,
+		Status: "GONE",
+		Data:   message,
+	})
+}
+
+func Unauthorized(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(web.WebResponse{
+		Code:   fiber.StatusUnauthorized,
+		Status: "UNAUTHORIZED",
+		Data:   message,
+	})
+}
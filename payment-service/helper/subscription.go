@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"math/big"
+	"payment-service/models/domain"
+	"time"
+)
+
+// NextBillingDate returns the end of a billing cycle of count intervals starting at start.
+// Month and year cycles follow the calendar, so a cycle starting on 31 January ends on 3 March.
+func NextBillingDate(start time.Time, interval string, count int) time.Time {
+	if count <= 0 {
+		count = 1
+	}
+
+	switch interval {
+	case domain.IntervalDay:
+		return start.AddDate(0, 0, count)
+	case domain.IntervalWeek:
+		return start.AddDate(0, 0, 7*count)
+	case domain.IntervalYear:
+		return start.AddDate(count, 0, 0)
+	default:
+		return start.AddDate(0, count, 0)
+	}
+}
+
+// Prorate returns the share of amount that covers the remaining part of a period, rounding half
+// up.
+func Prorate(amount int64, remaining time.Duration, period time.Duration) int64 {
+	if remaining <= 0 || period <= 0 {
+		return 0
+	}
+	if remaining >= period {
+		return amount
+	}
+
+	value := new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(remaining))),
+		big.NewInt(int64(period)),
+	)
+	return roundHalfUp(value)
+}
@@ -0,0 +1,136 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// Vault encrypts values with AES-GCM. Ciphertexts are base64 encoded with the nonce prepended.
+type Vault struct {
+	aead cipher.AEAD
+}
+
+func NewVault(key []byte) (*Vault, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Vault{aead: aead}, nil
+}
+
+func (vault *Vault) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, vault.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := vault.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (vault *Vault) Decrypt(ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := vault.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("ciphertext too short")
+	}
+
+	plaintext, err := vault.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// ContainsPAN reports whether value holds something that looks like a card number: a run of
+// 13 to 19 digits, optionally separated by spaces or dashes, that passes the Luhn check.
+func ContainsPAN(value string) bool {
+	return len(findPANs(value)) > 0
+}
+
+// MaskPAN replaces all but the last four digits of every card number in value with '*', keeping
+// the separators and everything around them.
+func MaskPAN(value string) string {
+	pans := findPANs(value)
+	if len(pans) == 0 {
+		return value
+	}
+
+	masked := []byte(value)
+	for _, pan := range pans {
+		keep := 4
+		for i := pan[1] - 1; i >= pan[0]; i-- {
+			if masked[i] < '0' || masked[i] > '9' {
+				continue
+			}
+			if keep > 0 {
+				keep--
+				continue
+			}
+			masked[i] = '*'
+		}
+	}
+
+	return string(masked)
+}
+
+// findPANs returns the start and end offsets of the card numbers in value.
+func findPANs(value string) [][2]int {
+	var pans [][2]int
+	digits := []byte{}
+	start, end := 0, 0
+	for i := 0; i <= len(value); i++ {
+		if i < len(value) {
+			char := value[i]
+			if char >= '0' && char <= '9' {
+				if len(digits) == 0 {
+					start = i
+				}
+				digits = append(digits, char)
+				end = i + 1
+				continue
+			}
+			if (char == ' ' || char == '-') && len(digits) > 0 {
+				continue
+			}
+		}
+
+		if len(digits) >= 13 && len(digits) <= 19 && luhnValid(digits) {
+			pans = append(pans, [2]int{start, end})
+		}
+		digits = digits[:0]
+	}
+
+	return pans
+}
+
+func luhnValid(digits []byte) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"payment-service/cli"
 	"payment-service/config"
 	"payment-service/controller"
 	"payment-service/exception"
-	"payment-service/models/domain"
+	"payment-service/helper"
 	"payment-service/repository"
 	"payment-service/routes"
 	"payment-service/service"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/gofiber/fiber/v2"
@@ -24,17 +28,213 @@ func main() {
 
 	app := fiber.New(fiber.Config{
 		ErrorHandler: exception.NewErrorHandler,
+		// c.IP() reads the client address from the proxy header only for trusted proxies.
+		ProxyHeader:             config.TrustedProxyHeader(),
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          config.TrustedProxies(),
+		EnableIPValidation:      true,
 	})
 
 	db := config.NewDB()
-	db.AutoMigrate(&domain.Payment{})
+	if err := config.Migrate(db); err != nil {
+		log.Fatal("Migration Fail:", err)
+	}
 	validate := validator.New()
 
+	vaultKey, err := config.LoadVaultKey()
+	if err != nil {
+		log.Fatal("Vault Key Fail:", err)
+	}
+	vault, err := helper.NewVault(vaultKey)
+	if err != nil {
+		log.Fatal("Vault Key Fail:", err)
+	}
+
 	paymentRepository := repository.NewPaymentRepository(db)
-	paymentService := service.NewPaymentService(paymentRepository, db, validate)
+	paymentEventRepository := repository.NewPaymentEventRepository(db)
+	installmentRepository := repository.NewInstallmentRepository(db)
+	splitPaymentRepository := repository.NewSplitPaymentRepository(db)
+	ledgerRepository := repository.NewLedgerRepository(db)
+	ledgerService := service.NewLedgerService(ledgerRepository, db)
+	riskRepository := repository.NewRiskRepository(db)
+	riskConfig := config.NewRiskConfig()
+	riskConfig.IPCountries, err = config.LoadIPCountries()
+	if err != nil {
+		log.Fatal("IP Country Table Fail:", err)
+	}
+	riskService := service.NewRiskService(riskRepository, riskConfig, db)
+	paymentMethodRepository := repository.NewPaymentMethodRepository(db)
+	paymentMethodService := service.NewPaymentMethodService(paymentMethodRepository, vault, db, validate)
+	feeSchedule, err := config.LoadFeeSchedule()
+	if err != nil {
+		log.Fatal("Fee Schedule Fail:", err)
+	}
+	feeService := service.NewFeeService(repository.NewFeeRepository(db), feeSchedule, db)
+	qrConfig := config.NewQRConfig()
+	bankTransferConfig := config.NewBankTransferConfig()
+	codConfig := config.NewCODConfig()
+	exchangeRateService := service.NewExchangeRateService(repository.NewExchangeRateRepository(db), db, validate)
+	balanceService := service.NewBalanceService(repository.NewBalanceRepository(db), config.NewBalanceConfig(), db, validate)
+	payoutConfig := config.NewPayoutConfig()
+	payoutService := service.NewPayoutService(repository.NewPayoutRepository(db), paymentRepository, payoutConfig, db, validate)
+	paymentService := service.NewPaymentService(service.PaymentServiceDeps{
+		PaymentRepository:      paymentRepository,
+		PaymentEventRepository: paymentEventRepository,
+		InstallmentRepository:  installmentRepository,
+		SplitPaymentRepository: splitPaymentRepository,
+		LedgerService:          ledgerService,
+		RiskService:            riskService,
+		PaymentMethodService:   paymentMethodService,
+		FeeService:             feeService,
+		ExchangeRateService:    exchangeRateService,
+		BalanceService:         balanceService,
+		PayoutService:          payoutService,
+		QRConfig:               qrConfig,
+		BankTransferConfig:     bankTransferConfig,
+		CODConfig:              codConfig,
+		DB:                     db,
+		Validate:               validate,
+	})
+	settlementRepository := repository.NewSettlementRepository(db)
+	settlementService := service.NewSettlementService(paymentRepository, settlementRepository, db, validate)
+	disputeRepository := repository.NewDisputeRepository(db)
+	disputeService := service.NewDisputeService(disputeRepository, paymentRepository, paymentService, config.DisputeEvidenceDir(), db, validate)
+
+	if len(os.Args) > 1 && os.Args[1] == "import-settlement" {
+		if err := cli.RunSettlementImport(os.Args[2:], settlementService, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import-rates" {
+		if err := cli.RunExchangeRateImport(os.Args[2:], exchangeRateService, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	paymentLinkService := service.NewPaymentLinkService(repository.NewPaymentLinkRepository(db), paymentService, config.NewPaymentLinkConfig(), db, validate)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), config.IdempotencyTTL(), db)
+	go cleanupIdempotencyKeys(idempotencyService)
+	qrService := service.NewQRService(paymentRepository, paymentService, db, validate)
+	bankTransferService := service.NewBankTransferService(repository.NewBankMutationRepository(db), paymentRepository, paymentService, bankTransferConfig, db, validate)
+	subscriptionConfig := config.NewSubscriptionConfig()
+	subscriptionService := service.NewSubscriptionService(repository.NewSubscriptionRepository(db), paymentService, paymentMethodService, subscriptionConfig, db, validate)
+	go billSubscriptions(subscriptionService, subscriptionConfig.SchedulerInterval)
+	dunningConfig := config.NewDunningConfig()
+	dunningService := service.NewDunningService(repository.NewDunningRepository(db), paymentEventRepository, paymentService, dunningConfig, db)
+	go runDunning(dunningService, dunningConfig.SchedulerInterval)
+	installmentConfig := config.NewInstallmentConfig()
+	installmentService := service.NewInstallmentService(installmentRepository, paymentService, paymentMethodService, installmentConfig, db, validate)
+	go runInstallments(installmentService, installmentConfig.SchedulerInterval)
+	go runPayouts(payoutService, payoutConfig.SchedulerInterval)
+	splitPaymentService := service.NewSplitPaymentService(splitPaymentRepository, installmentRepository, paymentService, db, validate)
+
 	paymentController := controller.NewPaymentController(paymentService)
+	ledgerController := controller.NewLedgerController(ledgerService)
+	settlementController := controller.NewSettlementController(settlementService)
+	riskController := controller.NewRiskController(riskService, paymentService)
+	paymentMethodController := controller.NewPaymentMethodController(paymentMethodService)
+	disputeController := controller.NewDisputeController(disputeService)
+	feeController := controller.NewFeeController(feeService)
+	exchangeRateController := controller.NewExchangeRateController(exchangeRateService)
+	paymentLinkController := controller.NewPaymentLinkController(paymentLinkService)
+	qrController := controller.NewQRController(qrService)
+	bankTransferController := controller.NewBankTransferController(bankTransferService)
+	subscriptionController := controller.NewSubscriptionController(subscriptionService)
+	dunningController := controller.NewDunningController(dunningService)
+	installmentController := controller.NewInstallmentController(installmentService)
+	splitPaymentController := controller.NewSplitPaymentController(splitPaymentService)
+	balanceController := controller.NewBalanceController(balanceService)
+	walletController := controller.NewWalletController(balanceService)
+	payoutController := controller.NewPayoutController(payoutService)
 
-	routes.PaymentRoutes(app, paymentController)
+	routes.PaymentRoutes(app, paymentController, idempotencyService)
+	routes.LedgerRoutes(app, ledgerController)
+	routes.SettlementRoutes(app, settlementController)
+	routes.RiskRoutes(app, riskController)
+	routes.PaymentMethodRoutes(app, paymentMethodController)
+	routes.DisputeRoutes(app, disputeController, config.DisputeWebhookSecret())
+	routes.FeeRoutes(app, feeController)
+	routes.ExchangeRateRoutes(app, exchangeRateController)
+	routes.PaymentLinkRoutes(app, paymentLinkController)
+	routes.QRRoutes(app, qrController, qrConfig.WebhookSecret, qrConfig.SimulatorEnabled)
+	routes.BankTransferRoutes(app, bankTransferController, bankTransferConfig.SimulatorEnabled)
+	routes.SubscriptionRoutes(app, subscriptionController)
+	routes.DunningRoutes(app, dunningController)
+	routes.InstallmentRoutes(app, installmentController)
+	routes.SplitPaymentRoutes(app, splitPaymentController)
+	routes.BalanceRoutes(app, balanceController)
+	routes.WalletRoutes(app, walletController)
+	routes.PayoutRoutes(app, payoutController)
 
 	app.Listen(":3000")
 }
+
+// cleanupIdempotencyKeys removes expired Idempotency-Key records once an hour.
+func cleanupIdempotencyKeys(idempotencyService service.IdempotencyService) {
+	for range time.Tick(time.Hour) {
+		removed, err := idempotencyService.Cleanup(context.Background())
+		if err != nil {
+			log.Println("Idempotency Cleanup Fail:", err)
+			continue
+		}
+		if removed > 0 {
+			log.Printf("removed %d expired idempotency keys", removed)
+		}
+	}
+}
+
+// billSubscriptions charges the subscriptions that are due, once every interval.
+func billSubscriptions(subscriptionService service.SubscriptionService, interval time.Duration) {
+	for range time.Tick(interval) {
+		billed, err := subscriptionService.BillDue(context.Background(), time.Now())
+		if err != nil {
+			log.Println("Subscription Billing Fail:", err)
+		}
+		if billed > 0 {
+			log.Printf("billed %d subscriptions", billed)
+		}
+	}
+}
+
+// runDunning opens dunning cases for failed payments and retries the due ones, once every
+// interval.
+func runDunning(dunningService service.DunningService, interval time.Duration) {
+	for range time.Tick(interval) {
+		handled, err := dunningService.Run(context.Background(), time.Now())
+		if err != nil {
+			log.Println("Dunning Fail:", err)
+		}
+		if handled > 0 {
+			log.Printf("handled %d dunning cases", handled)
+		}
+	}
+}
+
+// runInstallments flags overdue installments and charges the due ones, once every interval.
+func runInstallments(installmentService service.InstallmentService, interval time.Duration) {
+	for range time.Tick(interval) {
+		handled, err := installmentService.Run(context.Background(), time.Now())
+		if err != nil {
+			log.Println("Installment Scheduler Fail:", err)
+		}
+		if handled > 0 {
+			log.Printf("handled %d installments", handled)
+		}
+	}
+}
+
+// runPayouts generates the payout batches of the sellers due a payout, once every interval.
+func runPayouts(payoutService service.PayoutService, interval time.Duration) {
+	for range time.Tick(interval) {
+		batches, err := payoutService.Generate(context.Background())
+		if err != nil {
+			log.Println("Payout Fail:", err)
+		}
+		if len(batches) > 0 {
+			log.Printf("generated %d payout batches", len(batches))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IdempotencyHeader is the request header carrying the client's idempotency key.
+const IdempotencyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency makes a route safe to retry. A request carrying an Idempotency-Key header runs
+// once; later requests with the same key and the same method, path and body get the stored
+// response back. Server errors are not stored so the client can retry them.
+func Idempotency(idempotencyService service.IdempotencyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return helper.BadRequest(c, "Idempotency-Key must be at most 255 characters")
+		}
+
+		stored, replay, err := idempotencyService.Begin(c.Context(), key, fingerprint(c))
+		var conflict exception.ConflictError
+		if errors.As(err, &conflict) {
+			return helper.Conflict(c, conflict.Data)
+		}
+		if err != nil {
+			return helper.InternalServerError(c, err.Error())
+		}
+
+		if replay {
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return c.Status(stored.ResponseStatus).SendString(stored.ResponseBody)
+		}
+
+		if err := c.Next(); err != nil {
+			releaseKey(c, idempotencyService, key)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			releaseKey(c, idempotencyService, key)
+			return nil
+		}
+
+		if err := idempotencyService.Complete(c.Context(), key, status, c.Response().Body()); err != nil {
+			log.Printf("Warning: storing response for Idempotency-Key %q failed: %v", key, err)
+		}
+
+		return nil
+	}
+}
+
+// fingerprint identifies the request a key was first used for.
+func fingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Path()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func releaseKey(c *fiber.Ctx, idempotencyService service.IdempotencyService, key string) {
+	if err := idempotencyService.Release(c.Context(), key); err != nil {
+		log.Printf("Warning: releasing Idempotency-Key %q failed: %v", key, err)
+	}
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Balance account types. Gift cards can be redeemed by anyone holding the code; store credit
+// only by the customer it was issued to. A customer has at most one wallet, topped up with
+// ordinary payments and spent with the wallet provider.
+const (
+	BalanceGiftCard    = "gift_card"
+	BalanceStoreCredit = "store_credit"
+	BalanceWallet      = "wallet"
+)
+
+// Balance transaction types. Redemptions and refunded top-ups are negative, issues, top-ups and
+// reversals positive.
+const (
+	BalanceIssue       = "issue"
+	BalanceRedeem      = "redeem"
+	BalanceReversal    = "reversal"
+	BalanceTopUp       = "top_up"
+	BalanceTopUpRefund = "top_up_refund"
+)
+
+// BalanceAccount is a gift card, store credit or wallet held by this service. Payments with the
+// balance or wallet provider are captured by debiting Balance instead of going through an
+// external gateway. The check constraint keeps the balance from going negative even if a debit
+// slips past the service checks.
+type BalanceAccount struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Code           string     `gorm:"type:varchar(32);not null;uniqueIndex" json:"code"`
+	Type           string     `gorm:"type:varchar(20);not null" json:"type"`
+	CustomerID     string     `gorm:"type:varchar(100);index" json:"customer_id,omitempty"`
+	Currency       string     `gorm:"type:varchar(3);not null" json:"currency"`
+	InitialBalance int64      `gorm:"not null" json:"initial_balance"`
+	Balance        int64      `gorm:"not null;check:balance >= 0" json:"balance"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (account BalanceAccount) Expired(now time.Time) bool {
+	return account.ExpiresAt != nil && !account.ExpiresAt.After(now)
+}
+
+// BalanceTransaction is one change to the balance of an account, with the balance it left.
+type BalanceTransaction struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"account_id"`
+	PaymentID    *uuid.UUID `gorm:"type:uuid;index" json:"payment_id"`
+	Type         string     `gorm:"type:varchar(20);not null" json:"type"`
+	Amount       int64      `gorm:"not null" json:"amount"`
+	BalanceAfter int64      `gorm:"not null" json:"balance_after"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Wallet top-up statuses. A pending top-up is paid like an order; the wallet is only credited
+// once that payment succeeds.
+const (
+	TopUpPending   = "pending"
+	TopUpCompleted = "completed"
+	TopUpRefunded  = "refunded"
+)
+
+// WalletTopUp is an amount a customer adds to their wallet. Its ID is the order_id of the
+// payments made for it.
+type WalletTopUp struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	AccountID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"account_id"`
+	CustomerID  string     `gorm:"type:varchar(100);not null;index" json:"customer_id"`
+	Amount      int64      `gorm:"not null" json:"amount"`
+	Currency    string     `gorm:"type:varchar(3);not null" json:"currency"`
+	Status      string     `gorm:"type:varchar(20);not null" json:"status"`
+	PaymentID   *uuid.UUID `gorm:"type:uuid;index" json:"payment_id"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
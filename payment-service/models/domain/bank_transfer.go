@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How a bank transfer payment is told apart from other incoming transfers.
+const (
+	BankTransferVirtualAccount = "virtual_account"
+	BankTransferUniqueAmount   = "unique_amount"
+)
+
+// Reconciliation results of a bank mutation.
+const (
+	MutationMatched   = "matched"
+	MutationUnmatched = "unmatched"
+)
+
+// Reasons a bank mutation could not be matched to a payment.
+const (
+	MutationNoMatch        = "no_match"
+	MutationAmbiguous      = "ambiguous"
+	MutationAmountMismatch = "amount_mismatch"
+	MutationOutsideWindow  = "outside_window"
+	MutationCaptureFailed  = "capture_failed"
+)
+
+// Where a bank mutation came from.
+const (
+	MutationSourceFile = "file"
+	MutationSourceAPI  = "api"
+)
+
+// BankTransfer holds the instructions a customer follows to pay by bank transfer. In virtual
+// account mode the account number identifies the payment; in unique amount mode a small code is
+// added to the amount so the transfer amount does.
+type BankTransfer struct {
+	Mode           string `gorm:"type:varchar(20)" json:"mode"`
+	VirtualAccount string `gorm:"type:varchar(30);index" json:"virtual_account,omitempty"`
+	UniqueCode     int64  `json:"unique_code,omitempty"`
+	TransferAmount int64  `json:"transfer_amount"`
+}
+
+// BankMutation is one incoming transfer on the merchant's bank account as reported by the bank.
+type BankMutation struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Provider       string     `gorm:"type:varchar(100);not null;uniqueIndex:idx_bank_mutations_external" json:"provider"`
+	ExternalID     string     `gorm:"type:varchar(100);not null;uniqueIndex:idx_bank_mutations_external" json:"external_id"`
+	VirtualAccount string     `gorm:"type:varchar(30)" json:"virtual_account,omitempty"`
+	Amount         int64      `json:"amount"`
+	TransactedAt   time.Time  `json:"transacted_at"`
+	Description    string     `json:"description,omitempty"`
+	Source         string     `gorm:"type:varchar(10)" json:"source"`
+	Status         string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	Reason         string     `gorm:"type:varchar(30)" json:"reason,omitempty"`
+	PaymentID      *uuid.UUID `gorm:"type:uuid" json:"payment_id"`
+	Resolved       bool       `gorm:"not null;default:false" json:"resolved"`
+	ResolutionNote string     `json:"resolution_note,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
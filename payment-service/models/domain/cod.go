@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// Outcomes of a cash-on-delivery drop-off reported by the courier or operations.
+const (
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// CODCollection records what happened when the courier delivered a cash-on-delivery order.
+type CODCollection struct {
+	Outcome         string     `gorm:"type:varchar(20)" json:"outcome"`
+	CollectedAmount int64      `json:"collected_amount"`
+	CollectedBy     string     `gorm:"type:varchar(100)" json:"collected_by"`
+	Note            string     `json:"note,omitempty"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dispute states. won and lost are final.
+const (
+	DisputeNeedsResponse = "needs_response"
+	DisputeUnderReview   = "under_review"
+	DisputeWon           = "won"
+	DisputeLost          = "lost"
+)
+
+// Dispute is a customer's challenge of a successful payment, raised through the provider.
+type Dispute struct {
+	ID                uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	PaymentID         uuid.UUID         `gorm:"type:uuid;not null;index" json:"payment_id"`
+	Provider          string            `gorm:"type:varchar(100);not null;uniqueIndex:idx_disputes_provider_ref" json:"provider"`
+	ProviderDisputeID string            `gorm:"type:varchar(100);not null;uniqueIndex:idx_disputes_provider_ref" json:"provider_dispute_id"`
+	Reason            string            `json:"reason"`
+	Amount            int64             `json:"amount"`
+	Status            string            `gorm:"type:varchar(20);not null;index" json:"status"`
+	RespondBy         *time.Time        `json:"respond_by"`
+	ResolutionNote    string            `json:"resolution_note"`
+	ClosedAt          *time.Time        `json:"closed_at"`
+	Evidence          []DisputeEvidence `gorm:"foreignKey:DisputeID" json:"evidence,omitempty"`
+	CreatedAt         time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DisputeEvidence is a file attached to a dispute. The file itself lives on local disk.
+type DisputeEvidence struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	DisputeID   uuid.UUID `gorm:"type:uuid;not null;index" json:"dispute_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StoragePath string    `json:"-"`
+	Note        string    `json:"note"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// IsClosed reports whether the dispute reached a final state.
+func (dispute Dispute) IsClosed() bool {
+	return dispute.Status == DisputeWon || dispute.Status == DisputeLost
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dunning case states. Only active cases are retried by the scheduler.
+const (
+	DunningActive    = "active"
+	DunningRecovered = "recovered"
+	DunningExhausted = "exhausted"
+)
+
+// Notification events emitted while dunning an order.
+const (
+	DunningPaymentFailed  = "payment_failed"
+	DunningRetryFailed    = "retry_failed"
+	DunningRecoveredEvent = "recovered"
+	DunningOrderCancelled = "order_cancelled"
+)
+
+// DunningCase tracks the recovery of an order whose payment with a saved payment method failed.
+// The scheduler retries the payment at NextAttemptAt until it succeeds or the retries run out,
+// after which the order is cancelled.
+type DunningCase struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID         uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	CustomerID      string    `gorm:"type:varchar(100);not null" json:"customer_id"`
+	PaymentMethodID uuid.UUID `gorm:"type:uuid;not null" json:"payment_method_id"`
+	Provider        string    `gorm:"not null" json:"provider"`
+	Amount          int64     `gorm:"not null" json:"amount"`
+	Currency        string    `gorm:"type:varchar(3);not null" json:"currency"`
+	Status          string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	// Attempts counts the retries made so far, not the payment that opened the case.
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt *time.Time `gorm:"index" json:"next_attempt_at"`
+	LastPaymentID uuid.UUID  `gorm:"type:uuid;not null" json:"last_payment_id"`
+	LastError     string     `json:"last_error,omitempty"`
+	ClosedAt      *time.Time `json:"closed_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Events []DunningEvent `gorm:"foreignKey:CaseID" json:"events,omitempty"`
+}
+
+// DunningEvent is a notification emitted at one step of a dunning case. Notified records whether
+// it reached the configured notification endpoint.
+type DunningEvent struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CaseID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"case_id"`
+	OrderID       uuid.UUID  `gorm:"type:uuid;not null" json:"order_id"`
+	Type          string     `gorm:"type:varchar(30);not null" json:"type"`
+	Attempt       int        `json:"attempt"`
+	PaymentID     *uuid.UUID `gorm:"type:uuid" json:"payment_id"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	Message       string     `json:"message,omitempty"`
+	Notified      bool       `gorm:"not null;default:false" json:"notified"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Exchange rate sources.
+const (
+	RateSourceFile = "file"
+	RateSourceAPI  = "api"
+)
+
+// RoundingHalfUp is the only rounding mode used for currency conversion; it is stored on each
+// converted payment so audits can repeat the calculation.
+const RoundingHalfUp = "half_up"
+
+// ExchangeRate says how many units of QuoteCurrency buy one unit of BaseCurrency from
+// EffectiveAt on. Rates are never edited; a newer row supersedes an older one.
+type ExchangeRate struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	BaseCurrency  string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_pair_effective" json:"base_currency"`
+	QuoteCurrency string    `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_pair_effective" json:"quote_currency"`
+	Rate          string    `gorm:"type:varchar(40);not null" json:"rate"`
+	EffectiveAt   time.Time `gorm:"not null;uniqueIndex:idx_exchange_rates_pair_effective" json:"effective_at"`
+	Source        string    `gorm:"type:varchar(10);not null" json:"source"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package domain
+
+// Kinds of fee lines recorded on a payment.
+const (
+	FeeCapture         = "capture"
+	FeeRefund          = "refund"
+	FeeCaptureReturned = "capture_returned"
+)
+
+// FeeLine is one provider charge on a payment. Returned fees carry a negative amount.
+type FeeLine struct {
+	Kind       string `json:"kind"`
+	Rule       string `json:"rule"`
+	PercentBps int64  `json:"percent_bps,omitempty"`
+	Fixed      int64  `json:"fixed,omitempty"`
+	Amount     int64  `json:"amount"`
+}
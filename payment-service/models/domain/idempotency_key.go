@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// IdempotencyKey stores the outcome of a request sent with an Idempotency-Key header. A key
+// without a response status is still being processed.
+type IdempotencyKey struct {
+	Key            string    `gorm:"type:varchar(255);primaryKey" json:"key"`
+	Fingerprint    string    `gorm:"type:varchar(64);not null" json:"fingerprint"`
+	ResponseStatus int       `gorm:"not null;default:0" json:"response_status"`
+	ResponseBody   string    `gorm:"type:text" json:"-"`
+	ExpiresAt      time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (key IdempotencyKey) IsCompleted() bool {
+	return key.ResponseStatus != 0
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Installment plan states.
+const (
+	InstallmentPlanActive    = "active"
+	InstallmentPlanCompleted = "completed"
+)
+
+// Installment states. A scheduled installment becomes overdue once its grace period has passed
+// without a successful payment.
+const (
+	InstallmentScheduled = "scheduled"
+	InstallmentOverdue   = "overdue"
+	InstallmentPaid      = "paid"
+)
+
+// InstallmentPlan splits the total of an order into Count installments due one interval apart,
+// starting when the plan is created. The order is paid once the last installment is.
+type InstallmentPlan struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	CustomerID string    `gorm:"type:varchar(100);not null;index" json:"customer_id"`
+	Provider   string    `gorm:"not null" json:"provider"`
+	// PaymentMethodID is charged automatically when an installment falls due. Without it the
+	// customer pays each installment through POST /payments.
+	PaymentMethodID *uuid.UUID    `gorm:"type:uuid" json:"payment_method_id"`
+	TotalAmount     int64         `gorm:"not null" json:"total_amount"`
+	PaidAmount      int64         `gorm:"not null;default:0" json:"paid_amount"`
+	Currency        string        `gorm:"type:varchar(3);not null" json:"currency"`
+	Count           int           `gorm:"not null" json:"count"`
+	Interval        string        `gorm:"type:varchar(10);not null" json:"interval"`
+	IntervalCount   int           `gorm:"not null;default:1" json:"interval_count"`
+	Status          string        `gorm:"type:varchar(20);not null;index" json:"status"`
+	CompletedAt     *time.Time    `json:"completed_at"`
+	CreatedAt       time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+	Installments    []Installment `gorm:"foreignKey:PlanID" json:"installments,omitempty"`
+}
+
+// Installment is one scheduled amount of a plan. PaymentID and Attempts track the payment
+// attempts made against it; Number is what payments refer to as their installment_number.
+type Installment struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	PlanID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_installments_plan_number" json:"plan_id"`
+	Number        int        `gorm:"not null;uniqueIndex:idx_installments_plan_number" json:"number"`
+	Amount        int64      `gorm:"not null" json:"amount"`
+	DueAt         time.Time  `gorm:"not null;index" json:"due_at"`
+	Status        string     `gorm:"type:varchar(20);not null" json:"status"`
+	PaymentID     *uuid.UUID `gorm:"type:uuid" json:"payment_id"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+	OverdueAt     *time.Time `json:"overdue_at"`
+	PaidAt        *time.Time `json:"paid_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
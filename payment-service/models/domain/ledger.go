@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Chart of accounts used by the ledger.
+const (
+	AccountCustomerReceivable = "customer_receivable"
+	AccountProviderClearing   = "provider_clearing"
+	AccountRevenue            = "revenue"
+	AccountRefunds            = "refunds"
+	AccountFees               = "fees"
+)
+
+var ErrImmutableJournal = errors.New("journal entries are immutable")
+
+type LedgerAccount struct {
+	Code          string    `gorm:"type:varchar(50);primaryKey" json:"code"`
+	Name          string    `json:"name"`
+	NormalBalance string    `gorm:"type:varchar(10);not null" json:"normal_balance"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// JournalEntry is an immutable, balanced set of ledger lines describing one money movement.
+type JournalEntry struct {
+	ID          uuid.UUID     `gorm:"type:uuid;primaryKey" json:"id"`
+	PaymentID   *uuid.UUID    `gorm:"type:uuid;index" json:"payment_id"`
+	Type        string        `gorm:"type:varchar(50);not null" json:"type"`
+	Description string        `json:"description"`
+	Lines       []JournalLine `gorm:"foreignKey:EntryID" json:"lines"`
+	CreatedAt   time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// JournalLine is one debit or credit, in the currency of the payment it was posted for. Amounts in
+// different currencies never net against each other.
+type JournalLine struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	EntryID     uuid.UUID `gorm:"type:uuid;index;not null" json:"entry_id"`
+	AccountCode string    `gorm:"type:varchar(50);index;not null" json:"account_code"`
+	Currency    string    `gorm:"type:varchar(3);index" json:"currency"`
+	Debit       int64     `gorm:"not null;default:0" json:"debit"`
+	Credit      int64     `gorm:"not null;default:0" json:"credit"`
+}
+
+func (JournalEntry) BeforeUpdate(tx *gorm.DB) error { return ErrImmutableJournal }
+func (JournalEntry) BeforeDelete(tx *gorm.DB) error { return ErrImmutableJournal }
+func (JournalLine) BeforeUpdate(tx *gorm.DB) error  { return ErrImmutableJournal }
+func (JournalLine) BeforeDelete(tx *gorm.DB) error  { return ErrImmutableJournal }
@@ -8,13 +8,54 @@ import (
 )
 
 type Payment struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
-	OrderID   uuid.UUID      `gorm:"type:uuid;not null" json:"order_id"`
-	Amount    int64          `json:"amount"`
-	Status    string         `gorm:"type:varchar(50);default:'pending'" json:"status"`
-	Provider  string         `json:"provider"`
-	PaidAt    *time.Time     `json:"paid_at"`
-	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                uuid.UUID           `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID           uuid.UUID           `gorm:"type:uuid;not null" json:"order_id"`
+	Amount            int64               `json:"amount"`
+	Currency          string              `gorm:"type:varchar(3)" json:"currency"`
+	Status            string              `gorm:"type:varchar(50);default:'pending'" json:"status"`
+	Provider          string              `json:"provider"`
+	Method            string              `gorm:"type:varchar(30)" json:"method"`
+	ProviderReference string              `gorm:"type:varchar(100);index" json:"provider_reference"`
+	Attempt           int                 `gorm:"not null;default:1" json:"attempt"`
+	InstallmentNumber int                 `gorm:"not null;default:0" json:"installment_number,omitempty"`
+	TenderNumber      int                 `gorm:"not null;default:0" json:"tender_number,omitempty"`
+	CustomerID        string              `gorm:"type:varchar(100);index" json:"customer_id"`
+	IPAddress         string              `gorm:"type:varchar(64);index" json:"ip_address"`
+	Country           string              `gorm:"type:varchar(2)" json:"country"`
+	RiskScore         int                 `json:"risk_score"`
+	RiskDecision      string              `gorm:"type:varchar(10)" json:"risk_decision"`
+	PaymentMethodID   *uuid.UUID          `gorm:"type:uuid;index" json:"payment_method_id"`
+	BalanceAccountID  *uuid.UUID          `gorm:"type:uuid;index" json:"balance_account_id,omitempty"`
+	TopUp             bool                `gorm:"not null;default:false" json:"top_up,omitempty"`
+	FeeAmount         int64               `gorm:"not null;default:0" json:"fee_amount"`
+	NetAmount         int64               `gorm:"not null;default:0" json:"net_amount"`
+	RefundedAmount    int64               `gorm:"not null;default:0" json:"refunded_amount"`
+	FeeBreakdown      []FeeLine           `gorm:"serializer:json" json:"fee_breakdown"`
+	Conversion        *CurrencyConversion `gorm:"embedded;embeddedPrefix:conversion_" json:"conversion"`
+	QRPayload         string              `gorm:"type:text" json:"qr_payload,omitempty"`
+	BankTransfer      *BankTransfer       `gorm:"embedded;embeddedPrefix:bank_transfer_" json:"bank_transfer,omitempty"`
+	Collection        *CODCollection      `gorm:"embedded;embeddedPrefix:collection_" json:"collection,omitempty"`
+	ExpiresAt         *time.Time          `json:"expires_at"`
+	PaidAt            *time.Time          `json:"paid_at"`
+	CreatedAt         time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt         gorm.DeletedAt      `gorm:"index" json:"-"`
+}
+
+// AwaitsCapture reports whether a payment that was just created is captured straight away. Held
+// payments wait for a reviewer; QR and bank transfer payments wait for the customer's money, cash
+// on delivery for the courier. Balance payments are captured as they are created.
+func (payment Payment) AwaitsCapture() bool {
+	return payment.Status == "pending" && payment.QRPayload == "" && payment.BankTransfer == nil
+}
+
+// CurrencyConversion records how the order total was converted into the payment currency.
+// Repeating the calculation from these fields reproduces Amount exactly.
+type CurrencyConversion struct {
+	OrderAmount    int64      `json:"order_amount"`
+	OrderCurrency  string     `gorm:"type:varchar(3)" json:"order_currency"`
+	ExchangeRateID *uuid.UUID `gorm:"type:uuid" json:"exchange_rate_id"`
+	Rate           string     `gorm:"type:varchar(40)" json:"rate"`
+	Inverted       bool       `json:"inverted"`
+	Rounding       string     `gorm:"type:varchar(10)" json:"rounding"`
 }
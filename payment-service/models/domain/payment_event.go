@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event sources name what triggered a status transition.
+const (
+	EventSourceAPI     = "api"
+	EventSourceWebhook = "webhook"
+	EventSourceJob     = "job"
+)
+
+// ProviderResponseLimit caps the provider payload kept on an event.
+const ProviderResponseLimit = 1000
+
+// PaymentEvent is one entry of a payment's status timeline. FromStatus is empty for the event
+// that records the creation of the payment. ActorClaimed marks an actor the caller named itself
+// rather than one the service verified.
+type PaymentEvent struct {
+	ID               uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PaymentID        uuid.UUID `gorm:"type:uuid;not null;index" json:"payment_id"`
+	FromStatus       string    `gorm:"type:varchar(50)" json:"from_status"`
+	ToStatus         string    `gorm:"type:varchar(50);not null" json:"to_status"`
+	Source           string    `gorm:"type:varchar(20);not null" json:"source"`
+	Actor            string    `gorm:"type:varchar(100)" json:"actor"`
+	ActorClaimed     bool      `gorm:"not null;default:false" json:"actor_claimed"`
+	ProviderResponse string    `gorm:"type:text" json:"provider_response,omitempty"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// CallbackAttempt records one delivery of a payment status callback to order-service.
+type CallbackAttempt struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PaymentID     uuid.UUID `gorm:"type:uuid;not null;index" json:"payment_id"`
+	URL           string    `json:"url"`
+	PaymentStatus string    `gorm:"type:varchar(50)" json:"payment_status"`
+	Succeeded     bool      `json:"succeeded"`
+	Error         string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// EventOrigin describes who or what is changing a payment. It travels on the context so every
+// status write can record it without each call site passing it along.
+type EventOrigin struct {
+	Source           string
+	Actor            string
+	ActorClaimed     bool
+	ProviderResponse string
+}
+
+type eventOriginKey struct{}
+
+func WithEventOrigin(ctx context.Context, origin EventOrigin) context.Context {
+	return context.WithValue(ctx, eventOriginKey{}, origin)
+}
+
+// EventOriginFrom returns the origin attached to ctx. Changes without one are attributed to
+// the API.
+func EventOriginFrom(ctx context.Context) EventOrigin {
+	origin, _ := ctx.Value(eventOriginKey{}).(EventOrigin)
+	if origin.Source == "" {
+		origin.Source = EventSourceAPI
+	}
+	return origin
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payment link states, derived from the stored timestamps.
+const (
+	PaymentLinkActive  = "active"
+	PaymentLinkUsed    = "used"
+	PaymentLinkExpired = "expired"
+)
+
+// PaymentLink is a shareable checkout URL for one order. A one-time link is consumed by the
+// first successful checkout; other links stay usable until they expire.
+type PaymentLink struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"order_id"`
+	Token      string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	CustomerID string     `gorm:"type:varchar(100)" json:"customer_id"`
+	Providers  []string   `gorm:"serializer:json" json:"providers"`
+	OneTime    bool       `gorm:"not null;default:false" json:"one_time"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	PaymentID  *uuid.UUID `gorm:"type:uuid" json:"payment_id"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (link PaymentLink) Status(now time.Time) string {
+	if link.OneTime && link.UsedAt != nil {
+		return PaymentLinkUsed
+	}
+	if !link.ExpiresAt.After(now) {
+		return PaymentLinkExpired
+	}
+	return PaymentLinkActive
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PaymentMethod is a customer's saved provider token. Token and card details are stored
+// encrypted; only the customer and provider stay in clear text so they can be queried.
+type PaymentMethod struct {
+	ID               uuid.UUID      `gorm:"type:uuid;primaryKey" json:"id"`
+	CustomerID       string         `gorm:"type:varchar(100);not null;index" json:"customer_id"`
+	Provider         string         `gorm:"not null" json:"provider"`
+	EncryptedToken   string         `gorm:"type:text;not null" json:"-"`
+	EncryptedDetails string         `gorm:"type:text;not null" json:"-"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// CardDetails is the display data kept alongside a token, serialized into EncryptedDetails.
+type CardDetails struct {
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Seller entry types. Sales are positive; clawbacks and payouts negative.
+const (
+	SellerSale     = "sale"
+	SellerClawback = "clawback"
+	SellerPayout   = "payout"
+)
+
+// Seller is a marketplace seller paid out by bank transfer. Balance is what the platform owes the
+// seller and may go negative when a sale that was already paid out is refunded; later sales make
+// up for it before the next payout.
+type Seller struct {
+	ID                string    `gorm:"type:varchar(100);primaryKey" json:"id"`
+	Name              string    `gorm:"type:varchar(200);not null" json:"name"`
+	Currency          string    `gorm:"type:varchar(3);not null" json:"currency"`
+	CommissionBps     *int64    `json:"commission_bps"`
+	BankName          string    `gorm:"type:varchar(100);not null" json:"bank_name"`
+	BankAccountNumber string    `gorm:"type:varchar(50);not null" json:"bank_account_number"`
+	BankAccountName   string    `gorm:"type:varchar(200);not null" json:"bank_account_name"`
+	Balance           int64     `gorm:"not null;default:0" json:"balance"`
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SellerShare is the part of an order total, in the order currency, that belongs to one seller.
+// The shares of an order add up to its total.
+type SellerShare struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_seller_shares_order_seller" json:"order_id"`
+	SellerID  string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_seller_shares_order_seller" json:"seller_id"`
+	Amount    int64     `gorm:"not null" json:"amount"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// SellerEntry is one change to a seller's balance, with the balance it left. A sale credits the
+// seller's part of a payment less the platform commission; a clawback takes the same amounts
+// back when the payment is refunded or charged back.
+type SellerEntry struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	SellerID         string     `gorm:"type:varchar(100);not null;index" json:"seller_id"`
+	PaymentID        *uuid.UUID `gorm:"type:uuid;index" json:"payment_id"`
+	PayoutID         *uuid.UUID `gorm:"type:uuid;index" json:"payout_id"`
+	Type             string     `gorm:"type:varchar(20);not null" json:"type"`
+	GrossAmount      int64      `gorm:"not null;default:0" json:"gross_amount"`
+	CommissionAmount int64      `gorm:"not null;default:0" json:"commission_amount"`
+	Amount           int64      `gorm:"not null" json:"amount"`
+	BalanceAfter     int64      `gorm:"not null" json:"balance_after"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PayoutBatch groups the payouts of one currency generated together, exported as a single
+// bank-transfer file.
+type PayoutBatch struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Currency    string    `gorm:"type:varchar(3);not null" json:"currency"`
+	TotalAmount int64     `gorm:"not null" json:"total_amount"`
+	PayoutCount int       `gorm:"not null" json:"payout_count"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Payouts     []Payout  `gorm:"-" json:"payouts"`
+}
+
+// Payout transfers a seller's balance to the bank account the seller had when it was generated.
+type Payout struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	BatchID           uuid.UUID `gorm:"type:uuid;not null;index" json:"batch_id"`
+	SellerID          string    `gorm:"type:varchar(100);not null;index" json:"seller_id"`
+	Amount            int64     `gorm:"not null" json:"amount"`
+	Currency          string    `gorm:"type:varchar(3);not null" json:"currency"`
+	BankName          string    `gorm:"type:varchar(100);not null" json:"bank_name"`
+	BankAccountNumber string    `gorm:"type:varchar(50);not null" json:"bank_account_number"`
+	BankAccountName   string    `gorm:"type:varchar(200);not null" json:"bank_account_name"`
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Risk decisions. The engine produces allow, review or deny; admins resolve reviews with
+// approve or reject.
+const (
+	RiskAllow   = "allow"
+	RiskReview  = "review"
+	RiskDeny    = "deny"
+	RiskApprove = "approve"
+	RiskReject  = "reject"
+)
+
+// RiskAssessment records one decision about a payment together with the rules that fired.
+type RiskAssessment struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	PaymentID  uuid.UUID `gorm:"type:uuid;not null;index" json:"payment_id"`
+	Source     string    `gorm:"type:varchar(20);not null" json:"source"`
+	Actor      string    `json:"actor"`
+	Score      int       `json:"score"`
+	Decision   string    `gorm:"type:varchar(10);not null" json:"decision"`
+	FiredRules []string  `gorm:"serializer:json" json:"fired_rules"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
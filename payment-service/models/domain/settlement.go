@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reconciliation results of a settlement item.
+const (
+	SettlementMatched        = "matched"
+	SettlementMissing        = "missing"
+	SettlementExtra          = "extra"
+	SettlementAmountMismatch = "amount_mismatch"
+	SettlementStatusMismatch = "status_mismatch"
+)
+
+// SettlementReport is the reconciliation outcome of one imported provider settlement file.
+type SettlementReport struct {
+	ID             uuid.UUID        `gorm:"type:uuid;primaryKey" json:"id"`
+	Provider       string           `gorm:"type:varchar(100);not null;index" json:"provider"`
+	SettlementDate time.Time        `json:"settlement_date"`
+	FileName       string           `json:"file_name"`
+	TotalLines     int              `json:"total_lines"`
+	MatchedCount   int              `json:"matched_count"`
+	ExceptionCount int              `json:"exception_count"`
+	Items          []SettlementItem `gorm:"foreignKey:ReportID" json:"items,omitempty"`
+	CreatedAt      time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type SettlementItem struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ReportID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"report_id"`
+	ProviderReference string     `gorm:"type:varchar(100)" json:"provider_reference"`
+	PaymentID         *uuid.UUID `gorm:"type:uuid" json:"payment_id"`
+	ExpectedAmount    int64      `json:"expected_amount"`
+	SettledAmount     int64      `json:"settled_amount"`
+	ExpectedStatus    string     `json:"expected_status"`
+	SettledStatus     string     `json:"settled_status"`
+	Result            string     `gorm:"type:varchar(30);not null;index" json:"result"`
+	Resolved          bool       `gorm:"not null;default:false" json:"resolved"`
+	ResolutionNote    string     `json:"resolution_note"`
+	ResolvedAt        *time.Time `json:"resolved_at"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Split payment states. A split payment is paid once its captured tenders add up to the order
+// total, and failed once one of its tenders failed and the others were voided or refunded.
+const (
+	SplitPaymentOpen   = "open"
+	SplitPaymentPaid   = "paid"
+	SplitPaymentFailed = "failed"
+)
+
+// SplitPayment lets an order be paid with several tenders, e.g. a gift card and a card. Each
+// tender is a payment of part of the total that carries a tender_number; the tenders of an open
+// split payment never add up to more than TotalAmount.
+type SplitPayment struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	OrderID        uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	TotalAmount    int64      `gorm:"not null" json:"total_amount"`
+	CapturedAmount int64      `gorm:"not null;default:0" json:"captured_amount"`
+	Currency       string     `gorm:"type:varchar(3);not null" json:"currency"`
+	Status         string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	FailureReason  string     `json:"failure_reason,omitempty"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	// Tenders are the order's payments with a tender_number, loaded by the repository.
+	Tenders []Payment `gorm:"-" json:"tenders"`
+}
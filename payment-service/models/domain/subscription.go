@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Billing intervals of a subscription plan.
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// Subscription states. Only active and past_due subscriptions are billed by the scheduler.
+const (
+	SubscriptionActive    = "active"
+	SubscriptionPastDue   = "past_due"
+	SubscriptionPaused    = "paused"
+	SubscriptionCancelled = "cancelled"
+)
+
+// What a subscription charge pays for.
+const (
+	ChargeCycle     = "cycle"
+	ChargeProration = "proration"
+)
+
+// Subscription charge states.
+const (
+	ChargePending = "pending"
+	ChargePaid    = "paid"
+	ChargeFailed  = "failed"
+)
+
+// SubscriptionPlan is what a customer subscribes to: an amount billed every IntervalCount
+// intervals.
+type SubscriptionPlan struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name          string    `gorm:"type:varchar(100);not null" json:"name"`
+	Amount        int64     `gorm:"not null" json:"amount"`
+	Currency      string    `gorm:"type:varchar(3);not null" json:"currency"`
+	Interval      string    `gorm:"type:varchar(10);not null" json:"interval"`
+	IntervalCount int       `gorm:"not null;default:1" json:"interval_count"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Subscription bills a customer's saved payment method for a plan every cycle. NextBillingAt is
+// when the scheduler next charges it: the start of the next cycle, or the next retry of a failed
+// charge while the subscription is past due.
+type Subscription struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	CustomerID         string     `gorm:"type:varchar(100);not null;index" json:"customer_id"`
+	PlanID             uuid.UUID  `gorm:"type:uuid;not null" json:"plan_id"`
+	PaymentMethodID    uuid.UUID  `gorm:"type:uuid;not null" json:"payment_method_id"`
+	Provider           string     `gorm:"not null" json:"provider"`
+	Status             string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	CurrentPeriodStart *time.Time `json:"current_period_start"`
+	CurrentPeriodEnd   *time.Time `json:"current_period_end"`
+	NextBillingAt      *time.Time `gorm:"index" json:"next_billing_at"`
+	// RetryCount counts the failed attempts of the outstanding cycle charge.
+	RetryCount int `gorm:"not null;default:0" json:"retry_count"`
+	// Credit is owed to the customer after a downgrade and is deducted from the next cycles.
+	Credit      int64      `gorm:"not null;default:0" json:"credit"`
+	PausedAt    *time.Time `json:"paused_at"`
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SubscriptionCharge is one amount billed to a subscription, with the order generated for it in
+// order-service and the latest payment attempt against it.
+type SubscriptionCharge struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	Kind           string     `gorm:"type:varchar(20);not null" json:"kind"`
+	OrderID        *uuid.UUID `gorm:"type:uuid" json:"order_id"`
+	PaymentID      *uuid.UUID `gorm:"type:uuid" json:"payment_id"`
+	Amount         int64      `json:"amount"`
+	CreditApplied  int64      `json:"credit_applied"`
+	Currency       string     `gorm:"type:varchar(3)" json:"currency"`
+	PeriodStart    time.Time  `json:"period_start"`
+	PeriodEnd      time.Time  `json:"period_end"`
+	Status         string     `gorm:"type:varchar(20);not null" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	FailureReason  string     `json:"failure_reason,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package web
+
+import "time"
+
+type BalanceAccountIssueRequest struct {
+	Type string `json:"type" validate:"required,oneof=gift_card store_credit"`
+	// CustomerID is required for store credit, which only its customer can redeem.
+	CustomerID string     `json:"customer_id" validate:"max=100"`
+	Amount     int64      `json:"amount" validate:"required,min=1"`
+	Currency   string     `json:"currency" validate:"omitempty,len=3"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,28 @@
+package web
+
+// BankMutationRequest is one incoming transfer as reported by the bank.
+type BankMutationRequest struct {
+	ExternalID     string `json:"external_id" validate:"required,max=100"`
+	VirtualAccount string `json:"virtual_account" validate:"max=30"`
+	Amount         int64  `json:"amount" validate:"required,gt=0"`
+	TransactedAt   string `json:"transacted_at" validate:"required"`
+	Description    string `json:"description"`
+}
+
+type BankMutationImportRequest struct {
+	Provider string `json:"provider" form:"provider" validate:"required"`
+}
+
+type BankMutationImportResponse struct {
+	Imported  int `json:"imported"`
+	Skipped   int `json:"skipped"`
+	Matched   int `json:"matched"`
+	Unmatched int `json:"unmatched"`
+}
+
+// BankMutationResolveRequest closes an unmatched mutation. Naming a payment applies the transfer
+// to it; without one the note records what was done outside the system, such as a refund.
+type BankMutationResolveRequest struct {
+	Note      string `json:"note" validate:"required"`
+	PaymentID string `json:"payment_id" validate:"omitempty,uuid"`
+}
@@ -0,0 +1,10 @@
+package web
+
+// DeliveryConfirmationRequest is reported by the courier or operations when a cash-on-delivery
+// order reaches, or fails to reach, the customer.
+type DeliveryConfirmationRequest struct {
+	Outcome         string `json:"outcome" validate:"required,oneof=delivered failed"`
+	CollectedAmount int64  `json:"collected_amount" validate:"min=0"`
+	CollectedBy     string `json:"collected_by" validate:"required,max=100"`
+	Note            string `json:"note"`
+}
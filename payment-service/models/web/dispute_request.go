@@ -0,0 +1,38 @@
+package web
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisputeCreateRequest opens a dispute from the admin API.
+type DisputeCreateRequest struct {
+	PaymentID         uuid.UUID  `validate:"required" json:"payment_id"`
+	ProviderDisputeID string     `json:"provider_dispute_id"`
+	Reason            string     `validate:"required" json:"reason"`
+	Amount            int64      `validate:"gte=0" json:"amount"`
+	RespondBy         *time.Time `json:"respond_by"`
+}
+
+// DisputeWebhookRequest is a dispute notification from a provider. The first event for a
+// provider dispute id opens the dispute; later events move it along.
+type DisputeWebhookRequest struct {
+	ProviderDisputeID string     `validate:"required" json:"provider_dispute_id"`
+	ProviderReference string     `json:"provider_reference"`
+	Status            string     `validate:"required,oneof=needs_response under_review won lost" json:"status"`
+	Reason            string     `json:"reason"`
+	Amount            int64      `validate:"gte=0" json:"amount"`
+	RespondBy         *time.Time `json:"respond_by"`
+}
+
+type DisputeStatusRequest struct {
+	Status string `validate:"required,oneof=under_review won lost" json:"status"`
+	Note   string `json:"note"`
+}
+
+type DisputeEvidenceRequest struct {
+	FileName    string
+	ContentType string
+	Note        string `form:"note"`
+}
@@ -0,0 +1,19 @@
+package web
+
+type ExchangeRateCreateRequest struct {
+	BaseCurrency  string `json:"base_currency" validate:"required,len=3"`
+	QuoteCurrency string `json:"quote_currency" validate:"required,len=3"`
+	Rate          string `json:"rate" validate:"required"`
+	EffectiveAt   string `json:"effective_at"`
+}
+
+type ExchangeRateQuery struct {
+	Base  string `query:"base"`
+	Quote string `query:"quote"`
+	At    string `query:"at"`
+}
+
+type ExchangeRateImportResponse struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
@@ -0,0 +1,15 @@
+package web
+
+type FeeSummaryRequest struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+type FeeSummaryResponse struct {
+	Provider     string `json:"provider"`
+	Currency     string `json:"currency"`
+	PaymentCount int64  `json:"payment_count"`
+	GrossAmount  int64  `json:"gross_amount"`
+	FeeAmount    int64  `json:"fee_amount"`
+	NetAmount    int64  `json:"net_amount"`
+}
@@ -0,0 +1,13 @@
+package web
+
+import "github.com/google/uuid"
+
+type InstallmentPlanCreateRequest struct {
+	OrderID         uuid.UUID `json:"order_id" validate:"required"`
+	CustomerID      string    `json:"customer_id" validate:"required,max=100"`
+	Provider        string    `json:"provider" validate:"required"`
+	PaymentMethodID string    `json:"payment_method_id" validate:"omitempty,uuid"`
+	Count           int       `json:"count" validate:"required,min=2"`
+	Interval        string    `json:"interval" validate:"omitempty,oneof=day week month year"`
+	IntervalCount   int       `json:"interval_count" validate:"omitempty,min=1"`
+}
@@ -0,0 +1,29 @@
+package web
+
+// LedgerBalanceResponse lists an account's balance per currency; amounts in different
+// currencies are never added together.
+type LedgerBalanceResponse struct {
+	AccountCode   string                  `json:"account_code"`
+	Name          string                  `json:"name"`
+	NormalBalance string                  `json:"normal_balance"`
+	Balances      []LedgerCurrencyBalance `json:"balances"`
+}
+
+type LedgerCurrencyBalance struct {
+	Currency string `json:"currency"`
+	Debit    int64  `json:"debit"`
+	Credit   int64  `json:"credit"`
+	Balance  int64  `json:"balance"`
+}
+
+type LedgerInvariantResponse struct {
+	Balanced          bool                   `json:"balanced"`
+	Totals            []LedgerCurrencyTotals `json:"totals"`
+	UnbalancedEntries []string               `json:"unbalanced_entries"`
+}
+
+type LedgerCurrencyTotals struct {
+	Currency    string `json:"currency"`
+	TotalDebit  int64  `json:"total_debit"`
+	TotalCredit int64  `json:"total_credit"`
+}
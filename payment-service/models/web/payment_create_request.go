@@ -3,7 +3,24 @@ package web
 import "github.com/google/uuid"
 
 type PaymentCreateRequest struct {
-	OrderID  uuid.UUID `json:"order_id" validate:"required"`
-	Amount   int64     `json:"amount" validate:"required"`
-	Provider string    `json:"provider" validate:"required"`
+	OrderID    uuid.UUID `json:"order_id" validate:"required"`
+	Amount     int64     `json:"amount" validate:"required"`
+	Currency   string    `json:"currency" validate:"omitempty,len=3"`
+	Provider   string    `json:"provider" validate:"required"`
+	Method     string    `json:"method"`
+	CustomerID string    `json:"customer_id"`
+	// IPAddress is the client address the request came from. It feeds the risk rules, so it is
+	// never read from the body; controllers take it from the connection.
+	IPAddress       string `json:"-"`
+	PaymentMethodID string `json:"payment_method_id" validate:"omitempty,uuid"`
+	// BalanceCode is the gift card or store credit code paying with the balance provider. Wallet
+	// payments need no code; the wallet provider pays from the wallet of CustomerID.
+	BalanceCode string `json:"balance_code" validate:"max=32"`
+	// InstallmentNumber pays one installment of the order's installment plan instead of the
+	// order total.
+	InstallmentNumber int `json:"installment_number" validate:"omitempty,min=1"`
+	// SplitTender pays part of the order total as one tender of the order's split payment.
+	SplitTender bool `json:"split_tender"`
+	// TopUp pays a wallet top-up, whose ID is passed as OrderID, instead of an order.
+	TopUp bool `json:"top_up"`
 }
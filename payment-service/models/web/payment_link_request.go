@@ -0,0 +1,15 @@
+package web
+
+import "github.com/google/uuid"
+
+type PaymentLinkCreateRequest struct {
+	OrderID          uuid.UUID `json:"order_id" validate:"required"`
+	CustomerID       string    `json:"customer_id"`
+	Providers        []string  `json:"providers"`
+	ExpiresInMinutes int       `json:"expires_in_minutes" validate:"omitempty,min=1,max=43200"`
+	OneTime          bool      `json:"one_time"`
+}
+
+type CheckoutRequest struct {
+	Provider string `form:"provider" validate:"required"`
+}
@@ -0,0 +1,32 @@
+package web
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentLinkResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	OrderID    uuid.UUID  `json:"order_id"`
+	URL        string     `json:"url"`
+	CustomerID string     `json:"customer_id,omitempty"`
+	Providers  []string   `json:"providers"`
+	OneTime    bool       `json:"one_time"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	PaymentID  *uuid.UUID `json:"payment_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CheckoutPage is what the hosted checkout page shows for a link.
+type CheckoutPage struct {
+	Token     string
+	OrderID   uuid.UUID
+	Amount    int64
+	Currency  string
+	Display   string
+	Providers []string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,18 @@
+package web
+
+type PaymentMethodCreateRequest struct {
+	CustomerID    string `validate:"required" json:"customer_id"`
+	Provider      string `validate:"required" json:"provider"`
+	ProviderToken string `validate:"required" json:"provider_token"`
+	Brand         string `validate:"required" json:"brand"`
+	Last4         string `validate:"required,len=4,numeric" json:"last4"`
+	ExpMonth      int    `validate:"required,min=1,max=12" json:"exp_month"`
+	ExpYear       int    `validate:"required,min=2000,max=2100" json:"exp_year"`
+}
+
+// PaymentMethodUpdateRequest replaces the expiry and, when the provider reissued it, the token.
+type PaymentMethodUpdateRequest struct {
+	ProviderToken string `json:"provider_token"`
+	ExpMonth      int    `validate:"required,min=1,max=12" json:"exp_month"`
+	ExpYear       int    `validate:"required,min=2000,max=2100" json:"exp_year"`
+}
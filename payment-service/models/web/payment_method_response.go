@@ -0,0 +1,20 @@
+package web
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentMethodResponse never carries the provider token.
+type PaymentMethodResponse struct {
+	ID         uuid.UUID `json:"id"`
+	CustomerID string    `json:"customer_id"`
+	Provider   string    `json:"provider"`
+	Brand      string    `json:"brand"`
+	Last4      string    `json:"last4"`
+	ExpMonth   int       `json:"exp_month"`
+	ExpYear    int       `json:"exp_year"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,6 @@
+package web
+
+type PaymentPageResponse struct {
+	Items      []PaymentResponse `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
@@ -1,18 +1,41 @@
 package web
 
 import (
+	"payment-service/models/domain"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type PaymentResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	OrderID   uuid.UUID  `json:"order_id"`
-	Amount    int64      `json:"amount"`
-	Status    string     `json:"status"`
-	Provider  string     `json:"provider"`
-	PaidAt    *time.Time `json:"paid_at"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID                uuid.UUID                  `json:"id"`
+	OrderID           uuid.UUID                  `json:"order_id"`
+	Amount            int64                      `json:"amount"`
+	Currency          string                     `json:"currency,omitempty"`
+	Status            string                     `json:"status"`
+	Provider          string                     `json:"provider"`
+	Method            string                     `json:"method,omitempty"`
+	ProviderReference string                     `json:"provider_reference"`
+	Attempt           int                        `json:"attempt"`
+	InstallmentNumber int                        `json:"installment_number,omitempty"`
+	TenderNumber      int                        `json:"tender_number,omitempty"`
+	CustomerID        string                     `json:"customer_id,omitempty"`
+	RiskScore         int                        `json:"risk_score"`
+	RiskDecision      string                     `json:"risk_decision,omitempty"`
+	PaymentMethodID   *uuid.UUID                 `json:"payment_method_id,omitempty"`
+	BalanceAccountID  *uuid.UUID                 `json:"balance_account_id,omitempty"`
+	TopUp             bool                       `json:"top_up,omitempty"`
+	FeeAmount         int64                      `json:"fee_amount"`
+	NetAmount         int64                      `json:"net_amount"`
+	RefundedAmount    int64                      `json:"refunded_amount,omitempty"`
+	FeeBreakdown      []domain.FeeLine           `json:"fee_breakdown"`
+	Conversion        *domain.CurrencyConversion `json:"conversion,omitempty"`
+	QRPayload         string                     `json:"qr_payload,omitempty"`
+	QRImageURL        string                     `json:"qr_image_url,omitempty"`
+	BankTransfer      *domain.BankTransfer       `json:"bank_transfer,omitempty"`
+	Collection        *domain.CODCollection      `json:"collection,omitempty"`
+	ExpiresAt         *time.Time                 `json:"expires_at"`
+	PaidAt            *time.Time                 `json:"paid_at"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	UpdatedAt         time.Time                  `json:"updated_at"`
 }
@@ -0,0 +1,17 @@
+package web
+
+type PaymentSearchRequest struct {
+	OrderID     string `query:"order_id" validate:"omitempty,uuid"`
+	Status      string `query:"status"`
+	Provider    string `query:"provider"`
+	MinAmount   int64  `query:"min_amount" validate:"gte=0"`
+	MaxAmount   int64  `query:"max_amount" validate:"gte=0"`
+	CreatedFrom string `query:"created_from"`
+	CreatedTo   string `query:"created_to"`
+	PaidFrom    string `query:"paid_from"`
+	PaidTo      string `query:"paid_to"`
+	SortBy      string `query:"sort_by" validate:"omitempty,oneof=created_at amount"`
+	SortOrder   string `query:"sort_order" validate:"omitempty,oneof=asc desc"`
+	Cursor      string `query:"cursor"`
+	Limit       int    `query:"limit" validate:"gte=0,lte=100"`
+}
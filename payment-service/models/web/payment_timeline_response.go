@@ -0,0 +1,8 @@
+package web
+
+import "payment-service/models/domain"
+
+type PaymentTimelineResponse struct {
+	Events           []domain.PaymentEvent    `json:"events"`
+	CallbackAttempts []domain.CallbackAttempt `json:"callback_attempts,omitempty"`
+}
@@ -0,0 +1,21 @@
+package web
+
+type SellerCreateRequest struct {
+	ID       string `json:"id" validate:"required,max=100"`
+	Name     string `json:"name" validate:"required,max=200"`
+	Currency string `json:"currency" validate:"omitempty,len=3"`
+	// CommissionBps overrides the platform commission for this seller, in basis points.
+	CommissionBps     *int64 `json:"commission_bps" validate:"omitempty,min=0,max=10000"`
+	BankName          string `json:"bank_name" validate:"required,max=100"`
+	BankAccountNumber string `json:"bank_account_number" validate:"required,numeric,max=50"`
+	BankAccountName   string `json:"bank_account_name" validate:"required,max=200"`
+}
+
+type SellerShareRequest struct {
+	SellerID string `json:"seller_id" validate:"required"`
+	Amount   int64  `json:"amount" validate:"required,min=1"`
+}
+
+type SellerSharesRequest struct {
+	Shares []SellerShareRequest `json:"shares" validate:"required,min=1,dive"`
+}
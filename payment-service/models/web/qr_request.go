@@ -0,0 +1,34 @@
+package web
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QRWebhookRequest is the provider notification for a scanned QR payment.
+type QRWebhookRequest struct {
+	Reference string `json:"reference" validate:"required"`
+	Status    string `json:"status" validate:"required,oneof=paid failed"`
+	Amount    int64  `json:"amount"`
+	// PaidAt is when the provider booked the payment; notifications without it are taken as paid
+	// on receipt.
+	PaidAt *time.Time `json:"paid_at"`
+}
+
+// QRSimulatorRequest carries the payload a customer's banking app read from the code.
+type QRSimulatorRequest struct {
+	Payload string `json:"payload" validate:"required"`
+}
+
+// QRScanResponse is what a banking app shows the customer before they confirm.
+type QRScanResponse struct {
+	PaymentID    uuid.UUID `json:"payment_id"`
+	Reference    string    `json:"reference"`
+	MerchantID   string    `json:"merchant_id"`
+	MerchantName string    `json:"merchant_name"`
+	MerchantCity string    `json:"merchant_city"`
+	Amount       int64     `json:"amount"`
+	Currency     string    `json:"currency"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
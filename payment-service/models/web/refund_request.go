@@ -0,0 +1,9 @@
+package web
+
+// PartialRefundRequest refunds part of a successful payment. A marketplace order names the
+// seller whose sale is refunded so only that seller is clawed back; without one every seller of
+// the payment gives back their share.
+type PartialRefundRequest struct {
+	Amount   int64  `json:"amount" validate:"required,min=1"`
+	SellerID string `json:"seller_id" validate:"max=100"`
+}
@@ -0,0 +1,6 @@
+package web
+
+type RiskReviewRequest struct {
+	Reviewer string `validate:"required" json:"reviewer"`
+	Note     string `json:"note"`
+}
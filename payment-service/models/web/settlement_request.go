@@ -0,0 +1,11 @@
+package web
+
+type SettlementImportRequest struct {
+	Provider       string `json:"provider" form:"provider" validate:"required"`
+	SettlementDate string `json:"settlement_date" form:"settlement_date" validate:"required"`
+	FileName       string `json:"file_name"`
+}
+
+type SettlementResolveRequest struct {
+	Note string `json:"note" validate:"required"`
+}
@@ -0,0 +1,7 @@
+package web
+
+import "github.com/google/uuid"
+
+type SplitPaymentCreateRequest struct {
+	OrderID uuid.UUID `json:"order_id" validate:"required"`
+}
@@ -0,0 +1,20 @@
+package web
+
+type SubscriptionPlanCreateRequest struct {
+	Name          string `json:"name" validate:"required,max=100"`
+	Amount        int64  `json:"amount" validate:"required,min=1"`
+	Currency      string `json:"currency" validate:"omitempty,len=3"`
+	Interval      string `json:"interval" validate:"required,oneof=day week month year"`
+	IntervalCount int    `json:"interval_count" validate:"min=0,max=36"`
+}
+
+type SubscriptionCreateRequest struct {
+	CustomerID      string `json:"customer_id" validate:"required,max=100"`
+	PlanID          string `json:"plan_id" validate:"required,uuid"`
+	PaymentMethodID string `json:"payment_method_id" validate:"required,uuid"`
+}
+
+// SubscriptionPlanChangeRequest moves a subscription to another plan in the middle of a cycle.
+type SubscriptionPlanChangeRequest struct {
+	PlanID string `json:"plan_id" validate:"required,uuid"`
+}
@@ -0,0 +1,8 @@
+package web
+
+type WalletTopUpRequest struct {
+	Amount int64 `json:"amount" validate:"required,min=1"`
+	// Currency is only used when the top-up opens the wallet; later top-ups are in the wallet's
+	// currency.
+	Currency string `json:"currency" validate:"omitempty,len=3"`
+}
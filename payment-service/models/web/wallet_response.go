@@ -0,0 +1,9 @@
+package web
+
+import "payment-service/models/domain"
+
+// WalletResponse is a customer's wallet with every change to its balance, oldest first.
+type WalletResponse struct {
+	domain.BalanceAccount
+	Transactions []domain.BalanceTransaction `json:"transactions"`
+}
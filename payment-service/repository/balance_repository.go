@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type BalanceRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, account domain.BalanceAccount) (domain.BalanceAccount, error)
+	FindById(ctx context.Context, tx *gorm.DB, accountId string) (domain.BalanceAccount, error)
+	FindByCode(ctx context.Context, tx *gorm.DB, code string) (domain.BalanceAccount, error)
+	UpdateBalance(ctx context.Context, tx *gorm.DB, account domain.BalanceAccount) (domain.BalanceAccount, error)
+	SaveTransaction(ctx context.Context, tx *gorm.DB, transaction domain.BalanceTransaction) (domain.BalanceTransaction, error)
+	FindTransactions(ctx context.Context, tx *gorm.DB, accountId string) ([]domain.BalanceTransaction, error)
+	FindWallet(ctx context.Context, tx *gorm.DB, customerId string) (domain.BalanceAccount, error)
+	SaveTopUp(ctx context.Context, tx *gorm.DB, topUp domain.WalletTopUp) (domain.WalletTopUp, error)
+	FindTopUpById(ctx context.Context, tx *gorm.DB, topUpId string) (domain.WalletTopUp, error)
+	UpdateTopUp(ctx context.Context, tx *gorm.DB, topUp domain.WalletTopUp) (domain.WalletTopUp, error)
+}
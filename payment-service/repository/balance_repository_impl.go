@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BalanceRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewBalanceRepository(db *gorm.DB) BalanceRepository {
+	return &BalanceRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *BalanceRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, account domain.BalanceAccount) (domain.BalanceAccount, error) {
+	err := tx.WithContext(ctx).Create(&account).Error
+
+	return account, err
+}
+
+// FindById locks the account row, so concurrent redemptions of the same account are applied one
+// after the other against the current balance.
+func (repository *BalanceRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, accountId string) (domain.BalanceAccount, error) {
+	var account domain.BalanceAccount
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", accountId).First(&account).Error
+
+	return account, err
+}
+
+func (repository *BalanceRepositoryImpl) FindByCode(ctx context.Context, tx *gorm.DB, code string) (domain.BalanceAccount, error) {
+	var account domain.BalanceAccount
+	err := tx.WithContext(ctx).Where("code = ?", code).First(&account).Error
+
+	return account, err
+}
+
+func (repository *BalanceRepositoryImpl) UpdateBalance(ctx context.Context, tx *gorm.DB, account domain.BalanceAccount) (domain.BalanceAccount, error) {
+	err := tx.WithContext(ctx).Model(&domain.BalanceAccount{}).Where("id = ?", account.ID).Update("balance", account.Balance).Error
+
+	return account, err
+}
+
+func (repository *BalanceRepositoryImpl) SaveTransaction(ctx context.Context, tx *gorm.DB, transaction domain.BalanceTransaction) (domain.BalanceTransaction, error) {
+	err := tx.WithContext(ctx).Create(&transaction).Error
+
+	return transaction, err
+}
+
+func (repository *BalanceRepositoryImpl) FindTransactions(ctx context.Context, tx *gorm.DB, accountId string) ([]domain.BalanceTransaction, error) {
+	var transactions []domain.BalanceTransaction
+	err := tx.WithContext(ctx).Where("account_id = ?", accountId).Order("created_at").Find(&transactions).Error
+
+	return transactions, err
+}
+
+func (repository *BalanceRepositoryImpl) FindWallet(ctx context.Context, tx *gorm.DB, customerId string) (domain.BalanceAccount, error) {
+	var account domain.BalanceAccount
+	err := tx.WithContext(ctx).Where("customer_id = ? AND type = ?", customerId, domain.BalanceWallet).First(&account).Error
+
+	return account, err
+}
+
+func (repository *BalanceRepositoryImpl) SaveTopUp(ctx context.Context, tx *gorm.DB, topUp domain.WalletTopUp) (domain.WalletTopUp, error) {
+	err := tx.WithContext(ctx).Create(&topUp).Error
+
+	return topUp, err
+}
+
+// FindTopUpById locks the top-up row, so a top-up is credited to its wallet at most once.
+func (repository *BalanceRepositoryImpl) FindTopUpById(ctx context.Context, tx *gorm.DB, topUpId string) (domain.WalletTopUp, error) {
+	var topUp domain.WalletTopUp
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", topUpId).First(&topUp).Error
+
+	return topUp, err
+}
+
+func (repository *BalanceRepositoryImpl) UpdateTopUp(ctx context.Context, tx *gorm.DB, topUp domain.WalletTopUp) (domain.WalletTopUp, error) {
+	err := tx.WithContext(ctx).Model(&domain.WalletTopUp{}).Where("id = ?", topUp.ID).Updates(map[string]interface{}{
+		"status":       topUp.Status,
+		"payment_id":   topUp.PaymentID,
+		"completed_at": topUp.CompletedAt,
+	}).Error
+
+	return topUp, err
+}
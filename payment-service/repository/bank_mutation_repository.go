@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type BankMutationRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, mutation domain.BankMutation) (domain.BankMutation, bool, error)
+	Update(ctx context.Context, tx *gorm.DB, mutation domain.BankMutation) (domain.BankMutation, error)
+	FindById(ctx context.Context, tx *gorm.DB, mutationId string) (domain.BankMutation, error)
+	FindByExternalId(ctx context.Context, tx *gorm.DB, provider string, externalId string) (domain.BankMutation, error)
+	FindExceptions(ctx context.Context, tx *gorm.DB, includeResolved bool) ([]domain.BankMutation, error)
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BankMutationRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewBankMutationRepository(db *gorm.DB) BankMutationRepository {
+	return &BankMutationRepositoryImpl{
+		DB: db,
+	}
+}
+
+// Save stores a mutation unless the bank already reported it under the same external id. The
+// boolean reports whether a row was written, so a statement can be imported more than once.
+func (repository *BankMutationRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, mutation domain.BankMutation) (domain.BankMutation, bool, error) {
+	result := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&mutation)
+
+	return mutation, result.RowsAffected > 0, result.Error
+}
+
+func (repository *BankMutationRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, mutation domain.BankMutation) (domain.BankMutation, error) {
+	err := tx.WithContext(ctx).Model(&domain.BankMutation{}).Where("id = ?", mutation.ID).Updates(map[string]interface{}{
+		"status":          mutation.Status,
+		"reason":          mutation.Reason,
+		"payment_id":      mutation.PaymentID,
+		"resolved":        mutation.Resolved,
+		"resolution_note": mutation.ResolutionNote,
+		"resolved_at":     mutation.ResolvedAt,
+	}).Error
+
+	return mutation, err
+}
+
+func (repository *BankMutationRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, mutationId string) (domain.BankMutation, error) {
+	var mutation domain.BankMutation
+	err := tx.WithContext(ctx).First(&mutation, "id = ?", mutationId).Error
+
+	return mutation, err
+}
+
+func (repository *BankMutationRepositoryImpl) FindByExternalId(ctx context.Context, tx *gorm.DB, provider string, externalId string) (domain.BankMutation, error) {
+	var mutation domain.BankMutation
+	err := tx.WithContext(ctx).First(&mutation, "provider = ? AND external_id = ?", provider, externalId).Error
+
+	return mutation, err
+}
+
+// FindExceptions returns the mutations that could not be matched to a payment, oldest first.
+func (repository *BankMutationRepositoryImpl) FindExceptions(ctx context.Context, tx *gorm.DB, includeResolved bool) ([]domain.BankMutation, error) {
+	query := tx.WithContext(ctx).Where("status = ?", domain.MutationUnmatched)
+	if !includeResolved {
+		query = query.Where("resolved = ?", false)
+	}
+
+	var mutations []domain.BankMutation
+	err := query.Order("transacted_at").Find(&mutations).Error
+
+	return mutations, err
+}
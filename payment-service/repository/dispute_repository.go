@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type DisputeRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, dispute domain.Dispute) (domain.Dispute, error)
+	Update(ctx context.Context, tx *gorm.DB, dispute domain.Dispute) (domain.Dispute, error)
+	FindById(ctx context.Context, tx *gorm.DB, disputeId string) (domain.Dispute, error)
+	FindByProviderDisputeId(ctx context.Context, tx *gorm.DB, provider string, providerDisputeId string) (domain.Dispute, error)
+	FindOpenByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) (domain.Dispute, error)
+	FindAll(ctx context.Context, tx *gorm.DB, status string) ([]domain.Dispute, error)
+	FindAllByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.Dispute, error)
+	SaveEvidence(ctx context.Context, tx *gorm.DB, evidence domain.DisputeEvidence) (domain.DisputeEvidence, error)
+	FindEvidenceById(ctx context.Context, tx *gorm.DB, disputeId string, evidenceId string) (domain.DisputeEvidence, error)
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DisputeRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewDisputeRepository(db *gorm.DB) DisputeRepository {
+	return &DisputeRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *DisputeRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, dispute domain.Dispute) (domain.Dispute, error) {
+	err := tx.WithContext(ctx).Create(&dispute).Error
+
+	return dispute, err
+}
+
+func (repository *DisputeRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, dispute domain.Dispute) (domain.Dispute, error) {
+	err := tx.WithContext(ctx).Model(&domain.Dispute{}).Where("id = ?", dispute.ID).Updates(map[string]interface{}{
+		"status":          dispute.Status,
+		"resolution_note": dispute.ResolutionNote,
+		"closed_at":       dispute.ClosedAt,
+	}).Error
+
+	return dispute, err
+}
+
+// FindById locks the dispute so concurrent webhook deliveries apply their transitions in turn.
+func (repository *DisputeRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, disputeId string) (domain.Dispute, error) {
+	var dispute domain.Dispute
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", disputeId).First(&dispute).Error
+	if err != nil {
+		return dispute, err
+	}
+
+	err = tx.WithContext(ctx).Where("dispute_id = ?", dispute.ID).Order("created_at ASC").Find(&dispute.Evidence).Error
+
+	return dispute, err
+}
+
+func (repository *DisputeRepositoryImpl) FindByProviderDisputeId(ctx context.Context, tx *gorm.DB, provider string, providerDisputeId string) (domain.Dispute, error) {
+	var dispute domain.Dispute
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND provider_dispute_id = ?", provider, providerDisputeId).
+		First(&dispute).Error
+
+	return dispute, err
+}
+
+func (repository *DisputeRepositoryImpl) FindOpenByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) (domain.Dispute, error) {
+	var dispute domain.Dispute
+	err := tx.WithContext(ctx).
+		Where("payment_id = ? AND status IN ?", paymentId, []string{domain.DisputeNeedsResponse, domain.DisputeUnderReview}).
+		First(&dispute).Error
+
+	return dispute, err
+}
+
+func (repository *DisputeRepositoryImpl) FindAll(ctx context.Context, tx *gorm.DB, status string) ([]domain.Dispute, error) {
+	query := tx.WithContext(ctx).Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var disputes []domain.Dispute
+	err := query.Find(&disputes).Error
+
+	return disputes, err
+}
+
+func (repository *DisputeRepositoryImpl) FindAllByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.Dispute, error) {
+	var disputes []domain.Dispute
+	err := tx.WithContext(ctx).Where("payment_id = ?", paymentId).Order("created_at ASC").Find(&disputes).Error
+
+	return disputes, err
+}
+
+func (repository *DisputeRepositoryImpl) SaveEvidence(ctx context.Context, tx *gorm.DB, evidence domain.DisputeEvidence) (domain.DisputeEvidence, error) {
+	err := tx.WithContext(ctx).Create(&evidence).Error
+
+	return evidence, err
+}
+
+func (repository *DisputeRepositoryImpl) FindEvidenceById(ctx context.Context, tx *gorm.DB, disputeId string, evidenceId string) (domain.DisputeEvidence, error) {
+	var evidence domain.DisputeEvidence
+	err := tx.WithContext(ctx).Where("id = ? AND dispute_id = ?", evidenceId, disputeId).First(&evidence).Error
+
+	return evidence, err
+}
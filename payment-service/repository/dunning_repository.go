@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DunningRepository interface {
+	FindNewFailures(ctx context.Context, tx *gorm.DB) ([]domain.Payment, error)
+	Save(ctx context.Context, tx *gorm.DB, dunningCase domain.DunningCase) (domain.DunningCase, error)
+	Update(ctx context.Context, tx *gorm.DB, dunningCase domain.DunningCase) (domain.DunningCase, error)
+	FindByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.DunningCase, error)
+	FindAll(ctx context.Context, tx *gorm.DB, status string) ([]domain.DunningCase, error)
+	FindDue(ctx context.Context, tx *gorm.DB, now time.Time) ([]domain.DunningCase, error)
+	SaveEvent(ctx context.Context, tx *gorm.DB, event domain.DunningEvent) (domain.DunningEvent, error)
+}
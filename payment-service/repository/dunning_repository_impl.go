@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DunningRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewDunningRepository(db *gorm.DB) DunningRepository {
+	return &DunningRepositoryImpl{
+		DB: db,
+	}
+}
+
+// FindNewFailures returns failed payments made with a saved payment method that are the latest
+// attempt on their order and have no dunning case yet. Subscription and installment orders are
+// left out because they track their unpaid charges on their own, and so are split tenders, whose
+// failure already unwinds the split payment.
+func (repository *DunningRepositoryImpl) FindNewFailures(ctx context.Context, tx *gorm.DB) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := tx.WithContext(ctx).
+		Where("status = ? AND payment_method_id IS NOT NULL AND customer_id <> '' AND tender_number = 0", "failed").
+		Where("NOT EXISTS (SELECT 1 FROM payments later WHERE later.order_id = payments.order_id AND later.attempt > payments.attempt)").
+		Where("NOT EXISTS (SELECT 1 FROM dunning_cases WHERE dunning_cases.order_id = payments.order_id)").
+		Where("NOT EXISTS (SELECT 1 FROM subscription_charges WHERE subscription_charges.order_id = payments.order_id)").
+		Where("NOT EXISTS (SELECT 1 FROM installment_plans WHERE installment_plans.order_id = payments.order_id)").
+		Order("updated_at").
+		Find(&payments).Error
+
+	return payments, err
+}
+
+func (repository *DunningRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, dunningCase domain.DunningCase) (domain.DunningCase, error) {
+	err := tx.WithContext(ctx).Omit("Events").Create(&dunningCase).Error
+
+	return dunningCase, err
+}
+
+func (repository *DunningRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, dunningCase domain.DunningCase) (domain.DunningCase, error) {
+	err := tx.WithContext(ctx).Model(&domain.DunningCase{}).Where("id = ?", dunningCase.ID).Updates(map[string]interface{}{
+		"status":          dunningCase.Status,
+		"attempts":        dunningCase.Attempts,
+		"next_attempt_at": dunningCase.NextAttemptAt,
+		"last_payment_id": dunningCase.LastPaymentID,
+		"last_error":      dunningCase.LastError,
+		"closed_at":       dunningCase.ClosedAt,
+	}).Error
+
+	return dunningCase, err
+}
+
+func (repository *DunningRepositoryImpl) FindByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.DunningCase, error) {
+	var dunningCase domain.DunningCase
+	err := tx.WithContext(ctx).
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&dunningCase, "order_id = ?", orderId).Error
+
+	return dunningCase, err
+}
+
+// FindAll returns the dunning cases in the given status, or every case when status is empty.
+func (repository *DunningRepositoryImpl) FindAll(ctx context.Context, tx *gorm.DB, status string) ([]domain.DunningCase, error) {
+	var cases []domain.DunningCase
+	query := tx.WithContext(ctx)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at").Find(&cases).Error
+
+	return cases, err
+}
+
+// FindDue returns the active cases whose next retry is due at now, longest overdue first.
+func (repository *DunningRepositoryImpl) FindDue(ctx context.Context, tx *gorm.DB, now time.Time) ([]domain.DunningCase, error) {
+	var cases []domain.DunningCase
+	err := tx.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", domain.DunningActive, now).
+		Order("next_attempt_at").
+		Find(&cases).Error
+
+	return cases, err
+}
+
+func (repository *DunningRepositoryImpl) SaveEvent(ctx context.Context, tx *gorm.DB, event domain.DunningEvent) (domain.DunningEvent, error) {
+	err := tx.WithContext(ctx).Create(&event).Error
+
+	return event, err
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExchangeRateRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, rate domain.ExchangeRate) (domain.ExchangeRate, bool, error)
+	FindAll(ctx context.Context, tx *gorm.DB, baseCurrency string, quoteCurrency string) ([]domain.ExchangeRate, error)
+	FindEffective(ctx context.Context, tx *gorm.DB, baseCurrency string, quoteCurrency string, at time.Time) (domain.ExchangeRate, error)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExchangeRateRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &ExchangeRateRepositoryImpl{
+		DB: db,
+	}
+}
+
+// Save stores a rate unless the pair already has one at the same effective time. The boolean
+// reports whether a row was written, which makes re-importing a rate file harmless.
+func (repository *ExchangeRateRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, rate domain.ExchangeRate) (domain.ExchangeRate, bool, error) {
+	result := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rate)
+
+	return rate, result.RowsAffected > 0, result.Error
+}
+
+func (repository *ExchangeRateRepositoryImpl) FindAll(ctx context.Context, tx *gorm.DB, baseCurrency string, quoteCurrency string) ([]domain.ExchangeRate, error) {
+	query := tx.WithContext(ctx)
+	if baseCurrency != "" {
+		query = query.Where("base_currency = ?", baseCurrency)
+	}
+	if quoteCurrency != "" {
+		query = query.Where("quote_currency = ?", quoteCurrency)
+	}
+
+	var rates []domain.ExchangeRate
+	err := query.Order("base_currency, quote_currency, effective_at DESC").Find(&rates).Error
+
+	return rates, err
+}
+
+// FindEffective returns the newest rate of the pair that had taken effect at the given time.
+func (repository *ExchangeRateRepositoryImpl) FindEffective(ctx context.Context, tx *gorm.DB, baseCurrency string, quoteCurrency string, at time.Time) (domain.ExchangeRate, error) {
+	var rate domain.ExchangeRate
+	err := tx.WithContext(ctx).
+		Where("base_currency = ? AND quote_currency = ? AND effective_at <= ?", baseCurrency, quoteCurrency, at.UTC()).
+		Order("effective_at DESC").
+		First(&rate).Error
+
+	return rate, err
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProviderFeeTotals holds the summed amounts of one provider's captured payments in one currency.
+type ProviderFeeTotals struct {
+	Provider     string
+	Currency     string
+	PaymentCount int64
+	GrossAmount  int64
+	FeeAmount    int64
+	NetAmount    int64
+}
+
+type FeeRepository interface {
+	SumByProvider(ctx context.Context, tx *gorm.DB, from *time.Time, to *time.Time) ([]ProviderFeeTotals, error)
+}
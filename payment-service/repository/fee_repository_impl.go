@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FeeRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewFeeRepository(db *gorm.DB) FeeRepository {
+	return &FeeRepositoryImpl{
+		DB: db,
+	}
+}
+
+// SumByProvider totals the payments captured within the range, grouped by provider and currency. Payments
+// refunded or charged back later are included with their current net amount.
+func (repository *FeeRepositoryImpl) SumByProvider(ctx context.Context, tx *gorm.DB, from *time.Time, to *time.Time) ([]ProviderFeeTotals, error) {
+	query := tx.WithContext(ctx).Model(&domain.Payment{}).
+		Select("provider, currency, COUNT(*) AS payment_count, SUM(amount) AS gross_amount, SUM(fee_amount) AS fee_amount, SUM(net_amount) AS net_amount").
+		Where("paid_at IS NOT NULL")
+	if from != nil {
+		query = query.Where("paid_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("paid_at <= ?", *to)
+	}
+
+	var totals []ProviderFeeTotals
+	err := query.Group("provider, currency").Order("provider ASC, currency ASC").Scan(&totals).Error
+
+	return totals, err
+}
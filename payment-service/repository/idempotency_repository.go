@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IdempotencyRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, key domain.IdempotencyKey) (domain.IdempotencyKey, bool, error)
+	FindByKey(ctx context.Context, tx *gorm.DB, key string) (domain.IdempotencyKey, error)
+	Update(ctx context.Context, tx *gorm.DB, key domain.IdempotencyKey) (domain.IdempotencyKey, error)
+	Delete(ctx context.Context, tx *gorm.DB, key string) error
+	DeleteExpired(ctx context.Context, tx *gorm.DB, now time.Time) (int64, error)
+}
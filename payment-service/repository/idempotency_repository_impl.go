@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		DB: db,
+	}
+}
+
+// Save claims the key. The boolean is false when another request holds it already.
+func (repository *IdempotencyRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, key domain.IdempotencyKey) (domain.IdempotencyKey, bool, error) {
+	result := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&key)
+
+	return key, result.RowsAffected > 0, result.Error
+}
+
+func (repository *IdempotencyRepositoryImpl) FindByKey(ctx context.Context, tx *gorm.DB, key string) (domain.IdempotencyKey, error) {
+	var idempotencyKey domain.IdempotencyKey
+	err := tx.WithContext(ctx).Where("key = ?", key).First(&idempotencyKey).Error
+
+	return idempotencyKey, err
+}
+
+func (repository *IdempotencyRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, key domain.IdempotencyKey) (domain.IdempotencyKey, error) {
+	err := tx.WithContext(ctx).Save(&key).Error
+
+	return key, err
+}
+
+func (repository *IdempotencyRepositoryImpl) Delete(ctx context.Context, tx *gorm.DB, key string) error {
+	return tx.WithContext(ctx).Where("key = ?", key).Delete(&domain.IdempotencyKey{}).Error
+}
+
+func (repository *IdempotencyRepositoryImpl) DeleteExpired(ctx context.Context, tx *gorm.DB, now time.Time) (int64, error) {
+	result := tx.WithContext(ctx).Where("expires_at <= ?", now).Delete(&domain.IdempotencyKey{})
+
+	return result.RowsAffected, result.Error
+}
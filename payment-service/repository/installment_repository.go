@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InstallmentRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, plan domain.InstallmentPlan) (domain.InstallmentPlan, error)
+	Update(ctx context.Context, tx *gorm.DB, plan domain.InstallmentPlan) (domain.InstallmentPlan, error)
+	FindById(ctx context.Context, tx *gorm.DB, planId string) (domain.InstallmentPlan, error)
+	FindByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.InstallmentPlan, error)
+	UpdateInstallment(ctx context.Context, tx *gorm.DB, installment domain.Installment) (domain.Installment, error)
+	FindDue(ctx context.Context, tx *gorm.DB, now time.Time) ([]domain.Installment, error)
+	MarkOverdue(ctx context.Context, tx *gorm.DB, dueBefore time.Time, now time.Time) (int64, error)
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InstallmentRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewInstallmentRepository(db *gorm.DB) InstallmentRepository {
+	return &InstallmentRepositoryImpl{
+		DB: db,
+	}
+}
+
+// Save inserts the plan together with its installments.
+func (repository *InstallmentRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, plan domain.InstallmentPlan) (domain.InstallmentPlan, error) {
+	err := tx.WithContext(ctx).Create(&plan).Error
+
+	return plan, err
+}
+
+func (repository *InstallmentRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, plan domain.InstallmentPlan) (domain.InstallmentPlan, error) {
+	err := tx.WithContext(ctx).Model(&domain.InstallmentPlan{}).Where("id = ?", plan.ID).Updates(map[string]interface{}{
+		"paid_amount":  plan.PaidAmount,
+		"status":       plan.Status,
+		"completed_at": plan.CompletedAt,
+	}).Error
+
+	return plan, err
+}
+
+func (repository *InstallmentRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, planId string) (domain.InstallmentPlan, error) {
+	var plan domain.InstallmentPlan
+	err := tx.WithContext(ctx).Preload("Installments", orderByNumber).First(&plan, "id = ?", planId).Error
+
+	return plan, err
+}
+
+func (repository *InstallmentRepositoryImpl) FindByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.InstallmentPlan, error) {
+	var plan domain.InstallmentPlan
+	err := tx.WithContext(ctx).Preload("Installments", orderByNumber).First(&plan, "order_id = ?", orderId).Error
+
+	return plan, err
+}
+
+func (repository *InstallmentRepositoryImpl) UpdateInstallment(ctx context.Context, tx *gorm.DB, installment domain.Installment) (domain.Installment, error) {
+	err := tx.WithContext(ctx).Model(&domain.Installment{}).Where("id = ?", installment.ID).Updates(map[string]interface{}{
+		"status":         installment.Status,
+		"payment_id":     installment.PaymentID,
+		"attempts":       installment.Attempts,
+		"failure_reason": installment.FailureReason,
+		"overdue_at":     installment.OverdueAt,
+		"paid_at":        installment.PaidAt,
+	}).Error
+
+	return installment, err
+}
+
+// FindDue returns the unpaid installments at or past their due date that have not been charged
+// yet, on active plans with a saved payment method, earliest first.
+func (repository *InstallmentRepositoryImpl) FindDue(ctx context.Context, tx *gorm.DB, now time.Time) ([]domain.Installment, error) {
+	var installments []domain.Installment
+	err := tx.WithContext(ctx).
+		Joins("JOIN installment_plans ON installment_plans.id = installments.plan_id").
+		Where("installment_plans.status = ? AND installment_plans.payment_method_id IS NOT NULL", domain.InstallmentPlanActive).
+		Where("installments.status <> ? AND installments.attempts = 0 AND installments.due_at <= ?", domain.InstallmentPaid, now).
+		Order("installments.due_at").
+		Order("installments.number").
+		Find(&installments).Error
+
+	return installments, err
+}
+
+// MarkOverdue flags the scheduled installments due before dueBefore as overdue and returns how
+// many changed.
+func (repository *InstallmentRepositoryImpl) MarkOverdue(ctx context.Context, tx *gorm.DB, dueBefore time.Time, now time.Time) (int64, error) {
+	result := tx.WithContext(ctx).Model(&domain.Installment{}).
+		Where("status = ? AND due_at <= ?", domain.InstallmentScheduled, dueBefore).
+		Updates(map[string]interface{}{
+			"status":     domain.InstallmentOverdue,
+			"overdue_at": now,
+		})
+
+	return result.RowsAffected, result.Error
+}
+
+func orderByNumber(db *gorm.DB) *gorm.DB {
+	return db.Order("number")
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+// AccountTotals holds the summed debits and credits posted to one account in one currency.
+type AccountTotals struct {
+	AccountCode string
+	Currency    string
+	Debit       int64
+	Credit      int64
+}
+
+type LedgerRepository interface {
+	SaveEntry(ctx context.Context, tx *gorm.DB, entry domain.JournalEntry) (domain.JournalEntry, error)
+	FindEntriesByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.JournalEntry, error)
+	FindAccounts(ctx context.Context, tx *gorm.DB) ([]domain.LedgerAccount, error)
+	FindAccountByCode(ctx context.Context, tx *gorm.DB, code string) (domain.LedgerAccount, error)
+	SumByAccount(ctx context.Context, tx *gorm.DB) ([]AccountTotals, error)
+	FindUnbalancedEntryIds(ctx context.Context, tx *gorm.DB) ([]string, error)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type LedgerRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &LedgerRepositoryImpl{
+		DB: db,
+	}
+}
+
+// SaveEntry inserts the entry together with its lines.
+func (repository *LedgerRepositoryImpl) SaveEntry(ctx context.Context, tx *gorm.DB, entry domain.JournalEntry) (domain.JournalEntry, error) {
+	err := tx.WithContext(ctx).Create(&entry).Error
+
+	return entry, err
+}
+
+func (repository *LedgerRepositoryImpl) FindEntriesByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.JournalEntry, error) {
+	var entries []domain.JournalEntry
+	err := tx.WithContext(ctx).Preload("Lines").Where("payment_id = ?", paymentId).Order("created_at ASC").Find(&entries).Error
+
+	return entries, err
+}
+
+func (repository *LedgerRepositoryImpl) FindAccounts(ctx context.Context, tx *gorm.DB) ([]domain.LedgerAccount, error) {
+	var accounts []domain.LedgerAccount
+	err := tx.WithContext(ctx).Order("code ASC").Find(&accounts).Error
+
+	return accounts, err
+}
+
+func (repository *LedgerRepositoryImpl) FindAccountByCode(ctx context.Context, tx *gorm.DB, code string) (domain.LedgerAccount, error) {
+	var account domain.LedgerAccount
+	err := tx.WithContext(ctx).Where("code = ?", code).First(&account).Error
+
+	return account, err
+}
+
+func (repository *LedgerRepositoryImpl) SumByAccount(ctx context.Context, tx *gorm.DB) ([]AccountTotals, error) {
+	var totals []AccountTotals
+	err := tx.WithContext(ctx).Model(&domain.JournalLine{}).
+		Select("account_code, currency, SUM(debit) AS debit, SUM(credit) AS credit").
+		Group("account_code, currency").
+		Order("account_code ASC, currency ASC").
+		Scan(&totals).Error
+
+	return totals, err
+}
+
+// FindUnbalancedEntryIds returns the entries whose lines do not net to zero in every currency.
+func (repository *LedgerRepositoryImpl) FindUnbalancedEntryIds(ctx context.Context, tx *gorm.DB) ([]string, error) {
+	var ids []string
+	err := tx.WithContext(ctx).Model(&domain.JournalLine{}).
+		Distinct("entry_id").
+		Group("entry_id, currency").
+		Having("SUM(debit) <> SUM(credit)").
+		Pluck("entry_id", &ids).Error
+
+	return ids, err
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type PaymentEventRepository interface {
+	FindEventsByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.PaymentEvent, error)
+	SaveCallbackAttempt(ctx context.Context, tx *gorm.DB, attempt domain.CallbackAttempt) (domain.CallbackAttempt, error)
+	FindCallbackAttemptsByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.CallbackAttempt, error)
+}
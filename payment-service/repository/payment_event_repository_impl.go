@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+// PaymentEventRepositoryImpl reads the payment timeline. Events themselves are written by
+// PaymentRepository together with the status change they describe.
+type PaymentEventRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewPaymentEventRepository(db *gorm.DB) PaymentEventRepository {
+	return &PaymentEventRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *PaymentEventRepositoryImpl) FindEventsByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.PaymentEvent, error) {
+	var events []domain.PaymentEvent
+	err := tx.WithContext(ctx).Where("payment_id = ?", paymentId).Order("created_at ASC").Find(&events).Error
+
+	return events, err
+}
+
+func (repository *PaymentEventRepositoryImpl) SaveCallbackAttempt(ctx context.Context, tx *gorm.DB, attempt domain.CallbackAttempt) (domain.CallbackAttempt, error) {
+	err := tx.WithContext(ctx).Create(&attempt).Error
+
+	return attempt, err
+}
+
+func (repository *PaymentEventRepositoryImpl) FindCallbackAttemptsByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.CallbackAttempt, error) {
+	var attempts []domain.CallbackAttempt
+	err := tx.WithContext(ctx).Where("payment_id = ?", paymentId).Order("created_at ASC").Find(&attempts).Error
+
+	return attempts, err
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentFilter narrows down payment queries. Zero values mean "no constraint".
+type PaymentFilter struct {
+	OrderID     *uuid.UUID
+	Status      string
+	Provider    string
+	MinAmount   int64
+	MaxAmount   int64
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	PaidFrom    *time.Time
+	PaidTo      *time.Time
+	SortBy      string
+	Descending  bool
+	After       *PaymentCursor
+	Limit       int
+}
+
+// PaymentCursor marks the last row of a page: the value of the sort column and the payment id
+// used as a tie breaker.
+type PaymentCursor struct {
+	SortValue string    `json:"v"`
+	ID        uuid.UUID `json:"id"`
+}
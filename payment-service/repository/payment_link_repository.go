@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentLinkRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, link domain.PaymentLink) (domain.PaymentLink, error)
+	FindById(ctx context.Context, tx *gorm.DB, linkId string) (domain.PaymentLink, error)
+	FindByToken(ctx context.Context, tx *gorm.DB, token string) (domain.PaymentLink, error)
+	Claim(ctx context.Context, tx *gorm.DB, linkId uuid.UUID, now time.Time) (bool, error)
+	Release(ctx context.Context, tx *gorm.DB, linkId uuid.UUID) error
+	SetPayment(ctx context.Context, tx *gorm.DB, linkId uuid.UUID, paymentId uuid.UUID) error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentLinkRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewPaymentLinkRepository(db *gorm.DB) PaymentLinkRepository {
+	return &PaymentLinkRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *PaymentLinkRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, link domain.PaymentLink) (domain.PaymentLink, error) {
+	err := tx.WithContext(ctx).Create(&link).Error
+
+	return link, err
+}
+
+func (repository *PaymentLinkRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, linkId string) (domain.PaymentLink, error) {
+	var link domain.PaymentLink
+	err := tx.WithContext(ctx).Where("id = ?", linkId).First(&link).Error
+
+	return link, err
+}
+
+func (repository *PaymentLinkRepositoryImpl) FindByToken(ctx context.Context, tx *gorm.DB, token string) (domain.PaymentLink, error) {
+	var link domain.PaymentLink
+	err := tx.WithContext(ctx).Where("token = ?", token).First(&link).Error
+
+	return link, err
+}
+
+// Claim marks the link as used unless another checkout already did. For one-time links the
+// single conditional update is what keeps two customers from paying through the same link.
+func (repository *PaymentLinkRepositoryImpl) Claim(ctx context.Context, tx *gorm.DB, linkId uuid.UUID, now time.Time) (bool, error) {
+	result := tx.WithContext(ctx).Model(&domain.PaymentLink{}).
+		Where("id = ? AND (one_time = ? OR used_at IS NULL)", linkId, false).
+		Update("used_at", now)
+
+	return result.RowsAffected > 0, result.Error
+}
+
+// Release undoes a claim after a checkout that did not produce a payment.
+func (repository *PaymentLinkRepositoryImpl) Release(ctx context.Context, tx *gorm.DB, linkId uuid.UUID) error {
+	return tx.WithContext(ctx).Model(&domain.PaymentLink{}).Where("id = ? AND payment_id IS NULL", linkId).Update("used_at", nil).Error
+}
+
+func (repository *PaymentLinkRepositoryImpl) SetPayment(ctx context.Context, tx *gorm.DB, linkId uuid.UUID, paymentId uuid.UUID) error {
+	return tx.WithContext(ctx).Model(&domain.PaymentLink{}).Where("id = ?", linkId).Update("payment_id", paymentId).Error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type PaymentMethodRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, method domain.PaymentMethod) (domain.PaymentMethod, error)
+	Update(ctx context.Context, tx *gorm.DB, method domain.PaymentMethod) (domain.PaymentMethod, error)
+	Delete(ctx context.Context, tx *gorm.DB, method domain.PaymentMethod) error
+	FindById(ctx context.Context, tx *gorm.DB, methodId string) (domain.PaymentMethod, error)
+	FindAllByCustomerId(ctx context.Context, tx *gorm.DB, customerId string) ([]domain.PaymentMethod, error)
+}
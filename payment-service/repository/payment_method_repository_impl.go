@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type PaymentMethodRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewPaymentMethodRepository(db *gorm.DB) PaymentMethodRepository {
+	return &PaymentMethodRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *PaymentMethodRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, method domain.PaymentMethod) (domain.PaymentMethod, error) {
+	err := tx.WithContext(ctx).Create(&method).Error
+
+	return method, err
+}
+
+func (repository *PaymentMethodRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, method domain.PaymentMethod) (domain.PaymentMethod, error) {
+	err := tx.WithContext(ctx).Model(&domain.PaymentMethod{}).Where("id = ?", method.ID).Updates(map[string]interface{}{
+		"encrypted_token":   method.EncryptedToken,
+		"encrypted_details": method.EncryptedDetails,
+	}).Error
+
+	return method, err
+}
+
+func (repository *PaymentMethodRepositoryImpl) Delete(ctx context.Context, tx *gorm.DB, method domain.PaymentMethod) error {
+	return tx.WithContext(ctx).Delete(&domain.PaymentMethod{}, "id = ?", method.ID).Error
+}
+
+func (repository *PaymentMethodRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, methodId string) (domain.PaymentMethod, error) {
+	var method domain.PaymentMethod
+	err := tx.WithContext(ctx).Where("id = ?", methodId).First(&method).Error
+
+	return method, err
+}
+
+func (repository *PaymentMethodRepositoryImpl) FindAllByCustomerId(ctx context.Context, tx *gorm.DB, customerId string) ([]domain.PaymentMethod, error) {
+	var methods []domain.PaymentMethod
+	err := tx.WithContext(ctx).Where("customer_id = ?", customerId).Order("created_at DESC").Find(&methods).Error
+
+	return methods, err
+}
@@ -10,6 +10,13 @@ import (
 type PaymentRepository interface {
 	Save(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error)
 	FindById(ctx context.Context, tx *gorm.DB, paymentId string) (domain.Payment, error)
+	FindByIdForUpdate(ctx context.Context, tx *gorm.DB, paymentId string) (domain.Payment, error)
 	UpdateStatus(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error)
 	FindOrderById(ctx context.Context, tx *gorm.DB, orderId string) (domain.Payment, error)
+	FindActiveByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.Payment, error)
+	FindActiveByInstallment(ctx context.Context, tx *gorm.DB, orderId string, installmentNumber int) (domain.Payment, error)
+	FindAllByOrderId(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.Payment, error)
+	FindAll(ctx context.Context, tx *gorm.DB, filter PaymentFilter) ([]domain.Payment, error)
+	FindByProviderReferences(ctx context.Context, tx *gorm.DB, provider string, references []string) ([]domain.Payment, error)
+	FindPendingBankTransfers(ctx context.Context, tx *gorm.DB, provider string) ([]domain.Payment, error)
 }
@@ -2,11 +2,30 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"payment-service/helper"
 	"payment-service/models/domain"
+	"strconv"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrActivePaymentExists is returned by Save when the order already has an active attempt.
+var ErrActivePaymentExists = errors.New("active payment already exists for order")
+
+// ActivePaymentStatuses are the statuses that block another attempt for the same order.
+var ActivePaymentStatuses = []string{"pending", "held", "awaiting_collection", "success"}
+
+// ActivePaymentCondition is the predicate of the partial unique index on payments.order_id,
+// payments.installment_number and payments.tender_number, so each installment and each tender of
+// an order has its own active attempt.
+// Save's conflict target has to repeat it verbatim for the database to pick the index.
+const ActivePaymentCondition = "status IN ('pending', 'held', 'awaiting_collection', 'success') AND deleted_at IS NULL"
+
 type PaymentRepositoryImpl struct {
 	DB *gorm.DB
 }
@@ -17,10 +36,26 @@ func NewPaymentRepository(db *gorm.DB) PaymentRepository {
 	}
 }
 
+// Save inserts the payment unless it would become a second active attempt for the order.
+// The conflict target matches the partial unique index created by config.Migrate, so the
+// check and the insert happen in a single statement.
 func (repository *PaymentRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error) {
-	err := tx.WithContext(ctx).Create(&payment).Error
+	result := tx.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "order_id"}, {Name: "installment_number"}, {Name: "tender_number"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: ActivePaymentCondition},
+		}},
+		DoNothing: true,
+	}).Create(&payment)
+	if result.Error != nil {
+		return payment, result.Error
+	}
 
-	return payment, err
+	if result.RowsAffected == 0 {
+		return payment, ErrActivePaymentExists
+	}
+
+	return payment, recordPaymentEvent(ctx, tx, payment.ID, "", payment.Status)
 }
 
 func (repository *PaymentRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, paymentId string) (domain.Payment, error) {
@@ -30,18 +65,200 @@ func (repository *PaymentRepositoryImpl) FindById(ctx context.Context, tx *gorm.
 	return payment, err
 }
 
-func (repository *PaymentRepositoryImpl) UpdateStatus(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error) {
-	err := tx.WithContext(ctx).Model(&domain.Payment{}).Where("id = ?", payment.ID).Updates(map[string]interface{}{
-		"status":  payment.Status,
-		"paid_at": payment.PaidAt,
-	}).Error
+// FindByIdForUpdate is FindById with the row locked until tx ends, so concurrent status changes
+// of the same payment are applied one after the other and each sees the status the last left.
+func (repository *PaymentRepositoryImpl) FindByIdForUpdate(ctx context.Context, tx *gorm.DB, paymentId string) (domain.Payment, error) {
+	var payment domain.Payment
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", paymentId).
+		First(&payment).Error
 
 	return payment, err
 }
 
+// UpdateStatus writes the payment's status fields and, when the status changes, appends the
+// transition to the payment's timeline in the same transaction.
+func (repository *PaymentRepositoryImpl) UpdateStatus(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error) {
+	var fromStatus string
+	err := tx.WithContext(ctx).Model(&domain.Payment{}).Where("id = ?", payment.ID).Pluck("status", &fromStatus).Error
+	if err != nil {
+		return payment, err
+	}
+
+	// Updating from the struct keeps the json serializer of fee_breakdown in play.
+	err = tx.WithContext(ctx).Model(&payment).
+		Select("status", "paid_at", "expires_at", "risk_decision", "fee_amount", "net_amount", "refunded_amount", "fee_breakdown", "qr_payload",
+			"collection_outcome", "collection_collected_amount", "collection_collected_by", "collection_note", "collection_confirmed_at", "updated_at").
+		Updates(&payment).Error
+	if err != nil {
+		return payment, err
+	}
+
+	if fromStatus == payment.Status {
+		return payment, nil
+	}
+
+	return payment, recordPaymentEvent(ctx, tx, payment.ID, fromStatus, payment.Status)
+}
+
+func recordPaymentEvent(ctx context.Context, tx *gorm.DB, paymentId uuid.UUID, fromStatus string, toStatus string) error {
+	origin := domain.EventOriginFrom(ctx)
+
+	// Card numbers are masked before truncation could cut one short of being recognised.
+	providerResponse := origin.ProviderResponse
+	if helper.ContainsPAN(providerResponse) {
+		providerResponse = helper.MaskPAN(providerResponse)
+	}
+	if len(providerResponse) > domain.ProviderResponseLimit {
+		providerResponse = providerResponse[:domain.ProviderResponseLimit]
+	}
+
+	return tx.WithContext(ctx).Create(&domain.PaymentEvent{
+		ID:               uuid.New(),
+		PaymentID:        paymentId,
+		FromStatus:       fromStatus,
+		ToStatus:         toStatus,
+		Source:           origin.Source,
+		Actor:            origin.Actor,
+		ActorClaimed:     origin.ActorClaimed,
+		ProviderResponse: providerResponse,
+	}).Error
+}
+
 func (repository *PaymentRepositoryImpl) FindOrderById(ctx context.Context, tx *gorm.DB, orderId string) (domain.Payment, error) {
 	var payment domain.Payment
 	err := tx.WithContext(ctx).Where("order_id = ?", orderId).First(&payment).Error
 
 	return payment, err
 }
+
+// FindActiveByOrderId returns the attempt that currently blocks new payments for the order,
+// i.e. the latest attempt that is still pending, held for review or already succeeded. The row is locked
+// so concurrent requests cannot both expire it and start a new attempt.
+func (repository *PaymentRepositoryImpl) FindActiveByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.Payment, error) {
+	var payment domain.Payment
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status IN ?", orderId, ActivePaymentStatuses).
+		Order("attempt DESC").
+		First(&payment).Error
+
+	return payment, err
+}
+
+// FindActiveByInstallment is FindActiveByOrderId for the attempts paying one installment.
+func (repository *PaymentRepositoryImpl) FindActiveByInstallment(ctx context.Context, tx *gorm.DB, orderId string, installmentNumber int) (domain.Payment, error) {
+	var payment domain.Payment
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND installment_number = ? AND status IN ?", orderId, installmentNumber, ActivePaymentStatuses).
+		Order("attempt DESC").
+		First(&payment).Error
+
+	return payment, err
+}
+
+func (repository *PaymentRepositoryImpl) FindAllByOrderId(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := tx.WithContext(ctx).Where("order_id = ?", orderId).Order("attempt ASC").Find(&payments).Error
+
+	return payments, err
+}
+
+// FindAll returns payments matching the filter using keyset pagination on the sort column and id.
+func (repository *PaymentRepositoryImpl) FindAll(ctx context.Context, tx *gorm.DB, filter PaymentFilter) ([]domain.Payment, error) {
+	query := tx.WithContext(ctx).Model(&domain.Payment{})
+
+	if filter.OrderID != nil {
+		query = query.Where("order_id = ?", *filter.OrderID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.MinAmount > 0 {
+		query = query.Where("amount >= ?", filter.MinAmount)
+	}
+	if filter.MaxAmount > 0 {
+		query = query.Where("amount <= ?", filter.MaxAmount)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedTo)
+	}
+	if filter.PaidFrom != nil {
+		query = query.Where("paid_at >= ?", *filter.PaidFrom)
+	}
+	if filter.PaidTo != nil {
+		query = query.Where("paid_at <= ?", *filter.PaidTo)
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	if sortBy != "created_at" && sortBy != "amount" {
+		return nil, fmt.Errorf("unsupported sort column %q", sortBy)
+	}
+
+	direction, comparator := "ASC", ">"
+	if filter.Descending {
+		direction, comparator = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		value, err := parseCursorValue(sortBy, filter.After.SortValue)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(
+			fmt.Sprintf("%s %s ? OR (%s = ? AND id %s ?)", sortBy, comparator, sortBy, comparator),
+			value, value, filter.After.ID,
+		)
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s, id %s", sortBy, direction, direction))
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var payments []domain.Payment
+	err := query.Find(&payments).Error
+
+	return payments, err
+}
+
+func (repository *PaymentRepositoryImpl) FindByProviderReferences(ctx context.Context, tx *gorm.DB, provider string, references []string) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	if len(references) == 0 {
+		return payments, nil
+	}
+
+	err := tx.WithContext(ctx).Where("provider = ? AND provider_reference IN ?", provider, references).Find(&payments).Error
+
+	return payments, err
+}
+
+// FindPendingBankTransfers returns the provider's bank transfer payments still waiting for money.
+func (repository *PaymentRepositoryImpl) FindPendingBankTransfers(ctx context.Context, tx *gorm.DB, provider string) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := tx.WithContext(ctx).
+		Where("provider = ? AND status = ? AND bank_transfer_mode <> ''", provider, "pending").
+		Order("created_at").
+		Find(&payments).Error
+
+	return payments, err
+}
+
+func parseCursorValue(sortBy string, value string) (interface{}, error) {
+	if sortBy == "amount" {
+		return strconv.ParseInt(value, 10, 64)
+	}
+
+	return time.Parse(time.RFC3339Nano, value)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type PayoutRepository interface {
+	SaveSeller(ctx context.Context, tx *gorm.DB, seller domain.Seller) (domain.Seller, error)
+	FindSellerById(ctx context.Context, tx *gorm.DB, sellerId string) (domain.Seller, error)
+	UpdateSellerBalance(ctx context.Context, tx *gorm.DB, seller domain.Seller) (domain.Seller, error)
+	FindPayableSellers(ctx context.Context, tx *gorm.DB, minAmount int64) ([]domain.Seller, error)
+	SaveShares(ctx context.Context, tx *gorm.DB, shares []domain.SellerShare) ([]domain.SellerShare, error)
+	FindShares(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.SellerShare, error)
+	SaveEntry(ctx context.Context, tx *gorm.DB, entry domain.SellerEntry) (domain.SellerEntry, error)
+	FindEntries(ctx context.Context, tx *gorm.DB, sellerId string) ([]domain.SellerEntry, error)
+	FindEntriesByPayment(ctx context.Context, tx *gorm.DB, paymentId string, entryType string) ([]domain.SellerEntry, error)
+	SaveBatch(ctx context.Context, tx *gorm.DB, batch domain.PayoutBatch) (domain.PayoutBatch, error)
+	SavePayout(ctx context.Context, tx *gorm.DB, payout domain.Payout) (domain.Payout, error)
+	FindBatchById(ctx context.Context, tx *gorm.DB, batchId string) (domain.PayoutBatch, error)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PayoutRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewPayoutRepository(db *gorm.DB) PayoutRepository {
+	return &PayoutRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *PayoutRepositoryImpl) SaveSeller(ctx context.Context, tx *gorm.DB, seller domain.Seller) (domain.Seller, error) {
+	err := tx.WithContext(ctx).Create(&seller).Error
+
+	return seller, err
+}
+
+// FindSellerById locks the seller row, so sales, clawbacks and payouts of the same seller are
+// applied one after the other against the current balance.
+func (repository *PayoutRepositoryImpl) FindSellerById(ctx context.Context, tx *gorm.DB, sellerId string) (domain.Seller, error) {
+	var seller domain.Seller
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", sellerId).First(&seller).Error
+
+	return seller, err
+}
+
+func (repository *PayoutRepositoryImpl) UpdateSellerBalance(ctx context.Context, tx *gorm.DB, seller domain.Seller) (domain.Seller, error) {
+	err := tx.WithContext(ctx).Model(&domain.Seller{}).Where("id = ?", seller.ID).Update("balance", seller.Balance).Error
+
+	return seller, err
+}
+
+// FindPayableSellers locks and returns the sellers whose balance reaches minAmount, grouped by
+// currency.
+func (repository *PayoutRepositoryImpl) FindPayableSellers(ctx context.Context, tx *gorm.DB, minAmount int64) ([]domain.Seller, error) {
+	var sellers []domain.Seller
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("balance > 0 AND balance >= ?", minAmount).
+		Order("currency").Order("id").
+		Find(&sellers).Error
+
+	return sellers, err
+}
+
+func (repository *PayoutRepositoryImpl) SaveShares(ctx context.Context, tx *gorm.DB, shares []domain.SellerShare) ([]domain.SellerShare, error) {
+	err := tx.WithContext(ctx).Create(&shares).Error
+
+	return shares, err
+}
+
+func (repository *PayoutRepositoryImpl) FindShares(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.SellerShare, error) {
+	var shares []domain.SellerShare
+	err := tx.WithContext(ctx).Where("order_id = ?", orderId).Order("seller_id").Find(&shares).Error
+
+	return shares, err
+}
+
+func (repository *PayoutRepositoryImpl) SaveEntry(ctx context.Context, tx *gorm.DB, entry domain.SellerEntry) (domain.SellerEntry, error) {
+	err := tx.WithContext(ctx).Create(&entry).Error
+
+	return entry, err
+}
+
+func (repository *PayoutRepositoryImpl) FindEntries(ctx context.Context, tx *gorm.DB, sellerId string) ([]domain.SellerEntry, error) {
+	var entries []domain.SellerEntry
+	err := tx.WithContext(ctx).Where("seller_id = ?", sellerId).Order("created_at").Find(&entries).Error
+
+	return entries, err
+}
+
+func (repository *PayoutRepositoryImpl) FindEntriesByPayment(ctx context.Context, tx *gorm.DB, paymentId string, entryType string) ([]domain.SellerEntry, error) {
+	var entries []domain.SellerEntry
+	err := tx.WithContext(ctx).Where("payment_id = ? AND type = ?", paymentId, entryType).Order("seller_id").Find(&entries).Error
+
+	return entries, err
+}
+
+func (repository *PayoutRepositoryImpl) SaveBatch(ctx context.Context, tx *gorm.DB, batch domain.PayoutBatch) (domain.PayoutBatch, error) {
+	err := tx.WithContext(ctx).Create(&batch).Error
+
+	return batch, err
+}
+
+func (repository *PayoutRepositoryImpl) SavePayout(ctx context.Context, tx *gorm.DB, payout domain.Payout) (domain.Payout, error) {
+	err := tx.WithContext(ctx).Create(&payout).Error
+
+	return payout, err
+}
+
+func (repository *PayoutRepositoryImpl) FindBatchById(ctx context.Context, tx *gorm.DB, batchId string) (domain.PayoutBatch, error) {
+	var batch domain.PayoutBatch
+	if err := tx.WithContext(ctx).Where("id = ?", batchId).First(&batch).Error; err != nil {
+		return batch, err
+	}
+
+	err := tx.WithContext(ctx).Where("batch_id = ?", batchId).Order("seller_id").Find(&batch.Payouts).Error
+
+	return batch, err
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RiskRepository interface {
+	SaveAssessment(ctx context.Context, tx *gorm.DB, assessment domain.RiskAssessment) (domain.RiskAssessment, error)
+	FindAssessmentsByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.RiskAssessment, error)
+	CountPaymentsByCustomerSince(ctx context.Context, tx *gorm.DB, customerId string, since time.Time) (int64, error)
+	CountPaymentsByIPSince(ctx context.Context, tx *gorm.DB, ipAddress string, since time.Time) (int64, error)
+	CountFailuresSince(ctx context.Context, tx *gorm.DB, customerId string, orderId string, since time.Time) (int64, error)
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RiskRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewRiskRepository(db *gorm.DB) RiskRepository {
+	return &RiskRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *RiskRepositoryImpl) SaveAssessment(ctx context.Context, tx *gorm.DB, assessment domain.RiskAssessment) (domain.RiskAssessment, error) {
+	err := tx.WithContext(ctx).Create(&assessment).Error
+
+	return assessment, err
+}
+
+func (repository *RiskRepositoryImpl) FindAssessmentsByPaymentId(ctx context.Context, tx *gorm.DB, paymentId string) ([]domain.RiskAssessment, error) {
+	var assessments []domain.RiskAssessment
+	err := tx.WithContext(ctx).Where("payment_id = ?", paymentId).Order("created_at ASC").Find(&assessments).Error
+
+	return assessments, err
+}
+
+func (repository *RiskRepositoryImpl) CountPaymentsByCustomerSince(ctx context.Context, tx *gorm.DB, customerId string, since time.Time) (int64, error) {
+	var count int64
+	err := tx.WithContext(ctx).Model(&domain.Payment{}).Where("customer_id = ? AND created_at >= ?", customerId, since).Count(&count).Error
+
+	return count, err
+}
+
+func (repository *RiskRepositoryImpl) CountPaymentsByIPSince(ctx context.Context, tx *gorm.DB, ipAddress string, since time.Time) (int64, error) {
+	var count int64
+	err := tx.WithContext(ctx).Model(&domain.Payment{}).Where("ip_address = ? AND created_at >= ?", ipAddress, since).Count(&count).Error
+
+	return count, err
+}
+
+// CountFailuresSince counts failed attempts of the customer or, without a customer, of the order.
+func (repository *RiskRepositoryImpl) CountFailuresSince(ctx context.Context, tx *gorm.DB, customerId string, orderId string, since time.Time) (int64, error) {
+	query := tx.WithContext(ctx).Model(&domain.Payment{}).Where("status = ? AND updated_at >= ?", "failed", since)
+	if customerId != "" {
+		query = query.Where("customer_id = ?", customerId)
+	} else {
+		query = query.Where("order_id = ?", orderId)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+
+	return count, err
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type SettlementRepository interface {
+	SaveReport(ctx context.Context, tx *gorm.DB, report domain.SettlementReport) (domain.SettlementReport, error)
+	FindReportById(ctx context.Context, tx *gorm.DB, reportId string) (domain.SettlementReport, error)
+	FindReports(ctx context.Context, tx *gorm.DB) ([]domain.SettlementReport, error)
+	FindExceptions(ctx context.Context, tx *gorm.DB, reportId string, includeResolved bool) ([]domain.SettlementItem, error)
+	FindItemById(ctx context.Context, tx *gorm.DB, itemId string) (domain.SettlementItem, error)
+	UpdateItem(ctx context.Context, tx *gorm.DB, item domain.SettlementItem) (domain.SettlementItem, error)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type SettlementRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewSettlementRepository(db *gorm.DB) SettlementRepository {
+	return &SettlementRepositoryImpl{
+		DB: db,
+	}
+}
+
+// SaveReport inserts the report together with its items.
+func (repository *SettlementRepositoryImpl) SaveReport(ctx context.Context, tx *gorm.DB, report domain.SettlementReport) (domain.SettlementReport, error) {
+	err := tx.WithContext(ctx).Create(&report).Error
+
+	return report, err
+}
+
+func (repository *SettlementRepositoryImpl) FindReportById(ctx context.Context, tx *gorm.DB, reportId string) (domain.SettlementReport, error) {
+	var report domain.SettlementReport
+	err := tx.WithContext(ctx).Preload("Items").Where("id = ?", reportId).First(&report).Error
+
+	return report, err
+}
+
+func (repository *SettlementRepositoryImpl) FindReports(ctx context.Context, tx *gorm.DB) ([]domain.SettlementReport, error) {
+	var reports []domain.SettlementReport
+	err := tx.WithContext(ctx).Order("created_at DESC").Find(&reports).Error
+
+	return reports, err
+}
+
+func (repository *SettlementRepositoryImpl) FindExceptions(ctx context.Context, tx *gorm.DB, reportId string, includeResolved bool) ([]domain.SettlementItem, error) {
+	query := tx.WithContext(ctx).Where("report_id = ? AND result <> ?", reportId, domain.SettlementMatched)
+	if !includeResolved {
+		query = query.Where("resolved = ?", false)
+	}
+
+	var items []domain.SettlementItem
+	err := query.Order("created_at ASC").Find(&items).Error
+
+	return items, err
+}
+
+func (repository *SettlementRepositoryImpl) FindItemById(ctx context.Context, tx *gorm.DB, itemId string) (domain.SettlementItem, error) {
+	var item domain.SettlementItem
+	err := tx.WithContext(ctx).Where("id = ?", itemId).First(&item).Error
+
+	return item, err
+}
+
+func (repository *SettlementRepositoryImpl) UpdateItem(ctx context.Context, tx *gorm.DB, item domain.SettlementItem) (domain.SettlementItem, error) {
+	err := tx.WithContext(ctx).Model(&domain.SettlementItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+		"resolved":        item.Resolved,
+		"resolution_note": item.ResolutionNote,
+		"resolved_at":     item.ResolvedAt,
+	}).Error
+
+	return item, err
+}
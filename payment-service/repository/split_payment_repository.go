@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type SplitPaymentRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, split domain.SplitPayment) (domain.SplitPayment, error)
+	Update(ctx context.Context, tx *gorm.DB, split domain.SplitPayment) (domain.SplitPayment, error)
+	FindById(ctx context.Context, tx *gorm.DB, splitId string) (domain.SplitPayment, error)
+	FindByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.SplitPayment, error)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SplitPaymentRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewSplitPaymentRepository(db *gorm.DB) SplitPaymentRepository {
+	return &SplitPaymentRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *SplitPaymentRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, split domain.SplitPayment) (domain.SplitPayment, error) {
+	err := tx.WithContext(ctx).Create(&split).Error
+
+	return split, err
+}
+
+func (repository *SplitPaymentRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, split domain.SplitPayment) (domain.SplitPayment, error) {
+	err := tx.WithContext(ctx).Model(&domain.SplitPayment{}).Where("id = ?", split.ID).Updates(map[string]interface{}{
+		"captured_amount": split.CapturedAmount,
+		"status":          split.Status,
+		"failure_reason":  split.FailureReason,
+		"closed_at":       split.ClosedAt,
+	}).Error
+
+	return split, err
+}
+
+func (repository *SplitPaymentRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, splitId string) (domain.SplitPayment, error) {
+	var split domain.SplitPayment
+	if err := tx.WithContext(ctx).First(&split, "id = ?", splitId).Error; err != nil {
+		return split, err
+	}
+
+	return findTenders(ctx, tx, split)
+}
+
+// FindByOrderId returns the order's split payment with its tenders. The row is locked so
+// concurrent tenders cannot both claim what is left of the order total.
+func (repository *SplitPaymentRepositoryImpl) FindByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.SplitPayment, error) {
+	var split domain.SplitPayment
+	err := tx.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&split, "order_id = ?", orderId).Error
+	if err != nil {
+		return split, err
+	}
+
+	return findTenders(ctx, tx, split)
+}
+
+func findTenders(ctx context.Context, tx *gorm.DB, split domain.SplitPayment) (domain.SplitPayment, error) {
+	err := tx.WithContext(ctx).
+		Where("order_id = ? AND tender_number > 0", split.OrderID).
+		Order("tender_number").
+		Find(&split.Tenders).Error
+
+	return split, err
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SubscriptionRepository interface {
+	SavePlan(ctx context.Context, tx *gorm.DB, plan domain.SubscriptionPlan) (domain.SubscriptionPlan, error)
+	FindPlanById(ctx context.Context, tx *gorm.DB, planId string) (domain.SubscriptionPlan, error)
+	FindPlans(ctx context.Context, tx *gorm.DB) ([]domain.SubscriptionPlan, error)
+	Save(ctx context.Context, tx *gorm.DB, subscription domain.Subscription) (domain.Subscription, error)
+	Update(ctx context.Context, tx *gorm.DB, subscription domain.Subscription) (domain.Subscription, error)
+	FindById(ctx context.Context, tx *gorm.DB, subscriptionId string) (domain.Subscription, error)
+	FindAllByCustomerId(ctx context.Context, tx *gorm.DB, customerId string) ([]domain.Subscription, error)
+	FindDue(ctx context.Context, tx *gorm.DB, now time.Time) ([]domain.Subscription, error)
+	SaveCharge(ctx context.Context, tx *gorm.DB, charge domain.SubscriptionCharge) (domain.SubscriptionCharge, error)
+	UpdateCharge(ctx context.Context, tx *gorm.DB, charge domain.SubscriptionCharge) (domain.SubscriptionCharge, error)
+	FindCharges(ctx context.Context, tx *gorm.DB, subscriptionId string) ([]domain.SubscriptionCharge, error)
+	FindOutstandingCharge(ctx context.Context, tx *gorm.DB, subscriptionId string) (domain.SubscriptionCharge, error)
+}
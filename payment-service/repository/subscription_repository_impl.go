@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SubscriptionRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewSubscriptionRepository(db *gorm.DB) SubscriptionRepository {
+	return &SubscriptionRepositoryImpl{
+		DB: db,
+	}
+}
+
+func (repository *SubscriptionRepositoryImpl) SavePlan(ctx context.Context, tx *gorm.DB, plan domain.SubscriptionPlan) (domain.SubscriptionPlan, error) {
+	err := tx.WithContext(ctx).Create(&plan).Error
+
+	return plan, err
+}
+
+func (repository *SubscriptionRepositoryImpl) FindPlanById(ctx context.Context, tx *gorm.DB, planId string) (domain.SubscriptionPlan, error) {
+	var plan domain.SubscriptionPlan
+	err := tx.WithContext(ctx).First(&plan, "id = ?", planId).Error
+
+	return plan, err
+}
+
+func (repository *SubscriptionRepositoryImpl) FindPlans(ctx context.Context, tx *gorm.DB) ([]domain.SubscriptionPlan, error) {
+	var plans []domain.SubscriptionPlan
+	err := tx.WithContext(ctx).Order("created_at").Find(&plans).Error
+
+	return plans, err
+}
+
+func (repository *SubscriptionRepositoryImpl) Save(ctx context.Context, tx *gorm.DB, subscription domain.Subscription) (domain.Subscription, error) {
+	err := tx.WithContext(ctx).Create(&subscription).Error
+
+	return subscription, err
+}
+
+func (repository *SubscriptionRepositoryImpl) Update(ctx context.Context, tx *gorm.DB, subscription domain.Subscription) (domain.Subscription, error) {
+	err := tx.WithContext(ctx).Model(&domain.Subscription{}).Where("id = ?", subscription.ID).Updates(map[string]interface{}{
+		"plan_id":              subscription.PlanID,
+		"status":               subscription.Status,
+		"current_period_start": subscription.CurrentPeriodStart,
+		"current_period_end":   subscription.CurrentPeriodEnd,
+		"next_billing_at":      subscription.NextBillingAt,
+		"retry_count":          subscription.RetryCount,
+		"credit":               subscription.Credit,
+		"paused_at":            subscription.PausedAt,
+		"cancelled_at":         subscription.CancelledAt,
+	}).Error
+
+	return subscription, err
+}
+
+func (repository *SubscriptionRepositoryImpl) FindById(ctx context.Context, tx *gorm.DB, subscriptionId string) (domain.Subscription, error) {
+	var subscription domain.Subscription
+	err := tx.WithContext(ctx).First(&subscription, "id = ?", subscriptionId).Error
+
+	return subscription, err
+}
+
+func (repository *SubscriptionRepositoryImpl) FindAllByCustomerId(ctx context.Context, tx *gorm.DB, customerId string) ([]domain.Subscription, error) {
+	var subscriptions []domain.Subscription
+	err := tx.WithContext(ctx).Where("customer_id = ?", customerId).Order("created_at").Find(&subscriptions).Error
+
+	return subscriptions, err
+}
+
+// FindDue returns the subscriptions the scheduler has to charge at now, longest overdue first.
+func (repository *SubscriptionRepositoryImpl) FindDue(ctx context.Context, tx *gorm.DB, now time.Time) ([]domain.Subscription, error) {
+	var subscriptions []domain.Subscription
+	err := tx.WithContext(ctx).
+		Where("status IN ?", []string{domain.SubscriptionActive, domain.SubscriptionPastDue}).
+		Where("next_billing_at <= ?", now).
+		Order("next_billing_at").
+		Find(&subscriptions).Error
+
+	return subscriptions, err
+}
+
+func (repository *SubscriptionRepositoryImpl) SaveCharge(ctx context.Context, tx *gorm.DB, charge domain.SubscriptionCharge) (domain.SubscriptionCharge, error) {
+	err := tx.WithContext(ctx).Create(&charge).Error
+
+	return charge, err
+}
+
+func (repository *SubscriptionRepositoryImpl) UpdateCharge(ctx context.Context, tx *gorm.DB, charge domain.SubscriptionCharge) (domain.SubscriptionCharge, error) {
+	err := tx.WithContext(ctx).Model(&domain.SubscriptionCharge{}).Where("id = ?", charge.ID).Updates(map[string]interface{}{
+		"order_id":       charge.OrderID,
+		"payment_id":     charge.PaymentID,
+		"status":         charge.Status,
+		"attempts":       charge.Attempts,
+		"failure_reason": charge.FailureReason,
+	}).Error
+
+	return charge, err
+}
+
+func (repository *SubscriptionRepositoryImpl) FindCharges(ctx context.Context, tx *gorm.DB, subscriptionId string) ([]domain.SubscriptionCharge, error) {
+	var charges []domain.SubscriptionCharge
+	err := tx.WithContext(ctx).Where("subscription_id = ?", subscriptionId).Order("created_at").Find(&charges).Error
+
+	return charges, err
+}
+
+// FindOutstandingCharge returns the most recent cycle charge that has not been paid yet.
+func (repository *SubscriptionRepositoryImpl) FindOutstandingCharge(ctx context.Context, tx *gorm.DB, subscriptionId string) (domain.SubscriptionCharge, error) {
+	var charge domain.SubscriptionCharge
+	err := tx.WithContext(ctx).
+		Where("subscription_id = ? AND kind = ? AND status <> ?", subscriptionId, domain.ChargeCycle, domain.ChargePaid).
+		Order("created_at DESC").
+		First(&charge).Error
+
+	return charge, err
+}
@@ -2,15 +2,212 @@ package routes
 
 import (
 	"payment-service/controller"
+	"payment-service/middleware"
+	"payment-service/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func PaymentRoutes(app *fiber.App, paymentController controller.PaymentController) {
+// PaymentRoutes registers the payment endpoints. Every endpoint that creates a payment or
+// changes its status honours the Idempotency-Key header.
+func PaymentRoutes(app *fiber.App, paymentController controller.PaymentController, idempotencyService service.IdempotencyService) {
 	payment := app.Group("/payments")
+	idempotent := middleware.Idempotency(idempotencyService)
 
-	payment.Post("/", paymentController.Create)
+	payment.Get("/", paymentController.FindAll)
+	payment.Get("/export", paymentController.Export)
+	payment.Post("/", idempotent, paymentController.Create)
 	payment.Get("/:paymentId", paymentController.FindById)
-	payment.Put("/success/:paymentId", paymentController.MarkAsSuccess)
-	payment.Put("/failed/:paymentId", paymentController.MarkAsFailed)
+	payment.Get("/:paymentId/events", paymentController.FindEvents)
+	payment.Put("/success/:paymentId", idempotent, paymentController.MarkAsSuccess)
+	payment.Put("/failed/:paymentId", idempotent, paymentController.MarkAsFailed)
+	payment.Post("/:paymentId/delivery", idempotent, paymentController.ConfirmDelivery)
+	payment.Put("/refund/:paymentId", idempotent, paymentController.Refund)
+	payment.Post("/:paymentId/refunds", idempotent, paymentController.RefundPart)
+	payment.Put("/chargeback/:paymentId", idempotent, paymentController.Chargeback)
+
+	app.Get("/orders/:orderId/payments", paymentController.FindAllByOrderId)
+}
+
+func LedgerRoutes(app *fiber.App, ledgerController controller.LedgerController) {
+	ledger := app.Group("/ledger")
+
+	ledger.Get("/accounts", ledgerController.FindBalances)
+	ledger.Get("/accounts/:accountCode", ledgerController.FindBalance)
+	ledger.Get("/invariants", ledgerController.CheckInvariants)
+	ledger.Get("/payments/:paymentId/entries", ledgerController.FindEntriesByPaymentId)
+}
+
+func SettlementRoutes(app *fiber.App, settlementController controller.SettlementController) {
+	settlement := app.Group("/settlements")
+
+	settlement.Post("/", settlementController.Import)
+	settlement.Get("/", settlementController.FindReports)
+	settlement.Get("/:reportId", settlementController.FindReportById)
+	settlement.Get("/:reportId/exceptions", settlementController.FindExceptions)
+	settlement.Put("/items/:itemId/resolve", settlementController.ResolveItem)
+}
+
+func RiskRoutes(app *fiber.App, riskController controller.RiskController) {
+	app.Get("/payments/:paymentId/risk", riskController.FindAssessments)
+
+	review := app.Group("/risk/reviews")
+
+	review.Put("/:paymentId/approve", riskController.Approve)
+	review.Put("/:paymentId/reject", riskController.Reject)
+}
+
+func PaymentMethodRoutes(app *fiber.App, paymentMethodController controller.PaymentMethodController) {
+	method := app.Group("/customers/:customerId/payment-methods")
+
+	method.Post("/", paymentMethodController.Create)
+	method.Get("/", paymentMethodController.FindAllByCustomerId)
+	method.Get("/:paymentMethodId", paymentMethodController.FindById)
+	method.Put("/:paymentMethodId", paymentMethodController.Update)
+	method.Delete("/:paymentMethodId", paymentMethodController.Delete)
+}
+
+// DisputeRoutes registers the dispute endpoints. The provider webhook needs webhookSecret.
+func DisputeRoutes(app *fiber.App, disputeController controller.DisputeController, webhookSecret string) {
+	dispute := app.Group("/disputes")
+
+	dispute.Post("/", disputeController.Open)
+	dispute.Get("/", disputeController.FindAll)
+	dispute.Get("/:disputeId", disputeController.FindById)
+	dispute.Put("/:disputeId/status", disputeController.UpdateStatus)
+	dispute.Post("/:disputeId/evidence", disputeController.AddEvidence)
+	dispute.Get("/:disputeId/evidence/:evidenceId", disputeController.DownloadEvidence)
+
+	app.Get("/payments/:paymentId/disputes", disputeController.FindAllByPaymentId)
+	app.Post("/webhooks/:provider/disputes", middleware.WebhookSecret(webhookSecret), disputeController.Webhook)
+}
+
+func FeeRoutes(app *fiber.App, feeController controller.FeeController) {
+	app.Get("/fees/summary", feeController.Summary)
+}
+
+func ExchangeRateRoutes(app *fiber.App, exchangeRateController controller.ExchangeRateController) {
+	rate := app.Group("/exchange-rates")
+
+	rate.Post("/", exchangeRateController.Create)
+	rate.Post("/import", exchangeRateController.Import)
+	rate.Get("/", exchangeRateController.FindAll)
+	rate.Get("/current", exchangeRateController.FindEffective)
+}
+
+func PaymentLinkRoutes(app *fiber.App, paymentLinkController controller.PaymentLinkController) {
+	link := app.Group("/payment-links")
+
+	link.Post("/", paymentLinkController.Create)
+	link.Get("/:linkId", paymentLinkController.FindById)
+
+	checkout := app.Group("/pay")
+
+	checkout.Get("/:token", paymentLinkController.Checkout)
+	checkout.Post("/:token", paymentLinkController.Pay)
+	checkout.Get("/:token/success", paymentLinkController.Success)
+	checkout.Get("/:token/failure", paymentLinkController.Failure)
+}
+
+// QRRoutes registers the QR image and provider webhook, which needs webhookSecret. The simulator
+// endpoints stand in for a customer's banking app and are only registered when simulator is true.
+func QRRoutes(app *fiber.App, qrController controller.QRController, webhookSecret string, simulator bool) {
+	app.Get("/payments/:paymentId/qr", qrController.Image)
+	app.Post("/webhooks/:provider/qr", middleware.WebhookSecret(webhookSecret), qrController.Webhook)
+
+	if simulator {
+		simulation := app.Group("/simulator/:provider/qr")
+
+		simulation.Post("/scan", qrController.SimulateScan)
+		simulation.Post("/pay", qrController.SimulatePayment)
+	}
+}
+
+// BankTransferRoutes registers the bank statement import and its exception queue. The simulator
+// endpoint stands in for the bank's mutation API and is only registered when simulator is true.
+func BankTransferRoutes(app *fiber.App, bankTransferController controller.BankTransferController, simulator bool) {
+	mutation := app.Group("/bank-mutations")
+
+	mutation.Post("/import", bankTransferController.Import)
+	mutation.Get("/exceptions", bankTransferController.FindExceptions)
+	mutation.Put("/:mutationId/resolve", bankTransferController.Resolve)
+
+	if simulator {
+		app.Post("/simulator/:provider/bank-transfers", bankTransferController.SimulateTransfer)
+	}
+}
+
+func SubscriptionRoutes(app *fiber.App, subscriptionController controller.SubscriptionController) {
+	plan := app.Group("/subscription-plans")
+
+	plan.Post("/", subscriptionController.CreatePlan)
+	plan.Get("/", subscriptionController.FindPlans)
+	plan.Get("/:planId", subscriptionController.FindPlanById)
+
+	subscription := app.Group("/subscriptions")
+
+	subscription.Post("/", subscriptionController.Create)
+	subscription.Get("/:subscriptionId", subscriptionController.FindById)
+	subscription.Get("/:subscriptionId/charges", subscriptionController.FindCharges)
+	subscription.Put("/:subscriptionId/pause", subscriptionController.Pause)
+	subscription.Put("/:subscriptionId/resume", subscriptionController.Resume)
+	subscription.Put("/:subscriptionId/cancel", subscriptionController.Cancel)
+	subscription.Put("/:subscriptionId/plan", subscriptionController.ChangePlan)
+
+	app.Get("/customers/:customerId/subscriptions", subscriptionController.FindAllByCustomerId)
+}
+
+func DunningRoutes(app *fiber.App, dunningController controller.DunningController) {
+	app.Get("/dunning", dunningController.FindAll)
+	app.Get("/orders/:orderId/dunning", dunningController.FindByOrderId)
+}
+
+func InstallmentRoutes(app *fiber.App, installmentController controller.InstallmentController) {
+	plan := app.Group("/installment-plans")
+
+	plan.Post("/", installmentController.Create)
+	plan.Get("/:planId", installmentController.FindById)
+
+	app.Get("/orders/:orderId/installment-plan", installmentController.FindByOrderId)
+}
+
+func SplitPaymentRoutes(app *fiber.App, splitPaymentController controller.SplitPaymentController) {
+	split := app.Group("/split-payments")
+
+	split.Post("/", splitPaymentController.Create)
+	split.Get("/:splitId", splitPaymentController.FindById)
+
+	app.Get("/orders/:orderId/split-payment", splitPaymentController.FindByOrderId)
+}
+
+func BalanceRoutes(app *fiber.App, balanceController controller.BalanceController) {
+	balance := app.Group("/balance-accounts")
+
+	balance.Post("/", balanceController.Issue)
+	balance.Get("/:code", balanceController.FindByCode)
+	balance.Get("/:code/transactions", balanceController.FindTransactions)
+}
+
+func WalletRoutes(app *fiber.App, walletController controller.WalletController) {
+	wallet := app.Group("/wallets")
+
+	wallet.Get("/:customerId", walletController.FindByCustomerId)
+	wallet.Post("/:customerId/top-ups", walletController.CreateTopUp)
+}
+
+func PayoutRoutes(app *fiber.App, payoutController controller.PayoutController) {
+	sellers := app.Group("/sellers")
+
+	sellers.Post("/", payoutController.CreateSeller)
+	sellers.Get("/:sellerId", payoutController.FindSeller)
+	sellers.Get("/:sellerId/entries", payoutController.FindEntries)
+
+	app.Post("/orders/:orderId/seller-shares", payoutController.SetShares)
+	app.Get("/orders/:orderId/seller-shares", payoutController.FindShares)
+
+	batches := app.Group("/payout-batches")
+
+	batches.Post("/", payoutController.Generate)
+	batches.Get("/:batchId", payoutController.FindBatch)
+	batches.Get("/:batchId/export", payoutController.ExportBatch)
 }
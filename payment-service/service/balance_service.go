@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+
+	"gorm.io/gorm"
+)
+
+type BalanceService interface {
+	Issue(ctx context.Context, request web.BalanceAccountIssueRequest) (domain.BalanceAccount, error)
+	FindByCode(ctx context.Context, code string) (domain.BalanceAccount, error)
+	FindTransactions(ctx context.Context, code string) ([]domain.BalanceTransaction, error)
+	Resolve(ctx context.Context, tx *gorm.DB, code string, customerId string, currency string, amount int64) (domain.BalanceAccount, error)
+	Redeem(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.BalanceTransaction, error)
+	Reverse(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.BalanceTransaction, error)
+	CreateTopUp(ctx context.Context, customerId string, request web.WalletTopUpRequest) (domain.WalletTopUp, error)
+	FindWallet(ctx context.Context, customerId string) (web.WalletResponse, error)
+	ResolveTopUp(ctx context.Context, tx *gorm.DB, topUpId string) (domain.WalletTopUp, error)
+	ResolveWallet(ctx context.Context, tx *gorm.DB, customerId string, currency string, amount int64) (domain.BalanceAccount, error)
+	CompleteTopUp(ctx context.Context, tx *gorm.DB, payment domain.Payment) error
+	RefundTopUp(ctx context.Context, tx *gorm.DB, payment domain.Payment) error
+}
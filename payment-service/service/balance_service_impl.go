@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// balanceProvider is the internal provider that pays with a gift card or store credit.
+const balanceProvider = "balance"
+
+// balanceCodeAlphabet leaves out characters that are easily confused when a code is typed in.
+const balanceCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var balanceCodePrefixes = map[string]string{
+	domain.BalanceGiftCard:    "GC",
+	domain.BalanceStoreCredit: "SC",
+	domain.BalanceWallet:      "WL",
+}
+
+type BalanceServiceImpl struct {
+	BalanceRepository repository.BalanceRepository
+	Config            config.BalanceConfig
+	DB                *gorm.DB
+	Validate          *validator.Validate
+}
+
+func NewBalanceService(balanceRepository repository.BalanceRepository, balanceConfig config.BalanceConfig, DB *gorm.DB, validate *validator.Validate) BalanceService {
+	return &BalanceServiceImpl{
+		BalanceRepository: balanceRepository,
+		Config:            balanceConfig,
+		DB:                DB,
+		Validate:          validate,
+	}
+}
+
+// Issue creates a gift card or store credit with a new code and records the issued amount as the
+// first transaction.
+func (service *BalanceServiceImpl) Issue(ctx context.Context, request web.BalanceAccountIssueRequest) (_ domain.BalanceAccount, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.BalanceAccount{}, err
+	}
+	if request.Type == domain.BalanceStoreCredit && request.CustomerID == "" {
+		return domain.BalanceAccount{}, errors.New("store credit needs a customer_id")
+	}
+
+	now := time.Now()
+	expiresAt := request.ExpiresAt
+	if expiresAt == nil && request.Type == domain.BalanceGiftCard && service.Config.GiftCardValidity > 0 {
+		validUntil := now.Add(service.Config.GiftCardValidity)
+		expiresAt = &validUntil
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return domain.BalanceAccount{}, errors.New("expires_at must be in the future")
+	}
+
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	code, err := newBalanceCode(balanceCodePrefixes[request.Type])
+	if err != nil {
+		return domain.BalanceAccount{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	account, err := service.BalanceRepository.Save(ctx, tx, domain.BalanceAccount{
+		ID:             uuid.New(),
+		Code:           code,
+		Type:           request.Type,
+		CustomerID:     request.CustomerID,
+		Currency:       currency,
+		InitialBalance: request.Amount,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		return domain.BalanceAccount{}, err
+	}
+
+	issued, err := service.record(ctx, tx, account, nil, domain.BalanceIssue, request.Amount)
+	if err != nil {
+		return domain.BalanceAccount{}, err
+	}
+
+	account.Balance = issued.BalanceAfter
+	return account, nil
+}
+
+func (service *BalanceServiceImpl) FindByCode(ctx context.Context, code string) (domain.BalanceAccount, error) {
+	account, err := service.BalanceRepository.FindByCode(ctx, service.DB, strings.ToUpper(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.BalanceAccount{}, exception.NotFoundError{Message: "balance account not found"}
+	}
+	return account, err
+}
+
+func (service *BalanceServiceImpl) FindTransactions(ctx context.Context, code string) ([]domain.BalanceTransaction, error) {
+	account, err := service.FindByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.BalanceRepository.FindTransactions(ctx, service.DB, account.ID.String())
+}
+
+// Resolve returns the account a payment of amount would be paid from, checking that the customer
+// may use it and that it currently covers the amount. The balance is only debited by Redeem.
+func (service *BalanceServiceImpl) Resolve(ctx context.Context, tx *gorm.DB, code string, customerId string, currency string, amount int64) (domain.BalanceAccount, error) {
+	if code == "" {
+		return domain.BalanceAccount{}, errors.New("balance_code is required for balance payments")
+	}
+
+	account, err := service.BalanceRepository.FindByCode(ctx, tx, strings.ToUpper(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.BalanceAccount{}, errors.New("balance account not found")
+	}
+	if err != nil {
+		return domain.BalanceAccount{}, err
+	}
+
+	if account.Type == domain.BalanceWallet {
+		return domain.BalanceAccount{}, fmt.Errorf("wallets are paid with provider %s", walletProvider)
+	}
+	if account.Type == domain.BalanceStoreCredit && account.CustomerID != customerId {
+		return domain.BalanceAccount{}, errors.New("store credit belongs to another customer")
+	}
+	if account.Currency != currency {
+		return domain.BalanceAccount{}, fmt.Errorf("balance account is in %s", account.Currency)
+	}
+
+	return account, checkBalance(account, amount, time.Now())
+}
+
+// Redeem debits the payment amount from its balance account. The account row stays locked until
+// tx ends, so two payments can never spend the same balance.
+func (service *BalanceServiceImpl) Redeem(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.BalanceTransaction, error) {
+	account, err := service.BalanceRepository.FindById(ctx, tx, payment.BalanceAccountID.String())
+	if err != nil {
+		return domain.BalanceTransaction{}, err
+	}
+	if err := checkBalance(account, payment.Amount, time.Now()); err != nil {
+		return domain.BalanceTransaction{}, err
+	}
+
+	return service.record(ctx, tx, account, &payment.ID, domain.BalanceRedeem, -payment.Amount)
+}
+
+// Reverse credits a refunded payment back to its balance account, even when the account has
+// expired in the meantime.
+func (service *BalanceServiceImpl) Reverse(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.BalanceTransaction, error) {
+	account, err := service.BalanceRepository.FindById(ctx, tx, payment.BalanceAccountID.String())
+	if err != nil {
+		return domain.BalanceTransaction{}, err
+	}
+
+	return service.record(ctx, tx, account, &payment.ID, domain.BalanceReversal, payment.Amount)
+}
+
+// record applies amount to the account balance and appends the matching transaction.
+func (service *BalanceServiceImpl) record(ctx context.Context, tx *gorm.DB, account domain.BalanceAccount, paymentId *uuid.UUID, transactionType string, amount int64) (domain.BalanceTransaction, error) {
+	account.Balance += amount
+	if _, err := service.BalanceRepository.UpdateBalance(ctx, tx, account); err != nil {
+		return domain.BalanceTransaction{}, err
+	}
+
+	return service.BalanceRepository.SaveTransaction(ctx, tx, domain.BalanceTransaction{
+		ID:           uuid.New(),
+		AccountID:    account.ID,
+		PaymentID:    paymentId,
+		Type:         transactionType,
+		Amount:       amount,
+		BalanceAfter: account.Balance,
+	})
+}
+
+func checkBalance(account domain.BalanceAccount, amount int64, now time.Time) error {
+	if account.Expired(now) {
+		return errors.New("balance account has expired")
+	}
+	if account.Balance < amount {
+		return fmt.Errorf("insufficient balance: %d %s available", account.Balance, account.Currency)
+	}
+	return nil
+}
+
+// newBalanceCode returns a code like GC-ABCD-EFGH-JKLM-NPQR.
+func newBalanceCode(prefix string) (string, error) {
+	limit := big.NewInt(int64(len(balanceCodeAlphabet)))
+	groups := make([]string, 0, 5)
+	groups = append(groups, prefix)
+	for range 4 {
+		group := make([]byte, 4)
+		for i := range group {
+			n, err := rand.Int(rand.Reader, limit)
+			if err != nil {
+				return "", err
+			}
+			group[i] = balanceCodeAlphabet[n.Int64()]
+		}
+		groups = append(groups, string(group))
+	}
+	return strings.Join(groups, "-"), nil
+}
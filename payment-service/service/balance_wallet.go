@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// walletProvider is the internal provider that pays from the customer's wallet.
+const walletProvider = "wallet"
+
+// CreateTopUp opens a top-up of the customer's wallet, opening the wallet itself on the first
+// top-up. The wallet is credited once a payment for the top-up succeeds.
+func (service *BalanceServiceImpl) CreateTopUp(ctx context.Context, customerId string, request web.WalletTopUpRequest) (_ domain.WalletTopUp, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.WalletTopUp{}, err
+	}
+	if customerId == "" || len(customerId) > 100 {
+		return domain.WalletTopUp{}, errors.New("invalid customer id")
+	}
+	if request.Amount < service.Config.WalletMinTopUp {
+		return domain.WalletTopUp{}, fmt.Errorf("top-up amount must be at least %d", service.Config.WalletMinTopUp)
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	currency := strings.ToUpper(request.Currency)
+	wallet, err := service.BalanceRepository.FindWallet(ctx, tx, customerId)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if currency == "" {
+			currency = defaultCurrency
+		}
+		code, err := newBalanceCode(balanceCodePrefixes[domain.BalanceWallet])
+		if err != nil {
+			return domain.WalletTopUp{}, err
+		}
+		wallet, err = service.BalanceRepository.Save(ctx, tx, domain.BalanceAccount{
+			ID:         uuid.New(),
+			Code:       code,
+			Type:       domain.BalanceWallet,
+			CustomerID: customerId,
+			Currency:   currency,
+		})
+		if err != nil {
+			return domain.WalletTopUp{}, err
+		}
+	case err != nil:
+		return domain.WalletTopUp{}, err
+	case currency != "" && currency != wallet.Currency:
+		return domain.WalletTopUp{}, fmt.Errorf("wallet is in %s", wallet.Currency)
+	}
+
+	if err := service.checkWalletLimit(wallet, request.Amount); err != nil {
+		return domain.WalletTopUp{}, err
+	}
+
+	return service.BalanceRepository.SaveTopUp(ctx, tx, domain.WalletTopUp{
+		ID:         uuid.New(),
+		AccountID:  wallet.ID,
+		CustomerID: customerId,
+		Amount:     request.Amount,
+		Currency:   wallet.Currency,
+		Status:     domain.TopUpPending,
+	})
+}
+
+func (service *BalanceServiceImpl) FindWallet(ctx context.Context, customerId string) (web.WalletResponse, error) {
+	wallet, err := service.BalanceRepository.FindWallet(ctx, service.DB, customerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return web.WalletResponse{}, exception.NotFoundError{Message: "customer has no wallet"}
+	}
+	if err != nil {
+		return web.WalletResponse{}, err
+	}
+
+	transactions, err := service.BalanceRepository.FindTransactions(ctx, service.DB, wallet.ID.String())
+	if err != nil {
+		return web.WalletResponse{}, err
+	}
+
+	return web.WalletResponse{BalanceAccount: wallet, Transactions: transactions}, nil
+}
+
+// ResolveTopUp returns the top-up a payment is about to pay, checking it is still open and that
+// the wallet has room for it.
+func (service *BalanceServiceImpl) ResolveTopUp(ctx context.Context, tx *gorm.DB, topUpId string) (domain.WalletTopUp, error) {
+	topUp, err := service.BalanceRepository.FindTopUpById(ctx, tx, topUpId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.WalletTopUp{}, errors.New("top-up not found")
+	}
+	if err != nil {
+		return domain.WalletTopUp{}, err
+	}
+	if topUp.Status != domain.TopUpPending {
+		return domain.WalletTopUp{}, fmt.Errorf("top-up is already %s", topUp.Status)
+	}
+
+	wallet, err := service.BalanceRepository.FindById(ctx, tx, topUp.AccountID.String())
+	if err != nil {
+		return domain.WalletTopUp{}, err
+	}
+
+	return topUp, service.checkWalletLimit(wallet, topUp.Amount)
+}
+
+// ResolveWallet returns the customer's wallet when it currently covers a payment of amount. The
+// balance is only debited by Redeem.
+func (service *BalanceServiceImpl) ResolveWallet(ctx context.Context, tx *gorm.DB, customerId string, currency string, amount int64) (domain.BalanceAccount, error) {
+	if customerId == "" {
+		return domain.BalanceAccount{}, errors.New("customer_id is required for wallet payments")
+	}
+
+	wallet, err := service.BalanceRepository.FindWallet(ctx, tx, customerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.BalanceAccount{}, errors.New("customer has no wallet")
+	}
+	if err != nil {
+		return domain.BalanceAccount{}, err
+	}
+	if wallet.Currency != currency {
+		return domain.BalanceAccount{}, fmt.Errorf("wallet is in %s", wallet.Currency)
+	}
+
+	return wallet, checkBalance(wallet, amount, time.Now())
+}
+
+// CompleteTopUp credits the top-up paid by payment to its wallet. The top-up amount is credited,
+// not the payment amount, so a top-up paid in another currency still lands in the wallet's.
+func (service *BalanceServiceImpl) CompleteTopUp(ctx context.Context, tx *gorm.DB, payment domain.Payment) error {
+	topUp, err := service.BalanceRepository.FindTopUpById(ctx, tx, payment.OrderID.String())
+	if err != nil {
+		return err
+	}
+	if topUp.Status != domain.TopUpPending {
+		return fmt.Errorf("top-up is already %s", topUp.Status)
+	}
+
+	wallet, err := service.BalanceRepository.FindById(ctx, tx, topUp.AccountID.String())
+	if err != nil {
+		return err
+	}
+	if _, err := service.record(ctx, tx, wallet, &payment.ID, domain.BalanceTopUp, topUp.Amount); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	topUp.Status = domain.TopUpCompleted
+	topUp.PaymentID = &payment.ID
+	topUp.CompletedAt = &now
+	_, err = service.BalanceRepository.UpdateTopUp(ctx, tx, topUp)
+	return err
+}
+
+// RefundTopUp takes a refunded top-up out of its wallet again. It fails when the
+// customer has already spent the money, since the balance may not go negative.
+func (service *BalanceServiceImpl) RefundTopUp(ctx context.Context, tx *gorm.DB, payment domain.Payment) error {
+	topUp, err := service.BalanceRepository.FindTopUpById(ctx, tx, payment.OrderID.String())
+	if err != nil {
+		return err
+	}
+	if topUp.Status != domain.TopUpCompleted {
+		return fmt.Errorf("top-up is %s", topUp.Status)
+	}
+
+	wallet, err := service.BalanceRepository.FindById(ctx, tx, topUp.AccountID.String())
+	if err != nil {
+		return err
+	}
+	if err := checkBalance(wallet, topUp.Amount, time.Now()); err != nil {
+		return err
+	}
+	if _, err := service.record(ctx, tx, wallet, &payment.ID, domain.BalanceTopUpRefund, -topUp.Amount); err != nil {
+		return err
+	}
+
+	topUp.Status = domain.TopUpRefunded
+	_, err = service.BalanceRepository.UpdateTopUp(ctx, tx, topUp)
+	return err
+}
+
+func (service *BalanceServiceImpl) checkWalletLimit(wallet domain.BalanceAccount, amount int64) error {
+	if limit := service.Config.WalletMaxBalance; limit > 0 && wallet.Balance+amount > limit {
+		return fmt.Errorf("top-up would take the wallet balance above %d %s", limit, wallet.Currency)
+	}
+	return nil
+}
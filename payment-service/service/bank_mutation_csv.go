@@ -0,0 +1,71 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"payment-service/models/web"
+	"strconv"
+	"strings"
+)
+
+// parseBankMutationCSV reads a bank statement export with a header row. The external_id, amount
+// and transacted_at columns are required; virtual_account and description are optional.
+func parseBankMutationCSV(file io.Reader) ([]web.BankMutationRequest, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("bank mutation file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"external_id", "amount", "transacted_at"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("bank mutation file is missing the %s column", required)
+		}
+	}
+
+	var requests []web.BankMutationRequest
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		amount, err := strconv.ParseInt(field("amount"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid amount %q", row, field("amount"))
+		}
+
+		requests = append(requests, web.BankMutationRequest{
+			ExternalID:     field("external_id"),
+			VirtualAccount: field("virtual_account"),
+			Amount:         amount,
+			TransactedAt:   field("transacted_at"),
+			Description:    field("description"),
+		})
+	}
+
+	return requests, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"payment-service/models/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// virtualAccountDigits is the length of the per-payment part of a virtual account number.
+const virtualAccountDigits = 10
+
+// issueBankTransfer gives a bank transfer payment the detail that identifies its transfer: a
+// virtual account number or a unique amount, never shared with another payment still waiting
+// for money at the same provider.
+func (service *PaymentServiceImpl) issueBankTransfer(ctx context.Context, tx *gorm.DB, payment *domain.Payment) error {
+	pending, err := service.PaymentRepository.FindPendingBankTransfers(ctx, tx, payment.Provider)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	usedAccounts := map[string]bool{}
+	usedAmounts := map[int64]bool{}
+	for _, other := range pending {
+		if other.ExpiresAt != nil && now.After(other.ExpiresAt.Add(service.BankTransferConfig.Grace)) {
+			continue
+		}
+		usedAccounts[other.BankTransfer.VirtualAccount] = true
+		usedAmounts[other.BankTransfer.TransferAmount] = true
+	}
+
+	transfer := &domain.BankTransfer{Mode: service.BankTransferConfig.Mode(payment.Provider), TransferAmount: payment.Amount}
+	switch transfer.Mode {
+	case domain.BankTransferVirtualAccount:
+		for attempt := 0; transfer.VirtualAccount == ""; attempt++ {
+			if attempt == 10 {
+				return errors.New("could not allocate a free virtual account number")
+			}
+			number, err := newVirtualAccountNumber(service.BankTransferConfig.VirtualAccountPrefix)
+			if err != nil {
+				return err
+			}
+			if !usedAccounts[number] {
+				transfer.VirtualAccount = number
+			}
+		}
+	case domain.BankTransferUniqueAmount:
+		for code := int64(1); transfer.UniqueCode == 0; code++ {
+			if code > service.BankTransferConfig.MaxUniqueCode {
+				return fmt.Errorf("no unique transfer code left for amount %d", payment.Amount)
+			}
+			if !usedAmounts[payment.Amount+code] {
+				transfer.UniqueCode = code
+				transfer.TransferAmount = payment.Amount + code
+			}
+		}
+	}
+
+	expiresAt := now.Add(service.BankTransferConfig.TTL)
+	payment.BankTransfer = transfer
+	payment.ExpiresAt = &expiresAt
+	return nil
+}
+
+func newVirtualAccountNumber(prefix string) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(virtualAccountDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%0*d", prefix, virtualAccountDigits, n), nil
+}
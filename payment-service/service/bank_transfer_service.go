@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"io"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+)
+
+type BankTransferService interface {
+	Import(ctx context.Context, request web.BankMutationImportRequest, file io.Reader) (web.BankMutationImportResponse, error)
+	Receive(ctx context.Context, provider string, request web.BankMutationRequest) (domain.BankMutation, error)
+	FindExceptions(ctx context.Context, includeResolved bool) ([]domain.BankMutation, error)
+	Resolve(ctx context.Context, mutationId string, request web.BankMutationResolveRequest) (domain.BankMutation, error)
+}
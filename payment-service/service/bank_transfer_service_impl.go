@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BankTransferServiceImpl struct {
+	BankMutationRepository repository.BankMutationRepository
+	PaymentRepository      repository.PaymentRepository
+	PaymentService         PaymentService
+	Config                 config.BankTransferConfig
+	DB                     *gorm.DB
+	Validate               *validator.Validate
+}
+
+func NewBankTransferService(bankMutationRepository repository.BankMutationRepository, paymentRepository repository.PaymentRepository, paymentService PaymentService, bankTransferConfig config.BankTransferConfig, DB *gorm.DB, validate *validator.Validate) BankTransferService {
+	return &BankTransferServiceImpl{
+		BankMutationRepository: bankMutationRepository,
+		PaymentRepository:      paymentRepository,
+		PaymentService:         paymentService,
+		Config:                 bankTransferConfig,
+		DB:                     DB,
+		Validate:               validate,
+	}
+}
+
+// Import loads a bank statement export and matches every new mutation. The whole file is
+// rejected when any row is invalid; mutations already on file are skipped so the same statement
+// can be imported again.
+func (service *BankTransferServiceImpl) Import(ctx context.Context, request web.BankMutationImportRequest, file io.Reader) (web.BankMutationImportResponse, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return web.BankMutationImportResponse{}, err
+	}
+
+	requests, err := parseBankMutationCSV(file)
+	if err != nil {
+		return web.BankMutationImportResponse{}, err
+	}
+
+	mutations := make([]domain.BankMutation, 0, len(requests))
+	for i, line := range requests {
+		mutation, err := service.newMutation(request.Provider, domain.MutationSourceFile, line)
+		if err != nil {
+			return web.BankMutationImportResponse{}, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		mutations = append(mutations, mutation)
+	}
+
+	var response web.BankMutationImportResponse
+	for _, mutation := range mutations {
+		recorded, created, err := service.record(ctx, mutation)
+		if err != nil {
+			return response, err
+		}
+		switch {
+		case !created:
+			response.Skipped++
+		case recorded.Status == domain.MutationMatched:
+			response.Imported++
+			response.Matched++
+		default:
+			response.Imported++
+			response.Unmatched++
+		}
+	}
+
+	return response, nil
+}
+
+// Receive records a single mutation pushed by the bank. Repeated pushes of the same mutation
+// return the mutation as first recorded.
+func (service *BankTransferServiceImpl) Receive(ctx context.Context, provider string, request web.BankMutationRequest) (domain.BankMutation, error) {
+	mutation, err := service.newMutation(provider, domain.MutationSourceAPI, request)
+	if err != nil {
+		return domain.BankMutation{}, err
+	}
+
+	recorded, _, err := service.record(ctx, mutation)
+	return recorded, err
+}
+
+func (service *BankTransferServiceImpl) FindExceptions(ctx context.Context, includeResolved bool) ([]domain.BankMutation, error) {
+	return service.BankMutationRepository.FindExceptions(ctx, service.DB, includeResolved)
+}
+
+// Resolve closes an unmatched mutation, applying it to request.PaymentID when one is named.
+func (service *BankTransferServiceImpl) Resolve(ctx context.Context, mutationId string, request web.BankMutationResolveRequest) (domain.BankMutation, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.BankMutation{}, err
+	}
+
+	mutation, err := service.BankMutationRepository.FindById(ctx, service.DB, mutationId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.BankMutation{}, exception.NotFoundError{Message: "bank mutation not found"}
+	}
+	if err != nil {
+		return domain.BankMutation{}, err
+	}
+
+	if mutation.Status == domain.MutationMatched {
+		return domain.BankMutation{}, errors.New("matched mutations need no resolution")
+	}
+	if mutation.Resolved {
+		return domain.BankMutation{}, fmt.Errorf("bank mutation %s already resolved", mutation.ID)
+	}
+
+	if request.PaymentID != "" {
+		payment, err := service.PaymentService.FindById(ctx, request.PaymentID)
+		if err != nil {
+			return domain.BankMutation{}, exception.NotFoundError{Message: "payment not found"}
+		}
+		if payment.BankTransfer == nil || payment.Provider != mutation.Provider {
+			return domain.BankMutation{}, fmt.Errorf("payment %s is not a %s bank transfer", payment.ID, mutation.Provider)
+		}
+		if payment.BankTransfer.TransferAmount != mutation.Amount {
+			return domain.BankMutation{}, fmt.Errorf("transfer amount %d does not match payment transfer amount %d", mutation.Amount, payment.BankTransfer.TransferAmount)
+		}
+
+		if _, err := service.PaymentService.MarkTransferPaid(ctx, request.PaymentID, mutation.TransactedAt); err != nil {
+			return domain.BankMutation{}, err
+		}
+
+		mutation.Status = domain.MutationMatched
+		mutation.PaymentID = &payment.ID
+	}
+
+	now := time.Now()
+	mutation.Resolved = true
+	mutation.ResolutionNote = request.Note
+	mutation.ResolvedAt = &now
+
+	return service.BankMutationRepository.Update(ctx, service.DB, mutation)
+}
+
+func (service *BankTransferServiceImpl) newMutation(provider string, source string, request web.BankMutationRequest) (domain.BankMutation, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.BankMutation{}, err
+	}
+
+	transactedAt, err := time.Parse(time.RFC3339, request.TransactedAt)
+	if err != nil {
+		return domain.BankMutation{}, errors.New("invalid transacted_at: expected RFC3339")
+	}
+
+	return domain.BankMutation{
+		ID:             uuid.New(),
+		Provider:       provider,
+		ExternalID:     request.ExternalID,
+		VirtualAccount: request.VirtualAccount,
+		Amount:         request.Amount,
+		TransactedAt:   transactedAt.UTC(),
+		Description:    request.Description,
+		Source:         source,
+		Status:         domain.MutationUnmatched,
+	}, nil
+}
+
+// record stores a new mutation, then matches it and captures the matched payment. The capture
+// runs in its own transaction, so a mutation is on file even when capturing fails; it then stays
+// in the exception queue.
+func (service *BankTransferServiceImpl) record(ctx context.Context, mutation domain.BankMutation) (domain.BankMutation, bool, error) {
+	saved, created, err := service.BankMutationRepository.Save(ctx, service.DB, mutation)
+	if err != nil {
+		return domain.BankMutation{}, false, err
+	}
+	if !created {
+		existing, err := service.BankMutationRepository.FindByExternalId(ctx, service.DB, mutation.Provider, mutation.ExternalID)
+		return existing, false, err
+	}
+
+	payment, reason, err := service.match(ctx, saved)
+	if err != nil {
+		return domain.BankMutation{}, true, err
+	}
+
+	if reason == "" {
+		if _, err := service.PaymentService.MarkTransferPaid(ctx, payment.ID.String(), saved.TransactedAt); err != nil {
+			reason = domain.MutationCaptureFailed
+		}
+	}
+
+	if reason == "" {
+		saved.Status = domain.MutationMatched
+		saved.PaymentID = &payment.ID
+	} else {
+		saved.Reason = reason
+	}
+
+	updated, err := service.BankMutationRepository.Update(ctx, service.DB, saved)
+	return updated, true, err
+}
+
+// match finds the one pending payment a mutation pays for. Mutations that name a virtual account
+// are matched on it; others are matched on the unique transfer amount. Either way the transfer
+// must fall between the payment's creation and its expiry plus the grace period.
+func (service *BankTransferServiceImpl) match(ctx context.Context, mutation domain.BankMutation) (domain.Payment, string, error) {
+	pending, err := service.PaymentRepository.FindPendingBankTransfers(ctx, service.DB, mutation.Provider)
+	if err != nil {
+		return domain.Payment{}, "", err
+	}
+
+	var candidates []domain.Payment
+	for _, payment := range pending {
+		transfer := payment.BankTransfer
+		if mutation.VirtualAccount != "" {
+			if transfer.VirtualAccount == mutation.VirtualAccount {
+				candidates = append(candidates, payment)
+			}
+		} else if transfer.Mode == domain.BankTransferUniqueAmount && transfer.TransferAmount == mutation.Amount {
+			candidates = append(candidates, payment)
+		}
+	}
+	if len(candidates) == 0 {
+		return domain.Payment{}, domain.MutationNoMatch, nil
+	}
+
+	var inWindow []domain.Payment
+	for _, payment := range candidates {
+		if mutation.TransactedAt.Before(payment.CreatedAt) {
+			continue
+		}
+		if payment.ExpiresAt != nil && mutation.TransactedAt.After(payment.ExpiresAt.Add(service.Config.Grace)) {
+			continue
+		}
+		inWindow = append(inWindow, payment)
+	}
+
+	switch {
+	case len(inWindow) == 0:
+		return domain.Payment{}, domain.MutationOutsideWindow, nil
+	case len(inWindow) > 1:
+		return domain.Payment{}, domain.MutationAmbiguous, nil
+	case inWindow[0].BankTransfer.TransferAmount != mutation.Amount:
+		return domain.Payment{}, domain.MutationAmountMismatch, nil
+	}
+
+	return inWindow[0], "", nil
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"io"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+)
+
+type DisputeService interface {
+	Open(ctx context.Context, request web.DisputeCreateRequest) (domain.Dispute, error)
+	HandleWebhook(ctx context.Context, provider string, request web.DisputeWebhookRequest) (domain.Dispute, error)
+	UpdateStatus(ctx context.Context, disputeId string, request web.DisputeStatusRequest) (domain.Dispute, error)
+	AddEvidence(ctx context.Context, disputeId string, request web.DisputeEvidenceRequest, file io.Reader) (domain.DisputeEvidence, error)
+	FindEvidence(ctx context.Context, disputeId string, evidenceId string) (domain.DisputeEvidence, error)
+	FindById(ctx context.Context, disputeId string) (domain.Dispute, error)
+	FindAll(ctx context.Context, status string) ([]domain.Dispute, error)
+	FindAllByPaymentId(ctx context.Context, paymentId string) ([]domain.Dispute, error)
+}
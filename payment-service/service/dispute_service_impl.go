@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// disputeTransitions lists the states each open dispute may move to.
+var disputeTransitions = map[string][]string{
+	domain.DisputeNeedsResponse: {domain.DisputeUnderReview, domain.DisputeWon, domain.DisputeLost},
+	domain.DisputeUnderReview:   {domain.DisputeNeedsResponse, domain.DisputeWon, domain.DisputeLost},
+}
+
+type DisputeServiceImpl struct {
+	DisputeRepository repository.DisputeRepository
+	PaymentRepository repository.PaymentRepository
+	PaymentService    PaymentService
+	EvidenceDir       string
+	DB                *gorm.DB
+	Validate          *validator.Validate
+}
+
+func NewDisputeService(disputeRepository repository.DisputeRepository, paymentRepository repository.PaymentRepository, paymentService PaymentService, evidenceDir string, DB *gorm.DB, validate *validator.Validate) DisputeService {
+	return &DisputeServiceImpl{
+		DisputeRepository: disputeRepository,
+		PaymentRepository: paymentRepository,
+		PaymentService:    paymentService,
+		EvidenceDir:       evidenceDir,
+		DB:                DB,
+		Validate:          validate,
+	}
+}
+
+func (service *DisputeServiceImpl) Open(ctx context.Context, request web.DisputeCreateRequest) (_ domain.Dispute, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Dispute{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	payment, err := service.PaymentRepository.FindById(ctx, tx, request.PaymentID.String())
+	if err != nil {
+		return domain.Dispute{}, exception.NotFoundError{Message: "payment not found"}
+	}
+
+	disputeId := uuid.New()
+	providerDisputeId := request.ProviderDisputeID
+	if providerDisputeId == "" {
+		providerDisputeId = disputeId.String()
+	}
+
+	return service.open(ctx, tx, payment, domain.Dispute{
+		ID:                disputeId,
+		ProviderDisputeID: providerDisputeId,
+		Reason:            request.Reason,
+		Amount:            request.Amount,
+		Status:            domain.DisputeNeedsResponse,
+		RespondBy:         request.RespondBy,
+	})
+}
+
+// HandleWebhook applies a provider dispute notification. Redelivered events that do not change
+// the state are accepted without side effects.
+func (service *DisputeServiceImpl) HandleWebhook(ctx context.Context, provider string, request web.DisputeWebhookRequest) (_ domain.Dispute, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Dispute{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	dispute, err := service.DisputeRepository.FindByProviderDisputeId(ctx, tx, provider, request.ProviderDisputeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if request.ProviderReference == "" {
+			return domain.Dispute{}, errors.New("provider_reference is required to open a dispute")
+		}
+
+		payments, err := service.PaymentRepository.FindByProviderReferences(ctx, tx, provider, []string{request.ProviderReference})
+		if err != nil {
+			return domain.Dispute{}, err
+		}
+		if len(payments) == 0 {
+			return domain.Dispute{}, exception.NotFoundError{Message: "payment not found"}
+		}
+
+		dispute, err = service.open(ctx, tx, payments[0], domain.Dispute{
+			ID:                uuid.New(),
+			ProviderDisputeID: request.ProviderDisputeID,
+			Reason:            request.Reason,
+			Amount:            request.Amount,
+			Status:            domain.DisputeNeedsResponse,
+			RespondBy:         request.RespondBy,
+		})
+		if err != nil {
+			return domain.Dispute{}, err
+		}
+	} else if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	if dispute.Status == request.Status {
+		return dispute, nil
+	}
+
+	return service.transition(ctx, tx, dispute, request.Status, "")
+}
+
+func (service *DisputeServiceImpl) UpdateStatus(ctx context.Context, disputeId string, request web.DisputeStatusRequest) (_ domain.Dispute, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Dispute{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	dispute, err := service.DisputeRepository.FindById(ctx, tx, disputeId)
+	if err != nil {
+		return domain.Dispute{}, exception.NotFoundError{Message: "dispute not found"}
+	}
+
+	// Submitting a response without anything to back it up is never what the reviewer meant.
+	if request.Status == domain.DisputeUnderReview && len(dispute.Evidence) == 0 {
+		return domain.Dispute{}, errors.New("attach evidence before submitting the dispute response")
+	}
+
+	return service.transition(ctx, tx, dispute, request.Status, request.Note)
+}
+
+// AddEvidence stores the uploaded file under the evidence directory and records it on the
+// dispute. Evidence can only be added while the dispute is open.
+func (service *DisputeServiceImpl) AddEvidence(ctx context.Context, disputeId string, request web.DisputeEvidenceRequest, file io.Reader) (_ domain.DisputeEvidence, err error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	dispute, err := service.DisputeRepository.FindById(ctx, tx, disputeId)
+	if err != nil {
+		return domain.DisputeEvidence{}, exception.NotFoundError{Message: "dispute not found"}
+	}
+
+	if dispute.IsClosed() {
+		return domain.DisputeEvidence{}, fmt.Errorf("dispute is already %s", dispute.Status)
+	}
+
+	evidenceId := uuid.New()
+	dir := filepath.Join(service.EvidenceDir, dispute.ID.String())
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return domain.DisputeEvidence{}, err
+	}
+
+	// The stored name never uses the client's file name, only its extension.
+	path := filepath.Join(dir, evidenceId.String()+strings.ToLower(filepath.Ext(filepath.Base(request.FileName))))
+	size, err := writeEvidenceFile(path, file)
+	if err != nil {
+		return domain.DisputeEvidence{}, err
+	}
+
+	evidence, err := service.DisputeRepository.SaveEvidence(ctx, tx, domain.DisputeEvidence{
+		ID:          evidenceId,
+		DisputeID:   dispute.ID,
+		FileName:    filepath.Base(request.FileName),
+		ContentType: request.ContentType,
+		Size:        size,
+		StoragePath: path,
+		Note:        request.Note,
+	})
+	if err != nil {
+		os.Remove(path)
+		return domain.DisputeEvidence{}, err
+	}
+
+	return evidence, nil
+}
+
+func (service *DisputeServiceImpl) FindEvidence(ctx context.Context, disputeId string, evidenceId string) (domain.DisputeEvidence, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.DisputeRepository.FindEvidenceById(ctx, tx, disputeId, evidenceId)
+}
+
+func (service *DisputeServiceImpl) FindById(ctx context.Context, disputeId string) (domain.Dispute, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.DisputeRepository.FindById(ctx, tx, disputeId)
+}
+
+func (service *DisputeServiceImpl) FindAll(ctx context.Context, status string) ([]domain.Dispute, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.DisputeRepository.FindAll(ctx, tx, status)
+}
+
+func (service *DisputeServiceImpl) FindAllByPaymentId(ctx context.Context, paymentId string) ([]domain.Dispute, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.DisputeRepository.FindAllByPaymentId(ctx, tx, paymentId)
+}
+
+// open records a new dispute against a successful payment. Disputes always cover the full
+// amount because a lost dispute reverses the whole payment.
+func (service *DisputeServiceImpl) open(ctx context.Context, tx *gorm.DB, payment domain.Payment, dispute domain.Dispute) (domain.Dispute, error) {
+	if payment.Status != "success" {
+		return domain.Dispute{}, errors.New("only successful payments can be disputed")
+	}
+
+	if dispute.Amount == 0 {
+		dispute.Amount = payment.Amount
+	}
+	if dispute.Amount != payment.Amount {
+		return domain.Dispute{}, fmt.Errorf("dispute amount %d must equal payment amount %d", dispute.Amount, payment.Amount)
+	}
+
+	existing, err := service.DisputeRepository.FindOpenByPaymentId(ctx, tx, payment.ID.String())
+	if err == nil && existing.ID != uuid.Nil {
+		return domain.Dispute{}, exception.ConflictError{
+			Message: fmt.Sprintf("payment %s already has open dispute %s", payment.ID, existing.ID),
+			Data:    existing,
+		}
+	}
+
+	dispute.PaymentID = payment.ID
+	dispute.Provider = payment.Provider
+
+	return service.DisputeRepository.Save(ctx, tx, dispute)
+}
+
+// transition moves the dispute to status. Losing a dispute charges the payment back in the same
+// transaction and tells the order service so the order can be flagged.
+func (service *DisputeServiceImpl) transition(ctx context.Context, tx *gorm.DB, dispute domain.Dispute, status string, note string) (domain.Dispute, error) {
+	if !containsString(disputeTransitions[dispute.Status], status) {
+		return domain.Dispute{}, fmt.Errorf("dispute cannot move from %s to %s", dispute.Status, status)
+	}
+
+	dispute.Status = status
+	if note != "" {
+		dispute.ResolutionNote = note
+	}
+	if dispute.IsClosed() {
+		now := time.Now()
+		dispute.ClosedAt = &now
+	}
+
+	updated, err := service.DisputeRepository.Update(ctx, tx, dispute)
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	if status != domain.DisputeLost {
+		return updated, nil
+	}
+
+	payment, err := service.PaymentRepository.FindByIdForUpdate(ctx, tx, dispute.PaymentID.String())
+	if err != nil {
+		return domain.Dispute{}, err
+	}
+
+	// A payment already charged back or refunded through another path has nothing left to reverse.
+	if payment.Status != "success" {
+		return updated, nil
+	}
+
+	if _, err := service.PaymentService.ApplyChargeback(ctx, tx, payment); err != nil {
+		return domain.Dispute{}, err
+	}
+
+	return updated, nil
+}
+
+func writeEvidenceFile(path string, file io.Reader) (int64, error) {
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(out, file)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, err
+	}
+
+	return size, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"time"
+)
+
+type DunningService interface {
+	Run(ctx context.Context, now time.Time) (int, error)
+	FindByOrderId(ctx context.Context, orderId string) (domain.DunningCase, error)
+	FindAll(ctx context.Context, status string) ([]domain.DunningCase, error)
+}
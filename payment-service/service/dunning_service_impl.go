@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// dunningActor is recorded on the payment timeline for retries made by the scheduler.
+const dunningActor = "dunning-scheduler"
+
+type DunningServiceImpl struct {
+	DunningRepository      repository.DunningRepository
+	PaymentEventRepository repository.PaymentEventRepository
+	PaymentService         PaymentService
+	Config                 config.DunningConfig
+	DB                     *gorm.DB
+}
+
+func NewDunningService(dunningRepository repository.DunningRepository, paymentEventRepository repository.PaymentEventRepository, paymentService PaymentService, dunningConfig config.DunningConfig, DB *gorm.DB) DunningService {
+	return &DunningServiceImpl{
+		DunningRepository:      dunningRepository,
+		PaymentEventRepository: paymentEventRepository,
+		PaymentService:         paymentService,
+		Config:                 dunningConfig,
+		DB:                     DB,
+	}
+}
+
+// Run opens a dunning case for every new payment failure and retries the cases that are due. It
+// returns how many cases were opened or retried.
+func (service *DunningServiceImpl) Run(ctx context.Context, now time.Time) (int, error) {
+	failures, err := service.DunningRepository.FindNewFailures(ctx, service.DB)
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	var errs []error
+	for _, payment := range failures {
+		if err := service.open(ctx, payment, now); err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", payment.OrderID, err))
+			continue
+		}
+		handled++
+	}
+
+	due, err := service.DunningRepository.FindDue(ctx, service.DB, now)
+	if err != nil {
+		return handled, errors.Join(append(errs, err)...)
+	}
+
+	for _, dunningCase := range due {
+		retried, err := service.retry(ctx, dunningCase, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("order %s: %w", dunningCase.OrderID, err))
+			continue
+		}
+		if retried {
+			handled++
+		}
+	}
+
+	return handled, errors.Join(errs...)
+}
+
+func (service *DunningServiceImpl) FindByOrderId(ctx context.Context, orderId string) (domain.DunningCase, error) {
+	dunningCase, err := service.DunningRepository.FindByOrderId(ctx, service.DB, orderId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.DunningCase{}, exception.NotFoundError{Message: "order has no dunning case"}
+	}
+
+	return dunningCase, err
+}
+
+func (service *DunningServiceImpl) FindAll(ctx context.Context, status string) ([]domain.DunningCase, error) {
+	switch status {
+	case "", domain.DunningActive, domain.DunningRecovered, domain.DunningExhausted:
+	default:
+		return nil, fmt.Errorf("unknown dunning status %q", status)
+	}
+
+	return service.DunningRepository.FindAll(ctx, service.DB, status)
+}
+
+// open starts dunning the order of a failed payment and schedules its first retry.
+func (service *DunningServiceImpl) open(ctx context.Context, payment domain.Payment, now time.Time) error {
+	dunningCase, err := service.DunningRepository.Save(ctx, service.DB, domain.DunningCase{
+		ID:              uuid.New(),
+		OrderID:         payment.OrderID,
+		CustomerID:      payment.CustomerID,
+		PaymentMethodID: *payment.PaymentMethodID,
+		Provider:        payment.Provider,
+		Amount:          payment.Amount,
+		Currency:        payment.Currency,
+		Status:          domain.DunningActive,
+		LastPaymentID:   payment.ID,
+		LastError:       "payment failed",
+	})
+	if err != nil {
+		return err
+	}
+
+	return service.scheduleOrCancel(ctx, dunningCase, domain.DunningPaymentFailed, now)
+}
+
+// retry makes another payment attempt for a due case with the saved payment method. It reports
+// false without retrying while the order still has an attempt in progress.
+func (service *DunningServiceImpl) retry(ctx context.Context, dunningCase domain.DunningCase, now time.Time) (bool, error) {
+	payments, err := service.PaymentService.FindAllByOrderId(ctx, dunningCase.OrderID.String())
+	if err != nil {
+		return false, err
+	}
+	for _, payment := range payments {
+		switch payment.Status {
+		case "success":
+			// The customer paid some other way in the meantime.
+			return true, service.recover(ctx, dunningCase, payment, now)
+		case "pending", "held", "awaiting_collection":
+			return false, nil
+		}
+	}
+
+	ctx = domain.WithEventOrigin(ctx, domain.EventOrigin{Source: domain.EventSourceJob, Actor: dunningActor})
+	dunningCase.Attempts++
+
+	payment, err := service.PaymentService.Create(ctx, web.PaymentCreateRequest{
+		OrderID:         dunningCase.OrderID,
+		Amount:          dunningCase.Amount,
+		Currency:        dunningCase.Currency,
+		Provider:        dunningCase.Provider,
+		Method:          "card",
+		CustomerID:      dunningCase.CustomerID,
+		PaymentMethodID: dunningCase.PaymentMethodID.String(),
+	})
+	if err == nil {
+		dunningCase.LastPaymentID = payment.ID
+		if payment.Status == "pending" {
+			payment, err = service.PaymentService.MarkAsSuccess(ctx, payment.ID.String())
+			if err != nil {
+				// Release the order so the next retry can make a new attempt.
+				if _, failErr := service.PaymentService.MarkAsFailed(ctx, dunningCase.LastPaymentID.String()); failErr != nil {
+					fmt.Printf("Warning: failing dunning payment %s failed: %v", dunningCase.LastPaymentID, failErr)
+				}
+			}
+		}
+	}
+
+	switch {
+	case err != nil:
+		dunningCase.LastError = err.Error()
+	case payment.Status == "success":
+		return true, service.recover(ctx, dunningCase, payment, now)
+	case payment.Status == "held":
+		// The attempt waits for risk review; the next run picks up its outcome.
+		_, err = service.DunningRepository.Update(ctx, service.DB, dunningCase)
+		return true, err
+	default:
+		dunningCase.LastError = "payment " + payment.Status
+	}
+
+	return true, service.scheduleOrCancel(ctx, dunningCase, domain.DunningRetryFailed, now)
+}
+
+// scheduleOrCancel records a failure on the case. It schedules the next retry, or cancels the
+// order in order-service when the retry schedule is used up.
+func (service *DunningServiceImpl) scheduleOrCancel(ctx context.Context, dunningCase domain.DunningCase, eventType string, now time.Time) error {
+	if dunningCase.Attempts < len(service.Config.RetrySchedule) {
+		nextAttemptAt := now.Add(service.Config.RetrySchedule[dunningCase.Attempts])
+		dunningCase.NextAttemptAt = &nextAttemptAt
+	} else {
+		dunningCase.Status = domain.DunningExhausted
+		dunningCase.NextAttemptAt = nil
+		dunningCase.ClosedAt = &now
+	}
+
+	if _, err := service.DunningRepository.Update(ctx, service.DB, dunningCase); err != nil {
+		return err
+	}
+	if err := service.emit(ctx, dunningCase, eventType, dunningCase.LastError); err != nil {
+		return err
+	}
+	if dunningCase.Status != domain.DunningExhausted {
+		return nil
+	}
+
+	callbackPayload := web.PaymentCallbackRequest{
+		OrderID:       dunningCase.OrderID,
+		PaymentID:     dunningCase.LastPaymentID,
+		PaymentStatus: "cancelled",
+	}
+	if err := deliverCallback(ctx, service.DB, service.PaymentEventRepository, service.getCallbackURL(), callbackPayload); err != nil {
+		fmt.Printf("Warning: cancelling dunned order in order service failed: %v", err)
+	}
+
+	message := fmt.Sprintf("order cancelled after %d retries", dunningCase.Attempts)
+	return service.emit(ctx, dunningCase, domain.DunningOrderCancelled, message)
+}
+
+func (service *DunningServiceImpl) recover(ctx context.Context, dunningCase domain.DunningCase, payment domain.Payment, now time.Time) error {
+	dunningCase.Status = domain.DunningRecovered
+	dunningCase.LastPaymentID = payment.ID
+	dunningCase.LastError = ""
+	dunningCase.NextAttemptAt = nil
+	dunningCase.ClosedAt = &now
+
+	if _, err := service.DunningRepository.Update(ctx, service.DB, dunningCase); err != nil {
+		return err
+	}
+
+	return service.emit(ctx, dunningCase, domain.DunningRecoveredEvent, "")
+}
+
+// emit records a dunning event after sending it to the notification endpoint, if one is set. A
+// notification that cannot be delivered does not hold up the dunning case.
+func (service *DunningServiceImpl) emit(ctx context.Context, dunningCase domain.DunningCase, eventType string, message string) error {
+	event := domain.DunningEvent{
+		ID:            uuid.New(),
+		CaseID:        dunningCase.ID,
+		OrderID:       dunningCase.OrderID,
+		Type:          eventType,
+		Attempt:       dunningCase.Attempts,
+		PaymentID:     &dunningCase.LastPaymentID,
+		NextAttemptAt: dunningCase.NextAttemptAt,
+		Message:       message,
+		CreatedAt:     time.Now(),
+	}
+
+	if service.Config.NotifyURL != "" {
+		if err := sendDunningNotification(ctx, service.Config.NotifyURL, event); err != nil {
+			fmt.Printf("Warning: dunning notification for order %s failed: %v", dunningCase.OrderID, err)
+		} else {
+			event.Notified = true
+		}
+	}
+
+	_, err := service.DunningRepository.SaveEvent(ctx, service.DB, event)
+	return err
+}
+
+func sendDunningNotification(ctx context.Context, url string, event domain.DunningEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("notification endpoint returned status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"payment-service/models/web"
+	"strings"
+)
+
+// parseExchangeRateCSV reads a rate file with a header row and the columns base_currency,
+// quote_currency, rate and effective_at.
+func parseExchangeRateCSV(file io.Reader) ([]web.ExchangeRateCreateRequest, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("exchange rate file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"base_currency", "quote_currency", "rate", "effective_at"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("exchange rate file is missing the %s column", required)
+		}
+	}
+
+	var requests []web.ExchangeRateCreateRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			index := columns[name]
+			if index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		requests = append(requests, web.ExchangeRateCreateRequest{
+			BaseCurrency:  field("base_currency"),
+			QuoteCurrency: field("quote_currency"),
+			Rate:          field("rate"),
+			EffectiveAt:   field("effective_at"),
+		})
+	}
+
+	return requests, nil
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"io"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"time"
+)
+
+// Conversion is the result of pricing an amount in another currency. Inverted is set when
+// only the opposite pair was on file and the rate was divided instead of multiplied.
+type Conversion struct {
+	Amount   int64
+	Rate     domain.ExchangeRate
+	Inverted bool
+}
+
+type ExchangeRateService interface {
+	Create(ctx context.Context, request web.ExchangeRateCreateRequest) (domain.ExchangeRate, error)
+	Import(ctx context.Context, source string, file io.Reader) (web.ExchangeRateImportResponse, error)
+	FindAll(ctx context.Context, query web.ExchangeRateQuery) ([]domain.ExchangeRate, error)
+	FindEffective(ctx context.Context, query web.ExchangeRateQuery) (domain.ExchangeRate, error)
+	Convert(ctx context.Context, amount int64, fromCurrency string, toCurrency string, at time.Time) (Conversion, error)
+}
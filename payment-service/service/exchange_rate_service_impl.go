@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ExchangeRateServiceImpl struct {
+	ExchangeRateRepository repository.ExchangeRateRepository
+	DB                     *gorm.DB
+	Validate               *validator.Validate
+}
+
+func NewExchangeRateService(exchangeRateRepository repository.ExchangeRateRepository, DB *gorm.DB, validate *validator.Validate) ExchangeRateService {
+	return &ExchangeRateServiceImpl{
+		ExchangeRateRepository: exchangeRateRepository,
+		DB:                     DB,
+		Validate:               validate,
+	}
+}
+
+// Create records a rate entered through the admin API. Posting the same pair and effective
+// time twice is a conflict because the earlier rate may already have priced payments.
+func (service *ExchangeRateServiceImpl) Create(ctx context.Context, request web.ExchangeRateCreateRequest) (domain.ExchangeRate, error) {
+	rate, err := service.newRate(request, domain.RateSourceAPI)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	saved, created, err := service.ExchangeRateRepository.Save(ctx, service.DB, rate)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	if !created {
+		return domain.ExchangeRate{}, exception.ConflictError{
+			Message: "exchange rate already exists",
+			Data:    fmt.Sprintf("%s/%s already has a rate effective at %s", rate.BaseCurrency, rate.QuoteCurrency, rate.EffectiveAt.Format(time.RFC3339)),
+		}
+	}
+
+	return saved, nil
+}
+
+// Import loads a rate file. The whole file is rejected when any row is invalid; rows that are
+// already on file are skipped so the same file can be imported again.
+func (service *ExchangeRateServiceImpl) Import(ctx context.Context, source string, file io.Reader) (_ web.ExchangeRateImportResponse, err error) {
+	requests, err := parseExchangeRateCSV(file)
+	if err != nil {
+		return web.ExchangeRateImportResponse{}, err
+	}
+
+	rates := make([]domain.ExchangeRate, 0, len(requests))
+	for i, request := range requests {
+		rate, err := service.newRate(request, source)
+		if err != nil {
+			return web.ExchangeRateImportResponse{}, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	var response web.ExchangeRateImportResponse
+	for _, rate := range rates {
+		_, created, err := service.ExchangeRateRepository.Save(ctx, tx, rate)
+		if err != nil {
+			return web.ExchangeRateImportResponse{}, err
+		}
+		if created {
+			response.Imported++
+		} else {
+			response.Skipped++
+		}
+	}
+
+	return response, nil
+}
+
+func (service *ExchangeRateServiceImpl) FindAll(ctx context.Context, query web.ExchangeRateQuery) ([]domain.ExchangeRate, error) {
+	return service.ExchangeRateRepository.FindAll(ctx, service.DB, strings.ToUpper(query.Base), strings.ToUpper(query.Quote))
+}
+
+// FindEffective returns the rate of the pair in force at query.At, or now when At is empty.
+func (service *ExchangeRateServiceImpl) FindEffective(ctx context.Context, query web.ExchangeRateQuery) (domain.ExchangeRate, error) {
+	if query.Base == "" || query.Quote == "" {
+		return domain.ExchangeRate{}, errors.New("base and quote are required")
+	}
+
+	at, err := parseSearchTime("at", query.At, false)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	if at == nil {
+		now := time.Now()
+		at = &now
+	}
+
+	rate, err := service.ExchangeRateRepository.FindEffective(ctx, service.DB, strings.ToUpper(query.Base), strings.ToUpper(query.Quote), *at)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ExchangeRate{}, exception.NotFoundError{Message: "exchange rate not found"}
+	}
+
+	return rate, err
+}
+
+// Convert prices an amount in another currency with the rate in force at the given time. When
+// only the opposite pair is on file its rate is inverted exactly before rounding.
+func (service *ExchangeRateServiceImpl) Convert(ctx context.Context, amount int64, fromCurrency string, toCurrency string, at time.Time) (Conversion, error) {
+	rate, err := service.ExchangeRateRepository.FindEffective(ctx, service.DB, fromCurrency, toCurrency, at)
+	inverted := false
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		rate, err = service.ExchangeRateRepository.FindEffective(ctx, service.DB, toCurrency, fromCurrency, at)
+		inverted = true
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Conversion{}, fmt.Errorf("no exchange rate from %s to %s", fromCurrency, toCurrency)
+	}
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	factor, err := helper.ParseRate(rate.Rate)
+	if err != nil {
+		return Conversion{}, err
+	}
+	if inverted {
+		factor = new(big.Rat).Inv(factor)
+	}
+
+	return Conversion{
+		Amount:   helper.ConvertAmount(amount, fromCurrency, toCurrency, factor),
+		Rate:     rate,
+		Inverted: inverted,
+	}, nil
+}
+
+func (service *ExchangeRateServiceImpl) newRate(request web.ExchangeRateCreateRequest, source string) (domain.ExchangeRate, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	base, quote := strings.ToUpper(request.BaseCurrency), strings.ToUpper(request.QuoteCurrency)
+	if base == quote {
+		return domain.ExchangeRate{}, errors.New("base and quote currency must differ")
+	}
+
+	if _, err := helper.ParseRate(request.Rate); err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	effectiveAt, err := parseSearchTime("effective_at", request.EffectiveAt, false)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	if effectiveAt == nil {
+		now := time.Now()
+		effectiveAt = &now
+	}
+
+	return domain.ExchangeRate{
+		ID:            uuid.New(),
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          request.Rate,
+		EffectiveAt:   effectiveAt.UTC(),
+		Source:        source,
+	}, nil
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+)
+
+type FeeService interface {
+	ApplyCaptureFee(payment domain.Payment) domain.Payment
+	ApplyRefundFee(payment domain.Payment, amount int64) domain.Payment
+	Summary(ctx context.Context, request web.FeeSummaryRequest) ([]web.FeeSummaryResponse, error)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"payment-service/config"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type FeeServiceImpl struct {
+	FeeRepository repository.FeeRepository
+	Schedule      config.FeeSchedule
+	DB            *gorm.DB
+}
+
+func NewFeeService(feeRepository repository.FeeRepository, schedule config.FeeSchedule, DB *gorm.DB) FeeService {
+	return &FeeServiceImpl{
+		FeeRepository: feeRepository,
+		Schedule:      schedule,
+		DB:            DB,
+	}
+}
+
+// ApplyCaptureFee prices a payment that was just captured and records the fee on it.
+func (service *FeeServiceImpl) ApplyCaptureFee(payment domain.Payment) domain.Payment {
+	rule, ok := service.findRule(payment.Provider, payment.Method, payment.Currency)
+	if ok {
+		percentBps, fixed := rule.PercentBps, rule.Fixed
+		if rule.Type == config.FeeFixed {
+			percentBps = 0
+		}
+		if rule.Type == config.FeeTiered {
+			tier := selectTier(rule.Tiers, payment.Amount)
+			percentBps, fixed = tier.PercentBps, tier.Fixed
+		}
+
+		payment = addFeeLine(payment, domain.FeeLine{
+			Kind:       domain.FeeCapture,
+			Rule:       describeFeeRule(rule),
+			PercentBps: percentBps,
+			Fixed:      fixed,
+			Amount:     percentOf(payment.Amount, percentBps) + fixed,
+		})
+	}
+
+	payment.NetAmount = payment.Amount - payment.FeeAmount
+	return payment
+}
+
+// ApplyRefundFee prices a refund of amount, which the caller has already added to
+// RefundedAmount. Every refund adds the provider's refund charge; where the provider gives the
+// capture fee back, each refund returns its share of it and the last refund the rest. What is
+// kept is what has not been refunded, less fees.
+func (service *FeeServiceImpl) ApplyRefundFee(payment domain.Payment, amount int64) domain.Payment {
+	rule, ok := service.findRule(payment.Provider, payment.Method, payment.Currency)
+	if ok && rule.ReturnFeeOnRefund {
+		var captured, returned int64
+		for _, line := range payment.FeeBreakdown {
+			switch line.Kind {
+			case domain.FeeCapture:
+				captured += line.Amount
+			case domain.FeeCaptureReturned:
+				returned -= line.Amount
+			}
+		}
+		share := captured - returned
+		if payment.RefundedAmount < payment.Amount && payment.Amount > 0 {
+			share = captured * amount / payment.Amount
+		}
+		if share != 0 {
+			payment = addFeeLine(payment, domain.FeeLine{Kind: domain.FeeCaptureReturned, Rule: describeFeeRule(rule), Amount: -share})
+		}
+	}
+	if ok && rule.RefundFixed > 0 {
+		payment = addFeeLine(payment, domain.FeeLine{Kind: domain.FeeRefund, Rule: describeFeeRule(rule), Fixed: rule.RefundFixed, Amount: rule.RefundFixed})
+	}
+
+	payment.NetAmount = payment.Amount - payment.RefundedAmount - payment.FeeAmount
+	return payment
+}
+
+func (service *FeeServiceImpl) Summary(ctx context.Context, request web.FeeSummaryRequest) ([]web.FeeSummaryResponse, error) {
+	from, err := parseSearchTime("from", request.From, false)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseSearchTime("to", request.To, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	totals, err := service.FeeRepository.SumByProvider(ctx, tx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []web.FeeSummaryResponse{}
+	for _, total := range totals {
+		summaries = append(summaries, web.FeeSummaryResponse{
+			Provider:     total.Provider,
+			Currency:     total.Currency,
+			PaymentCount: total.PaymentCount,
+			GrossAmount:  total.GrossAmount,
+			FeeAmount:    total.FeeAmount,
+			NetAmount:    total.NetAmount,
+		})
+	}
+
+	return summaries, nil
+}
+
+// findRule prefers the rule for the exact method over the provider-wide one and, between rules
+// for the same method, the rule for the payment's currency over the currency-wide one.
+func (service *FeeServiceImpl) findRule(provider string, method string, currency string) (config.FeeRule, bool) {
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	best, bestScore := -1, -1
+	for i, rule := range service.Schedule.Rules {
+		if rule.Provider != provider {
+			continue
+		}
+		if rule.Currency != "" && !strings.EqualFold(rule.Currency, currency) {
+			continue
+		}
+
+		score := 0
+		switch {
+		case rule.Method == method && method != "":
+			score = 2
+		case rule.Method != "":
+			continue
+		}
+		if rule.Currency != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	if best < 0 {
+		return config.FeeRule{}, false
+	}
+	return service.Schedule.Rules[best], true
+}
+
+func selectTier(tiers []config.FeeTier, amount int64) config.FeeTier {
+	for _, tier := range tiers {
+		if tier.UpTo == 0 || amount <= tier.UpTo {
+			return tier
+		}
+	}
+	return tiers[len(tiers)-1]
+}
+
+// percentOf rounds half up to the smallest currency unit.
+func percentOf(amount int64, basisPoints int64) int64 {
+	return (amount*basisPoints + 5000) / 10000
+}
+
+func addFeeLine(payment domain.Payment, line domain.FeeLine) domain.Payment {
+	payment.FeeBreakdown = append(append([]domain.FeeLine{}, payment.FeeBreakdown...), line)
+	payment.FeeAmount += line.Amount
+	return payment
+}
+
+func describeFeeRule(rule config.FeeRule) string {
+	method := rule.Method
+	if method == "" {
+		method = "*"
+	}
+	return fmt.Sprintf("%s/%s %s", rule.Provider, method, rule.Type)
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, key string, fingerprint string) (domain.IdempotencyKey, bool, error)
+	Complete(ctx context.Context, key string, status int, body []byte) error
+	Release(ctx context.Context, key string) error
+	Cleanup(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"payment-service/exception"
+	"payment-service/models/domain"
+	"payment-service/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IdempotencyServiceImpl struct {
+	IdempotencyRepository repository.IdempotencyRepository
+	TTL                   time.Duration
+	DB                    *gorm.DB
+}
+
+func NewIdempotencyService(idempotencyRepository repository.IdempotencyRepository, ttl time.Duration, DB *gorm.DB) IdempotencyService {
+	return &IdempotencyServiceImpl{
+		IdempotencyRepository: idempotencyRepository,
+		TTL:                   ttl,
+		DB:                    DB,
+	}
+}
+
+// Begin claims the key for a new request. When the key already carries a finished response
+// for the same request it is returned with replay set. A key reused for a different request,
+// or one whose first request is still running, is a conflict.
+func (service *IdempotencyServiceImpl) Begin(ctx context.Context, key string, fingerprint string) (domain.IdempotencyKey, bool, error) {
+	now := time.Now()
+	claim := domain.IdempotencyKey{Key: key, Fingerprint: fingerprint, ExpiresAt: now.Add(service.TTL)}
+
+	saved, created, err := service.IdempotencyRepository.Save(ctx, service.DB, claim)
+	if err != nil {
+		return domain.IdempotencyKey{}, false, err
+	}
+	if created {
+		return saved, false, nil
+	}
+
+	existing, err := service.IdempotencyRepository.FindByKey(ctx, service.DB, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.IdempotencyKey{}, false, idempotencyInProgress()
+	}
+	if err != nil {
+		return domain.IdempotencyKey{}, false, err
+	}
+
+	// An expired key no longer protects anything; start over as if it had been cleaned up.
+	if !existing.ExpiresAt.After(now) {
+		if err := service.IdempotencyRepository.Delete(ctx, service.DB, key); err != nil {
+			return domain.IdempotencyKey{}, false, err
+		}
+		saved, created, err := service.IdempotencyRepository.Save(ctx, service.DB, claim)
+		if err != nil {
+			return domain.IdempotencyKey{}, false, err
+		}
+		if !created {
+			return domain.IdempotencyKey{}, false, idempotencyInProgress()
+		}
+		return saved, false, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return domain.IdempotencyKey{}, false, exception.ConflictError{
+			Message: "idempotency key reused",
+			Data:    "Idempotency-Key was already used for a different request",
+		}
+	}
+
+	if !existing.IsCompleted() {
+		return domain.IdempotencyKey{}, false, idempotencyInProgress()
+	}
+
+	return existing, true, nil
+}
+
+// Complete stores the response so retries with the same key replay it.
+func (service *IdempotencyServiceImpl) Complete(ctx context.Context, key string, status int, body []byte) error {
+	existing, err := service.IdempotencyRepository.FindByKey(ctx, service.DB, key)
+	if err != nil {
+		return err
+	}
+
+	existing.ResponseStatus = status
+	existing.ResponseBody = string(body)
+	_, err = service.IdempotencyRepository.Update(ctx, service.DB, existing)
+
+	return err
+}
+
+// Release gives the key back when the request ended without a response worth replaying.
+func (service *IdempotencyServiceImpl) Release(ctx context.Context, key string) error {
+	return service.IdempotencyRepository.Delete(ctx, service.DB, key)
+}
+
+func (service *IdempotencyServiceImpl) Cleanup(ctx context.Context) (int64, error) {
+	return service.IdempotencyRepository.DeleteExpired(ctx, service.DB, time.Now())
+}
+
+func idempotencyInProgress() error {
+	return exception.ConflictError{
+		Message: "idempotency key in use",
+		Data:    "a request with this Idempotency-Key is still being processed",
+	}
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"time"
+)
+
+type InstallmentService interface {
+	Create(ctx context.Context, request web.InstallmentPlanCreateRequest) (domain.InstallmentPlan, error)
+	FindById(ctx context.Context, planId string) (domain.InstallmentPlan, error)
+	FindByOrderId(ctx context.Context, orderId string) (domain.InstallmentPlan, error)
+	Run(ctx context.Context, now time.Time) (int, error)
+}
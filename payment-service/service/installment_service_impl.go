@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"slices"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// installmentActor is recorded on the payment timeline for installments charged by the scheduler.
+const installmentActor = "installment-scheduler"
+
+type InstallmentServiceImpl struct {
+	InstallmentRepository repository.InstallmentRepository
+	PaymentService        PaymentService
+	PaymentMethodService  PaymentMethodService
+	Config                config.InstallmentConfig
+	DB                    *gorm.DB
+	Validate              *validator.Validate
+}
+
+func NewInstallmentService(installmentRepository repository.InstallmentRepository, paymentService PaymentService, paymentMethodService PaymentMethodService, installmentConfig config.InstallmentConfig, DB *gorm.DB, validate *validator.Validate) InstallmentService {
+	return &InstallmentServiceImpl{
+		InstallmentRepository: installmentRepository,
+		PaymentService:        paymentService,
+		PaymentMethodService:  paymentMethodService,
+		Config:                installmentConfig,
+		DB:                    DB,
+		Validate:              validate,
+	}
+}
+
+// Create splits the order total into the requested number of installments, the first due right
+// away. When the plan has a saved payment method the first installment is charged immediately;
+// a failed charge is recorded on the installment and left for the customer to pay.
+func (service *InstallmentServiceImpl) Create(ctx context.Context, request web.InstallmentPlanCreateRequest) (domain.InstallmentPlan, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.InstallmentPlan{}, err
+	}
+	if request.Count > service.Config.MaxCount {
+		return domain.InstallmentPlan{}, fmt.Errorf("an order can be split into at most %d installments", service.Config.MaxCount)
+	}
+
+	totalAmount, currency, err := fetchOrderAmount(ctx, request.OrderID)
+	if err != nil {
+		return domain.InstallmentPlan{}, err
+	}
+	if totalAmount < service.Config.MinAmount {
+		return domain.InstallmentPlan{}, fmt.Errorf("order total %d %s is below the installment minimum of %d", totalAmount, currency, service.Config.MinAmount)
+	}
+	if totalAmount < int64(request.Count) {
+		return domain.InstallmentPlan{}, fmt.Errorf("order total %d cannot be split into %d installments", totalAmount, request.Count)
+	}
+
+	existing, err := service.InstallmentRepository.FindByOrderId(ctx, service.DB, request.OrderID.String())
+	if err == nil {
+		return domain.InstallmentPlan{}, exception.ConflictError{Message: "order already has an installment plan", Data: existing}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.InstallmentPlan{}, err
+	}
+
+	payments, err := service.PaymentService.FindAllByOrderId(ctx, request.OrderID.String())
+	if err != nil {
+		return domain.InstallmentPlan{}, err
+	}
+	for _, payment := range payments {
+		if slices.Contains(repository.ActivePaymentStatuses, payment.Status) {
+			return domain.InstallmentPlan{}, fmt.Errorf("order already has a %s payment", payment.Status)
+		}
+	}
+
+	var paymentMethodId *uuid.UUID
+	if request.PaymentMethodID != "" {
+		method, err := service.PaymentMethodService.Resolve(ctx, service.DB, request.CustomerID, request.PaymentMethodID)
+		if err != nil {
+			return domain.InstallmentPlan{}, err
+		}
+		if method.Provider != request.Provider {
+			return domain.InstallmentPlan{}, fmt.Errorf("payment method belongs to provider %s, not %s", method.Provider, request.Provider)
+		}
+		paymentMethodId = &method.ID
+	}
+
+	interval := request.Interval
+	if interval == "" {
+		interval = domain.IntervalMonth
+	}
+	intervalCount := request.IntervalCount
+	if intervalCount == 0 {
+		intervalCount = 1
+	}
+
+	now := time.Now()
+	plan := domain.InstallmentPlan{
+		ID:              uuid.New(),
+		OrderID:         request.OrderID,
+		CustomerID:      request.CustomerID,
+		Provider:        request.Provider,
+		PaymentMethodID: paymentMethodId,
+		TotalAmount:     totalAmount,
+		Currency:        currency,
+		Count:           request.Count,
+		Interval:        interval,
+		IntervalCount:   intervalCount,
+		Status:          domain.InstallmentPlanActive,
+	}
+	for i, amount := range helper.SplitInstallments(totalAmount, request.Count) {
+		dueAt := now
+		if i > 0 {
+			dueAt = helper.NextBillingDate(now, interval, intervalCount*i)
+		}
+		plan.Installments = append(plan.Installments, domain.Installment{
+			ID:     uuid.New(),
+			PlanID: plan.ID,
+			Number: i + 1,
+			Amount: amount,
+			DueAt:  dueAt,
+			Status: domain.InstallmentScheduled,
+		})
+	}
+
+	plan, err = service.InstallmentRepository.Save(ctx, service.DB, plan)
+	if err != nil {
+		return domain.InstallmentPlan{}, err
+	}
+
+	if plan.PaymentMethodID != nil {
+		if err := service.charge(ctx, plan, plan.Installments[0]); err != nil {
+			fmt.Printf("Warning: charging installment 1 of plan %s failed: %v", plan.ID, err)
+		}
+	}
+
+	return service.FindById(ctx, plan.ID.String())
+}
+
+func (service *InstallmentServiceImpl) FindById(ctx context.Context, planId string) (domain.InstallmentPlan, error) {
+	plan, err := service.InstallmentRepository.FindById(ctx, service.DB, planId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.InstallmentPlan{}, exception.NotFoundError{Message: "installment plan not found"}
+	}
+	return plan, err
+}
+
+func (service *InstallmentServiceImpl) FindByOrderId(ctx context.Context, orderId string) (domain.InstallmentPlan, error) {
+	plan, err := service.InstallmentRepository.FindByOrderId(ctx, service.DB, orderId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.InstallmentPlan{}, exception.NotFoundError{Message: "order has no installment plan"}
+	}
+	return plan, err
+}
+
+// Run flags the installments left unpaid past their grace period as overdue, then charges the
+// due installments of plans with a saved payment method. It returns how many installments it
+// flagged or charged.
+func (service *InstallmentServiceImpl) Run(ctx context.Context, now time.Time) (int, error) {
+	overdue, err := service.InstallmentRepository.MarkOverdue(ctx, service.DB, now.Add(-service.Config.GracePeriod), now)
+	if err != nil {
+		return 0, err
+	}
+
+	due, err := service.InstallmentRepository.FindDue(ctx, service.DB, now)
+	if err != nil {
+		return int(overdue), err
+	}
+
+	charged := 0
+	var errs []error
+	for _, installment := range due {
+		plan, err := service.FindById(ctx, installment.PlanID.String())
+		if err == nil {
+			err = service.charge(ctx, plan, installment)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("installment %d of plan %s: %w", installment.Number, installment.PlanID, err))
+			continue
+		}
+		charged++
+	}
+
+	return int(overdue) + charged, errors.Join(errs...)
+}
+
+// charge pays an installment with the plan's saved payment method. The payment itself records
+// the attempt on the installment; a declined payment is recorded as the installment's failure
+// reason, and only failures to reach the database are returned as errors.
+func (service *InstallmentServiceImpl) charge(ctx context.Context, plan domain.InstallmentPlan, installment domain.Installment) error {
+	ctx = domain.WithEventOrigin(ctx, domain.EventOrigin{Source: domain.EventSourceJob, Actor: installmentActor})
+
+	payment, err := service.PaymentService.Create(ctx, web.PaymentCreateRequest{
+		OrderID:           plan.OrderID,
+		Amount:            installment.Amount,
+		Currency:          plan.Currency,
+		Provider:          plan.Provider,
+		Method:            "card",
+		CustomerID:        plan.CustomerID,
+		PaymentMethodID:   plan.PaymentMethodID.String(),
+		InstallmentNumber: installment.Number,
+	})
+	if err != nil {
+		// The payment was never created, so the attempt is counted here.
+		installment.Attempts++
+		installment.FailureReason = err.Error()
+		_, err = service.InstallmentRepository.UpdateInstallment(ctx, service.DB, installment)
+		return err
+	}
+
+	paymentId := payment.ID
+	failureReason := ""
+	if payment.Status == "pending" {
+		payment, err = service.PaymentService.MarkAsSuccess(ctx, paymentId.String())
+		if err != nil {
+			failureReason = err.Error()
+			// Release the installment so the customer can make a new attempt.
+			if _, failErr := service.PaymentService.MarkAsFailed(ctx, paymentId.String()); failErr != nil {
+				fmt.Printf("Warning: failing installment payment %s failed: %v", paymentId, failErr)
+			}
+		}
+	} else if payment.Status != "success" {
+		failureReason = "payment " + payment.Status
+	}
+
+	if failureReason == "" {
+		return nil
+	}
+
+	installment.Attempts++
+	installment.PaymentID = &paymentId
+	installment.FailureReason = failureReason
+	_, err = service.InstallmentRepository.UpdateInstallment(ctx, service.DB, installment)
+	return err
+}
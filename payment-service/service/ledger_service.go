@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+
+	"gorm.io/gorm"
+)
+
+type LedgerService interface {
+	PostPaymentSuccess(ctx context.Context, tx *gorm.DB, payment domain.Payment) error
+	PostRefund(ctx context.Context, tx *gorm.DB, payment domain.Payment) error
+	PostChargeback(ctx context.Context, tx *gorm.DB, payment domain.Payment) error
+	PostFee(ctx context.Context, tx *gorm.DB, payment domain.Payment, amount int64) error
+	FindBalances(ctx context.Context) ([]web.LedgerBalanceResponse, error)
+	FindBalance(ctx context.Context, accountCode string) (web.LedgerBalanceResponse, error)
+	FindEntriesByPaymentId(ctx context.Context, paymentId string) ([]domain.JournalEntry, error)
+	CheckInvariants(ctx context.Context) (web.LedgerInvariantResponse, error)
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LedgerServiceImpl struct {
+	LedgerRepository repository.LedgerRepository
+	DB               *gorm.DB
+}
+
+func NewLedgerService(ledgerRepository repository.LedgerRepository, DB *gorm.DB) LedgerService {
+	return &LedgerServiceImpl{
+		LedgerRepository: ledgerRepository,
+		DB:               DB,
+	}
+}
+
+// PostPaymentSuccess recognises the sale against the customer and settles it into provider clearing.
+func (service *LedgerServiceImpl) PostPaymentSuccess(ctx context.Context, tx *gorm.DB, payment domain.Payment) error {
+	return service.post(ctx, tx, payment, "payment_success", "payment captured", []domain.JournalLine{
+		{AccountCode: domain.AccountCustomerReceivable, Debit: payment.Amount},
+		{AccountCode: domain.AccountRevenue, Credit: payment.Amount},
+		{AccountCode: domain.AccountProviderClearing, Debit: payment.Amount},
+		{AccountCode: domain.AccountCustomerReceivable, Credit: payment.Amount},
+	})
+}
+
+func (service *LedgerServiceImpl) PostRefund(ctx context.Context, tx *gorm.DB, payment domain.Payment) error {
+	return service.post(ctx, tx, payment, "refund", "payment refunded", []domain.JournalLine{
+		{AccountCode: domain.AccountRefunds, Debit: payment.Amount},
+		{AccountCode: domain.AccountProviderClearing, Credit: payment.Amount},
+	})
+}
+
+func (service *LedgerServiceImpl) PostChargeback(ctx context.Context, tx *gorm.DB, payment domain.Payment) error {
+	return service.post(ctx, tx, payment, "chargeback", "payment charged back", []domain.JournalLine{
+		{AccountCode: domain.AccountRefunds, Debit: payment.Amount},
+		{AccountCode: domain.AccountProviderClearing, Credit: payment.Amount},
+	})
+}
+
+// PostFee books provider fees deducted from clearing. A negative amount is a fee the provider
+// gave back.
+func (service *LedgerServiceImpl) PostFee(ctx context.Context, tx *gorm.DB, payment domain.Payment, amount int64) error {
+	if amount == 0 {
+		return nil
+	}
+
+	if amount < 0 {
+		return service.post(ctx, tx, payment, "fee_returned", "provider fee returned", []domain.JournalLine{
+			{AccountCode: domain.AccountProviderClearing, Debit: -amount},
+			{AccountCode: domain.AccountFees, Credit: -amount},
+		})
+	}
+
+	return service.post(ctx, tx, payment, "fee", "provider fee", []domain.JournalLine{
+		{AccountCode: domain.AccountFees, Debit: amount},
+		{AccountCode: domain.AccountProviderClearing, Credit: amount},
+	})
+}
+
+// post books the lines in the payment's own currency, which is what Amount and the fees are in.
+func (service *LedgerServiceImpl) post(ctx context.Context, tx *gorm.DB, payment domain.Payment, entryType string, description string, lines []domain.JournalLine) error {
+	if err := validateJournalLines(lines); err != nil {
+		return err
+	}
+
+	currency := payment.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	paymentId := payment.ID
+	entry := domain.JournalEntry{
+		ID:          uuid.New(),
+		PaymentID:   &paymentId,
+		Type:        entryType,
+		Description: description,
+		Lines:       lines,
+	}
+	for i := range entry.Lines {
+		entry.Lines[i].ID = uuid.New()
+		entry.Lines[i].EntryID = entry.ID
+		entry.Lines[i].Currency = currency
+	}
+
+	_, err := service.LedgerRepository.SaveEntry(ctx, tx, entry)
+	return err
+}
+
+func validateJournalLines(lines []domain.JournalLine) error {
+	if len(lines) < 2 {
+		return errors.New("journal entry needs at least two lines")
+	}
+
+	var debit, credit int64
+	for _, line := range lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return errors.New("journal line amounts must not be negative")
+		}
+		if (line.Debit == 0) == (line.Credit == 0) {
+			return errors.New("journal line must be either a debit or a credit")
+		}
+		debit += line.Debit
+		credit += line.Credit
+	}
+
+	if debit != credit {
+		return fmt.Errorf("journal entry is unbalanced: debit %d, credit %d", debit, credit)
+	}
+
+	return nil
+}
+
+func (service *LedgerServiceImpl) FindBalances(ctx context.Context) ([]web.LedgerBalanceResponse, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	accounts, err := service.LedgerRepository.FindAccounts(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := service.LedgerRepository.SumByAccount(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	totalsByAccount := map[string][]repository.AccountTotals{}
+	for _, total := range totals {
+		totalsByAccount[total.AccountCode] = append(totalsByAccount[total.AccountCode], total)
+	}
+
+	balances := []web.LedgerBalanceResponse{}
+	for _, account := range accounts {
+		balances = append(balances, toLedgerBalanceResponse(account, totalsByAccount[account.Code]))
+	}
+
+	return balances, nil
+}
+
+func (service *LedgerServiceImpl) FindBalance(ctx context.Context, accountCode string) (web.LedgerBalanceResponse, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	account, err := service.LedgerRepository.FindAccountByCode(ctx, tx, accountCode)
+	if err != nil {
+		return web.LedgerBalanceResponse{}, err
+	}
+
+	totals, err := service.LedgerRepository.SumByAccount(ctx, tx)
+	if err != nil {
+		return web.LedgerBalanceResponse{}, err
+	}
+
+	accountTotals := []repository.AccountTotals{}
+	for _, total := range totals {
+		if total.AccountCode == account.Code {
+			accountTotals = append(accountTotals, total)
+		}
+	}
+
+	return toLedgerBalanceResponse(account, accountTotals), nil
+}
+
+// toLedgerBalanceResponse turns an account's per-currency totals into balances on the side the
+// account normally carries.
+func toLedgerBalanceResponse(account domain.LedgerAccount, totals []repository.AccountTotals) web.LedgerBalanceResponse {
+	response := web.LedgerBalanceResponse{
+		AccountCode:   account.Code,
+		Name:          account.Name,
+		NormalBalance: account.NormalBalance,
+		Balances:      []web.LedgerCurrencyBalance{},
+	}
+	for _, total := range totals {
+		balance := total.Debit - total.Credit
+		if account.NormalBalance == "credit" {
+			balance = total.Credit - total.Debit
+		}
+		response.Balances = append(response.Balances, web.LedgerCurrencyBalance{
+			Currency: total.Currency,
+			Debit:    total.Debit,
+			Credit:   total.Credit,
+			Balance:  balance,
+		})
+	}
+
+	return response
+}
+
+func (service *LedgerServiceImpl) FindEntriesByPaymentId(ctx context.Context, paymentId string) ([]domain.JournalEntry, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.LedgerRepository.FindEntriesByPaymentId(ctx, tx, paymentId)
+}
+
+// CheckInvariants verifies that the ledger balances in every currency and that every single entry
+// does.
+func (service *LedgerServiceImpl) CheckInvariants(ctx context.Context) (web.LedgerInvariantResponse, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	totals, err := service.LedgerRepository.SumByAccount(ctx, tx)
+	if err != nil {
+		return web.LedgerInvariantResponse{}, err
+	}
+
+	unbalanced, err := service.LedgerRepository.FindUnbalancedEntryIds(ctx, tx)
+	if err != nil {
+		return web.LedgerInvariantResponse{}, err
+	}
+
+	result := web.LedgerInvariantResponse{Totals: []web.LedgerCurrencyTotals{}, UnbalancedEntries: []string{}}
+	byCurrency := map[string]int{}
+	for _, total := range totals {
+		i, ok := byCurrency[total.Currency]
+		if !ok {
+			i = len(result.Totals)
+			byCurrency[total.Currency] = i
+			result.Totals = append(result.Totals, web.LedgerCurrencyTotals{Currency: total.Currency})
+		}
+		result.Totals[i].TotalDebit += total.Debit
+		result.Totals[i].TotalCredit += total.Credit
+	}
+	result.UnbalancedEntries = append(result.UnbalancedEntries, unbalanced...)
+	result.Balanced = len(unbalanced) == 0
+	for _, total := range result.Totals {
+		if total.TotalDebit != total.TotalCredit {
+			result.Balanced = false
+		}
+	}
+
+	return result, nil
+}
@@ -9,10 +9,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"payment-service/models/domain"
 	"payment-service/models/web"
+	"payment-service/repository"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // httpClient is used to perform HTTP requests. It is a variable so tests can substitute it.
@@ -67,51 +71,132 @@ func SendPaymentCallback(ctx context.Context, callbackURL string, payload web.Pa
 	return nil
 }
 
+// deliverCallback sends a status callback and records the delivery attempt on the payment's
+// timeline. The attempt is recorded whether or not order-service accepted the callback.
+func deliverCallback(ctx context.Context, tx *gorm.DB, paymentEventRepository repository.PaymentEventRepository, callbackURL string, payload web.PaymentCallbackRequest) error {
+	err := SendPaymentCallback(ctx, callbackURL, payload)
+
+	attempt := domain.CallbackAttempt{
+		ID:            uuid.New(),
+		PaymentID:     payload.PaymentID,
+		URL:           callbackURL,
+		PaymentStatus: payload.PaymentStatus,
+		Succeeded:     err == nil,
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if _, saveErr := paymentEventRepository.SaveCallbackAttempt(ctx, tx, attempt); saveErr != nil {
+		fmt.Printf("Warning: recording callback attempt failed: %v", saveErr)
+	}
+
+	return err
+}
+
 func (service *PaymentServiceImpl) getCallbackURL() string {
 	url := os.Getenv("ORDER_CALLBACK_URL")
 	return url
 }
 
+func (service *DunningServiceImpl) getCallbackURL() string {
+	url := os.Getenv("ORDER_CALLBACK_URL")
+	return url
+}
+
 // getOrderServiceURL returns the order service base URL
-func (service *PaymentServiceImpl) getOrderServiceURL() string {
+func getOrderServiceURL() string {
 	url := os.Getenv("ORDER_SERVICE_URL")
 	return url
 }
 
-// fetchOrderAmount fetches order details from order service and validates amount
-func (service *PaymentServiceImpl) fetchOrderAmount(ctx context.Context, orderID uuid.UUID) (int64, error) {
-	url := fmt.Sprintf("%s/orders/%s", service.getOrderServiceURL(), orderID.String())
+// fetchOrderAmount fetches the order total and its currency from order service. Orders created
+// before currencies existed are priced in defaultCurrency.
+func fetchOrderAmount(ctx context.Context, orderID uuid.UUID) (int64, string, error) {
+	url := fmt.Sprintf("%s/orders/%s", getOrderServiceURL(), orderID.String())
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return 0, err
+		return 0, "", err
 	}
 
 	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch order: %w", err)
+		return 0, "", fmt.Errorf("failed to fetch order: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return 0, errors.New("order not found")
+		return 0, "", errors.New("order not found")
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("order service returned status %d", resp.StatusCode)
+		return 0, "", fmt.Errorf("order service returned status %d", resp.StatusCode)
 	}
 
 	var result struct {
 		Code int `json:"code"`
 		Data struct {
-			TotalAmount int64 `json:"total_amount"`
+			TotalAmount int64  `json:"total_amount"`
+			Currency    string `json:"currency"`
 		} `json:"data"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("failed to decode order response: %w", err)
+		return 0, "", fmt.Errorf("failed to decode order response: %w", err)
+	}
+
+	currency := strings.ToUpper(result.Data.Currency)
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	return result.Data.TotalAmount, currency, nil
+}
+
+// createSubscriptionOrder creates a single-item order in order service for a subscription charge
+// and returns its id. The field names follow order service's create request.
+func createSubscriptionOrder(ctx context.Context, itemName string, amount int64, currency string, subscriptionID uuid.UUID) (uuid.UUID, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"ItemName":       itemName,
+		"Quantity":       1,
+		"Price":          amount,
+		"Currency":       currency,
+		"SubscriptionID": subscriptionID.String(),
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, getOrderServiceURL()+"/orders", bytes.NewReader(body))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create order: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return uuid.Nil, fmt.Errorf("order service returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			ID uuid.UUID `json:"id"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to decode order response: %w", err)
+	}
+	if result.Data.ID == uuid.Nil {
+		return uuid.Nil, errors.New("order service returned no order id")
 	}
 
-	return result.Data.TotalAmount, nil
+	return result.Data.ID, nil
 }
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ConfirmDelivery records the courier's report for a cash-on-delivery payment. Cash collected in
+// full settles the payment; a failed delivery or a short or excess collection cancels it, and
+// order-service cancels the order in turn.
+func (service *PaymentServiceImpl) ConfirmDelivery(ctx context.Context, paymentId string, request web.DeliveryConfirmationRequest) (_ domain.Payment, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Payment{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	payment, err := service.PaymentRepository.FindByIdForUpdate(ctx, tx, paymentId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Payment{}, exception.NotFoundError{Message: "payment not found"}
+	}
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if payment.Status != "awaiting_collection" {
+		return domain.Payment{}, errors.New("payment is not awaiting cash collection")
+	}
+
+	now := time.Now()
+	payment.Collection = &domain.CODCollection{
+		Outcome:         request.Outcome,
+		CollectedAmount: request.CollectedAmount,
+		CollectedBy:     request.CollectedBy,
+		Note:            request.Note,
+		ConfirmedAt:     &now,
+	}
+
+	if request.Outcome == domain.DeliveryDelivered && request.CollectedAmount == payment.Amount {
+		return service.settle(ctx, tx, payment, now)
+	}
+
+	payment.Status = "cancelled"
+	updated, err := service.PaymentRepository.UpdateStatus(ctx, tx, payment)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if err := service.unwindSplitTender(ctx, tx, updated); err != nil {
+		return domain.Payment{}, err
+	}
+
+	callbackPayload := web.PaymentCallbackRequest{
+		OrderID:       updated.OrderID,
+		PaymentID:     updated.ID,
+		PaymentStatus: "cancelled",
+	}
+
+	if err := deliverCallback(ctx, tx, service.PaymentEventRepository, service.getCallbackURL(), callbackPayload); err != nil {
+		fmt.Printf("Warning: cancelled payment callback to order service failed: %v", err)
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// checkInstallment validates a payment against the order's installment plan and returns the
+// installment it pays. It returns nil for orders without a plan, which are paid in full.
+func (service *PaymentServiceImpl) checkInstallment(ctx context.Context, tx *gorm.DB, request web.PaymentCreateRequest, currency string) (*domain.Installment, error) {
+	plan, err := service.InstallmentRepository.FindByOrderId(ctx, tx, request.OrderID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if request.InstallmentNumber > 0 {
+			return nil, errors.New("order has no installment plan")
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if request.InstallmentNumber == 0 {
+		return nil, errors.New("order is paid in installments, pass installment_number")
+	}
+	if plan.Status != domain.InstallmentPlanActive {
+		return nil, fmt.Errorf("installment plan is already %s", plan.Status)
+	}
+	if request.InstallmentNumber > len(plan.Installments) {
+		return nil, fmt.Errorf("installment plan has only %d installments", len(plan.Installments))
+	}
+
+	// Installments are paid in order, so an earlier one must be settled first.
+	for _, earlier := range plan.Installments[:request.InstallmentNumber-1] {
+		if earlier.Status != domain.InstallmentPaid {
+			return nil, fmt.Errorf("installment %d must be paid first", earlier.Number)
+		}
+	}
+
+	installment := plan.Installments[request.InstallmentNumber-1]
+	if installment.Status == domain.InstallmentPaid {
+		return nil, fmt.Errorf("installment %d is already paid", installment.Number)
+	}
+	if currency != plan.Currency {
+		return nil, fmt.Errorf("installments are paid in %s", plan.Currency)
+	}
+	if request.Amount != installment.Amount {
+		return nil, fmt.Errorf("payment amount %d does not match installment %d amount %d", request.Amount, installment.Number, installment.Amount)
+	}
+
+	return &installment, nil
+}
+
+// settleInstallment marks the installment a successful payment paid for, and completes the plan
+// when it was the last one. It reports whether the order is now paid in full.
+func (service *PaymentServiceImpl) settleInstallment(ctx context.Context, tx *gorm.DB, payment domain.Payment, paidAt time.Time) (bool, error) {
+	plan, err := service.InstallmentRepository.FindByOrderId(ctx, tx, payment.OrderID.String())
+	if err != nil {
+		return false, err
+	}
+	if payment.InstallmentNumber > len(plan.Installments) {
+		return false, fmt.Errorf("installment plan has no installment %d", payment.InstallmentNumber)
+	}
+
+	installment := plan.Installments[payment.InstallmentNumber-1]
+	installment.Status = domain.InstallmentPaid
+	installment.PaymentID = &payment.ID
+	installment.FailureReason = ""
+	installment.PaidAt = &paidAt
+	if _, err := service.InstallmentRepository.UpdateInstallment(ctx, tx, installment); err != nil {
+		return false, err
+	}
+
+	plan.PaidAmount += payment.Amount
+	plan.Installments[payment.InstallmentNumber-1] = installment
+	completed := true
+	for _, other := range plan.Installments {
+		if other.Status != domain.InstallmentPaid {
+			completed = false
+			break
+		}
+	}
+	if completed {
+		plan.Status = domain.InstallmentPlanCompleted
+		plan.CompletedAt = &paidAt
+	}
+
+	if _, err := service.InstallmentRepository.Update(ctx, tx, plan); err != nil {
+		return false, err
+	}
+
+	return completed, nil
+}
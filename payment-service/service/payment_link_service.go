@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+)
+
+var (
+	ErrPaymentLinkExpired = errors.New("payment link has expired")
+	ErrPaymentLinkUsed    = errors.New("payment link has already been used")
+)
+
+type PaymentLinkService interface {
+	Create(ctx context.Context, request web.PaymentLinkCreateRequest) (web.PaymentLinkResponse, error)
+	FindById(ctx context.Context, linkId string) (web.PaymentLinkResponse, error)
+	Checkout(ctx context.Context, token string) (web.CheckoutPage, error)
+	Pay(ctx context.Context, token string, request web.CheckoutRequest, ipAddress string) (domain.Payment, error)
+	FindResult(ctx context.Context, token string, paymentId string) (domain.Payment, error)
+}
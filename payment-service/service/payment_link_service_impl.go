@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"slices"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentLinkServiceImpl struct {
+	PaymentLinkRepository repository.PaymentLinkRepository
+	PaymentService        PaymentService
+	Config                config.PaymentLinkConfig
+	DB                    *gorm.DB
+	Validate              *validator.Validate
+}
+
+func NewPaymentLinkService(paymentLinkRepository repository.PaymentLinkRepository, paymentService PaymentService, linkConfig config.PaymentLinkConfig, DB *gorm.DB, validate *validator.Validate) PaymentLinkService {
+	return &PaymentLinkServiceImpl{
+		PaymentLinkRepository: paymentLinkRepository,
+		PaymentService:        paymentService,
+		Config:                linkConfig,
+		DB:                    DB,
+		Validate:              validate,
+	}
+}
+
+// Create issues a checkout link for an existing order.
+func (service *PaymentLinkServiceImpl) Create(ctx context.Context, request web.PaymentLinkCreateRequest) (web.PaymentLinkResponse, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return web.PaymentLinkResponse{}, err
+	}
+
+	if _, _, err := fetchOrderAmount(ctx, request.OrderID); err != nil {
+		return web.PaymentLinkResponse{}, err
+	}
+
+	providers := request.Providers
+	if len(providers) == 0 {
+		providers = service.Config.Providers
+	}
+	if len(providers) == 0 {
+		return web.PaymentLinkResponse{}, errors.New("no providers configured for payment links")
+	}
+
+	ttl := service.Config.DefaultTTL
+	if request.ExpiresInMinutes > 0 {
+		ttl = time.Duration(request.ExpiresInMinutes) * time.Minute
+	}
+
+	token, err := newLinkToken()
+	if err != nil {
+		return web.PaymentLinkResponse{}, err
+	}
+
+	link, err := service.PaymentLinkRepository.Save(ctx, service.DB, domain.PaymentLink{
+		ID:         uuid.New(),
+		OrderID:    request.OrderID,
+		Token:      token,
+		CustomerID: request.CustomerID,
+		Providers:  providers,
+		OneTime:    request.OneTime,
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+	if err != nil {
+		return web.PaymentLinkResponse{}, err
+	}
+
+	return helper.ToPaymentLinkResponse(link, service.linkURL(link), time.Now()), nil
+}
+
+func (service *PaymentLinkServiceImpl) FindById(ctx context.Context, linkId string) (web.PaymentLinkResponse, error) {
+	link, err := service.PaymentLinkRepository.FindById(ctx, service.DB, linkId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return web.PaymentLinkResponse{}, exception.NotFoundError{Message: "payment link not found"}
+	}
+	if err != nil {
+		return web.PaymentLinkResponse{}, err
+	}
+
+	return helper.ToPaymentLinkResponse(link, service.linkURL(link), time.Now()), nil
+}
+
+// Checkout loads what the checkout page shows. The order total is fetched fresh so the page
+// never shows a stale amount.
+func (service *PaymentLinkServiceImpl) Checkout(ctx context.Context, token string) (web.CheckoutPage, error) {
+	link, err := service.usableLink(ctx, token)
+	if err != nil {
+		return web.CheckoutPage{}, err
+	}
+
+	amount, currency, err := fetchOrderAmount(ctx, link.OrderID)
+	if err != nil {
+		return web.CheckoutPage{}, err
+	}
+
+	return web.CheckoutPage{
+		Token:     token,
+		OrderID:   link.OrderID,
+		Amount:    amount,
+		Currency:  currency,
+		Display:   helper.FormatAmount(amount, currency),
+		Providers: link.Providers,
+		ExpiresAt: link.ExpiresAt,
+	}, nil
+}
+
+// Pay creates and captures the payment chosen on the checkout page. A one-time link is claimed
+// before the payment is created and released again if creation fails.
+func (service *PaymentLinkServiceImpl) Pay(ctx context.Context, token string, request web.CheckoutRequest, ipAddress string) (domain.Payment, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Payment{}, err
+	}
+
+	link, err := service.usableLink(ctx, token)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if !slices.Contains(link.Providers, request.Provider) {
+		return domain.Payment{}, fmt.Errorf("provider %s is not offered by this payment link", request.Provider)
+	}
+
+	amount, currency, err := fetchOrderAmount(ctx, link.OrderID)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	claimed, err := service.PaymentLinkRepository.Claim(ctx, service.DB, link.ID, time.Now())
+	if err != nil {
+		return domain.Payment{}, err
+	}
+	if !claimed {
+		return domain.Payment{}, ErrPaymentLinkUsed
+	}
+
+	ctx = domain.WithEventOrigin(ctx, domain.EventOrigin{Source: domain.EventSourceAPI, Actor: "payment-link:" + link.ID.String()})
+	payment, err := service.PaymentService.Create(ctx, web.PaymentCreateRequest{
+		OrderID:    link.OrderID,
+		Amount:     amount,
+		Currency:   currency,
+		Provider:   request.Provider,
+		CustomerID: link.CustomerID,
+		IPAddress:  ipAddress,
+	})
+	if err != nil {
+		if releaseErr := service.PaymentLinkRepository.Release(ctx, service.DB, link.ID); releaseErr != nil {
+			fmt.Printf("Warning: releasing payment link %s failed: %v", link.ID, releaseErr)
+		}
+		return domain.Payment{}, err
+	}
+
+	if err := service.PaymentLinkRepository.SetPayment(ctx, service.DB, link.ID, payment.ID); err != nil {
+		return domain.Payment{}, err
+	}
+
+	if !payment.AwaitsCapture() {
+		return payment, nil
+	}
+
+	return service.PaymentService.MarkAsSuccess(ctx, payment.ID.String())
+}
+
+// FindResult returns a payment made through the link. Payments of other orders are reported as
+// missing so the result page cannot be used to look them up.
+func (service *PaymentLinkServiceImpl) FindResult(ctx context.Context, token string, paymentId string) (domain.Payment, error) {
+	link, err := service.PaymentLinkRepository.FindByToken(ctx, service.DB, token)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Payment{}, exception.NotFoundError{Message: "payment link not found"}
+	}
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	payment, err := service.PaymentService.FindById(ctx, paymentId)
+	if err != nil || payment.OrderID != link.OrderID {
+		return domain.Payment{}, exception.NotFoundError{Message: "payment not found"}
+	}
+
+	return payment, nil
+}
+
+func (service *PaymentLinkServiceImpl) usableLink(ctx context.Context, token string) (domain.PaymentLink, error) {
+	link, err := service.PaymentLinkRepository.FindByToken(ctx, service.DB, token)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.PaymentLink{}, exception.NotFoundError{Message: "payment link not found"}
+	}
+	if err != nil {
+		return domain.PaymentLink{}, err
+	}
+
+	switch link.Status(time.Now()) {
+	case domain.PaymentLinkExpired:
+		return domain.PaymentLink{}, ErrPaymentLinkExpired
+	case domain.PaymentLinkUsed:
+		return domain.PaymentLink{}, ErrPaymentLinkUsed
+	}
+
+	return link, nil
+}
+
+func (service *PaymentLinkServiceImpl) linkURL(link domain.PaymentLink) string {
+	return service.Config.BaseURL + "/pay/" + link.Token
+}
+
+// newLinkToken returns an unguessable URL-safe token.
+func newLinkToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
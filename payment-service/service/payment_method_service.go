@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+
+	"gorm.io/gorm"
+)
+
+type PaymentMethodService interface {
+	Create(ctx context.Context, request web.PaymentMethodCreateRequest) (web.PaymentMethodResponse, error)
+	Update(ctx context.Context, customerId string, methodId string, request web.PaymentMethodUpdateRequest) (web.PaymentMethodResponse, error)
+	Delete(ctx context.Context, customerId string, methodId string) error
+	FindById(ctx context.Context, customerId string, methodId string) (web.PaymentMethodResponse, error)
+	FindAllByCustomerId(ctx context.Context, customerId string) ([]web.PaymentMethodResponse, error)
+	Resolve(ctx context.Context, tx *gorm.DB, customerId string, methodId string) (domain.PaymentMethod, error)
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errPaymentMethodNotFound is shared by missing methods and methods of another customer so
+// callers cannot probe for foreign ids.
+var errPaymentMethodNotFound = exception.NotFoundError{Message: "payment method not found"}
+
+type PaymentMethodServiceImpl struct {
+	PaymentMethodRepository repository.PaymentMethodRepository
+	Vault                   *helper.Vault
+	DB                      *gorm.DB
+	Validate                *validator.Validate
+}
+
+func NewPaymentMethodService(paymentMethodRepository repository.PaymentMethodRepository, vault *helper.Vault, DB *gorm.DB, validate *validator.Validate) PaymentMethodService {
+	return &PaymentMethodServiceImpl{
+		PaymentMethodRepository: paymentMethodRepository,
+		Vault:                   vault,
+		DB:                      DB,
+		Validate:                validate,
+	}
+}
+
+func (service *PaymentMethodServiceImpl) Create(ctx context.Context, request web.PaymentMethodCreateRequest) (_ web.PaymentMethodResponse, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	if err := rejectPAN(map[string]string{
+		"customer_id":    request.CustomerID,
+		"provider":       request.Provider,
+		"provider_token": request.ProviderToken,
+		"brand":          request.Brand,
+	}); err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	details := domain.CardDetails{
+		Brand:    request.Brand,
+		Last4:    request.Last4,
+		ExpMonth: request.ExpMonth,
+		ExpYear:  request.ExpYear,
+	}
+
+	method := domain.PaymentMethod{
+		ID:         uuid.New(),
+		CustomerID: request.CustomerID,
+		Provider:   request.Provider,
+	}
+	if err := service.seal(&method, request.ProviderToken, details); err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	saved, err := service.PaymentMethodRepository.Save(ctx, tx, method)
+	if err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	return helper.ToPaymentMethodResponse(saved, details), nil
+}
+
+func (service *PaymentMethodServiceImpl) Update(ctx context.Context, customerId string, methodId string, request web.PaymentMethodUpdateRequest) (_ web.PaymentMethodResponse, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	if err := rejectPAN(map[string]string{"provider_token": request.ProviderToken}); err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	method, err := service.Resolve(ctx, tx, customerId, methodId)
+	if err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	token, details, err := service.open(method)
+	if err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	if request.ProviderToken != "" {
+		token = request.ProviderToken
+	}
+	details.ExpMonth = request.ExpMonth
+	details.ExpYear = request.ExpYear
+
+	if err := service.seal(&method, token, details); err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	updated, err := service.PaymentMethodRepository.Update(ctx, tx, method)
+	if err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	return helper.ToPaymentMethodResponse(updated, details), nil
+}
+
+func (service *PaymentMethodServiceImpl) Delete(ctx context.Context, customerId string, methodId string) (err error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	method, err := service.Resolve(ctx, tx, customerId, methodId)
+	if err != nil {
+		return err
+	}
+
+	return service.PaymentMethodRepository.Delete(ctx, tx, method)
+}
+
+func (service *PaymentMethodServiceImpl) FindById(ctx context.Context, customerId string, methodId string) (web.PaymentMethodResponse, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	method, err := service.Resolve(ctx, tx, customerId, methodId)
+	if err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	_, details, err := service.open(method)
+	if err != nil {
+		return web.PaymentMethodResponse{}, err
+	}
+
+	return helper.ToPaymentMethodResponse(method, details), nil
+}
+
+func (service *PaymentMethodServiceImpl) FindAllByCustomerId(ctx context.Context, customerId string) ([]web.PaymentMethodResponse, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	methods, err := service.PaymentMethodRepository.FindAllByCustomerId(ctx, tx, customerId)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []web.PaymentMethodResponse{}
+	for _, method := range methods {
+		_, details, err := service.open(method)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, helper.ToPaymentMethodResponse(method, details))
+	}
+
+	return responses, nil
+}
+
+// Resolve loads a payment method and checks that it belongs to the customer.
+func (service *PaymentMethodServiceImpl) Resolve(ctx context.Context, tx *gorm.DB, customerId string, methodId string) (domain.PaymentMethod, error) {
+	method, err := service.PaymentMethodRepository.FindById(ctx, tx, methodId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.PaymentMethod{}, errPaymentMethodNotFound
+	}
+	if err != nil {
+		return domain.PaymentMethod{}, err
+	}
+
+	if customerId == "" || method.CustomerID != customerId {
+		return domain.PaymentMethod{}, errPaymentMethodNotFound
+	}
+
+	return method, nil
+}
+
+func (service *PaymentMethodServiceImpl) seal(method *domain.PaymentMethod, token string, details domain.CardDetails) error {
+	encryptedToken, err := service.Vault.Encrypt(token)
+	if err != nil {
+		return err
+	}
+
+	serialized, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	encryptedDetails, err := service.Vault.Encrypt(string(serialized))
+	if err != nil {
+		return err
+	}
+
+	method.EncryptedToken = encryptedToken
+	method.EncryptedDetails = encryptedDetails
+	return nil
+}
+
+func (service *PaymentMethodServiceImpl) open(method domain.PaymentMethod) (string, domain.CardDetails, error) {
+	token, err := service.Vault.Decrypt(method.EncryptedToken)
+	if err != nil {
+		return "", domain.CardDetails{}, fmt.Errorf("decrypt payment method %s: %w", method.ID, err)
+	}
+
+	serialized, err := service.Vault.Decrypt(method.EncryptedDetails)
+	if err != nil {
+		return "", domain.CardDetails{}, fmt.Errorf("decrypt payment method %s: %w", method.ID, err)
+	}
+
+	var details domain.CardDetails
+	if err := json.Unmarshal([]byte(serialized), &details); err != nil {
+		return "", domain.CardDetails{}, err
+	}
+
+	return token, details, nil
+}
+
+// rejectPAN refuses input that carries a raw card number. The error names the field only so
+// the number never reaches a log line or response body.
+func rejectPAN(fields map[string]string) error {
+	for name, value := range fields {
+		if helper.ContainsPAN(value) {
+			return fmt.Errorf("%s must not contain a card number; tokenize the card with the provider first", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSearchLimit = 20
+	exportBatchSize    = 500
+)
+
+// Search returns one page of payments and the cursor of the next page, if any.
+func (service *PaymentServiceImpl) Search(ctx context.Context, request web.PaymentSearchRequest) ([]domain.Payment, string, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return nil, "", err
+	}
+
+	filter, err := toPaymentFilter(request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	// Fetch one extra row to learn whether another page exists.
+	filter.Limit = limit + 1
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	payments, err := service.PaymentRepository.FindAll(ctx, tx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(payments) <= limit {
+		return payments, "", nil
+	}
+
+	payments = payments[:limit]
+	return payments, encodePaymentCursor(filter.SortBy, payments[limit-1]), nil
+}
+
+// Export validates the request and returns a function that walks every matching payment in
+// batches, handing each one to write. Validation happens up front so callers can still reject
+// the request before they start streaming. The request cursor and limit are ignored so the
+// export always covers the whole result set.
+func (service *PaymentServiceImpl) Export(ctx context.Context, request web.PaymentSearchRequest) (func(write func(domain.Payment) error) error, error) {
+	request.Cursor = ""
+	request.Limit = 0
+	if err := service.Validate.Struct(request); err != nil {
+		return nil, err
+	}
+
+	filter, err := toPaymentFilter(request)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = exportBatchSize
+
+	return func(write func(domain.Payment) error) error {
+		for {
+			payments, err := service.PaymentRepository.FindAll(ctx, service.DB, filter)
+			if err != nil {
+				return err
+			}
+
+			for _, payment := range payments {
+				if err := write(payment); err != nil {
+					return err
+				}
+			}
+
+			if len(payments) < exportBatchSize {
+				return nil
+			}
+
+			last := payments[len(payments)-1]
+			filter.After = &repository.PaymentCursor{SortValue: paymentSortValue(filter.SortBy, last), ID: last.ID}
+		}
+	}, nil
+}
+
+func toPaymentFilter(request web.PaymentSearchRequest) (repository.PaymentFilter, error) {
+	filter := repository.PaymentFilter{
+		Status:     request.Status,
+		Provider:   request.Provider,
+		MinAmount:  request.MinAmount,
+		MaxAmount:  request.MaxAmount,
+		SortBy:     request.SortBy,
+		Descending: request.SortOrder != "asc",
+	}
+
+	if filter.SortBy == "" {
+		filter.SortBy = "created_at"
+	}
+
+	if request.MinAmount > 0 && request.MaxAmount > 0 && request.MinAmount > request.MaxAmount {
+		return filter, errors.New("min_amount must not be greater than max_amount")
+	}
+
+	if request.OrderID != "" {
+		orderId, err := uuid.Parse(request.OrderID)
+		if err != nil {
+			return filter, errors.New("invalid order_id")
+		}
+		filter.OrderID = &orderId
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseSearchTime("created_from", request.CreatedFrom, false); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseSearchTime("created_to", request.CreatedTo, true); err != nil {
+		return filter, err
+	}
+	if filter.PaidFrom, err = parseSearchTime("paid_from", request.PaidFrom, false); err != nil {
+		return filter, err
+	}
+	if filter.PaidTo, err = parseSearchTime("paid_to", request.PaidTo, true); err != nil {
+		return filter, err
+	}
+
+	if request.Cursor != "" {
+		cursor, err := decodePaymentCursor(request.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &cursor
+	}
+
+	return filter, nil
+}
+
+// parseSearchTime accepts RFC3339 timestamps or plain dates. A plain date used as an upper
+// bound covers the whole day.
+func parseSearchTime(field string, value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, nil
+	}
+
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected RFC3339 or YYYY-MM-DD", field)
+	}
+
+	if endOfDay {
+		parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return &parsed, nil
+}
+
+func paymentSortValue(sortBy string, payment domain.Payment) string {
+	if sortBy == "amount" {
+		return strconv.FormatInt(payment.Amount, 10)
+	}
+
+	return payment.CreatedAt.Format(time.RFC3339Nano)
+}
+
+func encodePaymentCursor(sortBy string, payment domain.Payment) string {
+	body, _ := json.Marshal(repository.PaymentCursor{SortValue: paymentSortValue(sortBy, payment), ID: payment.ID})
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func decodePaymentCursor(value string) (repository.PaymentCursor, error) {
+	var cursor repository.PaymentCursor
+
+	body, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	if err := json.Unmarshal(body, &cursor); err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+
+	return cursor, nil
+}
@@ -4,11 +4,27 @@ import (
 	"context"
 	"payment-service/models/domain"
 	"payment-service/models/web"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type PaymentService interface {
 	Create(ctx context.Context, request web.PaymentCreateRequest) (domain.Payment, error)
 	MarkAsSuccess(ctx context.Context, paymentId string) (domain.Payment, error)
+	MarkTransferPaid(ctx context.Context, paymentId string, paidAt time.Time) (domain.Payment, error)
+	MarkQRPaid(ctx context.Context, paymentId string, paidAt time.Time) (domain.Payment, error)
 	MarkAsFailed(ctx context.Context, paymentId string) (domain.Payment, error)
+	ConfirmDelivery(ctx context.Context, paymentId string, request web.DeliveryConfirmationRequest) (domain.Payment, error)
+	ApproveHeld(ctx context.Context, paymentId string, request web.RiskReviewRequest) (domain.Payment, error)
+	RejectHeld(ctx context.Context, paymentId string, request web.RiskReviewRequest) (domain.Payment, error)
+	Refund(ctx context.Context, paymentId string) (domain.Payment, error)
+	RefundPart(ctx context.Context, paymentId string, request web.PartialRefundRequest) (domain.Payment, error)
+	Chargeback(ctx context.Context, paymentId string) (domain.Payment, error)
+	ApplyChargeback(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error)
 	FindById(ctx context.Context, paymentId string) (domain.Payment, error)
+	FindAllByOrderId(ctx context.Context, orderId string) ([]domain.Payment, error)
+	FindEvents(ctx context.Context, paymentId string, includeCallbacks bool) (web.PaymentTimelineResponse, error)
+	Search(ctx context.Context, request web.PaymentSearchRequest) ([]domain.Payment, string, error)
+	Export(ctx context.Context, request web.PaymentSearchRequest) (func(write func(domain.Payment) error) error, error)
 }
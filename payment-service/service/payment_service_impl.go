@@ -6,10 +6,13 @@ import (
 	"errors"
 	"fmt"
 
+	"payment-service/config"
+	"payment-service/exception"
 	"payment-service/helper"
 	"payment-service/models/domain"
 	"payment-service/models/web"
 	"payment-service/repository"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator"
@@ -17,68 +20,425 @@ import (
 	"gorm.io/gorm"
 )
 
+// paymentAttemptTTL is how long a pending attempt stays valid before a new attempt may replace it.
+const paymentAttemptTTL = 30 * time.Minute
+
+// qrMethod marks payments the customer completes by scanning a QR code.
+const qrMethod = "qr"
+
+// bankTransferMethod marks payments the customer completes with a bank transfer.
+const bankTransferMethod = "bank_transfer"
+
+// codMethod marks payments collected in cash by the courier on delivery.
+const codMethod = "cod"
+
+// defaultCurrency prices orders and payments that do not name a currency.
+const defaultCurrency = "IDR"
+
+// PaymentServiceDeps are the collaborators a payment service is built from. The configs of
+// payment methods a deployment does not offer may be left zero.
+type PaymentServiceDeps struct {
+	PaymentRepository      repository.PaymentRepository
+	PaymentEventRepository repository.PaymentEventRepository
+	InstallmentRepository  repository.InstallmentRepository
+	SplitPaymentRepository repository.SplitPaymentRepository
+	LedgerService          LedgerService
+	RiskService            RiskService
+	PaymentMethodService   PaymentMethodService
+	FeeService             FeeService
+	ExchangeRateService    ExchangeRateService
+	BalanceService         BalanceService
+	PayoutService          PayoutService
+	QRConfig               config.QRConfig
+	BankTransferConfig     config.BankTransferConfig
+	CODConfig              config.CODConfig
+	DB                     *gorm.DB
+	Validate               *validator.Validate
+}
+
 type PaymentServiceImpl struct {
-	PaymentRepository repository.PaymentRepository
-	DB                *gorm.DB
-	Validate          *validator.Validate
+	PaymentServiceDeps
 }
 
-func NewPaymentService(paymentRepository repository.PaymentRepository, DB *gorm.DB, validate *validator.Validate) PaymentService {
-	return &PaymentServiceImpl{
-		PaymentRepository: paymentRepository,
-		DB:                DB,
-		Validate:          validate,
-	}
+func NewPaymentService(deps PaymentServiceDeps) PaymentService {
+	return &PaymentServiceImpl{PaymentServiceDeps: deps}
 }
 
 func (service *PaymentServiceImpl) Create(ctx context.Context, request web.PaymentCreateRequest) (domain.Payment, error) {
-	if err := service.Validate.Struct(request); err != nil {
+	payment, assessment, err := service.create(ctx, request)
+	if err != nil {
 		return domain.Payment{}, err
 	}
 
-	// Fetch order and validate amount
-	orderTotalAmount, err := service.fetchOrderAmount(ctx, request.OrderID)
+	// A denied attempt is kept for the audit trail but never reaches the provider.
+	if payment.Status == "denied" {
+		return domain.Payment{}, fmt.Errorf("payment denied by risk screening: %s", strings.Join(assessment.FiredRules, ", "))
+	}
+
+	return payment, nil
+}
+
+// create saves the attempt together with its risk assessment. A denied attempt is committed like
+// any other, so Create can report the denial once the transaction has ended.
+func (service *PaymentServiceImpl) create(ctx context.Context, request web.PaymentCreateRequest) (_ domain.Payment, _ domain.RiskAssessment, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Payment{}, domain.RiskAssessment{}, err
+	}
+
+	if request.Method == qrMethod && !service.QRConfig.Supports(request.Provider) {
+		return domain.Payment{}, domain.RiskAssessment{}, fmt.Errorf("provider %s does not support QR payments", request.Provider)
+	}
+	if request.Method == bankTransferMethod && service.BankTransferConfig.Mode(request.Provider) == "" {
+		return domain.Payment{}, domain.RiskAssessment{}, fmt.Errorf("provider %s does not support bank transfer payments", request.Provider)
+	}
+
+	// Fetch what the payment covers, the order or a wallet top-up, and validate amount
+	orderTotalAmount, orderCurrency, err := service.fetchPayableAmount(ctx, request)
 	if err != nil {
-		return domain.Payment{}, err
+		return domain.Payment{}, domain.RiskAssessment{}, err
 	}
 
-	// Validate that payment amount matches order total amount
-	if request.Amount != orderTotalAmount {
-		return domain.Payment{}, fmt.Errorf("payment amount %d does not match order total amount %d", request.Amount, orderTotalAmount)
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = orderCurrency
 	}
 
-	// Validate if payment already exists for the order
-	existingPayment, err := service.PaymentRepository.FindOrderById(ctx, service.DB, request.OrderID.String())
-	if err == nil && existingPayment.ID != uuid.Nil {
-		fmt.Printf("Payment already exists for order %s: returning payment %s",
-			request.OrderID.String(), existingPayment.ID.String())
-		return existingPayment, nil
+	installment, err := service.checkInstallment(ctx, service.DB, request, currency)
+	if err != nil {
+		return domain.Payment{}, domain.RiskAssessment{}, err
+	}
+
+	// A payment in another currency must match the order total converted at the current rate.
+	var conversion *domain.CurrencyConversion
+	switch {
+	case installment != nil:
+		// The installment's own amount and currency were checked above.
+	case request.SplitTender:
+		// Tenders are checked against what is left of the order total below.
+	case currency != orderCurrency:
+		converted, err := service.ExchangeRateService.Convert(ctx, orderTotalAmount, orderCurrency, currency, time.Now())
+		if err != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+		if request.Amount != converted.Amount {
+			return domain.Payment{}, domain.RiskAssessment{}, fmt.Errorf("payment amount %d %s does not match order total amount %d %s converted at rate %s (%d %s)",
+				request.Amount, currency, orderTotalAmount, orderCurrency, converted.Rate.Rate, converted.Amount, currency)
+		}
+
+		conversion = &domain.CurrencyConversion{
+			OrderAmount:    orderTotalAmount,
+			OrderCurrency:  orderCurrency,
+			ExchangeRateID: &converted.Rate.ID,
+			Rate:           converted.Rate.Rate,
+			Inverted:       converted.Inverted,
+			Rounding:       domain.RoundingHalfUp,
+		}
+	case request.Amount != orderTotalAmount:
+		// Validate that payment amount matches order total amount
+		return domain.Payment{}, domain.RiskAssessment{}, fmt.Errorf("payment amount %d does not match order total amount %d", request.Amount, orderTotalAmount)
 	}
 
 	tx := service.DB.Begin()
-	defer helper.CommitOrRollback(tx)
+	defer helper.CommitOrRollback(tx, &err)
+
+	split, err := service.checkSplitTender(ctx, tx, request, currency)
+	if err != nil {
+		return domain.Payment{}, domain.RiskAssessment{}, err
+	}
+
+	// Only one pending or successful attempt may exist per order, or per installment, at a time.
+	// A pending attempt past its expiry no longer blocks a retry. Tenders share the order, and
+	// checkSplitTender already made room for this one.
+	var activePayment domain.Payment
+	tenderNumber := 0
+	switch {
+	case split != nil:
+		tenderNumber = len(split.Tenders) + 1
+	case installment != nil:
+		activePayment, err = service.PaymentRepository.FindActiveByInstallment(ctx, tx, request.OrderID.String(), installment.Number)
+	default:
+		activePayment, err = service.PaymentRepository.FindActiveByOrderId(ctx, tx, request.OrderID.String())
+	}
+	if err == nil && activePayment.ID != uuid.Nil {
+		if !isPaymentExpired(activePayment) {
+			return domain.Payment{}, domain.RiskAssessment{}, activePaymentConflict(activePayment)
+		}
+
+		activePayment.Status = "expired"
+		if _, err := service.PaymentRepository.UpdateStatus(ctx, tx, activePayment); err != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+	}
+
+	attempts, err := service.PaymentRepository.FindAllByOrderId(ctx, tx, request.OrderID.String())
+	if err != nil {
+		return domain.Payment{}, domain.RiskAssessment{}, err
+	}
+
+	method := request.Method
+	var paymentMethodId *uuid.UUID
+	if request.PaymentMethodID != "" {
+		savedMethod, err := service.PaymentMethodService.Resolve(ctx, tx, request.CustomerID, request.PaymentMethodID)
+		if err != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+		if savedMethod.Provider != request.Provider {
+			return domain.Payment{}, domain.RiskAssessment{}, fmt.Errorf("payment method belongs to provider %s, not %s", savedMethod.Provider, request.Provider)
+		}
+		paymentMethodId = &savedMethod.ID
+		if method == "" {
+			method = "card"
+		}
+	}
+
+	var balanceAccountId *uuid.UUID
+	switch {
+	case request.Provider == balanceProvider:
+		account, err := service.BalanceService.Resolve(ctx, tx, request.BalanceCode, request.CustomerID, currency, request.Amount)
+		if err != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+		balanceAccountId = &account.ID
+		method = account.Type
+	case request.Provider == walletProvider:
+		wallet, err := service.BalanceService.ResolveWallet(ctx, tx, request.CustomerID, currency, request.Amount)
+		if err != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+		balanceAccountId = &wallet.ID
+		method = wallet.Type
+	case request.BalanceCode != "":
+		return domain.Payment{}, domain.RiskAssessment{}, fmt.Errorf("balance_code can only be used with provider %s", balanceProvider)
+	}
 
+	expiresAt := time.Now().Add(paymentAttemptTTL)
+	paymentId := uuid.New()
 	payment := domain.Payment{
-		ID:       uuid.New(),
-		OrderID:  request.OrderID,
-		Amount:   request.Amount,
-		Provider: request.Provider,
-		Status:   "pending",
+		ID:                paymentId,
+		OrderID:           request.OrderID,
+		Amount:            request.Amount,
+		Currency:          currency,
+		Provider:          request.Provider,
+		Method:            method,
+		ProviderReference: paymentId.String(),
+		Status:            "pending",
+		Attempt:           len(attempts) + 1,
+		InstallmentNumber: request.InstallmentNumber,
+		TenderNumber:      tenderNumber,
+		CustomerID:        request.CustomerID,
+		IPAddress:         request.IPAddress,
+		Country:           service.RiskService.Locate(request.IPAddress),
+		PaymentMethodID:   paymentMethodId,
+		BalanceAccountID:  balanceAccountId,
+		TopUp:             request.TopUp,
+		Conversion:        conversion,
+		ExpiresAt:         &expiresAt,
+	}
+
+	switch method {
+	case qrMethod:
+		if err := service.issueQR(&payment); err != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+	case bankTransferMethod:
+		if err := service.issueBankTransfer(ctx, tx, &payment); err != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+	}
+
+	// Cash is collected by the courier, so the attempt has no deadline and is only settled once
+	// the delivery is confirmed.
+	if service.CODConfig.Supports(payment.Provider) {
+		payment.Method = codMethod
+		payment.Status = "awaiting_collection"
+		payment.ExpiresAt = nil
+	}
+
+	assessment, err := service.RiskService.Evaluate(ctx, tx, payment)
+	if err != nil {
+		return domain.Payment{}, domain.RiskAssessment{}, err
+	}
+
+	payment.RiskScore = assessment.Score
+	payment.RiskDecision = assessment.Decision
+	switch assessment.Decision {
+	case domain.RiskReview:
+		payment.Status = "held"
+	case domain.RiskDeny:
+		payment.Status = "denied"
 	}
 
 	saved, err := service.PaymentRepository.Save(ctx, tx, payment)
+	if errors.Is(err, repository.ErrActivePaymentExists) {
+		// Lost the race against a concurrent request; report the attempt that won.
+		existing, findErr := service.PaymentRepository.FindActiveByOrderId(ctx, tx, request.OrderID.String())
+		if findErr != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+		return domain.Payment{}, domain.RiskAssessment{}, activePaymentConflict(existing)
+	}
+	if err != nil {
+		return domain.Payment{}, domain.RiskAssessment{}, err
+	}
+
+	if _, err := service.RiskService.Record(ctx, tx, assessment); err != nil {
+		return domain.Payment{}, domain.RiskAssessment{}, err
+	}
+
+	if installment != nil {
+		installment.Attempts++
+		installment.PaymentID = &saved.ID
+		if _, err := service.InstallmentRepository.UpdateInstallment(ctx, tx, *installment); err != nil {
+			return domain.Payment{}, domain.RiskAssessment{}, err
+		}
+	}
+
+	// Balance accounts are held by this service, so there is no gateway to wait for.
+	if saved.Status == "pending" && saved.BalanceAccountID != nil {
+		settled, err := service.settle(ctx, tx, saved, time.Now())
+		return settled, assessment, err
+	}
+
+	return saved, assessment, nil
+}
+
+// ApproveHeld releases a payment held for review so it can be captured. The attempt gets a fresh
+// expiry because the review may have outlasted the original one.
+func (service *PaymentServiceImpl) ApproveHeld(ctx context.Context, paymentId string, request web.RiskReviewRequest) (_ domain.Payment, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Payment{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	payment, err := service.PaymentRepository.FindByIdForUpdate(ctx, tx, paymentId)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if payment.Status != "held" {
+		return domain.Payment{}, errors.New("only held payments can be reviewed")
+	}
+
+	expiresAt := time.Now().Add(paymentAttemptTTL)
+	payment.Status = "pending"
+	payment.RiskDecision = domain.RiskApprove
+	payment.ExpiresAt = &expiresAt
+
+	// The code shown before the review has expired or is about to; the customer scans a new one.
+	if payment.Method == qrMethod {
+		if err := service.issueQR(&payment); err != nil {
+			return domain.Payment{}, err
+		}
+	}
+
+	// Transfer instructions stay the same, but the customer gets the full window to pay.
+	if payment.BankTransfer != nil {
+		expiresAt = time.Now().Add(service.BankTransferConfig.TTL)
+		payment.ExpiresAt = &expiresAt
+	}
+
+	if payment.Method == codMethod {
+		payment.Status = "awaiting_collection"
+		payment.ExpiresAt = nil
+	}
+
+	return service.review(ctx, tx, payment, request)
+}
+
+// RejectHeld closes a held payment and tells the order service the attempt failed.
+func (service *PaymentServiceImpl) RejectHeld(ctx context.Context, paymentId string, request web.RiskReviewRequest) (_ domain.Payment, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Payment{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	payment, err := service.PaymentRepository.FindByIdForUpdate(ctx, tx, paymentId)
 	if err != nil {
 		return domain.Payment{}, err
 	}
 
-	return saved, nil
+	if payment.Status != "held" {
+		return domain.Payment{}, errors.New("only held payments can be reviewed")
+	}
+
+	payment.Status = "rejected"
+	payment.RiskDecision = domain.RiskReject
+
+	updated, err := service.review(ctx, tx, payment, request)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if err := service.unwindSplitTender(ctx, tx, updated); err != nil {
+		return domain.Payment{}, err
+	}
+
+	// A top-up stays open for another payment and has no order to tell.
+	if updated.TopUp {
+		return updated, nil
+	}
+
+	callbackPayload := web.PaymentCallbackRequest{
+		OrderID:       updated.OrderID,
+		PaymentID:     updated.ID,
+		PaymentStatus: "failed",
+	}
+
+	if err := deliverCallback(ctx, tx, service.PaymentEventRepository, service.getCallbackURL(), callbackPayload); err != nil {
+		fmt.Printf("Warning: rejected payment callback to order service failed: %v", err)
+	}
+
+	return updated, nil
+}
+
+func (service *PaymentServiceImpl) review(ctx context.Context, tx *gorm.DB, payment domain.Payment, request web.RiskReviewRequest) (domain.Payment, error) {
+	updated, err := service.PaymentRepository.UpdateStatus(ctx, tx, payment)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	assessment := domain.RiskAssessment{
+		PaymentID: updated.ID,
+		Source:    "admin",
+		Actor:     request.Reviewer,
+		Score:     updated.RiskScore,
+		Decision:  updated.RiskDecision,
+		Note:      request.Note,
+	}
+	if _, err := service.RiskService.Record(ctx, tx, assessment); err != nil {
+		return domain.Payment{}, err
+	}
+
+	return updated, nil
 }
 
 func (service *PaymentServiceImpl) MarkAsSuccess(ctx context.Context, paymentId string) (domain.Payment, error) {
+	return service.capture(ctx, paymentId, time.Now(), 0)
+}
+
+// MarkTransferPaid captures a bank transfer payment at the time the bank booked the transfer, so
+// a statement imported after the payment expired still counts a transfer made in time.
+func (service *PaymentServiceImpl) MarkTransferPaid(ctx context.Context, paymentId string, paidAt time.Time) (domain.Payment, error) {
+	return service.capture(ctx, paymentId, paidAt, service.BankTransferConfig.Grace)
+}
+
+// MarkQRPaid captures a QR payment at the time the provider booked it, so a notification that
+// arrives after the code expired still counts a code paid in time.
+func (service *PaymentServiceImpl) MarkQRPaid(ctx context.Context, paymentId string, paidAt time.Time) (domain.Payment, error) {
+	return service.capture(ctx, paymentId, paidAt, service.QRConfig.Grace)
+}
+
+// capture marks a pending payment paid at paidAt. A payment whose expiry, extended by grace, lies
+// before paidAt is expired instead.
+func (service *PaymentServiceImpl) capture(ctx context.Context, paymentId string, paidAt time.Time, grace time.Duration) (_ domain.Payment, err error) {
 	tx := service.DB.Begin()
-	defer helper.CommitOrRollback(tx)
+	defer helper.CommitOrRollback(tx, &err)
 
-	payment, err := service.PaymentRepository.FindById(ctx, tx, paymentId)
+	payment, err := service.PaymentRepository.FindByIdForUpdate(ctx, tx, paymentId)
 	if err != nil {
 		return domain.Payment{}, err
 	}
@@ -87,33 +447,92 @@ func (service *PaymentServiceImpl) MarkAsSuccess(ctx context.Context, paymentId
 		return domain.Payment{}, errors.New("payment already finalized")
 	}
 
-	now := time.Now()
+	// An expired attempt stays pending until a new attempt replaces it, see isPaymentExpired.
+	if payment.ExpiresAt != nil && paidAt.After(payment.ExpiresAt.Add(grace)) {
+		return domain.Payment{}, errors.New("payment expired")
+	}
+
+	return service.settle(ctx, tx, payment, paidAt)
+}
+
+// settle moves a payment to success inside tx: it charges the capture fee, posts the journal
+// entries and tells order-service the order is paid.
+func (service *PaymentServiceImpl) settle(ctx context.Context, tx *gorm.DB, payment domain.Payment, paidAt time.Time) (domain.Payment, error) {
+	// A balance payment is captured by debiting its account, which may no longer cover it.
+	if payment.BalanceAccountID != nil {
+		if _, err := service.BalanceService.Redeem(ctx, tx, payment); err != nil {
+			return domain.Payment{}, err
+		}
+	}
+
 	payment.Status = "success"
-	payment.PaidAt = &now
+	payment.PaidAt = &paidAt
+	payment = service.FeeService.ApplyCaptureFee(payment)
 
 	updated, err := service.PaymentRepository.UpdateStatus(ctx, tx, payment)
 	if err != nil {
 		return domain.Payment{}, err
 	}
 
+	// The journal entries must commit together with the status change or not at all.
+	if err := service.LedgerService.PostPaymentSuccess(ctx, tx, updated); err != nil {
+		return domain.Payment{}, err
+	}
+
+	if err := service.LedgerService.PostFee(ctx, tx, updated, updated.FeeAmount); err != nil {
+		return domain.Payment{}, err
+	}
+
+	// Marketplace orders credit each seller's part of the payment, less commission.
+	if err := service.PayoutService.Accrue(ctx, tx, updated); err != nil {
+		return domain.Payment{}, err
+	}
+
+	// A top-up pays no order, so there is nothing to tell order-service; the wallet is credited
+	// instead.
+	if updated.TopUp {
+		if err := service.BalanceService.CompleteTopUp(ctx, tx, updated); err != nil {
+			return domain.Payment{}, err
+		}
+		return updated, nil
+	}
+
+	// The order is only paid once its last installment, or enough of its tenders, are; until then
+	// it is partially paid.
+	completed := true
+	switch {
+	case updated.InstallmentNumber > 0:
+		completed, err = service.settleInstallment(ctx, tx, updated, paidAt)
+	case updated.TenderNumber > 0:
+		completed, err = service.settleTender(ctx, tx, updated, paidAt)
+	}
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	callbackStatus := "success"
+	if !completed {
+		callbackStatus = "partially_paid"
+	}
+
 	callbackPayload := web.PaymentCallbackRequest{
 		OrderID:       updated.OrderID,
 		PaymentID:     updated.ID,
-		PaymentStatus: "success",
+		PaymentStatus: callbackStatus,
 	}
 
-	if err := SendPaymentCallback(ctx, service.getCallbackURL(), callbackPayload); err != nil {
+	if err := deliverCallback(ctx, tx, service.PaymentEventRepository, service.getCallbackURL(), callbackPayload); err != nil {
 		fmt.Printf("Warning: callback to order service failed: %v", err)
 	}
 
 	return updated, nil
 }
 
-func (service *PaymentServiceImpl) MarkAsFailed(ctx context.Context, paymentId string) (domain.Payment, error) {
+func (service *PaymentServiceImpl) MarkAsFailed(ctx context.Context, paymentId string) (_ domain.Payment, err error) {
 	tx := service.DB.Begin()
-	defer helper.CommitOrRollback(tx)
+	defer helper.CommitOrRollback(tx, &err)
 
-	payment, err := service.PaymentRepository.FindById(ctx, tx, paymentId)
+	payment, err := service.PaymentRepository.FindByIdForUpdate(ctx, tx, paymentId)
 	if err != nil {
 		return domain.Payment{}, err
 	}
@@ -130,19 +549,187 @@ func (service *PaymentServiceImpl) MarkAsFailed(ctx context.Context, paymentId s
 		return domain.Payment{}, err
 	}
 
+	if err := service.unwindSplitTender(ctx, tx, updated); err != nil {
+		return domain.Payment{}, err
+	}
+
+	// A top-up stays open for another payment and has no order to tell.
+	if updated.TopUp {
+		return updated, nil
+	}
+
 	callbackPayload := web.PaymentCallbackRequest{
 		OrderID:       updated.OrderID,
 		PaymentID:     updated.ID,
 		PaymentStatus: "failed",
 	}
 
-	if err := SendPaymentCallback(ctx, service.getCallbackURL(), callbackPayload); err != nil {
+	if err := deliverCallback(ctx, tx, service.PaymentEventRepository, service.getCallbackURL(), callbackPayload); err != nil {
 		fmt.Printf("Warning: failed payment callback to order service failed: %v", err)
 	}
 
 	return updated, nil
 }
 
+func (service *PaymentServiceImpl) Refund(ctx context.Context, paymentId string) (domain.Payment, error) {
+	return service.reverse(ctx, paymentId, "refunded", service.LedgerService.PostRefund)
+}
+
+// RefundPart gives back part of a successful payment. The payment stays successful until the
+// last of it is refunded, which refunds it like a full refund. Each part is charged the refund
+// fee on its own.
+func (service *PaymentServiceImpl) RefundPart(ctx context.Context, paymentId string, request web.PartialRefundRequest) (_ domain.Payment, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Payment{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	payment, err := service.PaymentRepository.FindByIdForUpdate(ctx, tx, paymentId)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if payment.Status != "success" {
+		return domain.Payment{}, errors.New("only successful payments can be refunded")
+	}
+	if payment.TopUp {
+		return domain.Payment{}, errors.New("top-ups are refunded in full")
+	}
+
+	remaining := payment.Amount - payment.RefundedAmount
+	if request.Amount > remaining {
+		return domain.Payment{}, fmt.Errorf("refund exceeds the %d left to refund", remaining)
+	}
+	if request.Amount == remaining {
+		return service.applyReversal(ctx, tx, payment, "refunded", service.LedgerService.PostRefund)
+	}
+
+	if err := service.PayoutService.ClawbackPart(ctx, tx, payment, request.Amount, request.SellerID); err != nil {
+		return domain.Payment{}, err
+	}
+
+	feeBefore := payment.FeeAmount
+	payment.RefundedAmount += request.Amount
+	payment = service.FeeService.ApplyRefundFee(payment, request.Amount)
+	updated, err := service.PaymentRepository.UpdateStatus(ctx, tx, payment)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	part := updated
+	part.Amount = request.Amount
+	if updated.BalanceAccountID != nil {
+		if _, err := service.BalanceService.Reverse(ctx, tx, part); err != nil {
+			return domain.Payment{}, err
+		}
+	}
+
+	if err := service.LedgerService.PostRefund(ctx, tx, part); err != nil {
+		return domain.Payment{}, err
+	}
+
+	if err := service.LedgerService.PostFee(ctx, tx, updated, updated.FeeAmount-feeBefore); err != nil {
+		return domain.Payment{}, err
+	}
+
+	return updated, nil
+}
+
+func (service *PaymentServiceImpl) Chargeback(ctx context.Context, paymentId string) (domain.Payment, error) {
+	return service.reverse(ctx, paymentId, "charged_back", service.LedgerService.PostChargeback)
+}
+
+// ApplyChargeback charges back a successful payment inside tx, for callers such as lost disputes
+// that already hold the payment row.
+func (service *PaymentServiceImpl) ApplyChargeback(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error) {
+	return service.applyReversal(ctx, tx, payment, "charged_back", service.LedgerService.PostChargeback)
+}
+
+// reverse moves a successful payment into a terminal reversal status and posts the matching
+// journal entry in the same transaction.
+func (service *PaymentServiceImpl) reverse(ctx context.Context, paymentId string, status string, post func(context.Context, *gorm.DB, domain.Payment) error) (_ domain.Payment, err error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	payment, err := service.PaymentRepository.FindByIdForUpdate(ctx, tx, paymentId)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	if payment.Status != "success" {
+		return domain.Payment{}, fmt.Errorf("only successful payments can be %s", strings.ReplaceAll(status, "_", " "))
+	}
+
+	return service.applyReversal(ctx, tx, payment, status, post)
+}
+
+// applyReversal moves a successful payment into status inside tx, applying the refund fee for
+// refunds, claws back the sellers' part of it and posts the reversal and fee journal entries.
+// What was refunded in part before is not reversed again. Chargebacks are reported to the order
+// service so the order can be flagged.
+func (service *PaymentServiceImpl) applyReversal(ctx context.Context, tx *gorm.DB, payment domain.Payment, status string, post func(context.Context, *gorm.DB, domain.Payment) error) (domain.Payment, error) {
+	feeBefore := payment.FeeAmount
+	outstanding := payment.Amount - payment.RefundedAmount
+	payment.Status = status
+	if status == "refunded" {
+		payment.RefundedAmount = payment.Amount
+		payment = service.FeeService.ApplyRefundFee(payment, outstanding)
+	} else {
+		payment.NetAmount = -payment.FeeAmount
+	}
+
+	updated, err := service.PaymentRepository.UpdateStatus(ctx, tx, payment)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	reversed := updated
+	reversed.Amount = outstanding
+
+	// Refunded balance payments go back to the gift card, store credit or wallet they were paid
+	// from. A refunded top-up is taken out of the wallet it was credited to.
+	switch {
+	case status == "refunded" && updated.BalanceAccountID != nil:
+		if _, err := service.BalanceService.Reverse(ctx, tx, reversed); err != nil {
+			return domain.Payment{}, err
+		}
+	case status == "refunded" && updated.TopUp:
+		if err := service.BalanceService.RefundTopUp(ctx, tx, updated); err != nil {
+			return domain.Payment{}, err
+		}
+	}
+
+	if err := service.PayoutService.Clawback(ctx, tx, updated); err != nil {
+		return domain.Payment{}, err
+	}
+
+	if err := post(ctx, tx, reversed); err != nil {
+		return domain.Payment{}, err
+	}
+
+	if err := service.LedgerService.PostFee(ctx, tx, updated, updated.FeeAmount-feeBefore); err != nil {
+		return domain.Payment{}, err
+	}
+
+	if status != "charged_back" || updated.TopUp {
+		return updated, nil
+	}
+
+	callbackPayload := web.PaymentCallbackRequest{
+		OrderID:       updated.OrderID,
+		PaymentID:     updated.ID,
+		PaymentStatus: "charged_back",
+	}
+
+	if err := deliverCallback(ctx, tx, service.PaymentEventRepository, service.getCallbackURL(), callbackPayload); err != nil {
+		fmt.Printf("Warning: chargeback callback to order service failed: %v", err)
+	}
+
+	return updated, nil
+}
+
 func (service *PaymentServiceImpl) FindById(ctx context.Context, paymentId string) (domain.Payment, error) {
 	tx := service.DB.Begin()
 	defer helper.CommitOrRollback(tx)
@@ -154,3 +741,82 @@ func (service *PaymentServiceImpl) FindById(ctx context.Context, paymentId strin
 
 	return result, nil
 }
+
+func (service *PaymentServiceImpl) FindAllByOrderId(ctx context.Context, orderId string) ([]domain.Payment, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	payments, err := service.PaymentRepository.FindAllByOrderId(ctx, tx, orderId)
+	if err != nil {
+		return []domain.Payment{}, err
+	}
+
+	return payments, nil
+}
+
+// fetchPayableAmount returns the amount and currency a payment has to cover: the order total, or
+// the amount of the wallet top-up whose ID the request passes as its order.
+func (service *PaymentServiceImpl) fetchPayableAmount(ctx context.Context, request web.PaymentCreateRequest) (int64, string, error) {
+	if !request.TopUp {
+		return fetchOrderAmount(ctx, request.OrderID)
+	}
+
+	switch {
+	case request.Provider == walletProvider:
+		return 0, "", errors.New("a wallet cannot be topped up from itself")
+	case request.InstallmentNumber > 0 || request.SplitTender:
+		return 0, "", errors.New("top-ups are paid in a single payment")
+	case service.CODConfig.Supports(request.Provider):
+		return 0, "", errors.New("top-ups cannot be paid cash on delivery")
+	}
+
+	topUp, err := service.BalanceService.ResolveTopUp(ctx, service.DB, request.OrderID.String())
+	if err != nil {
+		return 0, "", err
+	}
+	return topUp.Amount, topUp.Currency, nil
+}
+
+// issueQR gives a QR payment its scannable payload. The payload carries its own reference and
+// expiry, so the attempt expires together with the code.
+func (service *PaymentServiceImpl) issueQR(payment *domain.Payment) error {
+	if payment.ProviderReference == "" || payment.ProviderReference == payment.ID.String() {
+		reference, err := helper.NewQRReference()
+		if err != nil {
+			return err
+		}
+		payment.ProviderReference = reference
+	}
+
+	expiresAt := time.Now().Add(service.QRConfig.TTL)
+	payload, err := helper.EncodeQRPayload(helper.QRPayload{
+		MerchantGUI:      service.QRConfig.MerchantGUI,
+		MerchantID:       service.QRConfig.MerchantID,
+		MerchantName:     service.QRConfig.MerchantName,
+		MerchantCity:     service.QRConfig.MerchantCity,
+		MerchantCategory: service.QRConfig.MerchantCategory,
+		CountryCode:      service.QRConfig.CountryCode,
+		Currency:         payment.Currency,
+		Amount:           payment.Amount,
+		Reference:        payment.ProviderReference,
+		ExpiresAt:        expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	payment.QRPayload = payload
+	payment.ExpiresAt = &expiresAt
+	return nil
+}
+
+func activePaymentConflict(existing domain.Payment) error {
+	return exception.ConflictError{
+		Message: fmt.Sprintf("active payment %s already exists for order %s", existing.ID, existing.OrderID),
+		Data:    helper.ToPaymentResponse(existing),
+	}
+}
+
+func isPaymentExpired(payment domain.Payment) bool {
+	return payment.Status == "pending" && payment.ExpiresAt != nil && time.Now().After(*payment.ExpiresAt)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// checkSplitTender validates a payment against the order's split payment and returns the split
+// payment it is a tender of. It returns nil for orders paid with a single payment, including
+// orders whose split payment failed. Tenders whose attempt expired no longer count toward the
+// order total and are expired here.
+func (service *PaymentServiceImpl) checkSplitTender(ctx context.Context, tx *gorm.DB, request web.PaymentCreateRequest, currency string) (*domain.SplitPayment, error) {
+	split, err := service.SplitPaymentRepository.FindByOrderId(ctx, tx, request.OrderID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if request.SplitTender {
+			return nil, errors.New("order has no split payment")
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !request.SplitTender {
+		if split.Status == domain.SplitPaymentFailed {
+			return nil, nil
+		}
+		return nil, errors.New("order is paid with split tenders, pass split_tender")
+	}
+	if split.Status != domain.SplitPaymentOpen {
+		return nil, fmt.Errorf("split payment is already %s", split.Status)
+	}
+	if currency != split.Currency {
+		return nil, fmt.Errorf("tenders are paid in %s", split.Currency)
+	}
+	if request.Amount <= 0 {
+		return nil, errors.New("tender amount must be positive")
+	}
+
+	var committed int64
+	for i, tender := range split.Tenders {
+		if !slices.Contains(repository.ActivePaymentStatuses, tender.Status) {
+			continue
+		}
+		if isPaymentExpired(tender) {
+			tender.Status = "expired"
+			if split.Tenders[i], err = service.PaymentRepository.UpdateStatus(ctx, tx, tender); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		committed += tender.Amount
+	}
+
+	// Pending tenders count as well, so tenders can never be captured for more than the total.
+	if remaining := split.TotalAmount - committed; request.Amount > remaining {
+		return nil, fmt.Errorf("tender amount %d exceeds the %d left to pay on the order", request.Amount, remaining)
+	}
+
+	return &split, nil
+}
+
+// settleTender adds a captured tender to its split payment, which is paid once the captured
+// tenders reach the order total. It reports whether the order is now paid in full.
+func (service *PaymentServiceImpl) settleTender(ctx context.Context, tx *gorm.DB, payment domain.Payment, paidAt time.Time) (bool, error) {
+	split, err := service.SplitPaymentRepository.FindByOrderId(ctx, tx, payment.OrderID.String())
+	if err != nil {
+		return false, err
+	}
+
+	split.CapturedAmount += payment.Amount
+	completed := split.CapturedAmount >= split.TotalAmount
+	if completed {
+		split.Status = domain.SplitPaymentPaid
+		split.ClosedAt = &paidAt
+	}
+
+	if _, err := service.SplitPaymentRepository.Update(ctx, tx, split); err != nil {
+		return false, err
+	}
+
+	return completed, nil
+}
+
+// unwindSplitTender fails the split payment of a tender that did not go through: the other
+// tenders still in flight are cancelled and the captured ones refunded, so the customer is never
+// charged for part of an order that cannot be paid. Payments that are not tenders are left alone.
+func (service *PaymentServiceImpl) unwindSplitTender(ctx context.Context, tx *gorm.DB, failed domain.Payment) error {
+	if failed.TenderNumber == 0 {
+		return nil
+	}
+
+	split, err := service.SplitPaymentRepository.FindByOrderId(ctx, tx, failed.OrderID.String())
+	if err != nil {
+		return err
+	}
+	if split.Status != domain.SplitPaymentOpen {
+		return nil
+	}
+
+	for _, tender := range split.Tenders {
+		if tender.ID == failed.ID {
+			continue
+		}
+
+		switch tender.Status {
+		case "pending", "held", "awaiting_collection":
+			tender.Status = "cancelled"
+			if _, err := service.PaymentRepository.UpdateStatus(ctx, tx, tender); err != nil {
+				return err
+			}
+		case "success":
+			if _, err := service.applyReversal(ctx, tx, tender, "refunded", service.LedgerService.PostRefund); err != nil {
+				return err
+			}
+			split.CapturedAmount -= tender.Amount
+		}
+	}
+
+	now := time.Now()
+	split.Status = domain.SplitPaymentFailed
+	split.FailureReason = fmt.Sprintf("tender %d %s", failed.TenderNumber, failed.Status)
+	split.ClosedAt = &now
+
+	_, err = service.SplitPaymentRepository.Update(ctx, tx, split)
+	return err
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+)
+
+// FindEvents returns the status timeline of a payment, optionally with every callback
+// delivered to order-service for it.
+func (service *PaymentServiceImpl) FindEvents(ctx context.Context, paymentId string, includeCallbacks bool) (web.PaymentTimelineResponse, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	if _, err := service.PaymentRepository.FindById(ctx, tx, paymentId); err != nil {
+		return web.PaymentTimelineResponse{}, err
+	}
+
+	events, err := service.PaymentEventRepository.FindEventsByPaymentId(ctx, tx, paymentId)
+	if err != nil {
+		return web.PaymentTimelineResponse{}, err
+	}
+
+	timeline := web.PaymentTimelineResponse{Events: events}
+	if timeline.Events == nil {
+		timeline.Events = []domain.PaymentEvent{}
+	}
+
+	if includeCallbacks {
+		attempts, err := service.PaymentEventRepository.FindCallbackAttemptsByPaymentId(ctx, tx, paymentId)
+		if err != nil {
+			return web.PaymentTimelineResponse{}, err
+		}
+		timeline.CallbackAttempts = attempts
+		if timeline.CallbackAttempts == nil {
+			timeline.CallbackAttempts = []domain.CallbackAttempt{}
+		}
+	}
+
+	return timeline, nil
+}
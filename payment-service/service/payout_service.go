@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PayoutService interface {
+	CreateSeller(ctx context.Context, request web.SellerCreateRequest) (domain.Seller, error)
+	FindSeller(ctx context.Context, sellerId string) (domain.Seller, error)
+	FindEntries(ctx context.Context, sellerId string) ([]domain.SellerEntry, error)
+	SetShares(ctx context.Context, orderId uuid.UUID, request web.SellerSharesRequest) ([]domain.SellerShare, error)
+	FindShares(ctx context.Context, orderId string) ([]domain.SellerShare, error)
+	Accrue(ctx context.Context, tx *gorm.DB, payment domain.Payment) error
+	Clawback(ctx context.Context, tx *gorm.DB, payment domain.Payment) error
+	ClawbackPart(ctx context.Context, tx *gorm.DB, payment domain.Payment, amount int64, sellerId string) error
+	Generate(ctx context.Context) ([]domain.PayoutBatch, error)
+	FindBatch(ctx context.Context, batchId string) (domain.PayoutBatch, error)
+}
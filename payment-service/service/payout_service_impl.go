@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PayoutServiceImpl struct {
+	PayoutRepository  repository.PayoutRepository
+	PaymentRepository repository.PaymentRepository
+	Config            config.PayoutConfig
+	DB                *gorm.DB
+	Validate          *validator.Validate
+}
+
+func NewPayoutService(payoutRepository repository.PayoutRepository, paymentRepository repository.PaymentRepository, payoutConfig config.PayoutConfig, DB *gorm.DB, validate *validator.Validate) PayoutService {
+	return &PayoutServiceImpl{
+		PayoutRepository:  payoutRepository,
+		PaymentRepository: paymentRepository,
+		Config:            payoutConfig,
+		DB:                DB,
+		Validate:          validate,
+	}
+}
+
+func (service *PayoutServiceImpl) CreateSeller(ctx context.Context, request web.SellerCreateRequest) (domain.Seller, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Seller{}, err
+	}
+
+	existing, err := service.PayoutRepository.FindSellerById(ctx, service.DB, request.ID)
+	if err == nil {
+		return domain.Seller{}, exception.ConflictError{Message: "seller already exists", Data: existing}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Seller{}, err
+	}
+
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	return service.PayoutRepository.SaveSeller(ctx, service.DB, domain.Seller{
+		ID:                request.ID,
+		Name:              request.Name,
+		Currency:          currency,
+		CommissionBps:     request.CommissionBps,
+		BankName:          request.BankName,
+		BankAccountNumber: request.BankAccountNumber,
+		BankAccountName:   request.BankAccountName,
+	})
+}
+
+func (service *PayoutServiceImpl) FindSeller(ctx context.Context, sellerId string) (domain.Seller, error) {
+	seller, err := service.PayoutRepository.FindSellerById(ctx, service.DB, sellerId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Seller{}, exception.NotFoundError{Message: "seller not found"}
+	}
+	return seller, err
+}
+
+func (service *PayoutServiceImpl) FindEntries(ctx context.Context, sellerId string) ([]domain.SellerEntry, error) {
+	if _, err := service.FindSeller(ctx, sellerId); err != nil {
+		return nil, err
+	}
+
+	return service.PayoutRepository.FindEntries(ctx, service.DB, sellerId)
+}
+
+// SetShares records which part of the order total belongs to which seller. Shares are fixed once
+// set and must be in place before the order is paid, since only payments captured afterwards are
+// credited to the sellers.
+func (service *PayoutServiceImpl) SetShares(ctx context.Context, orderId uuid.UUID, request web.SellerSharesRequest) (_ []domain.SellerShare, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return nil, err
+	}
+
+	totalAmount, currency, err := fetchOrderAmount(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	var sum int64
+	listed := map[string]bool{}
+	for _, share := range request.Shares {
+		if listed[share.SellerID] {
+			return nil, fmt.Errorf("seller %s is listed twice", share.SellerID)
+		}
+		listed[share.SellerID] = true
+		sum += share.Amount
+	}
+	if sum != totalAmount {
+		return nil, fmt.Errorf("shares add up to %d, the order total is %d", sum, totalAmount)
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	existing, err := service.PayoutRepository.FindShares(ctx, tx, orderId.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, exception.ConflictError{Message: "order already has seller shares", Data: existing}
+	}
+
+	payments, err := service.PaymentRepository.FindAllByOrderId(ctx, tx, orderId.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		if payment.PaidAt != nil {
+			return nil, errors.New("order is already paid")
+		}
+	}
+
+	shares := make([]domain.SellerShare, 0, len(request.Shares))
+	for _, share := range request.Shares {
+		seller, err := service.PayoutRepository.FindSellerById(ctx, tx, share.SellerID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("seller %s not found", share.SellerID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if seller.Currency != currency {
+			return nil, fmt.Errorf("seller %s is paid in %s", seller.ID, seller.Currency)
+		}
+
+		shares = append(shares, domain.SellerShare{
+			ID:       uuid.New(),
+			OrderID:  orderId,
+			SellerID: share.SellerID,
+			Amount:   share.Amount,
+		})
+	}
+
+	return service.PayoutRepository.SaveShares(ctx, tx, shares)
+}
+
+func (service *PayoutServiceImpl) FindShares(ctx context.Context, orderId string) ([]domain.SellerShare, error) {
+	shares, err := service.PayoutRepository.FindShares(ctx, service.DB, orderId)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		return nil, exception.NotFoundError{Message: "order has no seller shares"}
+	}
+	return shares, nil
+}
+
+// Accrue credits each seller of the payment's order with their part of the payment, less the
+// platform commission. A payment covering part of the order, such as an installment or a tender,
+// is split in proportion to the shares. Orders without seller shares are left alone.
+func (service *PayoutServiceImpl) Accrue(ctx context.Context, tx *gorm.DB, payment domain.Payment) error {
+	shares, err := service.PayoutRepository.FindShares(ctx, tx, payment.OrderID.String())
+	if err != nil || len(shares) == 0 {
+		return err
+	}
+
+	// Shares are in the order currency, so a converted payment is split by the amount it paid of
+	// the order.
+	orderAmount := payment.Amount
+	if payment.Conversion != nil {
+		orderAmount = payment.Conversion.OrderAmount
+	}
+
+	amounts := make([]int64, len(shares))
+	for i, share := range shares {
+		amounts[i] = share.Amount
+	}
+
+	for i, gross := range helper.SplitByShares(orderAmount, amounts) {
+		seller, err := service.PayoutRepository.FindSellerById(ctx, tx, shares[i].SellerID)
+		if err != nil {
+			return err
+		}
+
+		commissionBps := service.Config.CommissionBps
+		if seller.CommissionBps != nil {
+			commissionBps = *seller.CommissionBps
+		}
+		commission := percentOf(gross, commissionBps)
+
+		_, err = service.record(ctx, tx, seller, domain.SellerEntry{
+			PaymentID:        &payment.ID,
+			Type:             domain.SellerSale,
+			GrossAmount:      gross,
+			CommissionAmount: commission,
+			Amount:           gross - commission,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Clawback takes back from each seller what they still hold of the payment's sale. The balance
+// may go negative when the sale has already been paid out.
+func (service *PayoutServiceImpl) Clawback(ctx context.Context, tx *gorm.DB, payment domain.Payment) error {
+	held, err := service.held(ctx, tx, payment)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range held {
+		seller, err := service.PayoutRepository.FindSellerById(ctx, tx, entry.SellerID)
+		if err != nil {
+			return err
+		}
+
+		_, err = service.record(ctx, tx, seller, domain.SellerEntry{
+			PaymentID:        &payment.ID,
+			Type:             domain.SellerClawback,
+			GrossAmount:      -entry.GrossAmount,
+			CommissionAmount: -entry.CommissionAmount,
+			Amount:           -entry.Amount,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClawbackPart takes back the sellers' part of a partial refund of amount. With sellerId only that
+// seller gives it back; otherwise every seller does, in proportion to what they still hold of the
+// sale. The commission is returned in the same proportion.
+func (service *PayoutServiceImpl) ClawbackPart(ctx context.Context, tx *gorm.DB, payment domain.Payment, amount int64, sellerId string) error {
+	held, err := service.held(ctx, tx, payment)
+	if err != nil || len(held) == 0 {
+		return err
+	}
+
+	// Sales are in the order currency, so the refund takes the same fraction of the order amount
+	// the payment paid.
+	orderAmount := payment.Amount
+	if payment.Conversion != nil {
+		orderAmount = payment.Conversion.OrderAmount
+	}
+	gross := helper.SplitByShares(orderAmount, []int64{amount, payment.Amount - amount})[0]
+
+	var parts []int64
+	if sellerId != "" {
+		index := -1
+		for i, entry := range held {
+			if entry.SellerID == sellerId {
+				index = i
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("seller %s has no sale left on this payment", sellerId)
+		}
+		if gross > held[index].GrossAmount {
+			return fmt.Errorf("refund is more than seller %s holds of this payment", sellerId)
+		}
+		held, parts = held[index:index+1], []int64{gross}
+	} else {
+		amounts := make([]int64, len(held))
+		for i, entry := range held {
+			amounts[i] = entry.GrossAmount
+		}
+		parts = helper.SplitByShares(gross, amounts)
+	}
+
+	for i, entry := range held {
+		if parts[i] == 0 {
+			continue
+		}
+
+		seller, err := service.PayoutRepository.FindSellerById(ctx, tx, entry.SellerID)
+		if err != nil {
+			return err
+		}
+
+		commission := helper.SplitByShares(entry.CommissionAmount, []int64{parts[i], entry.GrossAmount - parts[i]})[0]
+		_, err = service.record(ctx, tx, seller, domain.SellerEntry{
+			PaymentID:        &payment.ID,
+			Type:             domain.SellerClawback,
+			GrossAmount:      -parts[i],
+			CommissionAmount: -commission,
+			Amount:           -(parts[i] - commission),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// held nets each seller's sale of the payment against what was already clawed back, in the order
+// of the sales. Sellers with nothing left are dropped.
+func (service *PayoutServiceImpl) held(ctx context.Context, tx *gorm.DB, payment domain.Payment) ([]domain.SellerEntry, error) {
+	sales, err := service.PayoutRepository.FindEntriesByPayment(ctx, tx, payment.ID.String(), domain.SellerSale)
+	if err != nil {
+		return nil, err
+	}
+	clawbacks, err := service.PayoutRepository.FindEntriesByPayment(ctx, tx, payment.ID.String(), domain.SellerClawback)
+	if err != nil {
+		return nil, err
+	}
+
+	held := []domain.SellerEntry{}
+	for _, sale := range sales {
+		for _, clawback := range clawbacks {
+			if clawback.SellerID == sale.SellerID {
+				sale.GrossAmount += clawback.GrossAmount
+				sale.CommissionAmount += clawback.CommissionAmount
+				sale.Amount += clawback.Amount
+			}
+		}
+		if sale.GrossAmount > 0 {
+			held = append(held, sale)
+		}
+	}
+
+	return held, nil
+}
+
+// Generate pays out every seller whose balance reaches the minimum, in one batch per currency.
+// Each payout empties the seller's balance.
+func (service *PayoutServiceImpl) Generate(ctx context.Context) (_ []domain.PayoutBatch, err error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	sellers, err := service.PayoutRepository.FindPayableSellers(ctx, tx, service.Config.MinAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := []domain.PayoutBatch{}
+	for _, seller := range sellers {
+		if len(batches) == 0 || batches[len(batches)-1].Currency != seller.Currency {
+			batches = append(batches, domain.PayoutBatch{ID: uuid.New(), Currency: seller.Currency, Payouts: []domain.Payout{}})
+		}
+		batch := &batches[len(batches)-1]
+
+		payout, err := service.PayoutRepository.SavePayout(ctx, tx, domain.Payout{
+			ID:                uuid.New(),
+			BatchID:           batch.ID,
+			SellerID:          seller.ID,
+			Amount:            seller.Balance,
+			Currency:          seller.Currency,
+			BankName:          seller.BankName,
+			BankAccountNumber: seller.BankAccountNumber,
+			BankAccountName:   seller.BankAccountName,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = service.record(ctx, tx, seller, domain.SellerEntry{
+			PayoutID: &payout.ID,
+			Type:     domain.SellerPayout,
+			Amount:   -payout.Amount,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		batch.Payouts = append(batch.Payouts, payout)
+		batch.TotalAmount += payout.Amount
+		batch.PayoutCount++
+	}
+
+	for i, batch := range batches {
+		saved, err := service.PayoutRepository.SaveBatch(ctx, tx, batch)
+		if err != nil {
+			return nil, err
+		}
+		batches[i] = saved
+	}
+
+	return batches, nil
+}
+
+func (service *PayoutServiceImpl) FindBatch(ctx context.Context, batchId string) (domain.PayoutBatch, error) {
+	batch, err := service.PayoutRepository.FindBatchById(ctx, service.DB, batchId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.PayoutBatch{}, exception.NotFoundError{Message: "payout batch not found"}
+	}
+	return batch, err
+}
+
+// record applies entry to the seller's balance and saves it with the balance it left.
+func (service *PayoutServiceImpl) record(ctx context.Context, tx *gorm.DB, seller domain.Seller, entry domain.SellerEntry) (domain.SellerEntry, error) {
+	seller.Balance += entry.Amount
+	if _, err := service.PayoutRepository.UpdateSellerBalance(ctx, tx, seller); err != nil {
+		return domain.SellerEntry{}, err
+	}
+
+	entry.ID = uuid.New()
+	entry.SellerID = seller.ID
+	entry.BalanceAfter = seller.Balance
+	return service.PayoutRepository.SaveEntry(ctx, tx, entry)
+}
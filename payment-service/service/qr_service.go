@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+)
+
+// ErrQRCodeExpired reports a code that can no longer be paid, either because it timed out or
+// because its payment was already finalized.
+var ErrQRCodeExpired = errors.New("QR code is no longer payable")
+
+type QRService interface {
+	Image(ctx context.Context, paymentId string, format string, size int) ([]byte, string, error)
+	HandleWebhook(ctx context.Context, provider string, request web.QRWebhookRequest) (domain.Payment, error)
+	Scan(ctx context.Context, provider string, request web.QRSimulatorRequest) (web.QRScanResponse, error)
+	SimulatePayment(ctx context.Context, provider string, request web.QRSimulatorRequest) (domain.Payment, error)
+}
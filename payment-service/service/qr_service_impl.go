@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"time"
+
+	"github.com/go-playground/validator"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultQRImageSize = 256
+	maxQRImageSize     = 1024
+)
+
+type QRServiceImpl struct {
+	PaymentRepository repository.PaymentRepository
+	PaymentService    PaymentService
+	DB                *gorm.DB
+	Validate          *validator.Validate
+}
+
+func NewQRService(paymentRepository repository.PaymentRepository, paymentService PaymentService, DB *gorm.DB, validate *validator.Validate) QRService {
+	return &QRServiceImpl{
+		PaymentRepository: paymentRepository,
+		PaymentService:    paymentService,
+		DB:                DB,
+		Validate:          validate,
+	}
+}
+
+// Image renders the payment's QR code as "png" or "svg" and returns it with its content type.
+func (service *QRServiceImpl) Image(ctx context.Context, paymentId string, format string, size int) ([]byte, string, error) {
+	payment, err := service.PaymentService.FindById(ctx, paymentId)
+	if err != nil {
+		return nil, "", exception.NotFoundError{Message: "payment not found"}
+	}
+	if payment.QRPayload == "" {
+		return nil, "", exception.NotFoundError{Message: "payment has no QR code"}
+	}
+	if payment.Status != "pending" || isPaymentExpired(payment) {
+		return nil, "", ErrQRCodeExpired
+	}
+
+	switch format {
+	case "svg":
+		image, err := helper.QRCodeSVG(payment.QRPayload)
+		return image, "image/svg+xml", err
+	case "png", "":
+		if size <= 0 {
+			size = defaultQRImageSize
+		}
+		if size > maxQRImageSize {
+			size = maxQRImageSize
+		}
+		image, err := helper.QRCodePNG(payment.QRPayload, size)
+		return image, "image/png", err
+	default:
+		return nil, "", fmt.Errorf("unsupported QR image format %q", format)
+	}
+}
+
+// HandleWebhook confirms or fails a QR payment from the provider's notification. Repeated
+// notifications for a payment that already reached the notified status are acknowledged as is.
+func (service *QRServiceImpl) HandleWebhook(ctx context.Context, provider string, request web.QRWebhookRequest) (domain.Payment, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Payment{}, err
+	}
+
+	payment, err := service.findByReference(ctx, provider, request.Reference)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	switch request.Status {
+	case "paid":
+		if payment.Status == "success" {
+			return payment, nil
+		}
+		if request.Amount != payment.Amount {
+			return domain.Payment{}, fmt.Errorf("paid amount %d does not match payment amount %d", request.Amount, payment.Amount)
+		}
+		// Expiry is judged by when the customer paid, not by when the notification arrived.
+		paidAt := time.Now()
+		if request.PaidAt != nil && request.PaidAt.Before(paidAt) {
+			paidAt = *request.PaidAt
+		}
+		return service.PaymentService.MarkQRPaid(ctx, payment.ID.String(), paidAt)
+	default:
+		if payment.Status == "failed" {
+			return payment, nil
+		}
+		return service.PaymentService.MarkAsFailed(ctx, payment.ID.String())
+	}
+}
+
+// Scan decodes a payload the way a banking app would and checks it can still be paid.
+func (service *QRServiceImpl) Scan(ctx context.Context, provider string, request web.QRSimulatorRequest) (web.QRScanResponse, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return web.QRScanResponse{}, err
+	}
+
+	payload, err := helper.DecodeQRPayload(request.Payload)
+	if err != nil {
+		return web.QRScanResponse{}, err
+	}
+
+	payment, err := service.findByReference(ctx, provider, payload.Reference)
+	if err != nil {
+		return web.QRScanResponse{}, err
+	}
+	if payment.QRPayload != request.Payload {
+		return web.QRScanResponse{}, fmt.Errorf("QR payload does not belong to payment %s", payment.ID)
+	}
+	if payment.Status != "pending" || time.Now().After(payload.ExpiresAt) {
+		return web.QRScanResponse{}, ErrQRCodeExpired
+	}
+
+	return web.QRScanResponse{
+		PaymentID:    payment.ID,
+		Reference:    payload.Reference,
+		MerchantID:   payload.MerchantID,
+		MerchantName: payload.MerchantName,
+		MerchantCity: payload.MerchantCity,
+		Amount:       payload.Amount,
+		Currency:     payload.Currency,
+		ExpiresAt:    payload.ExpiresAt,
+	}, nil
+}
+
+// SimulatePayment pays a scanned code and confirms it through the same path as a provider webhook.
+func (service *QRServiceImpl) SimulatePayment(ctx context.Context, provider string, request web.QRSimulatorRequest) (domain.Payment, error) {
+	scan, err := service.Scan(ctx, provider, request)
+	if err != nil {
+		return domain.Payment{}, err
+	}
+
+	return service.HandleWebhook(ctx, provider, web.QRWebhookRequest{
+		Reference: scan.Reference,
+		Status:    "paid",
+		Amount:    scan.Amount,
+	})
+}
+
+func (service *QRServiceImpl) findByReference(ctx context.Context, provider string, reference string) (domain.Payment, error) {
+	payments, err := service.PaymentRepository.FindByProviderReferences(ctx, service.DB, provider, []string{reference})
+	if err != nil {
+		return domain.Payment{}, err
+	}
+	if len(payments) == 0 || payments[0].QRPayload == "" {
+		return domain.Payment{}, exception.NotFoundError{Message: fmt.Sprintf("no QR payment with reference %s at %s", reference, provider)}
+	}
+
+	return payments[0], nil
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+
+	"gorm.io/gorm"
+)
+
+type RiskService interface {
+	Locate(ipAddress string) string
+	Evaluate(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.RiskAssessment, error)
+	Record(ctx context.Context, tx *gorm.DB, assessment domain.RiskAssessment) (domain.RiskAssessment, error)
+	FindAssessmentsByPaymentId(ctx context.Context, paymentId string) ([]domain.RiskAssessment, error)
+}
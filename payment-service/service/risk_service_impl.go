@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"payment-service/config"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Scores contributed by each rule. Blocklist and hard amount limits alone reach the deny score.
+const (
+	velocityScore        = 40
+	reviewAmountScore    = 30
+	repeatedFailureScore = 50
+	blockScore           = 100
+)
+
+type RiskServiceImpl struct {
+	RiskRepository repository.RiskRepository
+	Config         config.RiskConfig
+	DB             *gorm.DB
+}
+
+func NewRiskService(riskRepository repository.RiskRepository, riskConfig config.RiskConfig, DB *gorm.DB) RiskService {
+	return &RiskServiceImpl{
+		RiskRepository: riskRepository,
+		Config:         riskConfig,
+		DB:             DB,
+	}
+}
+
+// Locate returns the country of a client address from the configured table, or "" when the
+// address is in none of its networks.
+func (service *RiskServiceImpl) Locate(ipAddress string) string {
+	return service.Config.IPCountries.Lookup(ipAddress)
+}
+
+// Evaluate scores a payment that is about to be created. The returned assessment is not stored;
+// callers record it once the payment itself has been saved.
+func (service *RiskServiceImpl) Evaluate(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.RiskAssessment, error) {
+	now := time.Now()
+	rules := service.Config
+	score := 0
+	fired := []string{}
+
+	fire := func(rule string, points int) {
+		score += points
+		fired = append(fired, rule)
+	}
+
+	if containsFold(rules.BlockedProviders, payment.Provider) {
+		fire("provider_blocklist", blockScore)
+	}
+
+	if payment.Country != "" && containsFold(rules.BlockedCountries, payment.Country) {
+		fire("country_blocklist", blockScore)
+	}
+
+	if rules.DenyAmount > 0 && payment.Amount >= rules.DenyAmount {
+		fire("amount_deny_threshold", blockScore)
+	} else if rules.ReviewAmount > 0 && payment.Amount >= rules.ReviewAmount {
+		fire("amount_review_threshold", reviewAmountScore)
+	}
+
+	since := now.Add(-rules.VelocityWindow)
+	if payment.CustomerID != "" {
+		count, err := service.RiskRepository.CountPaymentsByCustomerSince(ctx, tx, payment.CustomerID, since)
+		if err != nil {
+			return domain.RiskAssessment{}, err
+		}
+		if count >= rules.MaxPaymentsPerWindow {
+			fire("customer_velocity", velocityScore)
+		}
+	}
+
+	if payment.IPAddress != "" {
+		count, err := service.RiskRepository.CountPaymentsByIPSince(ctx, tx, payment.IPAddress, since)
+		if err != nil {
+			return domain.RiskAssessment{}, err
+		}
+		if count >= rules.MaxPaymentsPerWindow {
+			fire("ip_velocity", velocityScore)
+		}
+	}
+
+	failures, err := service.RiskRepository.CountFailuresSince(ctx, tx, payment.CustomerID, payment.OrderID.String(), now.Add(-rules.FailureWindow))
+	if err != nil {
+		return domain.RiskAssessment{}, err
+	}
+	if failures >= rules.MaxRecentFailures {
+		fire("repeated_failures", repeatedFailureScore)
+	}
+
+	decision := domain.RiskAllow
+	if score >= rules.DenyScore {
+		decision = domain.RiskDeny
+	} else if score >= rules.ReviewScore {
+		decision = domain.RiskReview
+	}
+
+	return domain.RiskAssessment{
+		ID:         uuid.New(),
+		PaymentID:  payment.ID,
+		Source:     "engine",
+		Score:      score,
+		Decision:   decision,
+		FiredRules: fired,
+	}, nil
+}
+
+func (service *RiskServiceImpl) Record(ctx context.Context, tx *gorm.DB, assessment domain.RiskAssessment) (domain.RiskAssessment, error) {
+	if assessment.ID == uuid.Nil {
+		assessment.ID = uuid.New()
+	}
+	if assessment.FiredRules == nil {
+		assessment.FiredRules = []string{}
+	}
+
+	return service.RiskRepository.SaveAssessment(ctx, tx, assessment)
+}
+
+func (service *RiskServiceImpl) FindAssessmentsByPaymentId(ctx context.Context, paymentId string) ([]domain.RiskAssessment, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.RiskRepository.FindAssessmentsByPaymentId(ctx, tx, paymentId)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
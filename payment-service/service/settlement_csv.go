@@ -0,0 +1,94 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// settlementLine is one row of a provider settlement file.
+type settlementLine struct {
+	ProviderReference string
+	Amount            int64
+	Status            string
+}
+
+// parseSettlementCSV reads a settlement file with a header row. The provider_reference, amount
+// and status columns are required; any other column is ignored.
+func parseSettlementCSV(file io.Reader) ([]settlementLine, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("settlement file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"provider_reference", "amount", "status"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("settlement file is missing the %s column", required)
+		}
+	}
+
+	var lines []settlementLine
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			index := columns[name]
+			if index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		reference := field("provider_reference")
+		if reference == "" {
+			return nil, fmt.Errorf("row %d: provider_reference is empty", row)
+		}
+
+		amount, err := strconv.ParseInt(field("amount"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid amount %q", row, field("amount"))
+		}
+
+		lines = append(lines, settlementLine{
+			ProviderReference: reference,
+			Amount:            amount,
+			Status:            normalizeSettlementStatus(field("status")),
+		})
+	}
+
+	return lines, nil
+}
+
+// normalizeSettlementStatus maps provider wording onto payment statuses.
+func normalizeSettlementStatus(status string) string {
+	switch strings.ToLower(status) {
+	case "settled", "success", "paid", "captured":
+		return "success"
+	case "refund", "refunded":
+		return "refunded"
+	case "chargeback", "charged_back":
+		return "charged_back"
+	default:
+		return strings.ToLower(status)
+	}
+}
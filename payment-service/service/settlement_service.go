@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	"io"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+)
+
+type SettlementService interface {
+	Import(ctx context.Context, request web.SettlementImportRequest, file io.Reader) (domain.SettlementReport, error)
+	FindReports(ctx context.Context) ([]domain.SettlementReport, error)
+	FindReportById(ctx context.Context, reportId string) (domain.SettlementReport, error)
+	FindExceptions(ctx context.Context, reportId string, includeResolved bool) ([]domain.SettlementItem, error)
+	ResolveItem(ctx context.Context, itemId string, request web.SettlementResolveRequest) (domain.SettlementItem, error)
+}
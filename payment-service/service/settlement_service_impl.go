@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SettlementServiceImpl struct {
+	PaymentRepository    repository.PaymentRepository
+	SettlementRepository repository.SettlementRepository
+	DB                   *gorm.DB
+	Validate             *validator.Validate
+}
+
+func NewSettlementService(paymentRepository repository.PaymentRepository, settlementRepository repository.SettlementRepository, DB *gorm.DB, validate *validator.Validate) SettlementService {
+	return &SettlementServiceImpl{
+		PaymentRepository:    paymentRepository,
+		SettlementRepository: settlementRepository,
+		DB:                   DB,
+		Validate:             validate,
+	}
+}
+
+// Import parses a provider settlement file and reconciles it against our payments. Lines are
+// matched by provider reference; successful payments paid on the settlement date that are not in
+// the file are reported as missing.
+func (service *SettlementServiceImpl) Import(ctx context.Context, request web.SettlementImportRequest, file io.Reader) (_ domain.SettlementReport, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.SettlementReport{}, err
+	}
+
+	settlementDate, err := time.Parse("2006-01-02", request.SettlementDate)
+	if err != nil {
+		return domain.SettlementReport{}, errors.New("settlement_date must be YYYY-MM-DD")
+	}
+
+	lines, err := parseSettlementCSV(file)
+	if err != nil {
+		return domain.SettlementReport{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	references := make([]string, 0, len(lines))
+	for _, line := range lines {
+		references = append(references, line.ProviderReference)
+	}
+
+	payments, err := service.PaymentRepository.FindByProviderReferences(ctx, tx, request.Provider, references)
+	if err != nil {
+		return domain.SettlementReport{}, err
+	}
+
+	paymentsByReference := map[string]domain.Payment{}
+	for _, payment := range payments {
+		paymentsByReference[payment.ProviderReference] = payment
+	}
+
+	report := domain.SettlementReport{
+		ID:             uuid.New(),
+		Provider:       request.Provider,
+		SettlementDate: settlementDate,
+		FileName:       request.FileName,
+		TotalLines:     len(lines),
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		item := domain.SettlementItem{
+			ID:                uuid.New(),
+			ReportID:          report.ID,
+			ProviderReference: line.ProviderReference,
+			SettledAmount:     line.Amount,
+			SettledStatus:     line.Status,
+		}
+
+		payment, found := paymentsByReference[line.ProviderReference]
+		if !found || seen[payment.ID] {
+			// Unknown references and repeated lines for one payment are both extra money movements.
+			item.Result = domain.SettlementExtra
+		} else {
+			seen[payment.ID] = true
+			paymentId := payment.ID
+			item.PaymentID = &paymentId
+			item.ExpectedAmount = payment.Amount
+			item.ExpectedStatus = payment.Status
+			item.Result = reconcileSettlementLine(payment, line)
+		}
+
+		report.Items = append(report.Items, item)
+	}
+
+	dayEnd := settlementDate.Add(24*time.Hour - time.Nanosecond)
+	settled, err := service.PaymentRepository.FindAll(ctx, tx, repository.PaymentFilter{
+		Provider: request.Provider,
+		Status:   "success",
+		PaidFrom: &settlementDate,
+		PaidTo:   &dayEnd,
+	})
+	if err != nil {
+		return domain.SettlementReport{}, err
+	}
+
+	for _, payment := range settled {
+		if seen[payment.ID] {
+			continue
+		}
+		paymentId := payment.ID
+		report.Items = append(report.Items, domain.SettlementItem{
+			ID:                uuid.New(),
+			ReportID:          report.ID,
+			ProviderReference: payment.ProviderReference,
+			PaymentID:         &paymentId,
+			ExpectedAmount:    payment.Amount,
+			ExpectedStatus:    payment.Status,
+			Result:            domain.SettlementMissing,
+		})
+	}
+
+	for _, item := range report.Items {
+		if item.Result == domain.SettlementMatched {
+			report.MatchedCount++
+		} else {
+			report.ExceptionCount++
+		}
+	}
+
+	saved, err := service.SettlementRepository.SaveReport(ctx, tx, report)
+	if err != nil {
+		return domain.SettlementReport{}, err
+	}
+
+	return saved, nil
+}
+
+func reconcileSettlementLine(payment domain.Payment, line settlementLine) string {
+	if payment.Amount != line.Amount {
+		return domain.SettlementAmountMismatch
+	}
+
+	if payment.Status != line.Status {
+		return domain.SettlementStatusMismatch
+	}
+
+	return domain.SettlementMatched
+}
+
+func (service *SettlementServiceImpl) FindReports(ctx context.Context) ([]domain.SettlementReport, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.SettlementRepository.FindReports(ctx, tx)
+}
+
+func (service *SettlementServiceImpl) FindReportById(ctx context.Context, reportId string) (domain.SettlementReport, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.SettlementRepository.FindReportById(ctx, tx, reportId)
+}
+
+func (service *SettlementServiceImpl) FindExceptions(ctx context.Context, reportId string, includeResolved bool) ([]domain.SettlementItem, error) {
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx)
+
+	return service.SettlementRepository.FindExceptions(ctx, tx, reportId, includeResolved)
+}
+
+func (service *SettlementServiceImpl) ResolveItem(ctx context.Context, itemId string, request web.SettlementResolveRequest) (_ domain.SettlementItem, err error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.SettlementItem{}, err
+	}
+
+	tx := service.DB.Begin()
+	defer helper.CommitOrRollback(tx, &err)
+
+	item, err := service.SettlementRepository.FindItemById(ctx, tx, itemId)
+	if err != nil {
+		return domain.SettlementItem{}, err
+	}
+
+	if item.Result == domain.SettlementMatched {
+		return domain.SettlementItem{}, errors.New("matched items need no resolution")
+	}
+
+	if item.Resolved {
+		return domain.SettlementItem{}, fmt.Errorf("settlement item %s already resolved", item.ID)
+	}
+
+	now := time.Now()
+	item.Resolved = true
+	item.ResolutionNote = request.Note
+	item.ResolvedAt = &now
+
+	return service.SettlementRepository.UpdateItem(ctx, tx, item)
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+)
+
+type SplitPaymentService interface {
+	Create(ctx context.Context, request web.SplitPaymentCreateRequest) (domain.SplitPayment, error)
+	FindById(ctx context.Context, splitId string) (domain.SplitPayment, error)
+	FindByOrderId(ctx context.Context, orderId string) (domain.SplitPayment, error)
+}
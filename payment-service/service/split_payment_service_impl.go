@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/exception"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"slices"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SplitPaymentServiceImpl struct {
+	SplitPaymentRepository repository.SplitPaymentRepository
+	InstallmentRepository  repository.InstallmentRepository
+	PaymentService         PaymentService
+	DB                     *gorm.DB
+	Validate               *validator.Validate
+}
+
+func NewSplitPaymentService(splitPaymentRepository repository.SplitPaymentRepository, installmentRepository repository.InstallmentRepository, paymentService PaymentService, DB *gorm.DB, validate *validator.Validate) SplitPaymentService {
+	return &SplitPaymentServiceImpl{
+		SplitPaymentRepository: splitPaymentRepository,
+		InstallmentRepository:  installmentRepository,
+		PaymentService:         paymentService,
+		DB:                     DB,
+		Validate:               validate,
+	}
+}
+
+// Create opens a split payment for the order total. The tenders are then paid one by one through
+// POST /payments with split_tender set.
+func (service *SplitPaymentServiceImpl) Create(ctx context.Context, request web.SplitPaymentCreateRequest) (domain.SplitPayment, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.SplitPayment{}, err
+	}
+
+	totalAmount, currency, err := fetchOrderAmount(ctx, request.OrderID)
+	if err != nil {
+		return domain.SplitPayment{}, err
+	}
+
+	existing, err := service.SplitPaymentRepository.FindByOrderId(ctx, service.DB, request.OrderID.String())
+	if err == nil {
+		return domain.SplitPayment{}, exception.ConflictError{Message: "order already has a split payment", Data: existing}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.SplitPayment{}, err
+	}
+
+	_, err = service.InstallmentRepository.FindByOrderId(ctx, service.DB, request.OrderID.String())
+	if err == nil {
+		return domain.SplitPayment{}, errors.New("order is paid in installments")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.SplitPayment{}, err
+	}
+
+	payments, err := service.PaymentService.FindAllByOrderId(ctx, request.OrderID.String())
+	if err != nil {
+		return domain.SplitPayment{}, err
+	}
+	for _, payment := range payments {
+		if slices.Contains(repository.ActivePaymentStatuses, payment.Status) {
+			return domain.SplitPayment{}, fmt.Errorf("order already has a %s payment", payment.Status)
+		}
+	}
+
+	split := domain.SplitPayment{
+		ID:          uuid.New(),
+		OrderID:     request.OrderID,
+		TotalAmount: totalAmount,
+		Currency:    currency,
+		Status:      domain.SplitPaymentOpen,
+		Tenders:     []domain.Payment{},
+	}
+
+	return service.SplitPaymentRepository.Save(ctx, service.DB, split)
+}
+
+func (service *SplitPaymentServiceImpl) FindById(ctx context.Context, splitId string) (domain.SplitPayment, error) {
+	split, err := service.SplitPaymentRepository.FindById(ctx, service.DB, splitId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.SplitPayment{}, exception.NotFoundError{Message: "split payment not found"}
+	}
+	return split, err
+}
+
+func (service *SplitPaymentServiceImpl) FindByOrderId(ctx context.Context, orderId string) (domain.SplitPayment, error) {
+	split, err := service.SplitPaymentRepository.FindByOrderId(ctx, service.DB, orderId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.SplitPayment{}, exception.NotFoundError{Message: "order has no split payment"}
+	}
+	return split, err
+}
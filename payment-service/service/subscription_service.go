@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"time"
+)
+
+type SubscriptionService interface {
+	CreatePlan(ctx context.Context, request web.SubscriptionPlanCreateRequest) (domain.SubscriptionPlan, error)
+	FindPlans(ctx context.Context) ([]domain.SubscriptionPlan, error)
+	FindPlanById(ctx context.Context, planId string) (domain.SubscriptionPlan, error)
+	Create(ctx context.Context, request web.SubscriptionCreateRequest) (domain.Subscription, error)
+	FindById(ctx context.Context, subscriptionId string) (domain.Subscription, error)
+	FindAllByCustomerId(ctx context.Context, customerId string) ([]domain.Subscription, error)
+	FindCharges(ctx context.Context, subscriptionId string) ([]domain.SubscriptionCharge, error)
+	Pause(ctx context.Context, subscriptionId string) (domain.Subscription, error)
+	Resume(ctx context.Context, subscriptionId string) (domain.Subscription, error)
+	Cancel(ctx context.Context, subscriptionId string) (domain.Subscription, error)
+	ChangePlan(ctx context.Context, subscriptionId string, request web.SubscriptionPlanChangeRequest) (domain.Subscription, error)
+	BillDue(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// subscriptionActor is recorded on the payment timeline for charges made by the scheduler.
+const subscriptionActor = "subscription-scheduler"
+
+type SubscriptionServiceImpl struct {
+	SubscriptionRepository repository.SubscriptionRepository
+	PaymentService         PaymentService
+	PaymentMethodService   PaymentMethodService
+	Config                 config.SubscriptionConfig
+	DB                     *gorm.DB
+	Validate               *validator.Validate
+}
+
+func NewSubscriptionService(subscriptionRepository repository.SubscriptionRepository, paymentService PaymentService, paymentMethodService PaymentMethodService, subscriptionConfig config.SubscriptionConfig, DB *gorm.DB, validate *validator.Validate) SubscriptionService {
+	return &SubscriptionServiceImpl{
+		SubscriptionRepository: subscriptionRepository,
+		PaymentService:         paymentService,
+		PaymentMethodService:   paymentMethodService,
+		Config:                 subscriptionConfig,
+		DB:                     DB,
+		Validate:               validate,
+	}
+}
+
+func (service *SubscriptionServiceImpl) CreatePlan(ctx context.Context, request web.SubscriptionPlanCreateRequest) (domain.SubscriptionPlan, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.SubscriptionPlan{}, err
+	}
+
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	intervalCount := request.IntervalCount
+	if intervalCount == 0 {
+		intervalCount = 1
+	}
+
+	return service.SubscriptionRepository.SavePlan(ctx, service.DB, domain.SubscriptionPlan{
+		ID:            uuid.New(),
+		Name:          request.Name,
+		Amount:        request.Amount,
+		Currency:      currency,
+		Interval:      request.Interval,
+		IntervalCount: intervalCount,
+	})
+}
+
+func (service *SubscriptionServiceImpl) FindPlans(ctx context.Context) ([]domain.SubscriptionPlan, error) {
+	return service.SubscriptionRepository.FindPlans(ctx, service.DB)
+}
+
+func (service *SubscriptionServiceImpl) FindPlanById(ctx context.Context, planId string) (domain.SubscriptionPlan, error) {
+	plan, err := service.SubscriptionRepository.FindPlanById(ctx, service.DB, planId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.SubscriptionPlan{}, exception.NotFoundError{Message: "subscription plan not found"}
+	}
+	return plan, err
+}
+
+// Create subscribes a customer to a plan and bills the first cycle right away. A failed first
+// charge leaves the subscription past due and retried like any other cycle; when the cycle cannot
+// be billed at all the subscription stays due for the scheduler.
+func (service *SubscriptionServiceImpl) Create(ctx context.Context, request web.SubscriptionCreateRequest) (domain.Subscription, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Subscription{}, err
+	}
+
+	plan, err := service.FindPlanById(ctx, request.PlanID)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	method, err := service.PaymentMethodService.Resolve(ctx, service.DB, request.CustomerID, request.PaymentMethodID)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	now := time.Now()
+	subscription, err := service.SubscriptionRepository.Save(ctx, service.DB, domain.Subscription{
+		ID:              uuid.New(),
+		CustomerID:      request.CustomerID,
+		PlanID:          plan.ID,
+		PaymentMethodID: method.ID,
+		Provider:        method.Provider,
+		Status:          domain.SubscriptionActive,
+		NextBillingAt:   &now,
+	})
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	billed, err := service.bill(ctx, subscription, now)
+	if err != nil {
+		fmt.Printf("Warning: billing subscription %s failed: %v", subscription.ID, err)
+		return subscription, nil
+	}
+
+	return billed, nil
+}
+
+func (service *SubscriptionServiceImpl) FindById(ctx context.Context, subscriptionId string) (domain.Subscription, error) {
+	subscription, err := service.SubscriptionRepository.FindById(ctx, service.DB, subscriptionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.Subscription{}, exception.NotFoundError{Message: "subscription not found"}
+	}
+	return subscription, err
+}
+
+func (service *SubscriptionServiceImpl) FindAllByCustomerId(ctx context.Context, customerId string) ([]domain.Subscription, error) {
+	return service.SubscriptionRepository.FindAllByCustomerId(ctx, service.DB, customerId)
+}
+
+func (service *SubscriptionServiceImpl) FindCharges(ctx context.Context, subscriptionId string) ([]domain.SubscriptionCharge, error) {
+	if _, err := service.FindById(ctx, subscriptionId); err != nil {
+		return nil, err
+	}
+	return service.SubscriptionRepository.FindCharges(ctx, service.DB, subscriptionId)
+}
+
+// Pause stops billing until the subscription is resumed. The paid period is not refunded.
+func (service *SubscriptionServiceImpl) Pause(ctx context.Context, subscriptionId string) (domain.Subscription, error) {
+	subscription, err := service.FindById(ctx, subscriptionId)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	if subscription.Status != domain.SubscriptionActive && subscription.Status != domain.SubscriptionPastDue {
+		return domain.Subscription{}, fmt.Errorf("%s subscription cannot be paused", subscription.Status)
+	}
+
+	now := time.Now()
+	subscription.Status = domain.SubscriptionPaused
+	subscription.PausedAt = &now
+	subscription.NextBillingAt = nil
+
+	return service.SubscriptionRepository.Update(ctx, service.DB, subscription)
+}
+
+// Resume restarts billing. A subscription whose paid period ran out while paused starts a new
+// cycle at once; one with an unpaid charge goes back to retrying it.
+func (service *SubscriptionServiceImpl) Resume(ctx context.Context, subscriptionId string) (domain.Subscription, error) {
+	subscription, err := service.FindById(ctx, subscriptionId)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	if subscription.Status != domain.SubscriptionPaused {
+		return domain.Subscription{}, errors.New("only paused subscriptions can be resumed")
+	}
+
+	now := time.Now()
+	nextBillingAt := now
+	subscription.Status = domain.SubscriptionActive
+	if subscription.RetryCount > 0 {
+		subscription.Status = domain.SubscriptionPastDue
+	} else if subscription.CurrentPeriodEnd != nil && subscription.CurrentPeriodEnd.After(now) {
+		nextBillingAt = *subscription.CurrentPeriodEnd
+	}
+	subscription.NextBillingAt = &nextBillingAt
+	subscription.PausedAt = nil
+
+	return service.SubscriptionRepository.Update(ctx, service.DB, subscription)
+}
+
+func (service *SubscriptionServiceImpl) Cancel(ctx context.Context, subscriptionId string) (domain.Subscription, error) {
+	subscription, err := service.FindById(ctx, subscriptionId)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	if subscription.Status == domain.SubscriptionCancelled {
+		return domain.Subscription{}, errors.New("subscription already cancelled")
+	}
+
+	service.cancel(&subscription, time.Now())
+	return service.SubscriptionRepository.Update(ctx, service.DB, subscription)
+}
+
+// ChangePlan moves a subscription to another plan with the same interval and currency, keeping
+// the current cycle. The unused part of the old plan is credited and the remaining part of the
+// new plan charged: an upgrade charges the difference immediately and is refused if that charge
+// fails; a downgrade leaves the difference as credit against the next cycles.
+func (service *SubscriptionServiceImpl) ChangePlan(ctx context.Context, subscriptionId string, request web.SubscriptionPlanChangeRequest) (domain.Subscription, error) {
+	if err := service.Validate.Struct(request); err != nil {
+		return domain.Subscription{}, err
+	}
+
+	subscription, err := service.FindById(ctx, subscriptionId)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	if subscription.Status != domain.SubscriptionActive {
+		return domain.Subscription{}, fmt.Errorf("plan of a %s subscription cannot be changed", subscription.Status)
+	}
+
+	current, err := service.FindPlanById(ctx, subscription.PlanID.String())
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	next, err := service.FindPlanById(ctx, request.PlanID)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	if next.ID == current.ID {
+		return domain.Subscription{}, errors.New("subscription is already on this plan")
+	}
+	if next.Interval != current.Interval || next.IntervalCount != current.IntervalCount || next.Currency != current.Currency {
+		return domain.Subscription{}, errors.New("plan change requires the same billing interval and currency")
+	}
+
+	now := time.Now()
+	var difference int64
+	if subscription.CurrentPeriodStart != nil && subscription.CurrentPeriodEnd != nil {
+		period := subscription.CurrentPeriodEnd.Sub(*subscription.CurrentPeriodStart)
+		remaining := subscription.CurrentPeriodEnd.Sub(now)
+		difference = helper.Prorate(next.Amount, remaining, period) - helper.Prorate(current.Amount, remaining, period)
+	}
+
+	if difference > 0 {
+		charge := domain.SubscriptionCharge{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			Kind:           domain.ChargeProration,
+			Amount:         difference,
+			Currency:       next.Currency,
+			PeriodStart:    now,
+			PeriodEnd:      *subscription.CurrentPeriodEnd,
+			Status:         domain.ChargePending,
+		}
+		itemName := fmt.Sprintf("%s to %s (prorated)", current.Name, next.Name)
+		if err := service.charge(ctx, subscription, &charge, itemName); err != nil {
+			return domain.Subscription{}, err
+		}
+		if charge.Status != domain.ChargePaid {
+			return domain.Subscription{}, fmt.Errorf("prorated charge failed: %s", charge.FailureReason)
+		}
+	} else {
+		subscription.Credit -= difference
+	}
+
+	subscription.PlanID = next.ID
+	return service.SubscriptionRepository.Update(ctx, service.DB, subscription)
+}
+
+// BillDue charges every subscription whose billing time has come and returns how many were
+// billed. A subscription that cannot be billed, for example because order-service is down, is
+// left due and picked up by the next run.
+func (service *SubscriptionServiceImpl) BillDue(ctx context.Context, now time.Time) (int, error) {
+	subscriptions, err := service.SubscriptionRepository.FindDue(ctx, service.DB, now)
+	if err != nil {
+		return 0, err
+	}
+
+	billed := 0
+	var errs []error
+	for _, subscription := range subscriptions {
+		if _, err := service.bill(ctx, subscription, now); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", subscription.ID, err))
+			continue
+		}
+		billed++
+	}
+
+	return billed, errors.Join(errs...)
+}
+
+// bill charges the cycle that is due: a new cycle for an active subscription, or another attempt
+// at the unpaid charge of a past due one. Each failed attempt schedules the next retry; when the
+// retries run out the subscription is cancelled.
+func (service *SubscriptionServiceImpl) bill(ctx context.Context, subscription domain.Subscription, now time.Time) (domain.Subscription, error) {
+	plan, err := service.FindPlanById(ctx, subscription.PlanID.String())
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	var charge domain.SubscriptionCharge
+	if subscription.Status == domain.SubscriptionPastDue {
+		charge, err = service.SubscriptionRepository.FindOutstandingCharge(ctx, service.DB, subscription.ID.String())
+		if err != nil {
+			return domain.Subscription{}, err
+		}
+	} else {
+		start := *subscription.NextBillingAt
+		charge = domain.SubscriptionCharge{
+			ID:             uuid.New(),
+			SubscriptionID: subscription.ID,
+			Kind:           domain.ChargeCycle,
+			Amount:         plan.Amount,
+			Currency:       plan.Currency,
+			PeriodStart:    start,
+			PeriodEnd:      helper.NextBillingDate(start, plan.Interval, plan.IntervalCount),
+			Status:         domain.ChargePending,
+		}
+		charge.CreditApplied = min(subscription.Credit, charge.Amount)
+		charge.Amount -= charge.CreditApplied
+		subscription.Credit -= charge.CreditApplied
+	}
+
+	if err := service.charge(ctx, subscription, &charge, plan.Name); err != nil {
+		return domain.Subscription{}, err
+	}
+
+	if charge.Status == domain.ChargePaid {
+		subscription.Status = domain.SubscriptionActive
+		subscription.RetryCount = 0
+		subscription.CurrentPeriodStart = &charge.PeriodStart
+		subscription.CurrentPeriodEnd = &charge.PeriodEnd
+		subscription.NextBillingAt = &charge.PeriodEnd
+	} else {
+		subscription.RetryCount++
+		if subscription.RetryCount > len(service.Config.RetrySchedule) {
+			service.cancel(&subscription, now)
+		} else {
+			retryAt := now.Add(service.Config.RetrySchedule[subscription.RetryCount-1])
+			subscription.Status = domain.SubscriptionPastDue
+			subscription.NextBillingAt = &retryAt
+		}
+	}
+
+	return service.SubscriptionRepository.Update(ctx, service.DB, subscription)
+}
+
+// charge stores a new charge with its order in order-service, then attempts a payment against
+// the subscription's saved payment method. Only failures to reach order-service or the database
+// are returned as errors; a declined payment is recorded on the charge.
+func (service *SubscriptionServiceImpl) charge(ctx context.Context, subscription domain.Subscription, charge *domain.SubscriptionCharge, itemName string) error {
+	if charge.Attempts == 0 {
+		if charge.Amount > 0 {
+			orderId, err := createSubscriptionOrder(ctx, itemName, charge.Amount, charge.Currency, subscription.ID)
+			if err != nil {
+				return err
+			}
+			charge.OrderID = &orderId
+		}
+
+		saved, err := service.SubscriptionRepository.SaveCharge(ctx, service.DB, *charge)
+		if err != nil {
+			return err
+		}
+		*charge = saved
+	}
+
+	// Credit covered the whole cycle, so there is nothing to collect.
+	if charge.OrderID == nil {
+		charge.Status = domain.ChargePaid
+		_, err := service.SubscriptionRepository.UpdateCharge(ctx, service.DB, *charge)
+		return err
+	}
+
+	ctx = domain.WithEventOrigin(ctx, domain.EventOrigin{Source: domain.EventSourceJob, Actor: subscriptionActor})
+	charge.Attempts++
+	charge.FailureReason = ""
+
+	payment, err := service.PaymentService.Create(ctx, web.PaymentCreateRequest{
+		OrderID:         *charge.OrderID,
+		Amount:          charge.Amount,
+		Currency:        charge.Currency,
+		Provider:        subscription.Provider,
+		Method:          "card",
+		CustomerID:      subscription.CustomerID,
+		PaymentMethodID: subscription.PaymentMethodID.String(),
+	})
+	if err == nil {
+		charge.PaymentID = &payment.ID
+		if payment.Status == "pending" {
+			payment, err = service.PaymentService.MarkAsSuccess(ctx, payment.ID.String())
+			if err != nil {
+				// Release the order so the next retry can make a new attempt.
+				if _, failErr := service.PaymentService.MarkAsFailed(ctx, charge.PaymentID.String()); failErr != nil {
+					fmt.Printf("Warning: failing subscription payment %s failed: %v", charge.PaymentID, failErr)
+				}
+			}
+		}
+	}
+
+	switch {
+	case err != nil:
+		charge.Status = domain.ChargeFailed
+		charge.FailureReason = err.Error()
+	case payment.Status != "success":
+		charge.Status = domain.ChargeFailed
+		charge.FailureReason = "payment " + payment.Status
+	default:
+		charge.Status = domain.ChargePaid
+	}
+
+	_, err = service.SubscriptionRepository.UpdateCharge(ctx, service.DB, *charge)
+	return err
+}
+
+func (service *SubscriptionServiceImpl) cancel(subscription *domain.Subscription, now time.Time) {
+	subscription.Status = domain.SubscriptionCancelled
+	subscription.CancelledAt = &now
+	subscription.NextBillingAt = nil
+}
@@ -0,0 +1,194 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type balanceFixture struct {
+	db                  *gorm.DB
+	paymentService      service.PaymentService
+	balanceService      service.BalanceService
+	splitPaymentService service.SplitPaymentService
+	stub                *orderStub
+	callbacks           *[]web.PaymentCallbackRequest
+	app                 *fiber.App
+}
+
+// setupBalances wires gift cards valid for 30 days, and records every callback sent to the order
+// service.
+func setupBalances(t *testing.T) balanceFixture {
+	stub := serveOrderStub(t)
+
+	callbacks := []web.PaymentCallbackRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload web.PaymentCallbackRequest
+		json.NewDecoder(r.Body).Decode(&payload)
+		callbacks = append(callbacks, payload)
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("ORDER_CALLBACK_URL", srv.URL)
+	t.Cleanup(func() { os.Setenv("ORDER_CALLBACK_URL", "") })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	installmentRepository := repository.NewInstallmentRepository(db)
+	splitPaymentRepository := repository.NewSplitPaymentRepository(db)
+	balanceService := service.NewBalanceService(repository.NewBalanceRepository(db), config.BalanceConfig{GiftCardValidity: 30 * 24 * time.Hour}, db, validate)
+	deps := newTestPaymentDeps(db)
+	deps.InstallmentRepository = installmentRepository
+	deps.SplitPaymentRepository = splitPaymentRepository
+	deps.BalanceService = balanceService
+	paymentService := service.NewPaymentService(deps)
+	splitPaymentService := service.NewSplitPaymentService(splitPaymentRepository, installmentRepository, paymentService, db, validate)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, db)
+
+	app := fiber.New()
+	routes.PaymentRoutes(app, controller.NewPaymentController(paymentService), idempotencyService)
+	routes.BalanceRoutes(app, controller.NewBalanceController(balanceService))
+
+	return balanceFixture{db, paymentService, balanceService, splitPaymentService, stub, &callbacks, app}
+}
+
+func (fixture balanceFixture) placeOrder(total int64) uuid.UUID {
+	orderId := uuid.New()
+	fixture.stub.orders[orderId.String()] = map[string]interface{}{"Price": float64(total), "Currency": "IDR"}
+	return orderId
+}
+
+func TestGiftCardRedeemAndRefund(t *testing.T) {
+	fixture := setupBalances(t)
+
+	status, response := postJSON(t, fixture.app, "/balance-accounts", map[string]interface{}{"type": "gift_card", "amount": 150_000})
+	assert.Equal(t, http.StatusOK, status)
+	issued := response.Data.(map[string]interface{})
+	code := issued["code"].(string)
+	assert.Regexp(t, `^GC(-[A-HJ-NP-Z2-9]{4}){4}$`, code)
+	assert.Equal(t, "IDR", issued["currency"])
+	expiresAt, err := time.Parse(time.RFC3339Nano, issued["expires_at"].(string))
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), expiresAt, time.Minute)
+
+	orderId := fixture.placeOrder(100_000)
+	status, response = postJSON(t, fixture.app, "/payments", map[string]interface{}{"order_id": orderId, "amount": 100_000, "provider": "balance", "balance_code": code})
+	assert.Equal(t, http.StatusOK, status)
+	paid := response.Data.(map[string]interface{})
+	assert.Equal(t, "success", paid["status"])
+	assert.Equal(t, domain.BalanceGiftCard, paid["method"])
+	assert.Equal(t, issued["id"], paid["balance_account_id"])
+	assert.Equal(t, "success", (*fixture.callbacks)[0].PaymentStatus)
+
+	account, err := fixture.balanceService.FindByCode(context.Background(), code)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50_000), account.Balance)
+
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: fixture.placeOrder(75_000), Amount: 75_000, Provider: "balance", BalanceCode: code})
+	assert.EqualError(t, err, "insufficient balance: 50000 IDR available")
+
+	_, err = fixture.paymentService.Refund(context.Background(), paid["id"].(string))
+	assert.NoError(t, err)
+
+	resp, _ := fixture.app.Test(httptest.NewRequest(http.MethodGet, "/balance-accounts/"+code+"/transactions", nil), -1)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var history struct {
+		Data []domain.BalanceTransaction `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&history)
+	assert.Len(t, history.Data, 3)
+	types := []string{}
+	for _, transaction := range history.Data {
+		types = append(types, transaction.Type)
+	}
+	assert.Equal(t, []string{domain.BalanceIssue, domain.BalanceRedeem, domain.BalanceReversal}, types)
+	assert.Equal(t, int64(-100_000), history.Data[1].Amount)
+	assert.Equal(t, int64(150_000), history.Data[2].BalanceAfter)
+
+	resp, _ = fixture.app.Test(httptest.NewRequest(http.MethodGet, "/balance-accounts/GC-NONE-NONE-NONE-NONE", nil), -1)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestStoreCreditRules(t *testing.T) {
+	fixture := setupBalances(t)
+
+	_, err := fixture.balanceService.Issue(context.Background(), web.BalanceAccountIssueRequest{Type: domain.BalanceStoreCredit, Amount: 50_000})
+	assert.EqualError(t, err, "store credit needs a customer_id")
+
+	credit, err := fixture.balanceService.Issue(context.Background(), web.BalanceAccountIssueRequest{Type: domain.BalanceStoreCredit, CustomerID: "cust-1", Amount: 50_000})
+	assert.NoError(t, err)
+	assert.Nil(t, credit.ExpiresAt)
+	assert.Regexp(t, `^SC-`, credit.Code)
+
+	orderId := fixture.placeOrder(50_000)
+	pay := func(customerId string, code string, provider string) error {
+		_, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 50_000, Provider: provider, CustomerID: customerId, BalanceCode: code})
+		return err
+	}
+
+	assert.EqualError(t, pay("cust-2", credit.Code, "balance"), "store credit belongs to another customer")
+	assert.EqualError(t, pay("cust-1", "", "balance"), "balance_code is required for balance payments")
+	assert.EqualError(t, pay("cust-1", credit.Code, "stripe"), "balance_code can only be used with provider balance")
+
+	usd, err := fixture.balanceService.Issue(context.Background(), web.BalanceAccountIssueRequest{Type: domain.BalanceGiftCard, Amount: 50_000, Currency: "usd"})
+	assert.NoError(t, err)
+	assert.EqualError(t, pay("cust-1", usd.Code, "balance"), "balance account is in USD")
+
+	assert.NoError(t, fixture.db.Model(&domain.BalanceAccount{}).Where("id = ?", credit.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	assert.EqualError(t, pay("cust-1", credit.Code, "balance"), "balance account has expired")
+
+	// Codes are matched regardless of case.
+	assert.NoError(t, fixture.db.Model(&domain.BalanceAccount{}).Where("id = ?", credit.ID).Update("expires_at", nil).Error)
+	assert.NoError(t, pay("cust-1", strings.ToLower(credit.Code), "balance"))
+}
+
+func TestGiftCardTenderIsRefundedWhenCardTenderFails(t *testing.T) {
+	fixture := setupBalances(t)
+
+	card, err := fixture.balanceService.Issue(context.Background(), web.BalanceAccountIssueRequest{Type: domain.BalanceGiftCard, Amount: 30_000})
+	assert.NoError(t, err)
+
+	orderId := fixture.placeOrder(100_000)
+	_, err = fixture.splitPaymentService.Create(context.Background(), web.SplitPaymentCreateRequest{OrderID: orderId})
+	assert.NoError(t, err)
+
+	giftCardTender, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 30_000, Provider: "balance", BalanceCode: card.Code, SplitTender: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "success", giftCardTender.Status)
+	assert.Equal(t, "partially_paid", (*fixture.callbacks)[0].PaymentStatus)
+
+	cardTender, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 70_000, Provider: "stripe", SplitTender: true})
+	assert.NoError(t, err)
+	_, err = fixture.paymentService.MarkAsFailed(context.Background(), cardTender.ID.String())
+	assert.NoError(t, err)
+
+	refunded, err := fixture.paymentService.FindById(context.Background(), giftCardTender.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "refunded", refunded.Status)
+
+	account, err := fixture.balanceService.FindByCode(context.Background(), card.Code)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30_000), account.Balance)
+}
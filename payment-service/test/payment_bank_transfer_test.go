@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupBankTransfers(t *testing.T) (*gorm.DB, service.PaymentService, service.BankTransferService, *fiber.App) {
+	os.Setenv("ORDER_CALLBACK_URL", "")
+	serveOrder(t, 150_000, "IDR")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	bankTransferConfig := config.BankTransferConfig{
+		VirtualAccountProviders: []string{"bca"},
+		UniqueAmountProviders:   []string{"mandiri"},
+		VirtualAccountPrefix:    "8808",
+		MaxUniqueCode:           999,
+		TTL:                     24 * time.Hour,
+		Grace:                   30 * time.Minute,
+	}
+
+	validate := validator.New()
+	paymentRepository := repository.NewPaymentRepository(db)
+	deps := newTestPaymentDeps(db)
+	deps.PaymentRepository = paymentRepository
+	deps.BankTransferConfig = bankTransferConfig
+	paymentService := service.NewPaymentService(deps)
+	bankTransferService := service.NewBankTransferService(repository.NewBankMutationRepository(db), paymentRepository, paymentService, bankTransferConfig, db, validate)
+
+	app := fiber.New()
+	routes.BankTransferRoutes(app, controller.NewBankTransferController(bankTransferService), true)
+
+	return db, paymentService, bankTransferService, app
+}
+
+func createBankTransfer(t *testing.T, paymentService service.PaymentService, provider string) domain.Payment {
+	payment, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 150_000, Provider: provider, Method: "bank_transfer"})
+	assert.NoError(t, err)
+	return payment
+}
+
+func mutationCSV(lines ...string) *strings.Reader {
+	return strings.NewReader("external_id,virtual_account,amount,transacted_at,description\n" + strings.Join(lines, "\n") + "\n")
+}
+
+func TestBankTransferIssuesUniqueDetails(t *testing.T) {
+	_, paymentService, _, _ := setupBankTransfers(t)
+
+	first := createBankTransfer(t, paymentService, "bca")
+	second := createBankTransfer(t, paymentService, "bca")
+	assert.Equal(t, "pending", first.Status)
+	assert.Equal(t, domain.BankTransferVirtualAccount, first.BankTransfer.Mode)
+	assert.Len(t, first.BankTransfer.VirtualAccount, 14)
+	assert.True(t, strings.HasPrefix(first.BankTransfer.VirtualAccount, "8808"))
+	assert.NotEqual(t, first.BankTransfer.VirtualAccount, second.BankTransfer.VirtualAccount)
+	assert.Equal(t, int64(150_000), first.BankTransfer.TransferAmount)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *first.ExpiresAt, time.Minute)
+
+	unique := createBankTransfer(t, paymentService, "mandiri")
+	next := createBankTransfer(t, paymentService, "mandiri")
+	assert.Equal(t, int64(1), unique.BankTransfer.UniqueCode)
+	assert.Equal(t, int64(150_001), unique.BankTransfer.TransferAmount)
+	assert.Equal(t, int64(150_002), next.BankTransfer.TransferAmount)
+	assert.Equal(t, int64(150_000), next.Amount)
+
+	_, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 150_000, Provider: "stripe", Method: "bank_transfer"})
+	assert.ErrorContains(t, err, "does not support bank transfer payments")
+}
+
+func TestImportMatchesMutations(t *testing.T) {
+	db, paymentService, bankTransferService, _ := setupBankTransfers(t)
+	va := createBankTransfer(t, paymentService, "bca")
+	underpaid := createBankTransfer(t, paymentService, "bca")
+	unique := createBankTransfer(t, paymentService, "mandiri")
+
+	transactedAt := time.Now().Add(time.Minute).UTC().Truncate(time.Second)
+	at := transactedAt.Format(time.RFC3339)
+	statement := func() *strings.Reader {
+		return mutationCSV(
+			fmt.Sprintf("T1,%s,150000,%s,transfer", va.BankTransfer.VirtualAccount, at),
+			fmt.Sprintf("T2,%s,100000,%s,", underpaid.BankTransfer.VirtualAccount, at),
+			fmt.Sprintf("T3,,150001,%s,", at),
+			fmt.Sprintf("T4,,99999,%s,unknown", at),
+		)
+	}
+
+	result, err := bankTransferService.Import(context.Background(), web.BankMutationImportRequest{Provider: "bca"}, statement())
+	assert.NoError(t, err)
+	assert.Equal(t, web.BankMutationImportResponse{Imported: 4, Matched: 1, Unmatched: 3}, result)
+
+	// The unique amount belongs to another provider's account, so only that provider's statement matches it.
+	result, err = bankTransferService.Import(context.Background(), web.BankMutationImportRequest{Provider: "mandiri"}, mutationCSV(fmt.Sprintf("T3,,150001,%s,", at)))
+	assert.NoError(t, err)
+	assert.Equal(t, web.BankMutationImportResponse{Imported: 1, Matched: 1}, result)
+
+	result, err = bankTransferService.Import(context.Background(), web.BankMutationImportRequest{Provider: "bca"}, statement())
+	assert.NoError(t, err)
+	assert.Equal(t, web.BankMutationImportResponse{Skipped: 4}, result)
+
+	for _, paid := range []domain.Payment{va, unique} {
+		var stored domain.Payment
+		assert.NoError(t, db.First(&stored, "id = ?", paid.ID).Error)
+		assert.Equal(t, "success", stored.Status)
+		assert.True(t, transactedAt.Equal(*stored.PaidAt))
+	}
+
+	exceptions, err := bankTransferService.FindExceptions(context.Background(), false)
+	assert.NoError(t, err)
+	reasons := map[string]string{}
+	for _, mutation := range exceptions {
+		reasons[mutation.ExternalID] = mutation.Reason
+	}
+	assert.Equal(t, map[string]string{"T2": domain.MutationAmountMismatch, "T3": domain.MutationNoMatch, "T4": domain.MutationNoMatch}, reasons)
+
+	_, err = bankTransferService.Import(context.Background(), web.BankMutationImportRequest{Provider: "bca"}, mutationCSV("T5,,100,yesterday,"))
+	assert.ErrorContains(t, err, "row 2")
+}
+
+func TestTransferMatchedByBookingTime(t *testing.T) {
+	db, paymentService, bankTransferService, _ := setupBankTransfers(t)
+	payment := createBankTransfer(t, paymentService, "bca")
+	late := createBankTransfer(t, paymentService, "bca")
+
+	createdAt := time.Now().Add(-3 * time.Hour)
+	expiresAt := time.Now().Add(-2 * time.Hour)
+	for _, id := range []uuid.UUID{payment.ID, late.ID} {
+		assert.NoError(t, db.Model(&domain.Payment{}).Where("id = ?", id).Updates(map[string]interface{}{"created_at": createdAt, "expires_at": expiresAt}).Error)
+	}
+
+	// Booked before the payment expired but imported afterwards: still paid.
+	mutation, err := bankTransferService.Receive(context.Background(), "bca", web.BankMutationRequest{
+		ExternalID: "T1", VirtualAccount: payment.BankTransfer.VirtualAccount, Amount: 150_000,
+		TransactedAt: expiresAt.Add(-time.Minute).UTC().Format(time.RFC3339),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MutationMatched, mutation.Status)
+
+	// Booked after expiry plus grace: left for an operator.
+	mutation, err = bankTransferService.Receive(context.Background(), "bca", web.BankMutationRequest{
+		ExternalID: "T2", VirtualAccount: late.BankTransfer.VirtualAccount, Amount: 150_000,
+		TransactedAt: expiresAt.Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MutationUnmatched, mutation.Status)
+	assert.Equal(t, domain.MutationOutsideWindow, mutation.Reason)
+}
+
+func TestResolveUnmatchedMutation(t *testing.T) {
+	db, paymentService, bankTransferService, _ := setupBankTransfers(t)
+	payment := createBankTransfer(t, paymentService, "bca")
+
+	at := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	// The customer typed the wrong virtual account number.
+	mutation, err := bankTransferService.Receive(context.Background(), "bca", web.BankMutationRequest{ExternalID: "T1", VirtualAccount: "880800000000", Amount: 150_000, TransactedAt: at})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MutationNoMatch, mutation.Reason)
+
+	resolved, err := bankTransferService.Resolve(context.Background(), mutation.ID.String(), web.BankMutationResolveRequest{Note: "customer confirmed by phone", PaymentID: payment.ID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.MutationMatched, resolved.Status)
+	assert.True(t, resolved.Resolved)
+	assert.Equal(t, payment.ID, *resolved.PaymentID)
+
+	var stored domain.Payment
+	assert.NoError(t, db.First(&stored, "id = ?", payment.ID).Error)
+	assert.Equal(t, "success", stored.Status)
+
+	_, err = bankTransferService.Resolve(context.Background(), mutation.ID.String(), web.BankMutationResolveRequest{Note: "again"})
+	assert.Error(t, err)
+
+	stray, err := bankTransferService.Receive(context.Background(), "bca", web.BankMutationRequest{ExternalID: "T2", Amount: 5_000, TransactedAt: at})
+	assert.NoError(t, err)
+	_, err = bankTransferService.Resolve(context.Background(), stray.ID.String(), web.BankMutationResolveRequest{Note: "refunded to sender"})
+	assert.NoError(t, err)
+
+	exceptions, err := bankTransferService.FindExceptions(context.Background(), false)
+	assert.NoError(t, err)
+	assert.Empty(t, exceptions)
+
+	exceptions, err = bankTransferService.FindExceptions(context.Background(), true)
+	assert.NoError(t, err)
+	assert.Len(t, exceptions, 1)
+}
+
+func TestBankTransferSimulator(t *testing.T) {
+	db, paymentService, _, app := setupBankTransfers(t)
+	payment := createBankTransfer(t, paymentService, "bca")
+
+	transfer := web.BankMutationRequest{ExternalID: "SIM-1", VirtualAccount: payment.BankTransfer.VirtualAccount, Amount: 150_000, TransactedAt: time.Now().Add(time.Minute).UTC().Format(time.RFC3339)}
+	status, response := postJSON(t, app, "/simulator/bca/bank-transfers", transfer)
+	assert.Equal(t, http.StatusOK, status)
+	first := response.Data.(map[string]interface{})
+	assert.Equal(t, domain.MutationMatched, first["status"])
+
+	status, response = postJSON(t, app, "/simulator/bca/bank-transfers", transfer)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, first["id"], response.Data.(map[string]interface{})["id"])
+
+	var events []domain.PaymentEvent
+	assert.NoError(t, db.Where("payment_id = ? AND to_status = ?", payment.ID, "success").Find(&events).Error)
+	assert.Len(t, events, 1)
+	assert.Equal(t, domain.EventSourceWebhook, events[0].Source)
+}
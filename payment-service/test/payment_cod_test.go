@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/exception"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupCOD wires a payment service whose "courier" provider collects cash on delivery and
+// records every callback sent to the order service.
+func setupCOD(t *testing.T) (*gorm.DB, service.PaymentService, service.LedgerService, *fiber.App, *[]web.PaymentCallbackRequest) {
+	serveOrder(t, 120_000, "IDR")
+
+	callbacks := []web.PaymentCallbackRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload web.PaymentCallbackRequest
+		json.NewDecoder(r.Body).Decode(&payload)
+		callbacks = append(callbacks, payload)
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("ORDER_CALLBACK_URL", srv.URL)
+	t.Cleanup(func() { os.Setenv("ORDER_CALLBACK_URL", "") })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	ledgerService := service.NewLedgerService(repository.NewLedgerRepository(db), db)
+	codConfig := config.CODConfig{Providers: []string{"courier"}}
+	deps := newTestPaymentDeps(db)
+	deps.LedgerService = ledgerService
+	deps.CODConfig = codConfig
+	paymentService := service.NewPaymentService(deps)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, db)
+
+	app := fiber.New()
+	routes.PaymentRoutes(app, controller.NewPaymentController(paymentService), idempotencyService)
+
+	return db, paymentService, ledgerService, app, &callbacks
+}
+
+func createCODPayment(t *testing.T, app *fiber.App) map[string]interface{} {
+	status, response := postJSON(t, app, "/payments", map[string]interface{}{"order_id": uuid.New(), "amount": 120_000, "provider": "courier"})
+	assert.Equal(t, http.StatusOK, status)
+	return response.Data.(map[string]interface{})
+}
+
+func TestCODPaymentAwaitsCollection(t *testing.T) {
+	db, paymentService, _, app, callbacks := setupCOD(t)
+
+	payment := createCODPayment(t, app)
+	assert.Equal(t, "awaiting_collection", payment["status"])
+	assert.Equal(t, "cod", payment["method"])
+	assert.Nil(t, payment["expires_at"])
+	assert.Empty(t, *callbacks)
+
+	paymentId := payment["id"].(string)
+	_, err := paymentService.MarkAsSuccess(context.Background(), paymentId)
+	assert.Error(t, err)
+
+	// The attempt keeps the order busy until the courier reports back.
+	var stored domain.Payment
+	assert.NoError(t, db.First(&stored, "id = ?", paymentId).Error)
+	_, err = paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: stored.OrderID, Amount: 120_000, Provider: "courier"})
+	var conflict exception.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+}
+
+func TestCODDeliveryCollectedSettlesPayment(t *testing.T) {
+	db, _, ledgerService, app, callbacks := setupCOD(t)
+	payment := createCODPayment(t, app)
+	paymentId := payment["id"].(string)
+
+	status, response := postJSON(t, app, "/payments/"+paymentId+"/delivery", web.DeliveryConfirmationRequest{Outcome: domain.DeliveryDelivered, CollectedAmount: 120_000, CollectedBy: "courier-17"})
+	assert.Equal(t, http.StatusOK, status)
+	settled := response.Data.(map[string]interface{})
+	assert.Equal(t, "success", settled["status"])
+	assert.Equal(t, float64(120_000), settled["collection"].(map[string]interface{})["collected_amount"])
+
+	assert.Equal(t, int64(120_000), findBalance(t, ledgerService, domain.AccountRevenue))
+	assert.Len(t, *callbacks, 1)
+	assert.Equal(t, "success", (*callbacks)[0].PaymentStatus)
+
+	var events []domain.PaymentEvent
+	assert.NoError(t, db.Where("payment_id = ? AND to_status = ?", paymentId, "success").Find(&events).Error)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "courier-17", events[0].Actor)
+
+	status, _ = postJSON(t, app, "/payments/"+paymentId+"/delivery", web.DeliveryConfirmationRequest{Outcome: domain.DeliveryDelivered, CollectedAmount: 120_000, CollectedBy: "courier-17"})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestCODMismatchAndFailedDeliveryCancel(t *testing.T) {
+	_, _, ledgerService, app, callbacks := setupCOD(t)
+
+	short := createCODPayment(t, app)
+	status, response := postJSON(t, app, "/payments/"+short["id"].(string)+"/delivery", web.DeliveryConfirmationRequest{Outcome: domain.DeliveryDelivered, CollectedAmount: 100_000, CollectedBy: "courier-17", Note: "customer short of cash"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "cancelled", response.Data.(map[string]interface{})["status"])
+
+	failed := createCODPayment(t, app)
+	status, response = postJSON(t, app, "/payments/"+failed["id"].(string)+"/delivery", web.DeliveryConfirmationRequest{Outcome: domain.DeliveryFailed, CollectedBy: "ops"})
+	assert.Equal(t, http.StatusOK, status)
+	cancelled := response.Data.(map[string]interface{})
+	assert.Equal(t, "cancelled", cancelled["status"])
+	assert.Equal(t, domain.DeliveryFailed, cancelled["collection"].(map[string]interface{})["outcome"])
+
+	assert.Equal(t, int64(0), findBalance(t, ledgerService, domain.AccountRevenue))
+	assert.Len(t, *callbacks, 2)
+	for _, callback := range *callbacks {
+		assert.Equal(t, "cancelled", callback.PaymentStatus)
+	}
+
+	status, _ = postJSON(t, app, "/payments/"+uuid.NewString()+"/delivery", web.DeliveryConfirmationRequest{Outcome: domain.DeliveryFailed, CollectedBy: "ops"})
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = postJSON(t, app, "/payments/"+failed["id"].(string)+"/delivery", web.DeliveryConfirmationRequest{Outcome: "lost", CollectedBy: "ops"})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -3,11 +3,15 @@ package test
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"payment-service/controller"
+	"payment-service/exception"
 	"payment-service/models/domain"
 	"payment-service/models/web"
 
@@ -17,8 +21,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
+// testClientIP is the peer address of requests sent through app.Test.
+const testClientIP = "0.0.0.0"
+
 // MockPaymentService is a mock for payment service
 type MockPaymentService struct {
 	mock.Mock
@@ -32,10 +40,46 @@ func (m *MockPaymentService) MarkAsSuccess(ctx context.Context, paymentId string
 	args := m.Called(ctx, paymentId)
 	return args.Get(0).(domain.Payment), args.Error(1)
 }
+func (m *MockPaymentService) MarkTransferPaid(ctx context.Context, paymentId string, paidAt time.Time) (domain.Payment, error) {
+	args := m.Called(ctx, paymentId, paidAt)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+func (m *MockPaymentService) MarkQRPaid(ctx context.Context, paymentId string, paidAt time.Time) (domain.Payment, error) {
+	args := m.Called(ctx, paymentId, paidAt)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
 func (m *MockPaymentService) MarkAsFailed(ctx context.Context, paymentId string) (domain.Payment, error) {
 	args := m.Called(ctx, paymentId)
 	return args.Get(0).(domain.Payment), args.Error(1)
 }
+func (m *MockPaymentService) ConfirmDelivery(ctx context.Context, paymentId string, request web.DeliveryConfirmationRequest) (domain.Payment, error) {
+	args := m.Called(ctx, paymentId, request)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+func (m *MockPaymentService) ApproveHeld(ctx context.Context, paymentId string, request web.RiskReviewRequest) (domain.Payment, error) {
+	args := m.Called(ctx, paymentId, request)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+func (m *MockPaymentService) RejectHeld(ctx context.Context, paymentId string, request web.RiskReviewRequest) (domain.Payment, error) {
+	args := m.Called(ctx, paymentId, request)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+func (m *MockPaymentService) Refund(ctx context.Context, paymentId string) (domain.Payment, error) {
+	args := m.Called(ctx, paymentId)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+func (m *MockPaymentService) RefundPart(ctx context.Context, paymentId string, request web.PartialRefundRequest) (domain.Payment, error) {
+	args := m.Called(ctx, paymentId, request)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+func (m *MockPaymentService) Chargeback(ctx context.Context, paymentId string) (domain.Payment, error) {
+	args := m.Called(ctx, paymentId)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+func (m *MockPaymentService) ApplyChargeback(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error) {
+	args := m.Called(ctx, tx, payment)
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
 func (m *MockPaymentService) FindById(ctx context.Context, paymentId string) (domain.Payment, error) {
 	args := m.Called(ctx, paymentId)
 	if args.Get(0) == nil {
@@ -43,6 +87,34 @@ func (m *MockPaymentService) FindById(ctx context.Context, paymentId string) (do
 	}
 	return args.Get(0).(domain.Payment), args.Error(1)
 }
+func (m *MockPaymentService) FindAllByOrderId(ctx context.Context, orderId string) ([]domain.Payment, error) {
+	args := m.Called(ctx, orderId)
+	if args.Get(0) == nil {
+		return []domain.Payment{}, args.Error(1)
+	}
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentService) FindEvents(ctx context.Context, paymentId string, includeCallbacks bool) (web.PaymentTimelineResponse, error) {
+	args := m.Called(ctx, paymentId, includeCallbacks)
+	return args.Get(0).(web.PaymentTimelineResponse), args.Error(1)
+}
+
+func (m *MockPaymentService) Search(ctx context.Context, request web.PaymentSearchRequest) ([]domain.Payment, string, error) {
+	args := m.Called(ctx, request)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).([]domain.Payment), args.String(1), args.Error(2)
+}
+
+func (m *MockPaymentService) Export(ctx context.Context, request web.PaymentSearchRequest) (func(write func(domain.Payment) error) error, error) {
+	args := m.Called(ctx, request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(func(write func(domain.Payment) error) error), args.Error(1)
+}
 
 // TestCreateSuccess tests controller Create happy path
 func TestCreateSuccess(t *testing.T) {
@@ -52,7 +124,7 @@ func TestCreateSuccess(t *testing.T) {
 	app := fiber.New()
 	app.Post("/payments", ctrl.Create)
 
-	req := web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 1000, Provider: "x"}
+	req := web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 1000, Provider: "x", IPAddress: testClientIP}
 	created := domain.Payment{ID: uuid.New(), OrderID: req.OrderID, Amount: req.Amount, Status: "success"}
 
 	svc.On("Create", mock.Anything, req).Return(domain.Payment{ID: created.ID, OrderID: req.OrderID, Amount: req.Amount, Provider: req.Provider, Status: "pending"}, nil)
@@ -67,7 +139,71 @@ func TestCreateSuccess(t *testing.T) {
 	svc.AssertExpectations(t)
 }
 
-// TestFindByIdSuccess tests controller FindById happy path
+// TestCreatePendingUsesPaymentResponse tests that a payment left pending is returned in the
+// same shape as a captured one
+func TestCreatePendingUsesPaymentResponse(t *testing.T) {
+	svc := new(MockPaymentService)
+	ctrl := controller.NewPaymentController(svc)
+
+	app := fiber.New()
+	app.Post("/payments", ctrl.Create)
+
+	req := web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 1000, Provider: "x", IPAddress: testClientIP}
+	created := domain.Payment{ID: uuid.New(), OrderID: req.OrderID, Amount: req.Amount, Status: "pending", IPAddress: req.IPAddress, QRPayload: "000201"}
+	svc.On("Create", mock.Anything, req).Return(created, nil)
+
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/payments", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response struct {
+		Data map[string]interface{} `json:"Data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, "/payments/"+created.ID.String()+"/qr", response.Data["qr_image_url"])
+	assert.NotContains(t, response.Data, "ip_address")
+	svc.AssertNotCalled(t, "MarkAsSuccess", mock.Anything, mock.Anything)
+}
+
+// TestCreateTakesClientAddressFromConnection tests that risk inputs in the body are ignored and
+// that the proxy header is only honoured for trusted proxies
+func TestCreateTakesClientAddressFromConnection(t *testing.T) {
+	orderId := uuid.New()
+	body := `{"order_id":"` + orderId.String() + `","amount":1000,"provider":"x","ip_address":"203.0.113.10","country":"KP"}`
+
+	for _, tc := range []struct {
+		name    string
+		trusted []string
+		want    string
+	}{
+		{name: "untrusted peer", want: testClientIP},
+		{name: "trusted proxy", trusted: []string{testClientIP}, want: "198.51.100.7"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := new(MockPaymentService)
+			ctrl := controller.NewPaymentController(svc)
+
+			app := fiber.New(fiber.Config{ProxyHeader: "X-Real-IP", EnableTrustedProxyCheck: true, TrustedProxies: tc.trusted, EnableIPValidation: true})
+			app.Post("/payments", ctrl.Create)
+
+			req := web.PaymentCreateRequest{OrderID: orderId, Amount: 1000, Provider: "x", IPAddress: tc.want}
+			svc.On("Create", mock.Anything, req).Return(domain.Payment{ID: uuid.New(), OrderID: orderId, Amount: 1000, Status: "held"}, nil)
+
+			r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("X-Real-IP", "198.51.100.7")
+
+			resp, _ := app.Test(r)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+// TestFindByIdSuccess tests controller FindById happy path and that risk inputs are not exposed
 func TestFindByIdSuccess(t *testing.T) {
 	svc := new(MockPaymentService)
 	ctrl := controller.NewPaymentController(svc)
@@ -76,13 +212,21 @@ func TestFindByIdSuccess(t *testing.T) {
 	app.Get("/payments/:paymentId", ctrl.FindById)
 
 	id := uuid.New()
-	found := domain.Payment{ID: id, OrderID: uuid.New(), Amount: 1000, Status: "success"}
+	found := domain.Payment{ID: id, OrderID: uuid.New(), Amount: 1000, Status: "success", IPAddress: "203.0.113.10", Country: "ID"}
 
 	svc.On("FindById", mock.Anything, id.String()).Return(found, nil)
 
 	r := httptest.NewRequest(http.MethodGet, "/payments/"+id.String(), nil)
 	resp, _ := app.Test(r)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response struct {
+		Data map[string]interface{} `json:"Data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, id.String(), response.Data["id"])
+	assert.NotContains(t, response.Data, "ip_address")
+	assert.NotContains(t, response.Data, "country")
 	svc.AssertExpectations(t)
 }
 
@@ -115,7 +259,7 @@ func TestCreateServiceError(t *testing.T) {
 	app := fiber.New()
 	app.Post("/payments", ctrl.Create)
 
-	req := web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 1000, Provider: "x"}
+	req := web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 1000, Provider: "x", IPAddress: testClientIP}
 	svc.On("Create", mock.Anything, req).Return(domain.Payment{}, assert.AnError)
 
 	body, _ := json.Marshal(req)
@@ -169,3 +313,146 @@ func TestMarkAsSuccessInvalidUUID(t *testing.T) {
 	resp, _ := app.Test(r)
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
+
+// TestFindAllByOrderIdSuccess tests listing payment attempts for an order
+func TestFindAllByOrderIdSuccess(t *testing.T) {
+	svc := new(MockPaymentService)
+	ctrl := controller.NewPaymentController(svc)
+
+	app := fiber.New()
+	app.Get("/orders/:orderId/payments", ctrl.FindAllByOrderId)
+
+	orderId := uuid.New()
+	attempts := []domain.Payment{
+		{ID: uuid.New(), OrderID: orderId, Amount: 1000, Status: "failed", Attempt: 1},
+		{ID: uuid.New(), OrderID: orderId, Amount: 1000, Status: "success", Attempt: 2},
+	}
+	svc.On("FindAllByOrderId", mock.Anything, orderId.String()).Return(attempts, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/orders/"+orderId.String()+"/payments", nil)
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	svc.AssertExpectations(t)
+}
+
+// TestFindAllByOrderIdInvalidUUID tests invalid order id handling when listing attempts
+func TestFindAllByOrderIdInvalidUUID(t *testing.T) {
+	svc := new(MockPaymentService)
+	ctrl := controller.NewPaymentController(svc)
+
+	app := fiber.New()
+	app.Get("/orders/:orderId/payments", ctrl.FindAllByOrderId)
+
+	r := httptest.NewRequest(http.MethodGet, "/orders/invalid-uuid/payments", nil)
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// TestCreateConflict tests controller Create when an active payment already exists
+func TestCreateConflict(t *testing.T) {
+	svc := new(MockPaymentService)
+	ctrl := controller.NewPaymentController(svc)
+
+	app := fiber.New()
+	app.Post("/payments", ctrl.Create)
+
+	req := web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 1000, Provider: "x", IPAddress: testClientIP}
+	existing := web.PaymentResponse{ID: uuid.New(), OrderID: req.OrderID, Amount: req.Amount, Status: "pending"}
+	svc.On("Create", mock.Anything, req).Return(domain.Payment{}, exception.ConflictError{Message: "conflict", Data: existing})
+
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/payments", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	svc.AssertNotCalled(t, "MarkAsSuccess", mock.Anything, mock.Anything)
+}
+
+// TestFindAllSuccess tests listing payments with filters and a next cursor
+func TestFindAllSuccess(t *testing.T) {
+	svc := new(MockPaymentService)
+	ctrl := controller.NewPaymentController(svc)
+
+	app := fiber.New()
+	app.Get("/payments", ctrl.FindAll)
+
+	expectedRequest := web.PaymentSearchRequest{Status: "success", Provider: "x", MinAmount: 100, Limit: 1}
+	found := []domain.Payment{{ID: uuid.New(), OrderID: uuid.New(), Amount: 1000, Status: "success", Provider: "x"}}
+	svc.On("Search", mock.Anything, expectedRequest).Return(found, "next", nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/payments?status=success&provider=x&min_amount=100&limit=1", nil)
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data web.PaymentPageResponse
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	assert.Len(t, body.Data.Items, 1)
+	assert.Equal(t, "next", body.Data.NextCursor)
+	svc.AssertExpectations(t)
+}
+
+// TestFindAllInvalidFilter tests listing payments when the service rejects the filter
+func TestFindAllInvalidFilter(t *testing.T) {
+	svc := new(MockPaymentService)
+	ctrl := controller.NewPaymentController(svc)
+
+	app := fiber.New()
+	app.Get("/payments", ctrl.FindAll)
+
+	svc.On("Search", mock.Anything, mock.Anything).Return(nil, "", assert.AnError)
+
+	r := httptest.NewRequest(http.MethodGet, "/payments?created_from=yesterday", nil)
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// TestExportCSV tests streaming payments as CSV
+func TestExportCSV(t *testing.T) {
+	svc := new(MockPaymentService)
+	ctrl := controller.NewPaymentController(svc)
+
+	app := fiber.New()
+	app.Get("/payments/export", ctrl.Export)
+
+	payments := []domain.Payment{
+		{ID: uuid.New(), OrderID: uuid.New(), Amount: 1000, Status: "success", Provider: "x", Attempt: 1},
+		{ID: uuid.New(), OrderID: uuid.New(), Amount: 2000, Status: "failed", Provider: "y", Attempt: 1},
+	}
+	stream := func(write func(domain.Payment) error) error {
+		for _, payment := range payments {
+			if err := write(payment); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	svc.On("Export", mock.Anything, web.PaymentSearchRequest{Provider: "x"}).Return(stream, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/payments/export?provider=x&format=csv", nil)
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+
+	body, _ := io.ReadAll(resp.Body)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "id,order_id"))
+	assert.Contains(t, lines[1], payments[0].ID.String())
+}
+
+// TestExportInvalidFormat tests export with an unsupported format
+func TestExportInvalidFormat(t *testing.T) {
+	svc := new(MockPaymentService)
+	ctrl := controller.NewPaymentController(svc)
+
+	app := fiber.New()
+	app.Get("/payments/export", ctrl.Export)
+
+	r := httptest.NewRequest(http.MethodGet, "/payments/export?format=xml", nil)
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	svc.AssertNotCalled(t, "Export", mock.Anything, mock.Anything)
+}
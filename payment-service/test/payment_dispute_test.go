@@ -0,0 +1,244 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"payment-service/controller"
+	"payment-service/exception"
+	"payment-service/middleware"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// setupDisputes returns a dispute service over a database holding one successful payment, and
+// records every callback sent to the order service.
+func setupDisputes(t *testing.T) (*gorm.DB, service.DisputeService, service.LedgerService, domain.Payment, *[]web.PaymentCallbackRequest) {
+	db, paymentService, ledgerService := setupLedger(t)
+
+	callbacks := []web.PaymentCallbackRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload web.PaymentCallbackRequest
+		json.NewDecoder(r.Body).Decode(&payload)
+		callbacks = append(callbacks, payload)
+	}))
+	t.Cleanup(srv.Close)
+
+	payment := seedPendingPayment(t, db, 5000)
+	assert.NoError(t, db.Model(&payment).Update("provider_reference", payment.ID.String()).Error)
+	payment, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	os.Setenv("ORDER_CALLBACK_URL", srv.URL)
+	t.Cleanup(func() { os.Setenv("ORDER_CALLBACK_URL", "") })
+
+	disputeService := service.NewDisputeService(repository.NewDisputeRepository(db), repository.NewPaymentRepository(db), paymentService, t.TempDir(), db, validator.New())
+
+	return db, disputeService, ledgerService, payment, &callbacks
+}
+
+func TestDisputeWebhookLostReversesPayment(t *testing.T) {
+	db, disputeService, ledgerService, payment, callbacks := setupDisputes(t)
+
+	opened := web.DisputeWebhookRequest{ProviderDisputeID: "dp_1", ProviderReference: payment.ProviderReference, Status: domain.DisputeNeedsResponse, Reason: "fraudulent"}
+	dispute, err := disputeService.HandleWebhook(context.Background(), payment.Provider, opened)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DisputeNeedsResponse, dispute.Status)
+	assert.Equal(t, payment.Amount, dispute.Amount)
+
+	// Redelivery of the same event changes nothing.
+	again, err := disputeService.HandleWebhook(context.Background(), payment.Provider, opened)
+	assert.NoError(t, err)
+	assert.Equal(t, dispute.ID, again.ID)
+
+	lost, err := disputeService.HandleWebhook(context.Background(), payment.Provider, web.DisputeWebhookRequest{ProviderDisputeID: "dp_1", Status: domain.DisputeLost})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DisputeLost, lost.Status)
+	assert.NotNil(t, lost.ClosedAt)
+
+	var reversed domain.Payment
+	assert.NoError(t, db.First(&reversed, "id = ?", payment.ID).Error)
+	assert.Equal(t, "charged_back", reversed.Status)
+	assert.Equal(t, int64(5000), findBalance(t, ledgerService, domain.AccountRefunds))
+
+	assert.Len(t, *callbacks, 1)
+	assert.Equal(t, "charged_back", (*callbacks)[0].PaymentStatus)
+	assert.Equal(t, payment.OrderID, (*callbacks)[0].OrderID)
+
+	_, err = disputeService.HandleWebhook(context.Background(), payment.Provider, web.DisputeWebhookRequest{ProviderDisputeID: "dp_1", ProviderReference: payment.ProviderReference, Status: domain.DisputeWon})
+	assert.Error(t, err)
+}
+
+func TestChargebackWithoutDisputeNotifiesOrderService(t *testing.T) {
+	db, paymentService, ledgerService := setupLedger(t)
+	payment := seedPendingPayment(t, db, 4000)
+	_, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	callbacks := []web.PaymentCallbackRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload web.PaymentCallbackRequest
+		json.NewDecoder(r.Body).Decode(&payload)
+		callbacks = append(callbacks, payload)
+	}))
+	defer srv.Close()
+	os.Setenv("ORDER_CALLBACK_URL", srv.URL)
+	defer os.Setenv("ORDER_CALLBACK_URL", "")
+
+	charged, err := paymentService.Chargeback(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "charged_back", charged.Status)
+	assert.Equal(t, int64(4000), findBalance(t, ledgerService, domain.AccountRefunds))
+
+	assert.Len(t, callbacks, 1)
+	assert.Equal(t, "charged_back", callbacks[0].PaymentStatus)
+	assert.Equal(t, payment.OrderID, callbacks[0].OrderID)
+}
+
+func TestDisputeAdminFlowWithEvidence(t *testing.T) {
+	db, disputeService, _, payment, callbacks := setupDisputes(t)
+
+	dispute, err := disputeService.Open(context.Background(), web.DisputeCreateRequest{PaymentID: payment.ID, Reason: "product_not_received"})
+	assert.NoError(t, err)
+	assert.Equal(t, dispute.ID.String(), dispute.ProviderDisputeID)
+
+	_, err = disputeService.UpdateStatus(context.Background(), dispute.ID.String(), web.DisputeStatusRequest{Status: domain.DisputeUnderReview})
+	assert.EqualError(t, err, "attach evidence before submitting the dispute response")
+
+	evidence, err := disputeService.AddEvidence(context.Background(), dispute.ID.String(), web.DisputeEvidenceRequest{FileName: "../../receipt.PDF", ContentType: "application/pdf", Note: "signed receipt"}, bytes.NewBufferString("%PDF-1.4"))
+	assert.NoError(t, err)
+	assert.Equal(t, "receipt.PDF", evidence.FileName)
+	assert.Equal(t, int64(8), evidence.Size)
+
+	stored, err := disputeService.FindEvidence(context.Background(), dispute.ID.String(), evidence.ID.String())
+	assert.NoError(t, err)
+	content, err := os.ReadFile(stored.StoragePath)
+	assert.NoError(t, err)
+	assert.Equal(t, "%PDF-1.4", string(content))
+
+	submitted, err := disputeService.UpdateStatus(context.Background(), dispute.ID.String(), web.DisputeStatusRequest{Status: domain.DisputeUnderReview})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DisputeUnderReview, submitted.Status)
+
+	won, err := disputeService.UpdateStatus(context.Background(), dispute.ID.String(), web.DisputeStatusRequest{Status: domain.DisputeWon, Note: "provider ruled for merchant"})
+	assert.NoError(t, err)
+	assert.Equal(t, "provider ruled for merchant", won.ResolutionNote)
+
+	var kept domain.Payment
+	assert.NoError(t, db.First(&kept, "id = ?", payment.ID).Error)
+	assert.Equal(t, "success", kept.Status)
+	assert.Empty(t, *callbacks)
+
+	_, err = disputeService.AddEvidence(context.Background(), dispute.ID.String(), web.DisputeEvidenceRequest{FileName: "late.txt"}, bytes.NewBufferString("late"))
+	assert.EqualError(t, err, "dispute is already won")
+
+	found, err := disputeService.FindById(context.Background(), dispute.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, found.Evidence, 1)
+}
+
+func TestDisputeOpenRules(t *testing.T) {
+	db, disputeService, _, payment, _ := setupDisputes(t)
+
+	_, err := disputeService.Open(context.Background(), web.DisputeCreateRequest{PaymentID: payment.ID, Reason: "duplicate", Amount: 10})
+	assert.Error(t, err)
+
+	_, err = disputeService.Open(context.Background(), web.DisputeCreateRequest{PaymentID: payment.ID, Reason: "duplicate"})
+	assert.NoError(t, err)
+
+	_, err = disputeService.Open(context.Background(), web.DisputeCreateRequest{PaymentID: payment.ID, Reason: "duplicate"})
+	var conflict exception.ConflictError
+	assert.True(t, errors.As(err, &conflict))
+
+	pending := seedPendingPayment(t, db, 100)
+	_, err = disputeService.Open(context.Background(), web.DisputeCreateRequest{PaymentID: pending.ID, Reason: "duplicate"})
+	assert.EqualError(t, err, "only successful payments can be disputed")
+}
+
+func TestDisputeControllerEvidenceUploadAndDownload(t *testing.T) {
+	_, disputeService, _, payment, _ := setupDisputes(t)
+	dispute, err := disputeService.Open(context.Background(), web.DisputeCreateRequest{PaymentID: payment.ID, Reason: "fraudulent"})
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	routes.DisputeRoutes(app, controller.NewDisputeController(disputeService), testWebhookSecret)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("note", "tracking")
+	part, _ := writer.CreateFormFile("file", "tracking.txt")
+	part.Write([]byte("JNE123"))
+	writer.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/disputes/"+dispute.ID.String()+"/evidence", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, _ := app.Test(r, -1)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var uploaded struct {
+		Data domain.DisputeEvidence `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&uploaded)
+	assert.Equal(t, "tracking", uploaded.Data.Note)
+
+	r = httptest.NewRequest(http.MethodGet, "/disputes/"+dispute.ID.String()+"/evidence/"+uploaded.Data.ID.String(), nil)
+	resp, _ = app.Test(r, -1)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	content, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "JNE123", string(content))
+}
+
+func TestDisputeWebhookUnknownPayment(t *testing.T) {
+	_, disputeService, _, _, _ := setupDisputes(t)
+
+	app := fiber.New()
+	routes.DisputeRoutes(app, controller.NewDisputeController(disputeService), testWebhookSecret)
+
+	body, _ := json.Marshal(web.DisputeWebhookRequest{ProviderDisputeID: "dp_x", ProviderReference: "missing", Status: domain.DisputeNeedsResponse})
+	r := httptest.NewRequest(http.MethodPost, "/webhooks/x/disputes", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(middleware.WebhookSecretHeader, testWebhookSecret)
+	resp, _ := app.Test(r, -1)
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestDisputeWebhookRequiresSecret(t *testing.T) {
+	db, disputeService, _, payment, callbacks := setupDisputes(t)
+	_, err := disputeService.HandleWebhook(context.Background(), payment.Provider, web.DisputeWebhookRequest{ProviderDisputeID: "dp_1", ProviderReference: payment.ProviderReference, Status: domain.DisputeNeedsResponse})
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	routes.DisputeRoutes(app, controller.NewDisputeController(disputeService), testWebhookSecret)
+
+	lost := web.DisputeWebhookRequest{ProviderDisputeID: "dp_1", ProviderReference: payment.ProviderReference, Status: domain.DisputeLost}
+	status, _ := postJSON(t, app, "/webhooks/"+payment.Provider+"/disputes", lost)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+	status, _ = postJSONWithSecret(t, app, "/webhooks/"+payment.Provider+"/disputes", lost, "whsec_wrong")
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+
+	var stored domain.Payment
+	assert.NoError(t, db.First(&stored, "id = ?", payment.ID).Error)
+	assert.Equal(t, "success", stored.Status)
+	disputes, err := disputeService.FindAllByPaymentId(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, disputes, 1)
+	assert.Equal(t, domain.DisputeNeedsResponse, disputes[0].Status)
+	assert.Empty(t, *callbacks)
+}
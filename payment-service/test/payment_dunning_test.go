@@ -0,0 +1,229 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type dunningFixture struct {
+	db             *gorm.DB
+	paymentService service.PaymentService
+	dunningService service.DunningService
+	stub           *orderStub
+	methodId       string
+	callbacks      *[]web.PaymentCallbackRequest
+	notifications  *[]domain.DunningEvent
+}
+
+// setupDunning wires a dunning service that retries on retrySchedule, and records the callbacks
+// sent to the order service and the notifications sent to the dunning endpoint.
+func setupDunning(t *testing.T, retrySchedule ...time.Duration) dunningFixture {
+	stub := serveOrderStub(t)
+
+	callbacks := []web.PaymentCallbackRequest{}
+	callbackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload web.PaymentCallbackRequest
+		json.NewDecoder(r.Body).Decode(&payload)
+		callbacks = append(callbacks, payload)
+	}))
+	t.Cleanup(callbackServer.Close)
+	os.Setenv("ORDER_CALLBACK_URL", callbackServer.URL)
+	t.Cleanup(func() { os.Setenv("ORDER_CALLBACK_URL", "") })
+
+	notifications := []domain.DunningEvent{}
+	notifyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event domain.DunningEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		notifications = append(notifications, event)
+	}))
+	t.Cleanup(notifyServer.Close)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	paymentMethodService := newTestPaymentMethodService(db, validate)
+	paymentEventRepository := repository.NewPaymentEventRepository(db)
+	deps := newTestPaymentDeps(db)
+	deps.PaymentEventRepository = paymentEventRepository
+	deps.PaymentMethodService = paymentMethodService
+	paymentService := service.NewPaymentService(deps)
+	dunningConfig := config.DunningConfig{RetrySchedule: retrySchedule, NotifyURL: notifyServer.URL}
+	dunningService := service.NewDunningService(repository.NewDunningRepository(db), paymentEventRepository, paymentService, dunningConfig, db)
+
+	method, err := paymentMethodService.Create(context.Background(), validCardRequest("cust-1"))
+	assert.NoError(t, err)
+
+	return dunningFixture{db, paymentService, dunningService, stub, method.ID.String(), &callbacks, &notifications}
+}
+
+// failPayment places an order in the order stub and fails a payment for it made with methodId.
+func (fixture dunningFixture) failPayment(t *testing.T, methodId string) domain.Payment {
+	orderId := uuid.New()
+	fixture.stub.orders[orderId.String()] = map[string]interface{}{"Price": float64(75_000), "Currency": "IDR"}
+
+	payment, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 75_000, Provider: "stripe", CustomerID: "cust-1", PaymentMethodID: methodId})
+	assert.NoError(t, err)
+	payment, err = fixture.paymentService.MarkAsFailed(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	return payment
+}
+
+func eventTypes(events []domain.DunningEvent) []string {
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestDunningRecoversOnRetry(t *testing.T) {
+	fixture := setupDunning(t, time.Hour, 2*time.Hour)
+	failed := fixture.failPayment(t, fixture.methodId)
+	now := time.Now()
+
+	handled, err := fixture.dunningService.Run(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handled)
+
+	opened, err := fixture.dunningService.FindByOrderId(context.Background(), failed.OrderID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DunningActive, opened.Status)
+	assert.Equal(t, failed.ID, opened.LastPaymentID)
+	assert.WithinDuration(t, now.Add(time.Hour), *opened.NextAttemptAt, time.Second)
+
+	// Nothing is due before the first retry.
+	handled, err = fixture.dunningService.Run(context.Background(), now.Add(30*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, handled)
+
+	handled, err = fixture.dunningService.Run(context.Background(), now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handled)
+
+	recovered, err := fixture.dunningService.FindByOrderId(context.Background(), failed.OrderID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DunningRecovered, recovered.Status)
+	assert.Equal(t, 1, recovered.Attempts)
+	assert.Nil(t, recovered.NextAttemptAt)
+	assert.NotNil(t, recovered.ClosedAt)
+	assert.Equal(t, []string{domain.DunningPaymentFailed, domain.DunningRecoveredEvent}, eventTypes(recovered.Events))
+
+	var retry domain.Payment
+	assert.NoError(t, fixture.db.First(&retry, "id = ?", recovered.LastPaymentID).Error)
+	assert.Equal(t, "success", retry.Status)
+	assert.Equal(t, 2, retry.Attempt)
+
+	var events []domain.PaymentEvent
+	assert.NoError(t, fixture.db.Where("payment_id = ? AND to_status = ?", retry.ID, "success").Find(&events).Error)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "dunning-scheduler", events[0].Actor)
+
+	assert.Equal(t, []string{domain.DunningPaymentFailed, domain.DunningRecoveredEvent}, eventTypes(*fixture.notifications))
+	last := (*fixture.callbacks)[len(*fixture.callbacks)-1]
+	assert.Equal(t, "success", last.PaymentStatus)
+}
+
+func TestDunningCancelsOrderAfterLastRetry(t *testing.T) {
+	fixture := setupDunning(t, time.Hour, 2*time.Hour)
+	failed := fixture.failPayment(t, fixture.methodId)
+	fixture.stub.setDecline(true)
+	now := time.Now()
+
+	for _, at := range []time.Time{now, now.Add(time.Hour), now.Add(3 * time.Hour)} {
+		handled, err := fixture.dunningService.Run(context.Background(), at)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, handled)
+	}
+
+	exhausted, err := fixture.dunningService.FindByOrderId(context.Background(), failed.OrderID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DunningExhausted, exhausted.Status)
+	assert.Equal(t, 2, exhausted.Attempts)
+	assert.Nil(t, exhausted.NextAttemptAt)
+	assert.Contains(t, exhausted.LastError, "amount")
+	assert.Equal(t, []string{domain.DunningPaymentFailed, domain.DunningRetryFailed, domain.DunningRetryFailed, domain.DunningOrderCancelled}, eventTypes(exhausted.Events))
+	assert.WithinDuration(t, now.Add(3*time.Hour), *exhausted.Events[1].NextAttemptAt, time.Second)
+	for _, event := range exhausted.Events {
+		assert.True(t, event.Notified)
+	}
+
+	last := (*fixture.callbacks)[len(*fixture.callbacks)-1]
+	assert.Equal(t, "cancelled", last.PaymentStatus)
+	assert.Equal(t, failed.OrderID, last.OrderID)
+
+	// A closed case is not retried again.
+	handled, err := fixture.dunningService.Run(context.Background(), now.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, handled)
+}
+
+func TestDunningOnlyTracksSavedPaymentMethods(t *testing.T) {
+	fixture := setupDunning(t, time.Hour)
+
+	orderId := uuid.New()
+	fixture.stub.orders[orderId.String()] = map[string]interface{}{"Price": float64(75_000), "Currency": "IDR"}
+	payment, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 75_000, Provider: "stripe"})
+	assert.NoError(t, err)
+	_, err = fixture.paymentService.MarkAsFailed(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	handled, err := fixture.dunningService.Run(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, handled)
+
+	app := fiber.New()
+	routes.DunningRoutes(app, controller.NewDunningController(fixture.dunningService))
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodGet, "/orders/"+orderId.String()+"/dunning", nil), -1)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/orders/not-a-uuid/dunning", nil), -1)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	failed := fixture.failPayment(t, fixture.methodId)
+	_, err = fixture.dunningService.Run(context.Background(), time.Now())
+	assert.NoError(t, err)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/orders/"+failed.OrderID.String()+"/dunning", nil), -1)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var found struct {
+		Data domain.DunningCase `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&found)
+	assert.Equal(t, domain.DunningActive, found.Data.Status)
+	assert.Len(t, found.Data.Events, 1)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/dunning?status=active", nil), -1)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var listed struct {
+		Data []domain.DunningCase `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&listed)
+	assert.Len(t, listed.Data, 1)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/dunning?status=lost", nil), -1)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentEventsRecordTransitions(t *testing.T) {
+	db, paymentService, _ := setupLedger(t)
+	payment := seedPendingPayment(t, db, 5000)
+
+	ctx := domain.WithEventOrigin(context.Background(), domain.EventOrigin{Source: domain.EventSourceAPI, Actor: "ops@example.com"})
+	_, err := paymentService.MarkAsSuccess(ctx, payment.ID.String())
+	assert.NoError(t, err)
+	_, err = paymentService.Refund(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	timeline, err := paymentService.FindEvents(context.Background(), payment.ID.String(), false)
+	assert.NoError(t, err)
+	assert.Len(t, timeline.Events, 2)
+	assert.Equal(t, "pending", timeline.Events[0].FromStatus)
+	assert.Equal(t, "success", timeline.Events[0].ToStatus)
+	assert.Equal(t, "ops@example.com", timeline.Events[0].Actor)
+	assert.Equal(t, "refunded", timeline.Events[1].ToStatus)
+	assert.Equal(t, domain.EventSourceAPI, timeline.Events[1].Source)
+	assert.Nil(t, timeline.CallbackAttempts)
+
+	// The callback URL is unset in this setup, so the single delivery is recorded as failed.
+	timeline, err = paymentService.FindEvents(context.Background(), payment.ID.String(), true)
+	assert.NoError(t, err)
+	assert.Len(t, timeline.CallbackAttempts, 1)
+	assert.Equal(t, "success", timeline.CallbackAttempts[0].PaymentStatus)
+	assert.False(t, timeline.CallbackAttempts[0].Succeeded)
+	assert.NotEmpty(t, timeline.CallbackAttempts[0].Error)
+}
+
+func TestPaymentEventMasksCardNumbers(t *testing.T) {
+	db, paymentService, _ := setupLedger(t)
+	payment := seedPendingPayment(t, db, 5000)
+
+	body := `{"status":"paid","card_number":"4111 1111 1111 1111","reference":"` + payment.ProviderReference + `"}`
+	ctx := domain.WithEventOrigin(context.Background(), domain.EventOrigin{Source: domain.EventSourceWebhook, Actor: "x", ProviderResponse: body})
+	_, err := paymentService.MarkAsSuccess(ctx, payment.ID.String())
+	assert.NoError(t, err)
+
+	var event domain.PaymentEvent
+	assert.NoError(t, db.Where("payment_id = ? AND to_status = ?", payment.ID, "success").First(&event).Error)
+	assert.Contains(t, event.ProviderResponse, `"card_number":"**** **** **** 1111"`)
+	assert.NotContains(t, event.ProviderResponse, "4111 1111")
+}
+
+func TestPaymentEventRolledBackWithStatus(t *testing.T) {
+	db, _, _ := setupLedger(t)
+	deps := newTestPaymentDeps(db)
+	deps.LedgerService = failingLedgerService{}
+	paymentService := service.NewPaymentService(deps)
+	payment := seedPendingPayment(t, db, 1000)
+
+	_, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.Error(t, err)
+
+	var count int64
+	assert.NoError(t, db.Model(&domain.PaymentEvent{}).Where("payment_id = ?", payment.ID).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestPaymentEventFromDisputeWebhook(t *testing.T) {
+	db, disputeService, _, payment, _ := setupDisputes(t)
+	paymentEvents := repository.NewPaymentEventRepository(db)
+
+	body := `{"provider_dispute_id":"dp_9","provider_reference":"` + payment.ProviderReference + `","status":"lost"}`
+	ctx := domain.WithEventOrigin(context.Background(), domain.EventOrigin{Source: domain.EventSourceWebhook, Actor: payment.Provider, ProviderResponse: body})
+	_, err := disputeService.HandleWebhook(ctx, payment.Provider, web.DisputeWebhookRequest{ProviderDisputeID: "dp_9", ProviderReference: payment.ProviderReference, Status: domain.DisputeLost})
+	assert.NoError(t, err)
+
+	events, err := paymentEvents.FindEventsByPaymentId(context.Background(), db, payment.ID.String())
+	assert.NoError(t, err)
+	last := events[len(events)-1]
+	assert.Equal(t, "charged_back", last.ToStatus)
+	assert.Equal(t, domain.EventSourceWebhook, last.Source)
+	assert.Equal(t, payment.Provider, last.Actor)
+	assert.False(t, last.ActorClaimed)
+	assert.Equal(t, body, last.ProviderResponse)
+
+	attempts, err := paymentEvents.FindCallbackAttemptsByPaymentId(context.Background(), db, payment.ID.String())
+	assert.NoError(t, err)
+	assert.True(t, attempts[len(attempts)-1].Succeeded)
+}
+
+func TestPaymentEventsController(t *testing.T) {
+	db, paymentService, _ := setupLedger(t)
+	payment := seedPendingPayment(t, db, 2000)
+	ctrl := controller.NewPaymentController(paymentService)
+
+	app := fiber.New()
+	app.Put("/payments/success/:paymentId", ctrl.MarkAsSuccess)
+	app.Get("/payments/:paymentId/events", ctrl.FindEvents)
+
+	req := httptest.NewRequest(http.MethodPut, "/payments/success/"+payment.ID.String(), nil)
+	req.Header.Set("X-Actor", "cashier-7")
+	resp, _ := app.Test(req)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/payments/"+payment.ID.String()+"/events?include_callbacks=true", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data web.PaymentTimelineResponse `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Len(t, body.Data.Events, 1)
+	assert.Equal(t, "cashier-7", body.Data.Events[0].Actor)
+	assert.True(t, body.Data.Events[0].ActorClaimed)
+	assert.Len(t, body.Data.CallbackAttempts, 1)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/payments/"+uuid.New().String()+"/events", nil))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPaymentEventTruncatesProviderResponse(t *testing.T) {
+	db, paymentService, _ := setupLedger(t)
+	payment := seedPendingPayment(t, db, 1000)
+
+	ctx := domain.WithEventOrigin(context.Background(), domain.EventOrigin{Source: domain.EventSourceWebhook, ProviderResponse: strings.Repeat("x", 5000)})
+	_, err := paymentService.MarkAsFailed(ctx, payment.ID.String())
+	assert.NoError(t, err)
+
+	timeline, err := paymentService.FindEvents(context.Background(), payment.ID.String(), false)
+	assert.NoError(t, err)
+	assert.Len(t, timeline.Events[0].ProviderResponse, domain.ProviderResponseLimit)
+}
@@ -81,3 +81,19 @@ func TestErrorHandler_InternalServerError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, writer.Code)
 	assert.Equal(t, "INTERNAL SERVER ERROR", writer.Status)
 }
+
+func TestErrorHandler_ConflictError(t *testing.T) {
+	app := setupApp()
+
+	app.Get("/conflict", func(c *fiber.Ctx) error {
+		return exception.ConflictError{Message: "duplicate", Data: "existing"}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/conflict", nil)
+	resp, _ := app.Test(req, -1)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	writer := decodeResponse(t, resp)
+	assert.Equal(t, http.StatusConflict, writer.Code)
+	assert.Equal(t, "CONFLICT", writer.Status)
+}
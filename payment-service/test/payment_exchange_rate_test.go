@@ -0,0 +1,196 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testRateFile = `base_currency,quote_currency,rate,effective_at
+USD,IDR,16000,2026-01-01T00:00:00Z
+USD,IDR,15500,2026-03-01T00:00:00Z
+EUR,USD,1.08,2026-01-01T00:00:00Z
+`
+
+func setupExchangeRates(t *testing.T) (*gorm.DB, service.PaymentService, service.ExchangeRateService) {
+	os.Setenv("ORDER_CALLBACK_URL", "")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	exchangeRateService := service.NewExchangeRateService(repository.NewExchangeRateRepository(db), db, validate)
+	deps := newTestPaymentDeps(db)
+	deps.ExchangeRateService = exchangeRateService
+	paymentService := service.NewPaymentService(deps)
+
+	_, err = exchangeRateService.Import(context.Background(), domain.RateSourceFile, strings.NewReader(testRateFile))
+	assert.NoError(t, err)
+
+	return db, paymentService, exchangeRateService
+}
+
+// serveOrder fakes order-service returning one order with the given total and currency.
+func serveOrder(t *testing.T, total int64, currency string) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 200,
+			"data": map[string]interface{}{"total_amount": total, "currency": currency},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("ORDER_SERVICE_URL", srv.URL)
+}
+
+func TestConvertAmountRoundsHalfUp(t *testing.T) {
+	rate, err := helper.ParseRate("0.0000625")
+	assert.NoError(t, err)
+
+	// 1,000,000 IDR is 62.50 USD, stored as cents.
+	assert.Equal(t, int64(6_250), helper.ConvertAmount(1_000_000, "IDR", "USD", rate))
+	assert.Equal(t, int64(1), helper.ConvertAmount(80, "IDR", "USD", rate))
+	assert.Equal(t, int64(0), helper.ConvertAmount(79, "IDR", "USD", rate))
+
+	_, err = helper.ParseRate("1/3")
+	assert.Error(t, err)
+	_, err = helper.ParseRate("0")
+	assert.Error(t, err)
+}
+
+func TestExchangeRateImportIsIdempotent(t *testing.T) {
+	_, _, exchangeRateService := setupExchangeRates(t)
+
+	result, err := exchangeRateService.Import(context.Background(), domain.RateSourceFile, strings.NewReader(testRateFile))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Imported)
+	assert.Equal(t, 3, result.Skipped)
+
+	_, err = exchangeRateService.Import(context.Background(), domain.RateSourceFile, strings.NewReader("base_currency,quote_currency,rate,effective_at\nUSD,USD,1,2026-01-01\n"))
+	assert.Error(t, err)
+
+	history, err := exchangeRateService.FindAll(context.Background(), web.ExchangeRateQuery{Base: "usd", Quote: "idr"})
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "15500", history[0].Rate)
+}
+
+func TestExchangeRateEffectiveDating(t *testing.T) {
+	_, _, exchangeRateService := setupExchangeRates(t)
+
+	rate, err := exchangeRateService.FindEffective(context.Background(), web.ExchangeRateQuery{Base: "USD", Quote: "IDR", At: "2026-02-15"})
+	assert.NoError(t, err)
+	assert.Equal(t, "16000", rate.Rate)
+
+	rate, err = exchangeRateService.FindEffective(context.Background(), web.ExchangeRateQuery{Base: "USD", Quote: "IDR", At: "2026-03-02"})
+	assert.NoError(t, err)
+	assert.Equal(t, "15500", rate.Rate)
+
+	_, err = exchangeRateService.FindEffective(context.Background(), web.ExchangeRateQuery{Base: "USD", Quote: "IDR", At: "2025-12-31"})
+	assert.Error(t, err)
+
+	// Only USD/IDR is on file, so IDR to USD divides by the rate.
+	conversion, err := exchangeRateService.Convert(context.Background(), 1_000_000, "IDR", "USD", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.True(t, conversion.Inverted)
+	assert.Equal(t, int64(6_250), conversion.Amount)
+}
+
+func TestCreateConvertsOrderCurrency(t *testing.T) {
+	db, paymentService, _ := setupExchangeRates(t)
+	serveOrder(t, 1_550_000, "IDR")
+
+	orderId := uuid.New()
+	_, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 9_999, Currency: "USD", Provider: "x"})
+	assert.Error(t, err)
+
+	payment, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 10_000, Currency: "usd", Provider: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", payment.Currency)
+
+	var stored domain.Payment
+	assert.NoError(t, db.First(&stored, "id = ?", payment.ID).Error)
+	assert.NotNil(t, stored.Conversion)
+	assert.Equal(t, int64(1_550_000), stored.Conversion.OrderAmount)
+	assert.Equal(t, "IDR", stored.Conversion.OrderCurrency)
+	assert.Equal(t, "15500", stored.Conversion.Rate)
+	assert.True(t, stored.Conversion.Inverted)
+	assert.Equal(t, domain.RoundingHalfUp, stored.Conversion.Rounding)
+	assert.NotNil(t, stored.Conversion.ExchangeRateID)
+}
+
+func TestCreateInOrderCurrencySkipsConversion(t *testing.T) {
+	db, paymentService, _ := setupExchangeRates(t)
+	serveOrder(t, 50_000, "")
+
+	payment, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 50_000, Provider: "x"})
+	assert.NoError(t, err)
+
+	var stored domain.Payment
+	assert.NoError(t, db.First(&stored, "id = ?", payment.ID).Error)
+	assert.Equal(t, "IDR", stored.Currency)
+	assert.Nil(t, stored.Conversion)
+
+	_, err = paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 50_000, Currency: "GBP", Provider: "x"})
+	assert.Error(t, err)
+}
+
+func TestExchangeRateController(t *testing.T) {
+	_, _, exchangeRateService := setupExchangeRates(t)
+	ctrl := controller.NewExchangeRateController(exchangeRateService)
+
+	app := fiber.New()
+	app.Post("/exchange-rates", ctrl.Create)
+	app.Post("/exchange-rates/import", ctrl.Import)
+	app.Get("/exchange-rates/current", ctrl.FindEffective)
+
+	body := `{"base_currency":"SGD","quote_currency":"IDR","rate":"12000.5","effective_at":"2026-01-01T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/exchange-rates", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, _ := app.Test(req)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodPost, "/exchange-rates", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, _ = app.Test(req)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var upload bytes.Buffer
+	writer := multipart.NewWriter(&upload)
+	part, _ := writer.CreateFormFile("file", "rates.csv")
+	part.Write([]byte("base_currency,quote_currency,rate,effective_at\nJPY,IDR,105.2,2026-01-01\n"))
+	writer.Close()
+	req = httptest.NewRequest(http.MethodPost, "/exchange-rates/import", &upload)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, _ = app.Test(req)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/exchange-rates/current?base=JPY&quote=IDR", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/exchange-rates/current?base=GBP&quote=IDR", nil))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
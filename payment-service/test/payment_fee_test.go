@@ -0,0 +1,230 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func testFeeSchedule() config.FeeSchedule {
+	return config.FeeSchedule{Rules: []config.FeeRule{
+		{Provider: "stripe", Currency: "IDR", Type: config.FeePercentage, PercentBps: 290, Fixed: 300, RefundFixed: 150},
+		{Provider: "stripe", Currency: "USD", Type: config.FeePercentage, PercentBps: 290, Fixed: 30},
+		{Provider: "stripe", Method: "ewallet", Currency: "IDR", Type: config.FeeFixed, PercentBps: 999, Fixed: 1000},
+		{Provider: "xendit", Currency: "IDR", Type: config.FeeTiered, ReturnFeeOnRefund: true, Tiers: []config.FeeTier{
+			{UpTo: 100_000, PercentBps: 200},
+			{UpTo: 0, PercentBps: 150, Fixed: 500},
+		}},
+	}}
+}
+
+func setupFees(t *testing.T) (*gorm.DB, service.PaymentService, service.FeeService, service.LedgerService) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	ledgerService := service.NewLedgerService(repository.NewLedgerRepository(db), db)
+	feeService := service.NewFeeService(repository.NewFeeRepository(db), testFeeSchedule(), db)
+	deps := newTestPaymentDeps(db)
+	deps.LedgerService = ledgerService
+	deps.FeeService = feeService
+	paymentService := service.NewPaymentService(deps)
+
+	return db, paymentService, feeService, ledgerService
+}
+
+func seedProviderPayment(t *testing.T, db *gorm.DB, provider string, method string, amount int64) domain.Payment {
+	return seedProviderPaymentIn(t, db, provider, method, amount, "IDR")
+}
+
+func seedProviderPaymentIn(t *testing.T, db *gorm.DB, provider string, method string, amount int64, currency string) domain.Payment {
+	payment := domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: amount, Currency: currency, Provider: provider, Method: method, Status: "pending", Attempt: 1}
+	assert.NoError(t, db.Create(&payment).Error)
+	return payment
+}
+
+func TestFeeScheduleValidate(t *testing.T) {
+	assert.NoError(t, testFeeSchedule().Validate())
+
+	unknown := config.FeeSchedule{Rules: []config.FeeRule{{Provider: "x", Type: "flat"}}}
+	assert.Error(t, unknown.Validate())
+
+	unordered := config.FeeSchedule{Rules: []config.FeeRule{{Provider: "x", Type: config.FeeTiered, Tiers: []config.FeeTier{{UpTo: 0}, {UpTo: 100}}}}}
+	assert.Error(t, unordered.Validate())
+
+	fixedWithoutCurrency := config.FeeSchedule{Rules: []config.FeeRule{{Provider: "x", Type: config.FeeFixed, Fixed: 1000}}}
+	assert.Error(t, fixedWithoutCurrency.Validate())
+
+	percentageOnly := config.FeeSchedule{Rules: []config.FeeRule{{Provider: "x", Type: config.FeePercentage, PercentBps: 100}}}
+	assert.NoError(t, percentageOnly.Validate())
+}
+
+func TestCaptureFeeUsesRuleForPaymentCurrency(t *testing.T) {
+	db, paymentService, _, ledgerService := setupFees(t)
+	dollars := seedProviderPaymentIn(t, db, "stripe", "card", 10_000, "USD")
+	yen := seedProviderPaymentIn(t, db, "xendit", "", 10_000, "JPY")
+
+	captured, err := paymentService.MarkAsSuccess(context.Background(), dollars.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(320), captured.FeeAmount)
+	assert.Equal(t, int64(320), findBalanceIn(t, ledgerService, domain.AccountFees, "USD"))
+	assert.Equal(t, int64(0), findBalanceIn(t, ledgerService, domain.AccountFees, "IDR"))
+
+	// a schedule with rupiah rules only does not price yen
+	captured, err = paymentService.MarkAsSuccess(context.Background(), yen.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), captured.FeeAmount)
+}
+
+func TestCaptureFeePercentagePlusFixed(t *testing.T) {
+	db, paymentService, _, ledgerService := setupFees(t)
+	payment := seedProviderPayment(t, db, "stripe", "card", 10_000)
+
+	captured, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(590), captured.FeeAmount)
+	assert.Equal(t, int64(9_410), captured.NetAmount)
+
+	var stored domain.Payment
+	assert.NoError(t, db.First(&stored, "id = ?", payment.ID).Error)
+	assert.Equal(t, int64(9_410), stored.NetAmount)
+	assert.Len(t, stored.FeeBreakdown, 1)
+	assert.Equal(t, domain.FeeCapture, stored.FeeBreakdown[0].Kind)
+	assert.Equal(t, "stripe/* percentage", stored.FeeBreakdown[0].Rule)
+
+	assert.Equal(t, int64(590), findBalance(t, ledgerService, domain.AccountFees))
+	assert.Equal(t, int64(9_410), findBalance(t, ledgerService, domain.AccountProviderClearing))
+}
+
+func TestCaptureFeeUsesMethodSpecificRule(t *testing.T) {
+	db, paymentService, _, _ := setupFees(t)
+	payment := seedProviderPayment(t, db, "stripe", "ewallet", 10_000)
+
+	captured, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1_000), captured.FeeAmount)
+	assert.Equal(t, int64(0), captured.FeeBreakdown[0].PercentBps)
+}
+
+func TestCaptureFeeTieredAndRefundReturnsFee(t *testing.T) {
+	db, paymentService, _, ledgerService := setupFees(t)
+	small := seedProviderPayment(t, db, "xendit", "", 50_000)
+	large := seedProviderPayment(t, db, "xendit", "", 200_000)
+
+	smallCaptured, err := paymentService.MarkAsSuccess(context.Background(), small.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1_000), smallCaptured.FeeAmount)
+
+	largeCaptured, err := paymentService.MarkAsSuccess(context.Background(), large.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3_500), largeCaptured.FeeAmount)
+
+	refunded, err := paymentService.Refund(context.Background(), large.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), refunded.FeeAmount)
+	assert.Equal(t, int64(0), refunded.NetAmount)
+	assert.Equal(t, domain.FeeCaptureReturned, refunded.FeeBreakdown[1].Kind)
+
+	assert.Equal(t, int64(1_000), findBalance(t, ledgerService, domain.AccountFees))
+}
+
+func TestRefundFeeChargedWhenCaptureFeeIsKept(t *testing.T) {
+	db, paymentService, _, ledgerService := setupFees(t)
+	payment := seedProviderPayment(t, db, "stripe", "card", 10_000)
+
+	_, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	refunded, err := paymentService.Refund(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(740), refunded.FeeAmount)
+	assert.Equal(t, int64(-740), refunded.NetAmount)
+	assert.Equal(t, int64(740), findBalance(t, ledgerService, domain.AccountFees))
+
+	invariants, err := ledgerService.CheckInvariants(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, invariants.Balanced)
+}
+
+func TestPartialRefundsArePricedLikeFullRefunds(t *testing.T) {
+	db, paymentService, _, ledgerService := setupFees(t)
+	ctx := context.Background()
+	stripe := seedProviderPayment(t, db, "stripe", "card", 10_000)
+	xendit := seedProviderPayment(t, db, "xendit", "", 200_000)
+	for _, payment := range []domain.Payment{stripe, xendit} {
+		_, err := paymentService.MarkAsSuccess(ctx, payment.ID.String())
+		assert.NoError(t, err)
+	}
+
+	// Each refund is charged the refund fee and keeps only what was not refunded.
+	part, err := paymentService.RefundPart(ctx, stripe.ID.String(), web.PartialRefundRequest{Amount: 4_000})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(740), part.FeeAmount)
+	assert.Equal(t, int64(5_260), part.NetAmount)
+	rest, err := paymentService.RefundPart(ctx, stripe.ID.String(), web.PartialRefundRequest{Amount: 6_000})
+	assert.NoError(t, err)
+	assert.Equal(t, "refunded", rest.Status)
+	assert.Equal(t, int64(890), rest.FeeAmount)
+	assert.Equal(t, int64(-890), rest.NetAmount)
+
+	// A returned capture fee comes back in proportion, and in full with the last refund.
+	part, err = paymentService.RefundPart(ctx, xendit.ID.String(), web.PartialRefundRequest{Amount: 50_000})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2_625), part.FeeAmount)
+	assert.Equal(t, int64(147_375), part.NetAmount)
+	rest, err = paymentService.RefundPart(ctx, xendit.ID.String(), web.PartialRefundRequest{Amount: 150_000})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), rest.FeeAmount)
+	assert.Equal(t, int64(0), rest.NetAmount)
+
+	var stored domain.Payment
+	assert.NoError(t, db.First(&stored, "id = ?", stripe.ID).Error)
+	assert.Equal(t, int64(-890), stored.NetAmount)
+	assert.Equal(t, int64(890), findBalance(t, ledgerService, domain.AccountFees))
+
+	invariants, err := ledgerService.CheckInvariants(ctx)
+	assert.NoError(t, err)
+	assert.True(t, invariants.Balanced)
+}
+
+func TestFeeSummaryByProvider(t *testing.T) {
+	db, paymentService, feeService, _ := setupFees(t)
+
+	for _, payment := range []domain.Payment{
+		seedProviderPayment(t, db, "stripe", "card", 10_000),
+		seedProviderPayment(t, db, "stripe", "card", 20_000),
+		seedProviderPayment(t, db, "xendit", "", 50_000),
+		seedProviderPaymentIn(t, db, "stripe", "card", 10_000, "USD"),
+	} {
+		_, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+		assert.NoError(t, err)
+	}
+	seedProviderPayment(t, db, "xendit", "", 70_000)
+
+	today := time.Now().Format("2006-01-02")
+	summaries, err := feeService.Summary(context.Background(), web.FeeSummaryRequest{From: today, To: today})
+	assert.NoError(t, err)
+	assert.Len(t, summaries, 3)
+	assert.Equal(t, web.FeeSummaryResponse{Provider: "stripe", Currency: "IDR", PaymentCount: 2, GrossAmount: 30_000, FeeAmount: 1_470, NetAmount: 28_530}, summaries[0])
+	assert.Equal(t, web.FeeSummaryResponse{Provider: "stripe", Currency: "USD", PaymentCount: 1, GrossAmount: 10_000, FeeAmount: 320, NetAmount: 9_680}, summaries[1])
+	assert.Equal(t, web.FeeSummaryResponse{Provider: "xendit", Currency: "IDR", PaymentCount: 1, GrossAmount: 50_000, FeeAmount: 1_000, NetAmount: 49_000}, summaries[2])
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	summaries, err = feeService.Summary(context.Background(), web.FeeSummaryRequest{From: yesterday, To: yesterday})
+	assert.NoError(t, err)
+	assert.Empty(t, summaries)
+
+	_, err = feeService.Summary(context.Background(), web.FeeSummaryRequest{From: "last week"})
+	assert.Error(t, err)
+}
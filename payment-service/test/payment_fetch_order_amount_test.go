@@ -8,9 +8,9 @@ import (
 	"os"
 	"testing"
 
+	"payment-service/config"
 	"payment-service/models/domain"
 	"payment-service/models/web"
-	"payment-service/service"
 
 	"github.com/go-playground/validator"
 	"github.com/google/uuid"
@@ -38,17 +38,18 @@ func TestFetchOrderAmountSuccess(t *testing.T) {
 	os.Setenv("ORDER_SERVICE_URL", srv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: orderTotal, Provider: "x"}
 
-	// simulate no existing payment
-	mockRepo.On("FindOrderById", mock.Anything, mock.Anything, orderId.String()).Return(domain.Payment{}, assert.AnError)
+	// simulate no active payment and no earlier attempts
+	mockRepo.On("FindActiveByOrderId", mock.Anything, mock.Anything, orderId.String()).Return(domain.Payment{}, assert.AnError)
+	mockRepo.On("FindAllByOrderId", mock.Anything, mock.Anything, orderId.String()).Return([]domain.Payment{}, nil)
 
 	// expect Save to be called and return the payment
 	expected := domain.Payment{ID: uuid.New(), OrderID: orderId, Amount: orderTotal, Provider: "x", Status: "pending"}
@@ -71,11 +72,11 @@ func TestFetchOrderAmountNotFound(t *testing.T) {
 	os.Setenv("ORDER_SERVICE_URL", srv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: 1000, Provider: "x"}
@@ -96,11 +97,11 @@ func TestFetchOrderAmountInvalidJSON(t *testing.T) {
 	os.Setenv("ORDER_SERVICE_URL", srv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: 1000, Provider: "x"}
@@ -119,11 +120,11 @@ func TestFetchOrderAmountServerError(t *testing.T) {
 	os.Setenv("ORDER_SERVICE_URL", srv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: 1000, Provider: "x"}
@@ -3,6 +3,7 @@ package test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -126,3 +127,25 @@ func TestCommitOrRollback(t *testing.T) {
 	db.Model(&domain.Payment{}).Count(&count)
 	assert.EqualValues(t, int64(1), count)
 }
+
+func TestCommitOrRollbackOnReturnedError(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&domain.Payment{})
+
+	write := func(fail bool) (err error) {
+		tx := db.Begin()
+		defer helper.CommitOrRollback(tx, &err)
+		tx.Create(&domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: 1000, Status: "success"})
+		if fail {
+			return errors.New("later write failed")
+		}
+		return nil
+	}
+
+	assert.Error(t, write(true))
+	assert.NoError(t, write(false))
+
+	var count int64
+	db.Model(&domain.Payment{}).Count(&count)
+	assert.EqualValues(t, int64(1), count)
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupIdempotency(t *testing.T) (*gorm.DB, *fiber.App, service.IdempotencyService) {
+	db, paymentService, _ := setupLedger(t)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, db)
+
+	app := fiber.New()
+	routes.PaymentRoutes(app, controller.NewPaymentController(paymentService), idempotencyService)
+
+	return db, app, idempotencyService
+}
+
+func sendWithKey(t *testing.T, app *fiber.App, method string, path string, key string, body string) (*http.Response, string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(resp.Body)
+	return resp, string(content)
+}
+
+func TestIdempotentCreateReplaysResponse(t *testing.T) {
+	db, app, _ := setupIdempotency(t)
+	serveOrder(t, 5000, "IDR")
+
+	body := `{"order_id":"` + uuid.New().String() + `","amount":5000,"provider":"x"}`
+	first, firstBody := sendWithKey(t, app, http.MethodPost, "/payments", "create-1", body)
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+
+	second, secondBody := sendWithKey(t, app, http.MethodPost, "/payments", "create-1", body)
+	assert.Equal(t, http.StatusOK, second.StatusCode)
+	assert.Equal(t, "true", second.Header.Get("Idempotent-Replayed"))
+	assert.Equal(t, firstBody, secondBody)
+
+	var count int64
+	assert.NoError(t, db.Model(&domain.Payment{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	mismatch, _ := sendWithKey(t, app, http.MethodPost, "/payments", "create-1", `{"order_id":"`+uuid.New().String()+`","amount":5000,"provider":"x"}`)
+	assert.Equal(t, http.StatusConflict, mismatch.StatusCode)
+}
+
+func TestIdempotentStatusMarkReplaysInsteadOfFailing(t *testing.T) {
+	db, app, _ := setupIdempotency(t)
+	payment := seedPendingPayment(t, db, 1000)
+	path := "/payments/success/" + payment.ID.String()
+
+	first, firstBody := sendWithKey(t, app, http.MethodPut, path, "capture-1", "")
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+
+	retry, retryBody := sendWithKey(t, app, http.MethodPut, path, "capture-1", "")
+	assert.Equal(t, http.StatusOK, retry.StatusCode)
+	assert.Equal(t, firstBody, retryBody)
+
+	// Without the key the retry reaches the service and is rejected.
+	plain, _ := sendWithKey(t, app, http.MethodPut, path, "", "")
+	assert.Equal(t, http.StatusBadRequest, plain.StatusCode)
+
+	// The same key on another endpoint is a different request.
+	refund, _ := sendWithKey(t, app, http.MethodPut, "/payments/refund/"+payment.ID.String(), "capture-1", "")
+	assert.Equal(t, http.StatusConflict, refund.StatusCode)
+}
+
+func TestIdempotencyKeyInProgressAndExpiry(t *testing.T) {
+	db, app, idempotencyService := setupIdempotency(t)
+	payment := seedPendingPayment(t, db, 1000)
+	path := "/payments/failed/" + payment.ID.String()
+
+	assert.NoError(t, db.Create(&domain.IdempotencyKey{Key: "busy", Fingerprint: "x", ExpiresAt: time.Now().Add(time.Hour)}).Error)
+	resp, _ := sendWithKey(t, app, http.MethodPut, path, "busy", "")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	assert.NoError(t, db.Create(&domain.IdempotencyKey{Key: "stale", Fingerprint: "x", ResponseStatus: 200, ExpiresAt: time.Now().Add(-time.Minute)}).Error)
+	resp, _ = sendWithKey(t, app, http.MethodPut, path, "stale", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Idempotent-Replayed"))
+
+	assert.NoError(t, db.Create(&domain.IdempotencyKey{Key: "old", Fingerprint: "x", ResponseStatus: 200, ExpiresAt: time.Now().Add(-time.Minute)}).Error)
+	removed, err := idempotencyService.Cleanup(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	resp, _ = sendWithKey(t, app, http.MethodPut, path, strings.Repeat("k", 256), "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,239 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type installmentFixture struct {
+	paymentService       service.PaymentService
+	paymentMethodService service.PaymentMethodService
+	installmentService   service.InstallmentService
+	stub                 *orderStub
+	methodId             string
+	callbacks            *[]web.PaymentCallbackRequest
+	app                  *fiber.App
+}
+
+// setupInstallments wires an installment service for orders of at least 1.000.000 split into at
+// most six installments, and records every callback sent to the order service.
+func setupInstallments(t *testing.T) installmentFixture {
+	stub := serveOrderStub(t)
+
+	callbacks := []web.PaymentCallbackRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload web.PaymentCallbackRequest
+		json.NewDecoder(r.Body).Decode(&payload)
+		callbacks = append(callbacks, payload)
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("ORDER_CALLBACK_URL", srv.URL)
+	t.Cleanup(func() { os.Setenv("ORDER_CALLBACK_URL", "") })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	installmentRepository := repository.NewInstallmentRepository(db)
+	paymentMethodService := newTestPaymentMethodService(db, validate)
+	deps := newTestPaymentDeps(db)
+	deps.InstallmentRepository = installmentRepository
+	deps.PaymentMethodService = paymentMethodService
+	paymentService := service.NewPaymentService(deps)
+	installmentConfig := config.InstallmentConfig{MinAmount: 1_000_000, MaxCount: 6, GracePeriod: 24 * time.Hour}
+	installmentService := service.NewInstallmentService(installmentRepository, paymentService, paymentMethodService, installmentConfig, db, validate)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, db)
+
+	method, err := paymentMethodService.Create(context.Background(), validCardRequest("cust-1"))
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	routes.PaymentRoutes(app, controller.NewPaymentController(paymentService), idempotencyService)
+	routes.InstallmentRoutes(app, controller.NewInstallmentController(installmentService))
+
+	return installmentFixture{paymentService, paymentMethodService, installmentService, stub, method.ID.String(), &callbacks, app}
+}
+
+func (fixture installmentFixture) placeOrder(total int64) uuid.UUID {
+	orderId := uuid.New()
+	fixture.stub.orders[orderId.String()] = map[string]interface{}{"Price": float64(total), "Currency": "IDR"}
+	return orderId
+}
+
+func (fixture installmentFixture) lastCallback() web.PaymentCallbackRequest {
+	return (*fixture.callbacks)[len(*fixture.callbacks)-1]
+}
+
+func TestSplitInstallments(t *testing.T) {
+	assert.Equal(t, []int64{333_334, 333_333, 333_333}, helper.SplitInstallments(1_000_000, 3))
+	assert.Equal(t, []int64{3, 3, 2, 2}, helper.SplitInstallments(10, 4))
+	assert.Equal(t, []int64{500, 500}, helper.SplitInstallments(1000, 2))
+	assert.Nil(t, helper.SplitInstallments(1000, 0))
+}
+
+func TestInstallmentPlanPaidInstallmentByInstallment(t *testing.T) {
+	fixture := setupInstallments(t)
+	orderId := fixture.placeOrder(1_000_000)
+
+	plan, err := fixture.installmentService.Create(context.Background(), web.InstallmentPlanCreateRequest{OrderID: orderId, CustomerID: "cust-1", Provider: "stripe", Count: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InstallmentPlanActive, plan.Status)
+	assert.Equal(t, domain.IntervalMonth, plan.Interval)
+	assert.Len(t, plan.Installments, 3)
+	assert.Equal(t, int64(333_334), plan.Installments[0].Amount)
+	assert.Equal(t, plan.Installments[0].DueAt.AddDate(0, 2, 0), plan.Installments[2].DueAt)
+
+	// The order can no longer be paid in full, nor out of order.
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 1_000_000, Provider: "stripe"})
+	assert.EqualError(t, err, "order is paid in installments, pass installment_number")
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 333_333, Provider: "stripe", InstallmentNumber: 2})
+	assert.EqualError(t, err, "installment 1 must be paid first")
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 333_333, Provider: "stripe", InstallmentNumber: 1})
+	assert.EqualError(t, err, "payment amount 333333 does not match installment 1 amount 333334")
+
+	status, response := postJSON(t, fixture.app, "/payments", map[string]interface{}{"order_id": orderId, "amount": 333_334, "provider": "stripe", "installment_number": 1})
+	assert.Equal(t, http.StatusOK, status)
+	first := response.Data.(map[string]interface{})
+	assert.Equal(t, "success", first["status"])
+	assert.Equal(t, float64(1), first["installment_number"])
+	assert.Equal(t, "partially_paid", fixture.lastCallback().PaymentStatus)
+
+	for number := 2; number <= 3; number++ {
+		payment, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 333_333, Provider: "stripe", InstallmentNumber: number})
+		assert.NoError(t, err)
+		_, err = fixture.paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "success", fixture.lastCallback().PaymentStatus)
+	assert.Len(t, *fixture.callbacks, 3)
+
+	paid, err := fixture.installmentService.FindByOrderId(context.Background(), orderId.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InstallmentPlanCompleted, paid.Status)
+	assert.Equal(t, int64(1_000_000), paid.PaidAmount)
+	assert.NotNil(t, paid.CompletedAt)
+	for _, installment := range paid.Installments {
+		assert.Equal(t, domain.InstallmentPaid, installment.Status)
+		assert.Equal(t, 1, installment.Attempts)
+		assert.NotNil(t, installment.PaymentID)
+	}
+
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 333_333, Provider: "stripe", InstallmentNumber: 3})
+	assert.EqualError(t, err, "installment plan is already completed")
+}
+
+func TestInstallmentPlanAutoChargeAndOverdue(t *testing.T) {
+	fixture := setupInstallments(t)
+	orderId := fixture.placeOrder(2_000_000)
+
+	plan, err := fixture.installmentService.Create(context.Background(), web.InstallmentPlanCreateRequest{OrderID: orderId, CustomerID: "cust-1", Provider: "stripe", PaymentMethodID: fixture.methodId, Count: 2, Interval: domain.IntervalWeek})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InstallmentPaid, plan.Installments[0].Status)
+	assert.Equal(t, int64(1_000_000), plan.PaidAmount)
+	assert.Equal(t, "partially_paid", fixture.lastCallback().PaymentStatus)
+
+	// Nothing is due until the second installment's due date.
+	now := time.Now()
+	handled, err := fixture.installmentService.Run(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, handled)
+
+	// The saved card is removed, so the scheduled charge fails.
+	assert.NoError(t, fixture.paymentMethodService.Delete(context.Background(), "cust-1", fixture.methodId))
+	dueAt := plan.Installments[1].DueAt
+	handled, err = fixture.installmentService.Run(context.Background(), dueAt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handled)
+
+	unpaid, err := fixture.installmentService.FindById(context.Background(), plan.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InstallmentScheduled, unpaid.Installments[1].Status)
+	assert.Equal(t, 1, unpaid.Installments[1].Attempts)
+	assert.NotEmpty(t, unpaid.Installments[1].FailureReason)
+
+	// The failed charge is not repeated; once the grace period passes the installment is overdue.
+	handled, err = fixture.installmentService.Run(context.Background(), dueAt.Add(25*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, handled)
+
+	overdue, err := fixture.installmentService.FindById(context.Background(), plan.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InstallmentOverdue, overdue.Installments[1].Status)
+	assert.NotNil(t, overdue.Installments[1].OverdueAt)
+
+	payment, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 1_000_000, Provider: "stripe", InstallmentNumber: 2})
+	assert.NoError(t, err)
+	_, err = fixture.paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "success", fixture.lastCallback().PaymentStatus)
+
+	completed, err := fixture.installmentService.FindById(context.Background(), plan.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InstallmentPlanCompleted, completed.Status)
+	assert.Equal(t, domain.InstallmentPaid, completed.Installments[1].Status)
+	assert.Equal(t, 2, completed.Installments[1].Attempts)
+	assert.Empty(t, completed.Installments[1].FailureReason)
+}
+
+func TestInstallmentPlanCreateRules(t *testing.T) {
+	fixture := setupInstallments(t)
+
+	_, err := fixture.installmentService.Create(context.Background(), web.InstallmentPlanCreateRequest{OrderID: fixture.placeOrder(500_000), CustomerID: "cust-1", Provider: "stripe", Count: 2})
+	assert.EqualError(t, err, "order total 500000 IDR is below the installment minimum of 1000000")
+
+	_, err = fixture.installmentService.Create(context.Background(), web.InstallmentPlanCreateRequest{OrderID: fixture.placeOrder(1_000_000), CustomerID: "cust-1", Provider: "stripe", Count: 12})
+	assert.EqualError(t, err, "an order can be split into at most 6 installments")
+
+	paidOrder := fixture.placeOrder(1_000_000)
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: paidOrder, Amount: 1_000_000, Provider: "stripe"})
+	assert.NoError(t, err)
+	_, err = fixture.installmentService.Create(context.Background(), web.InstallmentPlanCreateRequest{OrderID: paidOrder, CustomerID: "cust-1", Provider: "stripe", Count: 2})
+	assert.EqualError(t, err, "order already has a pending payment")
+
+	orderId := fixture.placeOrder(1_000_000)
+	status, _ := postJSON(t, fixture.app, "/installment-plans", web.InstallmentPlanCreateRequest{OrderID: orderId, CustomerID: "cust-1", Provider: "stripe", Count: 4})
+	assert.Equal(t, http.StatusOK, status)
+
+	_, err = fixture.installmentService.Create(context.Background(), web.InstallmentPlanCreateRequest{OrderID: orderId, CustomerID: "cust-1", Provider: "stripe", Count: 2})
+	var conflict exception.ConflictError
+	assert.True(t, errors.As(err, &conflict))
+
+	resp, _ := fixture.app.Test(httptest.NewRequest(http.MethodGet, "/orders/"+orderId.String()+"/installment-plan", nil), -1)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var found struct {
+		Data domain.InstallmentPlan `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&found)
+	assert.Equal(t, 4, found.Data.Count)
+	assert.Len(t, found.Data.Installments, 4)
+
+	resp, _ = fixture.app.Test(httptest.NewRequest(http.MethodGet, "/orders/"+uuid.NewString()+"/installment-plan", nil), -1)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: paidOrder, Amount: 1_000_000, Provider: "stripe", InstallmentNumber: 1})
+	assert.EqualError(t, err, "order has no installment plan")
+}
@@ -0,0 +1,220 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/repository"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// failingLedgerService rejects every posting so tests can verify status changes roll back.
+type failingLedgerService struct {
+	service.LedgerService
+}
+
+func (failingLedgerService) PostPaymentSuccess(ctx context.Context, tx *gorm.DB, payment domain.Payment) error {
+	return errors.New("ledger unavailable")
+}
+
+func setupLedger(t *testing.T) (*gorm.DB, service.PaymentService, service.LedgerService) {
+	os.Setenv("ORDER_CALLBACK_URL", "")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	ledgerService := service.NewLedgerService(repository.NewLedgerRepository(db), db)
+	deps := newTestPaymentDeps(db)
+	deps.LedgerService = ledgerService
+	paymentService := service.NewPaymentService(deps)
+
+	return db, paymentService, ledgerService
+}
+
+func seedPendingPayment(t *testing.T, db *gorm.DB, amount int64) domain.Payment {
+	payment := domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: amount, Provider: "x", Status: "pending", Attempt: 1}
+	assert.NoError(t, db.Create(&payment).Error)
+	return payment
+}
+
+func findBalance(t *testing.T, ledgerService service.LedgerService, code string) int64 {
+	return findBalanceIn(t, ledgerService, code, "IDR")
+}
+
+func findBalanceIn(t *testing.T, ledgerService service.LedgerService, code string, currency string) int64 {
+	balance, err := ledgerService.FindBalance(context.Background(), code)
+	assert.NoError(t, err)
+	for _, amount := range balance.Balances {
+		if amount.Currency == currency {
+			return amount.Balance
+		}
+	}
+	return 0
+}
+
+func TestLedgerPostsPaymentSuccessAndRefund(t *testing.T) {
+	db, paymentService, ledgerService := setupLedger(t)
+	payment := seedPendingPayment(t, db, 5000)
+
+	_, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(5000), findBalance(t, ledgerService, domain.AccountRevenue))
+	assert.Equal(t, int64(5000), findBalance(t, ledgerService, domain.AccountProviderClearing))
+	assert.Equal(t, int64(0), findBalance(t, ledgerService, domain.AccountCustomerReceivable))
+
+	refunded, err := paymentService.Refund(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "refunded", refunded.Status)
+
+	assert.Equal(t, int64(0), findBalance(t, ledgerService, domain.AccountProviderClearing))
+	assert.Equal(t, int64(5000), findBalance(t, ledgerService, domain.AccountRefunds))
+
+	entries, err := ledgerService.FindEntriesByPaymentId(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "payment_success", entries[0].Type)
+	assert.Len(t, entries[0].Lines, 4)
+
+	result, err := ledgerService.CheckInvariants(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, result.Balanced)
+	assert.Len(t, result.Totals, 1)
+	assert.Equal(t, result.Totals[0].TotalDebit, result.Totals[0].TotalCredit)
+}
+
+func TestLedgerKeepsCurrenciesApart(t *testing.T) {
+	db, paymentService, ledgerService := setupLedger(t)
+	rupiah := seedPendingPayment(t, db, 50_000)
+	dollars := domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: 300, Currency: "USD", Provider: "x", Status: "pending", Attempt: 1}
+	assert.NoError(t, db.Create(&dollars).Error)
+
+	for _, payment := range []domain.Payment{rupiah, dollars} {
+		_, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(50_000), findBalanceIn(t, ledgerService, domain.AccountRevenue, "IDR"))
+	assert.Equal(t, int64(300), findBalanceIn(t, ledgerService, domain.AccountRevenue, "USD"))
+
+	entries, err := ledgerService.FindEntriesByPaymentId(context.Background(), dollars.ID.String())
+	assert.NoError(t, err)
+	for _, line := range entries[0].Lines {
+		assert.Equal(t, "USD", line.Currency)
+	}
+
+	result, err := ledgerService.CheckInvariants(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, result.Balanced)
+	assert.Len(t, result.Totals, 2)
+
+	// an entry that balances only by adding dollars to rupiah is not balanced
+	entry := domain.JournalEntry{ID: uuid.New(), Type: "manual", Lines: []domain.JournalLine{
+		{ID: uuid.New(), AccountCode: domain.AccountFees, Currency: "IDR", Debit: 300},
+		{ID: uuid.New(), AccountCode: domain.AccountProviderClearing, Currency: "USD", Credit: 300},
+	}}
+	assert.NoError(t, db.Create(&entry).Error)
+
+	result, err = ledgerService.CheckInvariants(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, result.Balanced)
+	assert.Equal(t, []string{entry.ID.String()}, result.UnbalancedEntries)
+}
+
+func TestLedgerChargebackRequiresSuccessfulPayment(t *testing.T) {
+	db, paymentService, ledgerService := setupLedger(t)
+	payment := seedPendingPayment(t, db, 3000)
+
+	_, err := paymentService.Chargeback(context.Background(), payment.ID.String())
+	assert.Error(t, err)
+
+	_, err = paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	charged, err := paymentService.Chargeback(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "charged_back", charged.Status)
+	assert.Equal(t, int64(3000), findBalance(t, ledgerService, domain.AccountRefunds))
+
+	// a reversed payment cannot be reversed again
+	_, err = paymentService.Refund(context.Background(), payment.ID.String())
+	assert.Error(t, err)
+}
+
+func TestLedgerFailureRollsBackStatusChange(t *testing.T) {
+	db, _, _ := setupLedger(t)
+	deps := newTestPaymentDeps(db)
+	deps.LedgerService = failingLedgerService{}
+	paymentService := service.NewPaymentService(deps)
+	payment := seedPendingPayment(t, db, 1000)
+
+	_, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.Error(t, err)
+
+	var stored domain.Payment
+	assert.NoError(t, db.First(&stored, "id = ?", payment.ID).Error)
+	assert.Equal(t, "pending", stored.Status)
+}
+
+func TestLedgerEntriesAreImmutable(t *testing.T) {
+	db, paymentService, _ := setupLedger(t)
+	payment := seedPendingPayment(t, db, 1000)
+
+	_, err := paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+
+	var line domain.JournalLine
+	assert.NoError(t, db.First(&line).Error)
+	line.Debit = 999
+	assert.ErrorIs(t, db.Save(&line).Error, domain.ErrImmutableJournal)
+	assert.ErrorIs(t, db.Delete(&line).Error, domain.ErrImmutableJournal)
+}
+
+func TestLedgerInvariantDetectsUnbalancedEntry(t *testing.T) {
+	db, _, ledgerService := setupLedger(t)
+
+	entry := domain.JournalEntry{ID: uuid.New(), Type: "manual"}
+	assert.NoError(t, db.Create(&entry).Error)
+	assert.NoError(t, db.Create(&domain.JournalLine{ID: uuid.New(), EntryID: entry.ID, AccountCode: domain.AccountRevenue, Debit: 100}).Error)
+
+	result, err := ledgerService.CheckInvariants(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, result.Balanced)
+	assert.Equal(t, []string{entry.ID.String()}, result.UnbalancedEntries)
+}
+
+func TestLedgerControllerBalances(t *testing.T) {
+	_, _, ledgerService := setupLedger(t)
+	ctrl := controller.NewLedgerController(ledgerService)
+
+	app := fiber.New()
+	app.Get("/ledger/accounts", ctrl.FindBalances)
+	app.Get("/ledger/accounts/:accountCode", ctrl.FindBalance)
+	app.Get("/ledger/invariants", ctrl.CheckInvariants)
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodGet, "/ledger/accounts", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/ledger/accounts/revenue", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/ledger/accounts/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/ledger/invariants", nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
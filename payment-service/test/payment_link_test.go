@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupPaymentLinks(t *testing.T) (*gorm.DB, service.PaymentLinkService, *fiber.App) {
+	db, paymentService, _ := setupLedger(t)
+	serveOrder(t, 75_000, "IDR")
+
+	linkConfig := config.PaymentLinkConfig{BaseURL: "https://pay.example.com", Providers: []string{"xendit", "stripe"}, DefaultTTL: time.Hour}
+	paymentLinkService := service.NewPaymentLinkService(repository.NewPaymentLinkRepository(db), paymentService, linkConfig, db, validator.New())
+
+	app := fiber.New()
+	routes.PaymentLinkRoutes(app, controller.NewPaymentLinkController(paymentLinkService))
+
+	return db, paymentLinkService, app
+}
+
+func linkToken(link web.PaymentLinkResponse) string {
+	return strings.TrimPrefix(link.URL, "https://pay.example.com/pay/")
+}
+
+func submitCheckout(t *testing.T, app *fiber.App, token string, provider string) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, "/pay/"+token, strings.NewReader(url.Values{"provider": {provider}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp
+}
+
+func readPage(t *testing.T, app *fiber.App, path string) (int, string) {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "1,550,000 IDR", helper.FormatAmount(1_550_000, "IDR"))
+	assert.Equal(t, "100.05 USD", helper.FormatAmount(10_005, "USD"))
+	assert.Equal(t, "0.07 USD", helper.FormatAmount(7, "USD"))
+}
+
+func TestPaymentLinkCheckoutFlow(t *testing.T) {
+	db, paymentLinkService, app := setupPaymentLinks(t)
+	orderId := uuid.New()
+
+	link, err := paymentLinkService.Create(context.Background(), web.PaymentLinkCreateRequest{OrderID: orderId, OneTime: true})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(link.URL, "https://pay.example.com/pay/"))
+	assert.Equal(t, domain.PaymentLinkActive, link.Status)
+	token := linkToken(link)
+
+	status, page := readPage(t, app, "/pay/"+token)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, page, "75,000 IDR")
+	assert.Contains(t, page, `value="stripe"`)
+
+	resp := submitCheckout(t, app, token, "stripe")
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	location := resp.Header.Get("Location")
+	assert.Contains(t, location, "/pay/"+token+"/success?payment_id=")
+
+	status, page = readPage(t, app, location)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, page, "Payment successful")
+
+	var payment domain.Payment
+	assert.NoError(t, db.First(&payment, "order_id = ?", orderId).Error)
+	assert.Equal(t, "success", payment.Status)
+	assert.Equal(t, int64(75_000), payment.Amount)
+
+	stored, err := paymentLinkService.FindById(context.Background(), link.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentLinkUsed, stored.Status)
+	assert.Equal(t, payment.ID, *stored.PaymentID)
+
+	status, _ = readPage(t, app, "/pay/"+token)
+	assert.Equal(t, http.StatusGone, status)
+
+	// A result page only shows payments of the link's own order.
+	status, _ = readPage(t, app, "/pay/"+token+"/success?payment_id="+uuid.New().String())
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestPaymentLinkPaidFromWallet(t *testing.T) {
+	fixture := setupWallets(t)
+	fixture.topUp(t, "cust-1", 100_000)
+	orderId := fixture.placeOrder(60_000)
+
+	linkConfig := config.PaymentLinkConfig{BaseURL: "https://pay.example.com", Providers: []string{"wallet"}, DefaultTTL: time.Hour}
+	paymentLinkService := service.NewPaymentLinkService(repository.NewPaymentLinkRepository(fixture.db), fixture.paymentService, linkConfig, fixture.db, validator.New())
+	app := fiber.New()
+	routes.PaymentLinkRoutes(app, controller.NewPaymentLinkController(paymentLinkService))
+
+	link, err := paymentLinkService.Create(context.Background(), web.PaymentLinkCreateRequest{OrderID: orderId, CustomerID: "cust-1", OneTime: true})
+	assert.NoError(t, err)
+
+	// The wallet is charged as the payment is created; the link must not try to capture it again.
+	resp := submitCheckout(t, app, linkToken(link), "wallet")
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Location"), "/success?payment_id=")
+
+	var payment domain.Payment
+	assert.NoError(t, fixture.db.First(&payment, "order_id = ?", orderId).Error)
+	assert.Equal(t, "success", payment.Status)
+	assert.Equal(t, int64(40_000), fixture.walletBalance(t, "cust-1"))
+}
+
+func TestPaymentLinkRejectsUnknownProviderAndExpiry(t *testing.T) {
+	db, paymentLinkService, app := setupPaymentLinks(t)
+
+	link, err := paymentLinkService.Create(context.Background(), web.PaymentLinkCreateRequest{OrderID: uuid.New(), Providers: []string{"xendit"}})
+	assert.NoError(t, err)
+	token := linkToken(link)
+
+	resp := submitCheckout(t, app, token, "stripe")
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Location"), "/failure?message=")
+
+	status, page := readPage(t, app, resp.Header.Get("Location"))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, page, "provider stripe is not offered")
+
+	assert.NoError(t, db.Model(&domain.PaymentLink{}).Where("id = ?", link.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	status, _ = readPage(t, app, "/pay/"+token)
+	assert.Equal(t, http.StatusGone, status)
+
+	status, _ = readPage(t, app, "/pay/unknown-token")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestPaymentLinkReleasedWhenPaymentFails(t *testing.T) {
+	db, paymentLinkService, _ := setupPaymentLinks(t)
+	orderId := uuid.New()
+
+	// An attempt already in progress makes the checkout fail.
+	blocking := domain.Payment{ID: uuid.New(), OrderID: orderId, Amount: 75_000, Provider: "xendit", Status: "pending", Attempt: 1}
+	assert.NoError(t, db.Create(&blocking).Error)
+
+	link, err := paymentLinkService.Create(context.Background(), web.PaymentLinkCreateRequest{OrderID: orderId, OneTime: true})
+	assert.NoError(t, err)
+
+	_, err = paymentLinkService.Pay(context.Background(), linkToken(link), web.CheckoutRequest{Provider: "xendit"}, "203.0.113.10")
+	assert.Error(t, err)
+
+	stored, err := paymentLinkService.FindById(context.Background(), link.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentLinkActive, stored.Status)
+	assert.Nil(t, stored.UsedAt)
+}
@@ -0,0 +1,197 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupPaymentMethods(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+	return db
+}
+
+func validCardRequest(customerId string) web.PaymentMethodCreateRequest {
+	return web.PaymentMethodCreateRequest{
+		CustomerID:    customerId,
+		Provider:      "stripe",
+		ProviderToken: "pm_1Nv0secret",
+		Brand:         "visa",
+		Last4:         "4242",
+		ExpMonth:      12,
+		ExpYear:       2030,
+	}
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	vault, err := helper.NewVault(bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+
+	first, err := vault.Encrypt("pm_token")
+	assert.NoError(t, err)
+	second, err := vault.Encrypt("pm_token")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	plaintext, err := vault.Decrypt(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "pm_token", plaintext)
+
+	other, _ := helper.NewVault(bytes.Repeat([]byte{2}, 32))
+	_, err = other.Decrypt(first)
+	assert.Error(t, err)
+}
+
+func TestLoadVaultKeyOnlyGeneratesForDevelopment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.key")
+	os.Setenv("VAULT_KEY", "")
+	os.Setenv("VAULT_KEY_FILE", path)
+	t.Cleanup(func() {
+		os.Unsetenv("VAULT_KEY_FILE")
+		os.Unsetenv("VAULT_KEY_GENERATE")
+	})
+
+	_, err := config.LoadVaultKey()
+	assert.Error(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	os.Setenv("VAULT_KEY_GENERATE", "true")
+	generated, err := config.LoadVaultKey()
+	assert.NoError(t, err)
+	assert.Len(t, generated, 32)
+
+	// Later starts read the stored key, with or without the flag.
+	os.Unsetenv("VAULT_KEY_GENERATE")
+	loaded, err := config.LoadVaultKey()
+	assert.NoError(t, err)
+	assert.Equal(t, generated, loaded)
+}
+
+func TestContainsPAN(t *testing.T) {
+	assert.True(t, helper.ContainsPAN("4242424242424242"))
+	assert.True(t, helper.ContainsPAN("card 4111 1111 1111 1111 exp"))
+	assert.True(t, helper.ContainsPAN("5500-0000-0000-0004"))
+	assert.False(t, helper.ContainsPAN("4242424242424241"))
+	assert.False(t, helper.ContainsPAN("pm_1Nv0secret"))
+	assert.False(t, helper.ContainsPAN("4242"))
+}
+
+func TestMaskPAN(t *testing.T) {
+	assert.Equal(t, "************4242", helper.MaskPAN("4242424242424242"))
+	assert.Equal(t, `{"card":"**** **** **** 1111","amount":120000}`, helper.MaskPAN(`{"card":"4111 1111 1111 1111","amount":120000}`))
+	assert.Equal(t, "a ****-****-****-0004 b ************4242", helper.MaskPAN("a 5500-0000-0000-0004 b 4242424242424242"))
+	assert.Equal(t, "ref 4242424242424241", helper.MaskPAN("ref 4242424242424241"))
+}
+
+func TestPaymentMethodCreateStoresOnlyCiphertext(t *testing.T) {
+	db := setupPaymentMethods(t)
+	methodService := newTestPaymentMethodService(db, validator.New())
+
+	created, err := methodService.Create(context.Background(), validCardRequest("cust-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "visa", created.Brand)
+	assert.Equal(t, "4242", created.Last4)
+
+	var stored domain.PaymentMethod
+	assert.NoError(t, db.First(&stored, "id = ?", created.ID).Error)
+	assert.NotContains(t, stored.EncryptedToken, "pm_1Nv0secret")
+	assert.NotContains(t, stored.EncryptedDetails, "4242")
+	assert.NotContains(t, stored.EncryptedDetails, "visa")
+
+	found, err := methodService.FindById(context.Background(), "cust-1", created.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, created.Last4, found.Last4)
+	assert.Equal(t, 2030, found.ExpYear)
+}
+
+func TestPaymentMethodRejectsCardNumber(t *testing.T) {
+	db := setupPaymentMethods(t)
+	methodService := newTestPaymentMethodService(db, validator.New())
+
+	request := validCardRequest("cust-1")
+	request.ProviderToken = "4242 4242 4242 4242"
+
+	_, err := methodService.Create(context.Background(), request)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "4242")
+
+	var count int64
+	db.Model(&domain.PaymentMethod{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestPaymentMethodIsScopedToCustomer(t *testing.T) {
+	db := setupPaymentMethods(t)
+	methodService := newTestPaymentMethodService(db, validator.New())
+
+	created, err := methodService.Create(context.Background(), validCardRequest("cust-1"))
+	assert.NoError(t, err)
+
+	_, err = methodService.FindById(context.Background(), "cust-2", created.ID.String())
+	var notFound exception.NotFoundError
+	assert.True(t, errors.As(err, &notFound))
+
+	err = methodService.Delete(context.Background(), "cust-2", created.ID.String())
+	assert.True(t, errors.As(err, &notFound))
+
+	methods, err := methodService.FindAllByCustomerId(context.Background(), "cust-2")
+	assert.NoError(t, err)
+	assert.Empty(t, methods)
+}
+
+func TestPaymentMethodUpdateAndDelete(t *testing.T) {
+	db := setupPaymentMethods(t)
+	methodService := newTestPaymentMethodService(db, validator.New())
+
+	created, err := methodService.Create(context.Background(), validCardRequest("cust-1"))
+	assert.NoError(t, err)
+
+	updated, err := methodService.Update(context.Background(), "cust-1", created.ID.String(), web.PaymentMethodUpdateRequest{ExpMonth: 1, ExpYear: 2032})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated.ExpMonth)
+	assert.Equal(t, 2032, updated.ExpYear)
+	assert.Equal(t, "4242", updated.Last4)
+
+	assert.NoError(t, methodService.Delete(context.Background(), "cust-1", created.ID.String()))
+
+	methods, err := methodService.FindAllByCustomerId(context.Background(), "cust-1")
+	assert.NoError(t, err)
+	assert.Empty(t, methods)
+}
+
+func TestCreatePaymentWithSavedMethod(t *testing.T) {
+	db, paymentService, _ := setupRisk(t, 500)
+	methodService := newTestPaymentMethodService(db, validator.New())
+
+	method, err := methodService.Create(context.Background(), validCardRequest("cust-1"))
+	assert.NoError(t, err)
+
+	_, err = paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 500, Provider: "stripe", CustomerID: "cust-2", PaymentMethodID: method.ID.String()})
+	var notFound exception.NotFoundError
+	assert.True(t, errors.As(err, &notFound))
+
+	_, err = paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 500, Provider: "xendit", CustomerID: "cust-1", PaymentMethodID: method.ID.String()})
+	assert.Error(t, err)
+
+	payment, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 500, Provider: "stripe", CustomerID: "cust-1", PaymentMethodID: method.ID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, method.ID, *payment.PaymentMethodID)
+}
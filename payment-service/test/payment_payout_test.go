@@ -0,0 +1,271 @@
+package test
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type payoutFixture struct {
+	db             *gorm.DB
+	paymentService service.PaymentService
+	payoutService  service.PayoutService
+	stub           *orderStub
+	app            *fiber.App
+}
+
+// setupPayouts wires a 10% platform commission and pays out balances of at least 100.000.
+func setupPayouts(t *testing.T) payoutFixture {
+	stub := serveOrderStub(t)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	paymentRepository := repository.NewPaymentRepository(db)
+	payoutService := service.NewPayoutService(repository.NewPayoutRepository(db), paymentRepository, config.PayoutConfig{CommissionBps: 1000, MinAmount: 100_000}, db, validate)
+	deps := newTestPaymentDeps(db)
+	deps.PaymentRepository = paymentRepository
+	deps.PayoutService = payoutService
+	paymentService := service.NewPaymentService(deps)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, db)
+
+	app := fiber.New()
+	routes.PaymentRoutes(app, controller.NewPaymentController(paymentService), idempotencyService)
+	routes.PayoutRoutes(app, controller.NewPayoutController(payoutService))
+
+	return payoutFixture{db, paymentService, payoutService, stub, app}
+}
+
+func (fixture payoutFixture) placeOrder(total int64) uuid.UUID {
+	orderId := uuid.New()
+	fixture.stub.orders[orderId.String()] = map[string]interface{}{"Price": float64(total), "Currency": "IDR"}
+	return orderId
+}
+
+func (fixture payoutFixture) addSeller(t *testing.T, id string, commissionBps *int64) {
+	_, err := fixture.payoutService.CreateSeller(context.Background(), web.SellerCreateRequest{ID: id, Name: "Toko " + id, CommissionBps: commissionBps, BankName: "BCA", BankAccountNumber: "1234567890", BankAccountName: "PT " + id})
+	assert.NoError(t, err)
+}
+
+func (fixture payoutFixture) balance(t *testing.T, sellerId string) int64 {
+	seller, err := fixture.payoutService.FindSeller(context.Background(), sellerId)
+	assert.NoError(t, err)
+	return seller.Balance
+}
+
+func TestSplitByShares(t *testing.T) {
+	assert.Equal(t, []int64{200_000, 100_000}, helper.SplitByShares(300_000, []int64{200_000, 100_000}))
+	assert.Equal(t, []int64{66_666, 33_334}, helper.SplitByShares(100_000, []int64{200_000, 100_000}))
+	assert.Equal(t, []int64{0, 0}, helper.SplitByShares(100, []int64{0, 0}))
+}
+
+func TestMarketplaceSalesArePaidOutInBatches(t *testing.T) {
+	fixture := setupPayouts(t)
+	reduced := int64(500)
+	fixture.addSeller(t, "seller-a", nil)
+	fixture.addSeller(t, "seller-b", &reduced)
+
+	orderId := fixture.placeOrder(300_000)
+	status, response := postJSON(t, fixture.app, "/orders/"+orderId.String()+"/seller-shares", map[string]interface{}{
+		"shares": []map[string]interface{}{{"seller_id": "seller-a", "amount": 200_000}, {"seller_id": "seller-b", "amount": 100_000}},
+	})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, response.Data, 2)
+
+	status, _ = postJSON(t, fixture.app, "/payments", map[string]interface{}{"order_id": orderId, "amount": 300_000, "provider": "stripe"})
+	assert.Equal(t, http.StatusOK, status)
+
+	// 10% commission by default, 5% for seller-b.
+	assert.Equal(t, int64(180_000), fixture.balance(t, "seller-a"))
+	assert.Equal(t, int64(95_000), fixture.balance(t, "seller-b"))
+
+	status, response = postJSON(t, fixture.app, "/payout-batches", nil)
+	assert.Equal(t, http.StatusOK, status)
+	batches := response.Data.([]interface{})
+	assert.Len(t, batches, 1)
+	batch := batches[0].(map[string]interface{})
+	assert.Equal(t, "IDR", batch["currency"])
+	assert.Equal(t, float64(180_000), batch["total_amount"])
+	assert.Equal(t, float64(1), batch["payout_count"])
+
+	// seller-b stays below the minimum and waits for a later batch.
+	assert.Equal(t, int64(0), fixture.balance(t, "seller-a"))
+	assert.Equal(t, int64(95_000), fixture.balance(t, "seller-b"))
+
+	resp, _ := fixture.app.Test(httptest.NewRequest(http.MethodGet, "/payout-batches/"+batch["id"].(string)+"/export", nil), -1)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, []string{"reference", "seller_id", "bank_name", "account_number", "account_name", "amount", "currency", "description"}, rows[0])
+	assert.Equal(t, []string{"seller-a", "BCA", "1234567890", "PT seller-a", "180000", "IDR"}, rows[1][1:7])
+
+	entries, err := fixture.payoutService.FindEntries(context.Background(), "seller-a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{domain.SellerSale, domain.SellerPayout}, []string{entries[0].Type, entries[1].Type})
+	assert.Equal(t, int64(200_000), entries[0].GrossAmount)
+	assert.Equal(t, int64(20_000), entries[0].CommissionAmount)
+	assert.Equal(t, uuid.MustParse(rows[1][0]), *entries[1].PayoutID)
+
+	status, response = postJSON(t, fixture.app, "/payout-batches", nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, response.Data)
+}
+
+func TestRefundsAndChargebacksClawBackSellers(t *testing.T) {
+	fixture := setupPayouts(t)
+	fixture.addSeller(t, "seller-a", nil)
+	fixture.addSeller(t, "seller-b", nil)
+
+	pay := func(total int64) domain.Payment {
+		orderId := fixture.placeOrder(total)
+		_, err := fixture.payoutService.SetShares(context.Background(), orderId, web.SellerSharesRequest{Shares: []web.SellerShareRequest{{SellerID: "seller-a", Amount: total / 2}, {SellerID: "seller-b", Amount: total / 2}}})
+		assert.NoError(t, err)
+		payment, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: total, Provider: "stripe"})
+		assert.NoError(t, err)
+		payment, err = fixture.paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+		assert.NoError(t, err)
+		return payment
+	}
+
+	refunded := pay(400_000)
+	_, err := fixture.payoutService.Generate(context.Background())
+	assert.NoError(t, err)
+
+	// The sale was already paid out, so the refund leaves the sellers owing it.
+	_, err = fixture.paymentService.Refund(context.Background(), refunded.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-180_000), fixture.balance(t, "seller-a"))
+
+	chargedBack := pay(600_000)
+	assert.Equal(t, int64(90_000), fixture.balance(t, "seller-a"))
+	_, err = fixture.paymentService.Chargeback(context.Background(), chargedBack.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-180_000), fixture.balance(t, "seller-b"))
+
+	// Negative balances are never paid out.
+	batches, err := fixture.payoutService.Generate(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, batches)
+
+	entries, err := fixture.payoutService.FindEntries(context.Background(), "seller-b")
+	assert.NoError(t, err)
+	types := []string{}
+	for _, entry := range entries {
+		types = append(types, entry.Type)
+	}
+	assert.Equal(t, []string{domain.SellerSale, domain.SellerPayout, domain.SellerClawback, domain.SellerSale, domain.SellerClawback}, types)
+	assert.Equal(t, int64(-20_000), entries[2].CommissionAmount)
+}
+
+func TestSellerShareRules(t *testing.T) {
+	fixture := setupPayouts(t)
+	ctx := context.Background()
+	fixture.addSeller(t, "seller-a", nil)
+	_, err := fixture.payoutService.CreateSeller(ctx, web.SellerCreateRequest{ID: "seller-usd", Name: "USD", Currency: "usd", BankName: "BCA", BankAccountNumber: "1", BankAccountName: "USD"})
+	assert.NoError(t, err)
+
+	status, _ := postJSON(t, fixture.app, "/sellers", map[string]interface{}{"id": "seller-a", "name": "Again", "bank_name": "BCA", "bank_account_number": "1", "bank_account_name": "Again"})
+	assert.Equal(t, http.StatusConflict, status)
+
+	orderId := fixture.placeOrder(100_000)
+	share := func(shares ...web.SellerShareRequest) error {
+		_, err := fixture.payoutService.SetShares(ctx, orderId, web.SellerSharesRequest{Shares: shares})
+		return err
+	}
+	assert.EqualError(t, share(web.SellerShareRequest{SellerID: "seller-a", Amount: 90_000}), "shares add up to 90000, the order total is 100000")
+	assert.EqualError(t, share(web.SellerShareRequest{SellerID: "seller-a", Amount: 50_000}, web.SellerShareRequest{SellerID: "seller-a", Amount: 50_000}), "seller seller-a is listed twice")
+	assert.EqualError(t, share(web.SellerShareRequest{SellerID: "seller-x", Amount: 100_000}), "seller seller-x not found")
+	assert.EqualError(t, share(web.SellerShareRequest{SellerID: "seller-usd", Amount: 100_000}), "seller seller-usd is paid in USD")
+
+	assert.NoError(t, share(web.SellerShareRequest{SellerID: "seller-a", Amount: 100_000}))
+	status, _ = postJSON(t, fixture.app, "/orders/"+orderId.String()+"/seller-shares", map[string]interface{}{"shares": []map[string]interface{}{{"seller_id": "seller-a", "amount": 100_000}}})
+	assert.Equal(t, http.StatusConflict, status)
+
+	paidOrder := fixture.placeOrder(100_000)
+	payment, err := fixture.paymentService.Create(ctx, web.PaymentCreateRequest{OrderID: paidOrder, Amount: 100_000, Provider: "stripe"})
+	assert.NoError(t, err)
+	_, err = fixture.paymentService.MarkAsSuccess(ctx, payment.ID.String())
+	assert.NoError(t, err)
+	_, err = fixture.payoutService.SetShares(ctx, paidOrder, web.SellerSharesRequest{Shares: []web.SellerShareRequest{{SellerID: "seller-a", Amount: 100_000}}})
+	assert.EqualError(t, err, "order is already paid")
+
+	resp, _ := fixture.app.Test(httptest.NewRequest(http.MethodGet, "/orders/"+paidOrder.String()+"/seller-shares", nil), -1)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = fixture.app.Test(httptest.NewRequest(http.MethodGet, "/sellers/nobody", nil), -1)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = fixture.app.Test(httptest.NewRequest(http.MethodGet, "/payout-batches/"+uuid.NewString()+"/export", nil), -1)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPartialRefundsClawBackTheRefundedSeller(t *testing.T) {
+	fixture := setupPayouts(t)
+	ctx := context.Background()
+	fixture.addSeller(t, "seller-a", nil)
+	fixture.addSeller(t, "seller-b", nil)
+
+	orderId := fixture.placeOrder(300_000)
+	_, err := fixture.payoutService.SetShares(ctx, orderId, web.SellerSharesRequest{Shares: []web.SellerShareRequest{{SellerID: "seller-a", Amount: 200_000}, {SellerID: "seller-b", Amount: 100_000}}})
+	assert.NoError(t, err)
+	status, response := postJSON(t, fixture.app, "/payments", map[string]interface{}{"order_id": orderId, "amount": 300_000, "provider": "stripe"})
+	assert.Equal(t, http.StatusOK, status)
+	paymentId := response.Data.(map[string]interface{})["id"].(string)
+
+	status, response = postJSON(t, fixture.app, "/payments/"+paymentId+"/refunds", map[string]interface{}{"amount": 100_000, "seller_id": "seller-b"})
+	assert.Equal(t, http.StatusOK, status)
+	refunded := response.Data.(map[string]interface{})
+	assert.Equal(t, "success", refunded["status"])
+	assert.Equal(t, float64(100_000), refunded["refunded_amount"])
+	assert.Equal(t, int64(180_000), fixture.balance(t, "seller-a"))
+	assert.Equal(t, int64(0), fixture.balance(t, "seller-b"))
+
+	refund := func(amount int64, sellerId string) error {
+		_, err := fixture.paymentService.RefundPart(ctx, paymentId, web.PartialRefundRequest{Amount: amount, SellerID: sellerId})
+		return err
+	}
+	assert.EqualError(t, refund(50_000, "seller-b"), "seller seller-b has no sale left on this payment")
+	assert.EqualError(t, refund(250_000, ""), "refund exceeds the 200000 left to refund")
+
+	// Without a seller the refund is shared by whoever still holds part of the sale.
+	assert.NoError(t, refund(50_000, ""))
+	assert.Equal(t, int64(135_000), fixture.balance(t, "seller-a"))
+
+	// A full refund only reverses what is left.
+	payment, err := fixture.paymentService.Refund(ctx, paymentId)
+	assert.NoError(t, err)
+	assert.Equal(t, "refunded", payment.Status)
+	assert.Equal(t, int64(300_000), payment.RefundedAmount)
+	assert.Equal(t, int64(0), fixture.balance(t, "seller-a"))
+	assert.Equal(t, int64(0), fixture.balance(t, "seller-b"))
+
+	var refundTotal int64
+	fixture.db.Table("journal_lines").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Where("journal_entries.payment_id = ? AND journal_lines.account_code = ?", paymentId, domain.AccountRefunds).
+		Select("COALESCE(SUM(debit), 0)").Scan(&refundTotal)
+	assert.Equal(t, int64(300_000), refundTotal)
+
+	assert.EqualError(t, refund(1, ""), "only successful payments can be refunded")
+}
@@ -0,0 +1,256 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/helper"
+	"payment-service/middleware"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func testQRConfig() config.QRConfig {
+	return config.QRConfig{
+		Providers:        []string{"xendit"},
+		MerchantGUI:      "ID.CO.QRIS.WWW",
+		MerchantID:       "ID1020000000001",
+		MerchantName:     "TOKO CONTOH",
+		MerchantCity:     "JAKARTA",
+		MerchantCategory: "5411",
+		CountryCode:      "ID",
+		TTL:              15 * time.Minute,
+		Grace:            5 * time.Minute,
+	}
+}
+
+func setupQR(t *testing.T) (*gorm.DB, service.PaymentService, *fiber.App) {
+	os.Setenv("ORDER_CALLBACK_URL", "")
+	serveOrder(t, 75_000, "IDR")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	paymentRepository := repository.NewPaymentRepository(db)
+	deps := newTestPaymentDeps(db)
+	deps.PaymentRepository = paymentRepository
+	deps.QRConfig = testQRConfig()
+	paymentService := service.NewPaymentService(deps)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, db)
+	qrService := service.NewQRService(paymentRepository, paymentService, db, validate)
+
+	app := fiber.New()
+	routes.PaymentRoutes(app, controller.NewPaymentController(paymentService), idempotencyService)
+	routes.QRRoutes(app, controller.NewQRController(qrService), testWebhookSecret, true)
+
+	return db, paymentService, app
+}
+
+// testWebhookSecret is the secret the provider webhooks are registered with in tests.
+const testWebhookSecret = "whsec_test"
+
+func postJSON(t *testing.T, app *fiber.App, path string, body interface{}) (int, web.WebResponse) {
+	return postJSONWithSecret(t, app, path, body, "")
+}
+
+// postWebhook sends a provider notification signed with the test secret.
+func postWebhook(t *testing.T, app *fiber.App, path string, body interface{}) (int, web.WebResponse) {
+	return postJSONWithSecret(t, app, path, body, testWebhookSecret)
+}
+
+func postJSONWithSecret(t *testing.T, app *fiber.App, path string, body interface{}, secret string) (int, web.WebResponse) {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(middleware.WebhookSecretHeader, secret)
+	}
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	var response web.WebResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return resp.StatusCode, response
+}
+
+func createQRPayment(t *testing.T, paymentService service.PaymentService) domain.Payment {
+	payment, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 75_000, Provider: "xendit", Method: "qr"})
+	assert.NoError(t, err)
+	return payment
+}
+
+func TestQRPayloadRoundTrip(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	payload := helper.QRPayload{
+		MerchantGUI: "ID.CO.QRIS.WWW", MerchantID: "ID1020000000001", MerchantName: "TOKO CONTOH", MerchantCity: "JAKARTA",
+		MerchantCategory: "5411", CountryCode: "ID", Currency: "USD", Amount: 10_005, Reference: "QRABC123", ExpiresAt: expiresAt,
+	}
+
+	content, err := helper.EncodeQRPayload(payload)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(content, "000201010212"))
+	assert.Contains(t, content, "5303840")
+	assert.Contains(t, content, "5406100.05")
+
+	decoded, err := helper.DecodeQRPayload(content)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, decoded)
+
+	tampered := strings.Replace(content, "100.05", "100.06", 1)
+	_, err = helper.DecodeQRPayload(tampered)
+	assert.Error(t, err)
+
+	_, err = helper.EncodeQRPayload(helper.QRPayload{Currency: "XXX"})
+	assert.Error(t, err)
+}
+
+func TestCreateQRPaymentStaysPending(t *testing.T) {
+	_, _, app := setupQR(t)
+
+	status, response := postJSON(t, app, "/payments", map[string]interface{}{"order_id": uuid.New(), "amount": 75_000, "provider": "xendit", "method": "qr"})
+	assert.Equal(t, http.StatusOK, status)
+
+	data := response.Data.(map[string]interface{})
+	assert.Equal(t, "pending", data["status"])
+	reference := data["provider_reference"].(string)
+	assert.True(t, strings.HasPrefix(reference, "QR"))
+
+	payload, err := helper.DecodeQRPayload(data["qr_payload"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, reference, payload.Reference)
+	assert.Equal(t, int64(75_000), payload.Amount)
+	assert.Equal(t, "IDR", payload.Currency)
+	assert.Equal(t, "TOKO CONTOH", payload.MerchantName)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), payload.ExpiresAt, time.Minute)
+
+	status, response = postJSON(t, app, "/payments", map[string]interface{}{"order_id": uuid.New(), "amount": 75_000, "provider": "stripe", "method": "qr"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, response.Data, "does not support QR payments")
+}
+
+func TestQRImageFormats(t *testing.T) {
+	db, paymentService, app := setupQR(t)
+	payment := createQRPayment(t, paymentService)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/payments/"+payment.ID.String()+"/qr", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+	assert.True(t, bytes.HasPrefix(body, []byte("\x89PNG")))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/payments/"+payment.ID.String()+"/qr?format=svg", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+	body, _ = io.ReadAll(resp.Body)
+	assert.True(t, strings.HasPrefix(string(body), "<svg"))
+
+	card := seedPendingPayment(t, db, 1_000)
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/payments/"+card.ID.String()+"/qr", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	assert.NoError(t, db.Model(&domain.Payment{}).Where("id = ?", payment.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/payments/"+payment.ID.String()+"/qr", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+}
+
+func TestQRWebhookConfirmsPayment(t *testing.T) {
+	db, paymentService, app := setupQR(t)
+	payment := createQRPayment(t, paymentService)
+
+	paid := web.QRWebhookRequest{Reference: payment.ProviderReference, Status: "paid", Amount: 75_000}
+	status, _ := postJSON(t, app, "/webhooks/xendit/qr", paid)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = postJSONWithSecret(t, app, "/webhooks/xendit/qr", paid, "whsec_wrong")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = postWebhook(t, app, "/webhooks/xendit/qr", web.QRWebhookRequest{Reference: payment.ProviderReference, Status: "paid", Amount: 70_000})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = postWebhook(t, app, "/webhooks/stripe/qr", web.QRWebhookRequest{Reference: payment.ProviderReference, Status: "paid", Amount: 75_000})
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, response := postWebhook(t, app, "/webhooks/xendit/qr", web.QRWebhookRequest{Reference: payment.ProviderReference, Status: "paid", Amount: 75_000})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "success", response.Data.(map[string]interface{})["status"])
+
+	// Providers retry notifications; a repeat is acknowledged without a second capture.
+	status, _ = postWebhook(t, app, "/webhooks/xendit/qr", web.QRWebhookRequest{Reference: payment.ProviderReference, Status: "paid", Amount: 75_000})
+	assert.Equal(t, http.StatusOK, status)
+
+	var events []domain.PaymentEvent
+	assert.NoError(t, db.Where("payment_id = ? AND to_status = ?", payment.ID, "success").Find(&events).Error)
+	assert.Len(t, events, 1)
+	assert.Equal(t, domain.EventSourceWebhook, events[0].Source)
+	assert.Equal(t, "xendit", events[0].Actor)
+}
+
+func TestQRLateWebhookJudgedByPaidTime(t *testing.T) {
+	db, paymentService, app := setupQR(t)
+	payment := createQRPayment(t, paymentService)
+
+	// The code expired an hour ago; the notification arrives only now.
+	expiresAt := time.Now().Add(-time.Hour)
+	assert.NoError(t, db.Model(&domain.Payment{}).Where("id = ?", payment.ID).Update("expires_at", expiresAt).Error)
+
+	tooLate := expiresAt.Add(10 * time.Minute)
+	status, _ := postWebhook(t, app, "/webhooks/xendit/qr", web.QRWebhookRequest{Reference: payment.ProviderReference, Status: "paid", Amount: 75_000, PaidAt: &tooLate})
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = postWebhook(t, app, "/webhooks/xendit/qr", web.QRWebhookRequest{Reference: payment.ProviderReference, Status: "paid", Amount: 75_000})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	found, err := paymentService.FindById(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", found.Status)
+
+	withinGrace := expiresAt.Add(2 * time.Minute)
+	status, response := postWebhook(t, app, "/webhooks/xendit/qr", web.QRWebhookRequest{Reference: payment.ProviderReference, Status: "paid", Amount: 75_000, PaidAt: &withinGrace})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "success", response.Data.(map[string]interface{})["status"])
+}
+
+func TestQRSimulatorScanAndPay(t *testing.T) {
+	_, paymentService, app := setupQR(t)
+	payment := createQRPayment(t, paymentService)
+
+	status, response := postJSON(t, app, "/simulator/xendit/qr/scan", web.QRSimulatorRequest{Payload: payment.QRPayload})
+	assert.Equal(t, http.StatusOK, status)
+	scan := response.Data.(map[string]interface{})
+	assert.Equal(t, payment.ID.String(), scan["payment_id"])
+	assert.Equal(t, "TOKO CONTOH", scan["merchant_name"])
+	assert.Equal(t, float64(75_000), scan["amount"])
+
+	status, _ = postJSON(t, app, "/simulator/xendit/qr/scan", web.QRSimulatorRequest{Payload: strings.Replace(payment.QRPayload, "75000", "7500", 1)})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, response = postJSON(t, app, "/simulator/xendit/qr/pay", web.QRSimulatorRequest{Payload: payment.QRPayload})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "success", response.Data.(map[string]interface{})["status"])
+
+	status, _ = postJSON(t, app, "/simulator/xendit/qr/scan", web.QRSimulatorRequest{Payload: payment.QRPayload})
+	assert.Equal(t, http.StatusGone, status)
+}
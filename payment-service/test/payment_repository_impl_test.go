@@ -2,9 +2,13 @@ package test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"payment-service/config"
 	"payment-service/models/domain"
 	"payment-service/repository"
 
@@ -19,7 +23,7 @@ func TestPaymentRepositoryCRUD(t *testing.T) {
 	assert.NoError(t, err)
 
 	// migrate schema
-	err = db.AutoMigrate(&domain.Payment{})
+	err = config.Migrate(db)
 	assert.NoError(t, err)
 
 	repo := repository.NewPaymentRepository(db)
@@ -39,6 +43,10 @@ func TestPaymentRepositoryCRUD(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, pid, found.ID)
 
+	locked, err := repo.FindByIdForUpdate(context.Background(), tx, pid.String())
+	assert.NoError(t, err)
+	assert.Equal(t, found.Status, locked.Status)
+
 	// update status
 	now := time.Now()
 	found.Status = "success"
@@ -58,7 +66,7 @@ func TestPaymentRepositoryCRUD(t *testing.T) {
 func TestFindById_NotFound(t *testing.T) {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	assert.NoError(t, err)
-	_ = db.AutoMigrate(&domain.Payment{})
+	_ = config.Migrate(db)
 
 	repo := repository.NewPaymentRepository(db)
 	tx := db.Begin()
@@ -72,7 +80,7 @@ func TestFindById_NotFound(t *testing.T) {
 func TestUpdateStatus_NotFound(t *testing.T) {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	assert.NoError(t, err)
-	_ = db.AutoMigrate(&domain.Payment{})
+	_ = config.Migrate(db)
 
 	repo := repository.NewPaymentRepository(db)
 	tx := db.Begin()
@@ -86,3 +94,86 @@ func TestUpdateStatus_NotFound(t *testing.T) {
 	_, err = repo.FindById(context.Background(), tx, p.ID.String())
 	assert.Error(t, err)
 }
+
+func TestPaymentRepositoryAttempts(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	_ = config.Migrate(db)
+
+	repo := repository.NewPaymentRepository(db)
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	oid := uuid.New()
+	failed := domain.Payment{ID: uuid.New(), OrderID: oid, Amount: 1000, Provider: "x", Status: "failed", Attempt: 1}
+	pending := domain.Payment{ID: uuid.New(), OrderID: oid, Amount: 1000, Provider: "x", Status: "pending", Attempt: 2}
+	_, err = repo.Save(context.Background(), tx, failed)
+	assert.NoError(t, err)
+
+	// a failed attempt does not block a retry
+	_, err = repo.FindActiveByOrderId(context.Background(), tx, oid.String())
+	assert.Error(t, err)
+
+	_, err = repo.Save(context.Background(), tx, pending)
+	assert.NoError(t, err)
+
+	active, err := repo.FindActiveByOrderId(context.Background(), tx, oid.String())
+	assert.NoError(t, err)
+	assert.Equal(t, pending.ID, active.ID)
+
+	attempts, err := repo.FindAllByOrderId(context.Background(), tx, oid.String())
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 2)
+	assert.Equal(t, 1, attempts[0].Attempt)
+	assert.Equal(t, 2, attempts[1].Attempt)
+}
+
+func TestPaymentRepositorySave_ConcurrentDuplicates(t *testing.T) {
+	// A file database so every goroutine gets its own connection; immediate transactions
+	// and a busy timeout make SQLite serialize writers the way row locks would.
+	dsn := fmt.Sprintf("file:%s/payments.db?_busy_timeout=5000&_txlock=immediate", t.TempDir())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	repo := repository.NewPaymentRepository(db)
+	oid := uuid.New()
+
+	const workers = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, conflicts := 0, 0
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx := db.Begin()
+			_, err := repo.Save(context.Background(), tx, domain.Payment{ID: uuid.New(), OrderID: oid, Amount: 1000, Provider: "x", Status: "pending", Attempt: 1})
+			tx.Commit()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				created++
+			} else if errors.Is(err, repository.ErrActivePaymentExists) {
+				conflicts++
+			} else {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, workers-1, conflicts)
+
+	var count int64
+	db.Model(&domain.Payment{}).Where("order_id = ?", oid).Count(&count)
+	assert.EqualValues(t, 1, count)
+
+	// once the attempt fails a new one may be inserted
+	assert.NoError(t, db.Model(&domain.Payment{}).Where("order_id = ?", oid).Update("status", "failed").Error)
+	_, err = repo.Save(context.Background(), db, domain.Payment{ID: uuid.New(), OrderID: oid, Amount: 1000, Provider: "x", Status: "pending", Attempt: 2})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,234 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func testRiskConfig() config.RiskConfig {
+	countries, err := config.ParseIPCountries(strings.NewReader("# network,country\n198.51.0.0/16,ID\n198.51.100.0/24,kp\n"))
+	if err != nil {
+		panic(err)
+	}
+
+	return config.RiskConfig{
+		VelocityWindow:       10 * time.Minute,
+		MaxPaymentsPerWindow: 3,
+		ReviewAmount:         10_000,
+		DenyAmount:           1_000_000,
+		BlockedProviders:     []string{"shady-pay"},
+		BlockedCountries:     []string{"KP"},
+		FailureWindow:        24 * time.Hour,
+		MaxRecentFailures:    3,
+		ReviewScore:          30,
+		DenyScore:            100,
+		IPCountries:          countries,
+	}
+}
+
+// setupRisk wires the payment and risk services on an in-memory database and points the order
+// lookup at a stub that reports the given order total.
+func setupRisk(t *testing.T, orderTotal int64) (*gorm.DB, service.PaymentService, service.RiskService) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 200,
+			"data": map[string]interface{}{"total_amount": orderTotal},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	os.Setenv("ORDER_SERVICE_URL", srv.URL)
+	os.Setenv("ORDER_CALLBACK_URL", "")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	riskService := service.NewRiskService(repository.NewRiskRepository(db), testRiskConfig(), db)
+	deps := newTestPaymentDeps(db)
+	deps.RiskService = riskService
+	paymentService := service.NewPaymentService(deps)
+
+	return db, paymentService, riskService
+}
+
+func TestRiskEvaluateScoresRules(t *testing.T) {
+	db, _, riskService := setupRisk(t, 0)
+
+	allowed, err := riskService.Evaluate(context.Background(), db, domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: 500, Provider: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RiskAllow, allowed.Decision)
+	assert.Empty(t, allowed.FiredRules)
+
+	review, err := riskService.Evaluate(context.Background(), db, domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: 20_000, Provider: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RiskReview, review.Decision)
+	assert.Equal(t, []string{"amount_review_threshold"}, review.FiredRules)
+
+	denied, err := riskService.Evaluate(context.Background(), db, domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: 500, Provider: "Shady-Pay", Country: "KP"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RiskDeny, denied.Decision)
+	assert.Equal(t, 200, denied.Score)
+	assert.ElementsMatch(t, []string{"provider_blocklist", "country_blocklist"}, denied.FiredRules)
+}
+
+func TestRiskEvaluateCustomerVelocity(t *testing.T) {
+	db, _, riskService := setupRisk(t, 0)
+
+	for i := 0; i < 3; i++ {
+		payment := domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: 100, Provider: "x", Status: "failed", Attempt: 1, CustomerID: "cust-1"}
+		assert.NoError(t, db.Create(&payment).Error)
+	}
+
+	assessment, err := riskService.Evaluate(context.Background(), db, domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: 100, Provider: "x", CustomerID: "cust-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RiskReview, assessment.Decision)
+	assert.Equal(t, 90, assessment.Score)
+	assert.ElementsMatch(t, []string{"customer_velocity", "repeated_failures"}, assessment.FiredRules)
+}
+
+func TestCreateHoldsPaymentForReviewAndApproveReleasesIt(t *testing.T) {
+	_, paymentService, riskService := setupRisk(t, 20_000)
+
+	held, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 20_000, Provider: "x", CustomerID: "cust-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "held", held.Status)
+	assert.Equal(t, domain.RiskReview, held.RiskDecision)
+
+	_, err = paymentService.MarkAsSuccess(context.Background(), held.ID.String())
+	assert.EqualError(t, err, "payment already finalized")
+
+	_, err = paymentService.ApproveHeld(context.Background(), held.ID.String(), web.RiskReviewRequest{})
+	assert.Error(t, err)
+
+	approved, err := paymentService.ApproveHeld(context.Background(), held.ID.String(), web.RiskReviewRequest{Reviewer: "alice", Note: "known customer"})
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", approved.Status)
+
+	captured, err := paymentService.MarkAsSuccess(context.Background(), held.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "success", captured.Status)
+
+	assessments, err := riskService.FindAssessmentsByPaymentId(context.Background(), held.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, assessments, 2)
+	assert.Equal(t, "engine", assessments[0].Source)
+	assert.Equal(t, domain.RiskReview, assessments[0].Decision)
+	assert.Equal(t, "admin", assessments[1].Source)
+	assert.Equal(t, "alice", assessments[1].Actor)
+	assert.Equal(t, domain.RiskApprove, assessments[1].Decision)
+}
+
+func TestRejectHeldPaymentAllowsNewAttempt(t *testing.T) {
+	_, paymentService, _ := setupRisk(t, 20_000)
+	orderId := uuid.New()
+
+	held, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 20_000, Provider: "x"})
+	assert.NoError(t, err)
+
+	// A held attempt still blocks the order until it is reviewed.
+	_, err = paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 20_000, Provider: "x"})
+	assert.Error(t, err)
+
+	rejected, err := paymentService.RejectHeld(context.Background(), held.ID.String(), web.RiskReviewRequest{Reviewer: "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, "rejected", rejected.Status)
+	assert.Equal(t, domain.RiskReject, rejected.RiskDecision)
+
+	retry, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 20_000, Provider: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, retry.Attempt)
+}
+
+func TestCreateDeniedPaymentIsRecorded(t *testing.T) {
+	db, paymentService, riskService := setupRisk(t, 500)
+	orderId := uuid.New()
+
+	_, err := paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 500, Provider: "x", IPAddress: "198.51.100.7"})
+	assert.EqualError(t, err, "payment denied by risk screening: country_blocklist")
+
+	var denied domain.Payment
+	assert.NoError(t, db.Where("order_id = ?", orderId).First(&denied).Error)
+	assert.Equal(t, "denied", denied.Status)
+	assert.Equal(t, "KP", denied.Country)
+	assert.Equal(t, 100, denied.RiskScore)
+
+	assessments, err := riskService.FindAssessmentsByPaymentId(context.Background(), denied.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, assessments, 1)
+	assert.Equal(t, []string{"country_blocklist"}, assessments[0].FiredRules)
+}
+
+func TestRiskLocatesCountryByMostSpecificNetwork(t *testing.T) {
+	_, _, riskService := setupRisk(t, 500)
+
+	assert.Equal(t, "KP", riskService.Locate("198.51.100.7"))
+	assert.Equal(t, "ID", riskService.Locate("198.51.7.1"))
+	assert.Equal(t, "", riskService.Locate("203.0.113.10"))
+	assert.Equal(t, "", riskService.Locate("not-an-ip"))
+
+	_, err := config.ParseIPCountries(strings.NewReader("198.51.100.0/33,KP\n"))
+	assert.Error(t, err)
+	_, err = config.ParseIPCountries(strings.NewReader("198.51.100.0/24,Korea\n"))
+	assert.Error(t, err)
+}
+
+func TestPaymentControllerCreateDoesNotCaptureHeldPayment(t *testing.T) {
+	svc := new(MockPaymentService)
+	app := fiber.New()
+	ctrl := controller.NewPaymentController(svc)
+	app.Post("/payments", ctrl.Create)
+
+	req := web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 20_000, Provider: "x", IPAddress: testClientIP}
+	svc.On("Create", mock.Anything, req).Return(domain.Payment{ID: uuid.New(), OrderID: req.OrderID, Amount: req.Amount, Status: "held"}, nil)
+
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/payments", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := app.Test(r, -1)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	svc.AssertNotCalled(t, "MarkAsSuccess", mock.Anything, mock.Anything)
+}
+
+func TestRiskControllerApproveCapturesPayment(t *testing.T) {
+	svc := new(MockPaymentService)
+	app := fiber.New()
+	ctrl := controller.NewRiskController(nil, svc)
+	app.Put("/risk/reviews/:paymentId/approve", ctrl.Approve)
+
+	paymentId := uuid.New()
+	review := web.RiskReviewRequest{Reviewer: "alice"}
+	svc.On("ApproveHeld", mock.Anything, paymentId.String(), review).Return(domain.Payment{ID: paymentId, Status: "pending"}, nil)
+	svc.On("MarkAsSuccess", mock.Anything, paymentId.String()).Return(domain.Payment{ID: paymentId, Status: "success"}, nil)
+
+	body, _ := json.Marshal(review)
+	r := httptest.NewRequest(http.MethodPut, "/risk/reviews/"+paymentId.String()+"/approve", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := app.Test(r, -1)
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	svc.AssertExpectations(t)
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSearchService(t *testing.T) (service.PaymentService, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	repo := repository.NewPaymentRepository(db)
+	return newTestPaymentService(repo, db, validator.New()), db
+}
+
+func seedPayments(t *testing.T, db *gorm.DB) []domain.Payment {
+	base := time.Date(2026, 1, 10, 9, 0, 0, 0, time.UTC)
+	payments := []domain.Payment{
+		{ID: uuid.New(), OrderID: uuid.New(), Amount: 1000, Provider: "stripe", Status: "success", Attempt: 1, CreatedAt: base},
+		{ID: uuid.New(), OrderID: uuid.New(), Amount: 2500, Provider: "stripe", Status: "failed", Attempt: 1, CreatedAt: base.Add(time.Hour)},
+		{ID: uuid.New(), OrderID: uuid.New(), Amount: 4000, Provider: "xendit", Status: "success", Attempt: 1, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: uuid.New(), OrderID: uuid.New(), Amount: 7000, Provider: "stripe", Status: "success", Attempt: 1, CreatedAt: base.Add(24 * time.Hour)},
+	}
+	for _, payment := range payments {
+		assert.NoError(t, db.Create(&payment).Error)
+	}
+	return payments
+}
+
+func TestPaymentServiceSearchFilters(t *testing.T) {
+	svc, db := setupSearchService(t)
+	seeded := seedPayments(t, db)
+
+	got, next, err := svc.Search(context.Background(), web.PaymentSearchRequest{Provider: "stripe", Status: "success"})
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	assert.Len(t, got, 2)
+	// newest first by default
+	assert.Equal(t, seeded[3].ID, got[0].ID)
+
+	got, _, err = svc.Search(context.Background(), web.PaymentSearchRequest{MinAmount: 2000, MaxAmount: 5000, SortBy: "amount", SortOrder: "asc"})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, int64(2500), got[0].Amount)
+	assert.Equal(t, int64(4000), got[1].Amount)
+
+	got, _, err = svc.Search(context.Background(), web.PaymentSearchRequest{CreatedFrom: "2026-01-10", CreatedTo: "2026-01-10"})
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	got, _, err = svc.Search(context.Background(), web.PaymentSearchRequest{OrderID: seeded[1].OrderID.String()})
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, seeded[1].ID, got[0].ID)
+}
+
+func TestPaymentServiceSearchCursorPagination(t *testing.T) {
+	svc, db := setupSearchService(t)
+	seedPayments(t, db)
+
+	seen := map[uuid.UUID]bool{}
+	var amounts []int64
+	request := web.PaymentSearchRequest{SortBy: "amount", SortOrder: "desc", Limit: 3}
+	for page := 0; page < 3; page++ {
+		got, next, err := svc.Search(context.Background(), request)
+		assert.NoError(t, err)
+		for _, payment := range got {
+			assert.False(t, seen[payment.ID], "payment returned twice")
+			seen[payment.ID] = true
+			amounts = append(amounts, payment.Amount)
+		}
+		if next == "" {
+			break
+		}
+		request.Cursor = next
+	}
+
+	assert.Equal(t, []int64{7000, 4000, 2500, 1000}, amounts)
+}
+
+func TestPaymentServiceSearchInvalidRequest(t *testing.T) {
+	svc, _ := setupSearchService(t)
+
+	_, _, err := svc.Search(context.Background(), web.PaymentSearchRequest{CreatedFrom: "last week"})
+	assert.Error(t, err)
+
+	_, _, err = svc.Search(context.Background(), web.PaymentSearchRequest{SortBy: "provider"})
+	assert.Error(t, err)
+
+	_, _, err = svc.Search(context.Background(), web.PaymentSearchRequest{Cursor: "not-a-cursor"})
+	assert.Error(t, err)
+
+	_, _, err = svc.Search(context.Background(), web.PaymentSearchRequest{MinAmount: 5000, MaxAmount: 1000})
+	assert.Error(t, err)
+}
+
+func TestPaymentServiceExportNDJSON(t *testing.T) {
+	svc, db := setupSearchService(t)
+	seedPayments(t, db)
+
+	stream, err := svc.Export(context.Background(), web.PaymentSearchRequest{Provider: "stripe", Limit: 1})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	assert.NoError(t, helper.WritePaymentsNDJSON(writer, stream))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	for _, line := range lines {
+		var response web.PaymentResponse
+		assert.NoError(t, json.Unmarshal([]byte(line), &response))
+		assert.Equal(t, "stripe", response.Provider)
+	}
+}
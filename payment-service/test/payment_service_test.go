@@ -1,15 +1,21 @@
 package test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	"payment-service/config"
+	"payment-service/exception"
+	"payment-service/helper"
 	"payment-service/models/domain"
 	"payment-service/models/web"
+	"payment-service/repository"
 	"payment-service/service"
 
 	"github.com/go-playground/validator"
@@ -38,6 +44,14 @@ func (m *MockPaymentRepository) FindById(ctx context.Context, tx *gorm.DB, payme
 	return args.Get(0).(domain.Payment), args.Error(1)
 }
 
+func (m *MockPaymentRepository) FindByIdForUpdate(ctx context.Context, tx *gorm.DB, paymentId string) (domain.Payment, error) {
+	args := m.Called(ctx, tx, paymentId)
+	if args.Get(0) == nil {
+		return domain.Payment{}, args.Error(1)
+	}
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
 func (m *MockPaymentRepository) UpdateStatus(ctx context.Context, tx *gorm.DB, payment domain.Payment) (domain.Payment, error) {
 	args := m.Called(ctx, tx, payment)
 	return args.Get(0).(domain.Payment), args.Error(1)
@@ -51,6 +65,54 @@ func (m *MockPaymentRepository) FindOrderById(ctx context.Context, tx *gorm.DB,
 	return args.Get(0).(domain.Payment), args.Error(1)
 }
 
+func (m *MockPaymentRepository) FindActiveByOrderId(ctx context.Context, tx *gorm.DB, orderId string) (domain.Payment, error) {
+	args := m.Called(ctx, tx, orderId)
+	if args.Get(0) == nil {
+		return domain.Payment{}, args.Error(1)
+	}
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) FindActiveByInstallment(ctx context.Context, tx *gorm.DB, orderId string, installmentNumber int) (domain.Payment, error) {
+	args := m.Called(ctx, tx, orderId, installmentNumber)
+	if args.Get(0) == nil {
+		return domain.Payment{}, args.Error(1)
+	}
+	return args.Get(0).(domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) FindAllByOrderId(ctx context.Context, tx *gorm.DB, orderId string) ([]domain.Payment, error) {
+	args := m.Called(ctx, tx, orderId)
+	if args.Get(0) == nil {
+		return []domain.Payment{}, args.Error(1)
+	}
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) FindAll(ctx context.Context, tx *gorm.DB, filter repository.PaymentFilter) ([]domain.Payment, error) {
+	args := m.Called(ctx, tx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) FindByProviderReferences(ctx context.Context, tx *gorm.DB, provider string, references []string) ([]domain.Payment, error) {
+	args := m.Called(ctx, tx, provider, references)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
+func (m *MockPaymentRepository) FindPendingBankTransfers(ctx context.Context, tx *gorm.DB, provider string) ([]domain.Payment, error) {
+	args := m.Called(ctx, tx, provider)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Payment), args.Error(1)
+}
+
 func (m *MockPaymentRepository) MarkAsSuccess(ctx context.Context, tx *gorm.DB, paymentId string) error {
 	args := m.Called(ctx, tx, paymentId)
 	return args.Error(0)
@@ -61,6 +123,45 @@ func (m *MockPaymentRepository) MarkAsFailed(ctx context.Context, tx *gorm.DB, p
 	return args.Error(0)
 }
 
+// newTestPaymentService wires the payment service around the given repository, backing every
+// other collaborator with the real implementation on db.
+func newTestPaymentService(paymentRepository repository.PaymentRepository, db *gorm.DB, validate *validator.Validate) service.PaymentService {
+	deps := newTestPaymentDeps(db)
+	deps.PaymentRepository = paymentRepository
+	deps.Validate = validate
+	return service.NewPaymentService(deps)
+}
+
+// newTestPaymentDeps wires a payment service to db with empty fee, balance and payout configs and
+// no QR, bank transfer or cash on delivery providers. Tests replace what they exercise.
+func newTestPaymentDeps(db *gorm.DB) service.PaymentServiceDeps {
+	validate := validator.New()
+	return service.PaymentServiceDeps{
+		PaymentRepository:      repository.NewPaymentRepository(db),
+		PaymentEventRepository: repository.NewPaymentEventRepository(db),
+		InstallmentRepository:  repository.NewInstallmentRepository(db),
+		SplitPaymentRepository: repository.NewSplitPaymentRepository(db),
+		LedgerService:          service.NewLedgerService(repository.NewLedgerRepository(db), db),
+		RiskService:            service.NewRiskService(repository.NewRiskRepository(db), config.NewRiskConfig(), db),
+		PaymentMethodService:   newTestPaymentMethodService(db, validate),
+		FeeService:             service.NewFeeService(repository.NewFeeRepository(db), config.FeeSchedule{}, db),
+		ExchangeRateService:    service.NewExchangeRateService(repository.NewExchangeRateRepository(db), db, validate),
+		BalanceService:         service.NewBalanceService(repository.NewBalanceRepository(db), config.BalanceConfig{}, db, validate),
+		PayoutService:          service.NewPayoutService(repository.NewPayoutRepository(db), repository.NewPaymentRepository(db), config.PayoutConfig{}, db, validate),
+		DB:                     db,
+		Validate:               validate,
+	}
+}
+
+// newTestPaymentMethodService backs the vault with a fixed test key.
+func newTestPaymentMethodService(db *gorm.DB, validate *validator.Validate) service.PaymentMethodService {
+	vault, err := helper.NewVault(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		panic(err)
+	}
+	return service.NewPaymentMethodService(repository.NewPaymentMethodRepository(db), vault, db, validate)
+}
+
 // TEST SUCCESS CONDITIONS
 
 // TestPaymentServiceCreateSuccess tests creating a payment when order amount matches
@@ -80,17 +181,18 @@ func TestPaymentServiceCreateSuccess(t *testing.T) {
 
 	// setup sqlite in-memory DB for tx support
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: orderTotal, Provider: "stripe"}
 
-	// simulate no existing payment
-	mockRepo.On("FindOrderById", mock.Anything, mock.Anything, orderId.String()).Return(domain.Payment{}, assert.AnError)
+	// simulate no active payment and no earlier attempts
+	mockRepo.On("FindActiveByOrderId", mock.Anything, mock.Anything, orderId.String()).Return(domain.Payment{}, assert.AnError)
+	mockRepo.On("FindAllByOrderId", mock.Anything, mock.Anything, orderId.String()).Return([]domain.Payment{}, nil)
 
 	// expect Save to be called and return the payment
 	expected := domain.Payment{ID: uuid.New(), OrderID: orderId, Amount: orderTotal, Provider: "stripe", Status: "pending"}
@@ -115,11 +217,11 @@ func TestPaymentServiceMarkAsSuccess(t *testing.T) {
 	os.Setenv("ORDER_CALLBACK_URL", cbSrv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	paymentId := uuid.New()
 	orderId := uuid.New()
@@ -127,7 +229,7 @@ func TestPaymentServiceMarkAsSuccess(t *testing.T) {
 	updated := existing
 	updated.Status = "success"
 
-	mockRepo.On("FindById", mock.Anything, mock.Anything, paymentId.String()).Return(existing, nil)
+	mockRepo.On("FindByIdForUpdate", mock.Anything, mock.Anything, paymentId.String()).Return(existing, nil)
 	mockRepo.On("UpdateStatus", mock.Anything, mock.Anything, mock.MatchedBy(func(p domain.Payment) bool { return p.Status == "success" })).Return(updated, nil)
 
 	got, err := svc.MarkAsSuccess(context.Background(), paymentId.String())
@@ -147,18 +249,18 @@ func TestPaymentServiceMarkAsSuccess(t *testing.T) {
 // TestPaymentServiceMarkAsFailed tests successful mark-as-failed path
 func TestPaymentServiceMarkAsFailed(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	paymentId := uuid.New()
 	existing := domain.Payment{ID: paymentId, Amount: 1000, Status: "pending"}
 	updated := existing
 	updated.Status = "failed"
 
-	mockRepo.On("FindById", mock.Anything, mock.Anything, paymentId.String()).Return(existing, nil)
+	mockRepo.On("FindByIdForUpdate", mock.Anything, mock.Anything, paymentId.String()).Return(existing, nil)
 	mockRepo.On("UpdateStatus", mock.Anything, mock.Anything, mock.MatchedBy(func(p domain.Payment) bool { return p.Status == "failed" })).Return(updated, nil)
 
 	got, err := svc.MarkAsFailed(context.Background(), paymentId.String())
@@ -171,11 +273,11 @@ func TestPaymentServiceMarkAsFailed(t *testing.T) {
 // TestPaymentServiceFindById tests successful FindById
 func TestPaymentServiceFindById(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	paymentId := uuid.New()
 	expected := domain.Payment{ID: paymentId, OrderID: uuid.New(), Amount: 2000, Status: "success"}
@@ -207,11 +309,11 @@ func TestPaymentServiceCreateAmountMismatch(t *testing.T) {
 	os.Setenv("ORDER_SERVICE_URL", srv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: 1234, Provider: "x"}
@@ -236,24 +338,24 @@ func TestPaymentServiceCreateExistingPayment(t *testing.T) {
 	os.Setenv("ORDER_SERVICE_URL", srv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: orderTotal, Provider: "x"}
 
 	// Simulate existing payment found
 	existing := domain.Payment{ID: uuid.New(), OrderID: orderId, Amount: orderTotal, Status: "pending"}
-	mockRepo.On("FindOrderById", mock.Anything, mock.Anything, orderId.String()).Return(existing, nil)
+	mockRepo.On("FindActiveByOrderId", mock.Anything, mock.Anything, orderId.String()).Return(existing, nil)
 
-	// Should return the existing payment without error (idempotent behavior)
-	got, err := svc.Create(context.Background(), req)
-	assert.NoError(t, err)
-	assert.Equal(t, existing.ID, got.ID)
-	assert.Equal(t, existing.Status, got.Status)
+	// Should report a conflict carrying the existing payment
+	_, err := svc.Create(context.Background(), req)
+	var conflict exception.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.Equal(t, existing.ID, conflict.Data.(web.PaymentResponse).ID)
 
 	// Verify Save was NOT called (no new record created)
 	mockRepo.AssertNotCalled(t, "Save")
@@ -269,11 +371,11 @@ func TestPaymentServiceCreateOrderNotFound(t *testing.T) {
 	os.Setenv("ORDER_SERVICE_URL", srv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: 1000, Provider: "x"}
@@ -292,11 +394,11 @@ func TestPaymentServiceCreateOrderServiceError(t *testing.T) {
 	os.Setenv("ORDER_SERVICE_URL", srv.URL)
 
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	orderId := uuid.New()
 	req := web.PaymentCreateRequest{OrderID: orderId, Amount: 1000, Provider: "x"}
@@ -308,11 +410,11 @@ func TestPaymentServiceCreateOrderServiceError(t *testing.T) {
 // TestPaymentServiceCreateValidationError tests creating a payment with invalid request data
 func TestPaymentServiceCreateValidationError(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	// Invalid request: missing Provider
 	orderId := uuid.New()
@@ -325,21 +427,21 @@ func TestPaymentServiceCreateValidationError(t *testing.T) {
 // TestPaymentServiceMarkAsSuccessErrors tests error paths for MarkAsSuccess
 func TestPaymentServiceMarkAsSuccessErrors(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
-	// FindById error
-	mockRepo.On("FindById", mock.Anything, mock.Anything, "bad-id").Return(domain.Payment{}, assert.AnError)
+	// FindByIdForUpdate error
+	mockRepo.On("FindByIdForUpdate", mock.Anything, mock.Anything, "bad-id").Return(domain.Payment{}, assert.AnError)
 	_, err := svc.MarkAsSuccess(context.Background(), "bad-id")
 	assert.Error(t, err)
 
 	// Already finalized
 	pid := uuid.New()
 	donePayment := domain.Payment{ID: pid, Status: "success"}
-	mockRepo.On("FindById", mock.Anything, mock.Anything, pid.String()).Return(donePayment, nil)
+	mockRepo.On("FindByIdForUpdate", mock.Anything, mock.Anything, pid.String()).Return(donePayment, nil)
 	_, err = svc.MarkAsSuccess(context.Background(), pid.String())
 	assert.Error(t, err)
 }
@@ -347,21 +449,21 @@ func TestPaymentServiceMarkAsSuccessErrors(t *testing.T) {
 // TestPaymentServiceMarkAsFailedErrors tests error paths for MarkAsFailed
 func TestPaymentServiceMarkAsFailedErrors(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
-	// FindById error
-	mockRepo.On("FindById", mock.Anything, mock.Anything, "bad-id").Return(domain.Payment{}, assert.AnError)
+	// FindByIdForUpdate error
+	mockRepo.On("FindByIdForUpdate", mock.Anything, mock.Anything, "bad-id").Return(domain.Payment{}, assert.AnError)
 	_, err := svc.MarkAsFailed(context.Background(), "bad-id")
 	assert.Error(t, err)
 
 	// Already finalized
 	pid := uuid.New()
 	donePayment := domain.Payment{ID: pid, Status: "failed"}
-	mockRepo.On("FindById", mock.Anything, mock.Anything, pid.String()).Return(donePayment, nil)
+	mockRepo.On("FindByIdForUpdate", mock.Anything, mock.Anything, pid.String()).Return(donePayment, nil)
 	_, err = svc.MarkAsFailed(context.Background(), pid.String())
 	assert.Error(t, err)
 }
@@ -369,11 +471,11 @@ func TestPaymentServiceMarkAsFailedErrors(t *testing.T) {
 // TestPaymentServiceFindByIdNotFound tests FindById when payment not found
 func TestPaymentServiceFindByIdNotFound(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	mockRepo.On("FindById", mock.Anything, mock.Anything, "nonexistent-id").Return(domain.Payment{}, assert.AnError)
 
@@ -384,14 +486,53 @@ func TestPaymentServiceFindByIdNotFound(t *testing.T) {
 // TestPaymentServiceFindByIdRepoError tests FindById when repository returns error
 func TestPaymentServiceFindByIdRepoError(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&domain.Payment{})
+	config.Migrate(db)
 
 	mockRepo := new(MockPaymentRepository)
 	validate := validator.New()
-	svc := service.NewPaymentService(mockRepo, db, validate)
+	svc := newTestPaymentService(mockRepo, db, validate)
 
 	mockRepo.On("FindById", mock.Anything, mock.Anything, "error-id").Return(domain.Payment{}, assert.AnError)
 
 	_, err := svc.FindById(context.Background(), "error-id")
 	assert.Error(t, err)
 }
+
+// TestPaymentServiceCreateRetryAfterExpiry tests that an expired pending attempt is replaced by a new attempt
+func TestPaymentServiceCreateRetryAfterExpiry(t *testing.T) {
+	orderTotal := int64(4000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 200,
+			"data": map[string]interface{}{"total_amount": orderTotal},
+		})
+	}))
+	defer srv.Close()
+
+	os.Setenv("ORDER_SERVICE_URL", srv.URL)
+
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	config.Migrate(db)
+
+	mockRepo := new(MockPaymentRepository)
+	validate := validator.New()
+	svc := newTestPaymentService(mockRepo, db, validate)
+
+	orderId := uuid.New()
+	req := web.PaymentCreateRequest{OrderID: orderId, Amount: orderTotal, Provider: "x"}
+
+	expiredAt := time.Now().Add(-time.Minute)
+	stale := domain.Payment{ID: uuid.New(), OrderID: orderId, Amount: orderTotal, Status: "pending", Attempt: 1, ExpiresAt: &expiredAt}
+	mockRepo.On("FindActiveByOrderId", mock.Anything, mock.Anything, orderId.String()).Return(stale, nil)
+	mockRepo.On("UpdateStatus", mock.Anything, mock.Anything, mock.MatchedBy(func(p domain.Payment) bool { return p.ID == stale.ID && p.Status == "expired" })).Return(stale, nil)
+	mockRepo.On("FindAllByOrderId", mock.Anything, mock.Anything, orderId.String()).Return([]domain.Payment{stale}, nil)
+	mockRepo.On("Save", mock.Anything, mock.Anything, mock.MatchedBy(func(p domain.Payment) bool { return p.Attempt == 2 })).
+		Return(domain.Payment{ID: uuid.New(), OrderID: orderId, Amount: orderTotal, Status: "pending", Attempt: 2}, nil)
+
+	got, err := svc.Create(context.Background(), req)
+	assert.NoError(t, err)
+	assert.NotEqual(t, stale.ID, got.ID)
+	assert.Equal(t, 2, got.Attempt)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"payment-service/cli"
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSettlement(t *testing.T) (*gorm.DB, service.SettlementService) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	settlementService := service.NewSettlementService(repository.NewPaymentRepository(db), repository.NewSettlementRepository(db), db, validator.New())
+	return db, settlementService
+}
+
+func seedSettledPayment(t *testing.T, db *gorm.DB, reference string, amount int64, status string, paidAt time.Time) domain.Payment {
+	payment := domain.Payment{ID: uuid.New(), OrderID: uuid.New(), Amount: amount, Provider: "stripe", ProviderReference: reference, Status: status, Attempt: 1, PaidAt: &paidAt}
+	assert.NoError(t, db.Create(&payment).Error)
+	return payment
+}
+
+func resultsByReference(report domain.SettlementReport) map[string]string {
+	results := map[string]string{}
+	for _, item := range report.Items {
+		results[item.ProviderReference] = item.Result
+	}
+	return results
+}
+
+func TestSettlementImportReconciles(t *testing.T) {
+	db, settlementService := setupSettlement(t)
+	paidAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	seedSettledPayment(t, db, "ref-ok", 1000, "success", paidAt)
+	seedSettledPayment(t, db, "ref-amount", 2000, "success", paidAt)
+	seedSettledPayment(t, db, "ref-status", 3000, "refunded", paidAt)
+	seedSettledPayment(t, db, "ref-missing", 4000, "success", paidAt)
+
+	file := strings.NewReader("provider_reference,amount,status,settled_at\n" +
+		"ref-ok,1000,settled,2026-03-01\n" +
+		"ref-amount,1500,settled,2026-03-01\n" +
+		"ref-status,3000,settled,2026-03-01\n" +
+		"ref-unknown,500,settled,2026-03-01\n")
+
+	report, err := settlementService.Import(context.Background(), web.SettlementImportRequest{Provider: "stripe", SettlementDate: "2026-03-01", FileName: "stripe.csv"}, file)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.TotalLines)
+	assert.Equal(t, 1, report.MatchedCount)
+	assert.Equal(t, 4, report.ExceptionCount)
+
+	results := resultsByReference(report)
+	assert.Equal(t, domain.SettlementMatched, results["ref-ok"])
+	assert.Equal(t, domain.SettlementAmountMismatch, results["ref-amount"])
+	assert.Equal(t, domain.SettlementStatusMismatch, results["ref-status"])
+	assert.Equal(t, domain.SettlementExtra, results["ref-unknown"])
+	assert.Equal(t, domain.SettlementMissing, results["ref-missing"])
+
+	exceptions, err := settlementService.FindExceptions(context.Background(), report.ID.String(), false)
+	assert.NoError(t, err)
+	assert.Len(t, exceptions, 4)
+
+	resolved, err := settlementService.ResolveItem(context.Background(), exceptions[0].ID.String(), web.SettlementResolveRequest{Note: "confirmed with provider"})
+	assert.NoError(t, err)
+	assert.True(t, resolved.Resolved)
+
+	_, err = settlementService.ResolveItem(context.Background(), exceptions[0].ID.String(), web.SettlementResolveRequest{Note: "again"})
+	assert.Error(t, err)
+
+	exceptions, err = settlementService.FindExceptions(context.Background(), report.ID.String(), false)
+	assert.NoError(t, err)
+	assert.Len(t, exceptions, 3)
+}
+
+func TestSettlementImportRejectsInvalidFiles(t *testing.T) {
+	_, settlementService := setupSettlement(t)
+	request := web.SettlementImportRequest{Provider: "stripe", SettlementDate: "2026-03-01"}
+
+	_, err := settlementService.Import(context.Background(), request, strings.NewReader("reference,amount\nx,1\n"))
+	assert.Error(t, err)
+
+	_, err = settlementService.Import(context.Background(), request, strings.NewReader("provider_reference,amount,status\nx,abc,settled\n"))
+	assert.Error(t, err)
+
+	_, err = settlementService.Import(context.Background(), web.SettlementImportRequest{Provider: "stripe", SettlementDate: "01/03/2026"}, strings.NewReader("provider_reference,amount,status\n"))
+	assert.Error(t, err)
+}
+
+func TestSettlementControllerUpload(t *testing.T) {
+	db, settlementService := setupSettlement(t)
+	seedSettledPayment(t, db, "ref-ok", 1000, "success", time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	ctrl := controller.NewSettlementController(settlementService)
+	app := fiber.New()
+	app.Post("/settlements", ctrl.Import)
+	app.Get("/settlements/:reportId", ctrl.FindReportById)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("provider", "stripe")
+	writer.WriteField("settlement_date", "2026-03-01")
+	part, _ := writer.CreateFormFile("file", "stripe.csv")
+	part.Write([]byte("provider_reference,amount,status\nref-ok,1000,settled\n"))
+	writer.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/settlements", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, _ := app.Test(r)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	reports, err := settlementService.FindReports(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "stripe.csv", reports[0].FileName)
+
+	resp, _ = app.Test(httptest.NewRequest(http.MethodGet, "/settlements/"+reports[0].ID.String(), nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// upload without a file
+	r = httptest.NewRequest(http.MethodPost, "/settlements", strings.NewReader(""))
+	resp, _ = app.Test(r)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSettlementImportCLI(t *testing.T) {
+	db, settlementService := setupSettlement(t)
+	seedSettledPayment(t, db, "ref-ok", 1000, "success", time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	path := filepath.Join(t.TempDir(), "stripe.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("provider_reference,amount,status\nref-ok,1000,settled\nref-x,10,settled\n"), 0o600))
+
+	var out bytes.Buffer
+	err := cli.RunSettlementImport([]string{"-provider", "stripe", "-date", "2026-03-01", path}, settlementService, &out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "1 matched, 1 exceptions")
+	assert.Contains(t, out.String(), "ref-x")
+
+	err = cli.RunSettlementImport([]string{"-provider", "stripe"}, settlementService, &out)
+	assert.Error(t, err)
+}
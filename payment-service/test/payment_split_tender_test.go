@@ -0,0 +1,212 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type splitTenderFixture struct {
+	db                  *gorm.DB
+	paymentService      service.PaymentService
+	splitPaymentService service.SplitPaymentService
+	installmentService  service.InstallmentService
+	stub                *orderStub
+	callbacks           *[]web.PaymentCallbackRequest
+	app                 *fiber.App
+}
+
+// setupSplitTenders wires a split payment service and records every callback sent to the order
+// service.
+func setupSplitTenders(t *testing.T) splitTenderFixture {
+	stub := serveOrderStub(t)
+
+	callbacks := []web.PaymentCallbackRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload web.PaymentCallbackRequest
+		json.NewDecoder(r.Body).Decode(&payload)
+		callbacks = append(callbacks, payload)
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("ORDER_CALLBACK_URL", srv.URL)
+	t.Cleanup(func() { os.Setenv("ORDER_CALLBACK_URL", "") })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	installmentRepository := repository.NewInstallmentRepository(db)
+	splitPaymentRepository := repository.NewSplitPaymentRepository(db)
+	paymentMethodService := newTestPaymentMethodService(db, validate)
+	deps := newTestPaymentDeps(db)
+	deps.InstallmentRepository = installmentRepository
+	deps.SplitPaymentRepository = splitPaymentRepository
+	deps.PaymentMethodService = paymentMethodService
+	paymentService := service.NewPaymentService(deps)
+	splitPaymentService := service.NewSplitPaymentService(splitPaymentRepository, installmentRepository, paymentService, db, validate)
+	installmentConfig := config.InstallmentConfig{MinAmount: 1000, MaxCount: 6}
+	installmentService := service.NewInstallmentService(installmentRepository, paymentService, paymentMethodService, installmentConfig, db, validate)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), 0, db)
+
+	app := fiber.New()
+	routes.PaymentRoutes(app, controller.NewPaymentController(paymentService), idempotencyService)
+	routes.SplitPaymentRoutes(app, controller.NewSplitPaymentController(splitPaymentService))
+
+	return splitTenderFixture{db, paymentService, splitPaymentService, installmentService, stub, &callbacks, app}
+}
+
+func (fixture splitTenderFixture) placeOrder(total int64) uuid.UUID {
+	orderId := uuid.New()
+	fixture.stub.orders[orderId.String()] = map[string]interface{}{"Price": float64(total), "Currency": "IDR"}
+	return orderId
+}
+
+func (fixture splitTenderFixture) tender(orderId uuid.UUID, amount int64, provider string) (domain.Payment, error) {
+	return fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: amount, Provider: provider, SplitTender: true})
+}
+
+func TestSplitTenderPaysOrderAcrossTenders(t *testing.T) {
+	fixture := setupSplitTenders(t)
+	orderId := fixture.placeOrder(100_000)
+
+	status, response := postJSON(t, fixture.app, "/split-payments", map[string]interface{}{"order_id": orderId})
+	assert.Equal(t, http.StatusOK, status)
+	opened := response.Data.(map[string]interface{})
+	assert.Equal(t, domain.SplitPaymentOpen, opened["status"])
+	assert.Equal(t, float64(100_000), opened["total_amount"])
+
+	status, _ = postJSON(t, fixture.app, "/split-payments", map[string]interface{}{"order_id": orderId})
+	assert.Equal(t, http.StatusConflict, status)
+
+	// The order can no longer be paid with a single payment.
+	_, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 100_000, Provider: "stripe"})
+	assert.EqualError(t, err, "order is paid with split tenders, pass split_tender")
+
+	status, response = postJSON(t, fixture.app, "/payments", map[string]interface{}{"order_id": orderId, "amount": 40_000, "provider": "giftcard", "split_tender": true})
+	assert.Equal(t, http.StatusOK, status)
+	first := response.Data.(map[string]interface{})
+	assert.Equal(t, "success", first["status"])
+	assert.Equal(t, float64(1), first["tender_number"])
+	assert.Equal(t, "partially_paid", (*fixture.callbacks)[0].PaymentStatus)
+
+	_, err = fixture.tender(orderId, 70_000, "stripe")
+	assert.EqualError(t, err, "tender amount 70000 exceeds the 60000 left to pay on the order")
+
+	second, err := fixture.tender(orderId, 60_000, "stripe")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, second.TenderNumber)
+
+	// The pending tender already claims the rest of the total.
+	_, err = fixture.tender(orderId, 1, "stripe")
+	assert.EqualError(t, err, "tender amount 1 exceeds the 0 left to pay on the order")
+
+	_, err = fixture.paymentService.MarkAsSuccess(context.Background(), second.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, *fixture.callbacks, 2)
+	assert.Equal(t, "success", (*fixture.callbacks)[1].PaymentStatus)
+
+	resp, _ := fixture.app.Test(httptest.NewRequest(http.MethodGet, "/orders/"+orderId.String()+"/split-payment", nil), -1)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var found struct {
+		Data domain.SplitPayment `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&found)
+	assert.Equal(t, domain.SplitPaymentPaid, found.Data.Status)
+	assert.Equal(t, int64(100_000), found.Data.CapturedAmount)
+	assert.NotNil(t, found.Data.ClosedAt)
+	assert.Len(t, found.Data.Tenders, 2)
+
+	_, err = fixture.tender(orderId, 1, "stripe")
+	assert.EqualError(t, err, "split payment is already paid")
+}
+
+func TestSplitTenderFailureRefundsOtherTenders(t *testing.T) {
+	fixture := setupSplitTenders(t)
+	orderId := fixture.placeOrder(100_000)
+
+	split, err := fixture.splitPaymentService.Create(context.Background(), web.SplitPaymentCreateRequest{OrderID: orderId})
+	assert.NoError(t, err)
+
+	captured, err := fixture.tender(orderId, 40_000, "giftcard")
+	assert.NoError(t, err)
+	_, err = fixture.paymentService.MarkAsSuccess(context.Background(), captured.ID.String())
+	assert.NoError(t, err)
+	declined, err := fixture.tender(orderId, 30_000, "stripe")
+	assert.NoError(t, err)
+	pending, err := fixture.tender(orderId, 30_000, "midtrans")
+	assert.NoError(t, err)
+
+	_, err = fixture.paymentService.MarkAsFailed(context.Background(), declined.ID.String())
+	assert.NoError(t, err)
+
+	failed, err := fixture.splitPaymentService.FindById(context.Background(), split.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SplitPaymentFailed, failed.Status)
+	assert.Equal(t, "tender 2 failed", failed.FailureReason)
+	assert.Equal(t, int64(0), failed.CapturedAmount)
+	assert.Equal(t, []string{"refunded", "failed", "cancelled"}, []string{failed.Tenders[0].Status, failed.Tenders[1].Status, failed.Tenders[2].Status})
+	assert.Equal(t, pending.ID, failed.Tenders[2].ID)
+
+	var refunds []domain.JournalEntry
+	assert.NoError(t, fixture.db.Where("payment_id = ? AND type = ?", captured.ID, "refund").Find(&refunds).Error)
+	assert.Len(t, refunds, 1)
+
+	last := (*fixture.callbacks)[len(*fixture.callbacks)-1]
+	assert.Equal(t, "failed", last.PaymentStatus)
+	assert.Equal(t, declined.ID, last.PaymentID)
+
+	// A failed split payment takes no more tenders, and the order can be paid in full again.
+	_, err = fixture.tender(orderId, 10_000, "stripe")
+	assert.EqualError(t, err, "split payment is already failed")
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 100_000, Provider: "stripe"})
+	assert.NoError(t, err)
+}
+
+func TestSplitPaymentCreateRules(t *testing.T) {
+	fixture := setupSplitTenders(t)
+
+	orderId := fixture.placeOrder(100_000)
+	_, err := fixture.tender(orderId, 50_000, "stripe")
+	assert.EqualError(t, err, "order has no split payment")
+
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: orderId, Amount: 100_000, Provider: "stripe"})
+	assert.NoError(t, err)
+	_, err = fixture.splitPaymentService.Create(context.Background(), web.SplitPaymentCreateRequest{OrderID: orderId})
+	assert.EqualError(t, err, "order already has a pending payment")
+
+	installmentOrder := fixture.placeOrder(100_000)
+	_, err = fixture.installmentService.Create(context.Background(), web.InstallmentPlanCreateRequest{OrderID: installmentOrder, CustomerID: "cust-1", Provider: "stripe", Count: 2})
+	assert.NoError(t, err)
+	_, err = fixture.splitPaymentService.Create(context.Background(), web.SplitPaymentCreateRequest{OrderID: installmentOrder})
+	assert.EqualError(t, err, "order is paid in installments")
+
+	splitOrder := fixture.placeOrder(100_000)
+	_, err = fixture.splitPaymentService.Create(context.Background(), web.SplitPaymentCreateRequest{OrderID: splitOrder})
+	assert.NoError(t, err)
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: splitOrder, Amount: 50_000, Currency: "USD", Provider: "stripe", SplitTender: true})
+	assert.EqualError(t, err, "tenders are paid in IDR")
+
+	resp, _ := fixture.app.Test(httptest.NewRequest(http.MethodGet, "/split-payments/"+uuid.NewString(), nil), -1)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = fixture.app.Test(httptest.NewRequest(http.MethodGet, "/orders/not-a-uuid/split-payment", nil), -1)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
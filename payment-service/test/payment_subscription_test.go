@@ -0,0 +1,298 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/helper"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// orderStub stands in for order-service: it keeps the orders payment-service creates and serves
+// them back. While decline is set it reports a different total, so every payment is refused.
+type orderStub struct {
+	mu      sync.Mutex
+	orders  map[string]map[string]interface{}
+	decline bool
+}
+
+func serveOrderStub(t *testing.T) *orderStub {
+	stub := &orderStub{orders: map[string]map[string]interface{}{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			var order map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&order)
+			order["id"] = uuid.NewString()
+			stub.orders[order["id"].(string)] = order
+			json.NewEncoder(w).Encode(map[string]interface{}{"Data": order})
+			return
+		}
+
+		order, ok := stub.orders[strings.TrimPrefix(r.URL.Path, "/orders/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		total := int64(order["Price"].(float64))
+		if stub.decline {
+			total++
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"Data": map[string]interface{}{"total_amount": total, "currency": order["Currency"]}})
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("ORDER_SERVICE_URL", srv.URL)
+
+	return stub
+}
+
+func (stub *orderStub) setDecline(decline bool) {
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	stub.decline = decline
+}
+
+func setupSubscriptions(t *testing.T, retrySchedule ...time.Duration) (*gorm.DB, service.SubscriptionService, *orderStub, string) {
+	os.Setenv("ORDER_CALLBACK_URL", "")
+	stub := serveOrderStub(t)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	paymentMethodService := newTestPaymentMethodService(db, validate)
+	deps := newTestPaymentDeps(db)
+	deps.PaymentMethodService = paymentMethodService
+	paymentService := service.NewPaymentService(deps)
+	subscriptionService := service.NewSubscriptionService(repository.NewSubscriptionRepository(db), paymentService, paymentMethodService, config.SubscriptionConfig{RetrySchedule: retrySchedule}, db, validate)
+
+	method, err := paymentMethodService.Create(context.Background(), validCardRequest("cust-1"))
+	assert.NoError(t, err)
+
+	return db, subscriptionService, stub, method.ID.String()
+}
+
+func createPlan(t *testing.T, subscriptionService service.SubscriptionService, name string, amount int64, interval string) domain.SubscriptionPlan {
+	plan, err := subscriptionService.CreatePlan(context.Background(), web.SubscriptionPlanCreateRequest{Name: name, Amount: amount, Interval: interval})
+	assert.NoError(t, err)
+	return plan
+}
+
+func subscribe(t *testing.T, subscriptionService service.SubscriptionService, planId uuid.UUID, methodId string) domain.Subscription {
+	subscription, err := subscriptionService.Create(context.Background(), web.SubscriptionCreateRequest{CustomerID: "cust-1", PlanID: planId.String(), PaymentMethodID: methodId})
+	assert.NoError(t, err)
+	return subscription
+}
+
+func findCharges(t *testing.T, subscriptionService service.SubscriptionService, subscriptionId uuid.UUID) []domain.SubscriptionCharge {
+	charges, err := subscriptionService.FindCharges(context.Background(), subscriptionId.String())
+	assert.NoError(t, err)
+	return charges
+}
+
+func TestNextBillingDateAndProration(t *testing.T) {
+	start := time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC), helper.NextBillingDate(start, domain.IntervalMonth, 1))
+	assert.Equal(t, time.Date(2026, 2, 14, 9, 0, 0, 0, time.UTC), helper.NextBillingDate(start, domain.IntervalWeek, 2))
+	assert.Equal(t, time.Date(2027, 1, 31, 9, 0, 0, 0, time.UTC), helper.NextBillingDate(start, domain.IntervalYear, 1))
+
+	assert.Equal(t, int64(50_000), helper.Prorate(100_000, 15*24*time.Hour, 30*24*time.Hour))
+	assert.Equal(t, int64(33_334), helper.Prorate(100_001, 10*24*time.Hour, 30*24*time.Hour))
+	assert.Equal(t, int64(0), helper.Prorate(100_000, -time.Hour, 30*24*time.Hour))
+	assert.Equal(t, int64(100_000), helper.Prorate(100_000, 40*24*time.Hour, 30*24*time.Hour))
+}
+
+func TestSubscriptionBillsEveryCycle(t *testing.T) {
+	_, subscriptionService, stub, methodId := setupSubscriptions(t, time.Hour)
+	plan := createPlan(t, subscriptionService, "Pro", 99_000, domain.IntervalMonth)
+
+	subscription := subscribe(t, subscriptionService, plan.ID, methodId)
+	assert.Equal(t, domain.SubscriptionActive, subscription.Status)
+	assert.Equal(t, "stripe", subscription.Provider)
+	assert.Equal(t, subscription.CurrentPeriodStart.AddDate(0, 1, 0), *subscription.CurrentPeriodEnd)
+	assert.Equal(t, *subscription.CurrentPeriodEnd, *subscription.NextBillingAt)
+
+	charges := findCharges(t, subscriptionService, subscription.ID)
+	assert.Len(t, charges, 1)
+	assert.Equal(t, domain.ChargePaid, charges[0].Status)
+	assert.Equal(t, subscription.ID.String(), stub.orders[charges[0].OrderID.String()]["SubscriptionID"])
+
+	billed, err := subscriptionService.BillDue(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, billed)
+
+	billed, err = subscriptionService.BillDue(context.Background(), subscription.CurrentPeriodEnd.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, billed)
+
+	renewed, err := subscriptionService.FindById(context.Background(), subscription.ID.String())
+	assert.NoError(t, err)
+	assert.True(t, subscription.CurrentPeriodEnd.Equal(*renewed.CurrentPeriodStart))
+	assert.Len(t, findCharges(t, subscriptionService, subscription.ID), 2)
+}
+
+func TestSubscriptionRetriesFailedChargeThenCancels(t *testing.T) {
+	_, subscriptionService, stub, methodId := setupSubscriptions(t, time.Hour, 2*time.Hour)
+	plan := createPlan(t, subscriptionService, "Pro", 99_000, domain.IntervalMonth)
+
+	stub.setDecline(true)
+	subscription := subscribe(t, subscriptionService, plan.ID, methodId)
+	assert.Equal(t, domain.SubscriptionPastDue, subscription.Status)
+	assert.Equal(t, 1, subscription.RetryCount)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *subscription.NextBillingAt, time.Minute)
+
+	now := subscription.NextBillingAt.Add(time.Second)
+	_, err := subscriptionService.BillDue(context.Background(), now)
+	assert.NoError(t, err)
+	retried, _ := subscriptionService.FindById(context.Background(), subscription.ID.String())
+	assert.Equal(t, domain.SubscriptionPastDue, retried.Status)
+	assert.Equal(t, 2, retried.RetryCount)
+	assert.True(t, now.Add(2*time.Hour).Equal(*retried.NextBillingAt))
+
+	_, err = subscriptionService.BillDue(context.Background(), retried.NextBillingAt.Add(time.Second))
+	assert.NoError(t, err)
+	cancelled, _ := subscriptionService.FindById(context.Background(), subscription.ID.String())
+	assert.Equal(t, domain.SubscriptionCancelled, cancelled.Status)
+	assert.Nil(t, cancelled.NextBillingAt)
+
+	// Every attempt went against the same cycle charge and order.
+	charges := findCharges(t, subscriptionService, subscription.ID)
+	assert.Len(t, charges, 1)
+	assert.Equal(t, domain.ChargeFailed, charges[0].Status)
+	assert.Equal(t, 3, charges[0].Attempts)
+	assert.Contains(t, charges[0].FailureReason, "does not match order total")
+}
+
+func TestSubscriptionRetrySucceeds(t *testing.T) {
+	_, subscriptionService, stub, methodId := setupSubscriptions(t, time.Hour)
+	plan := createPlan(t, subscriptionService, "Pro", 99_000, domain.IntervalMonth)
+
+	stub.setDecline(true)
+	subscription := subscribe(t, subscriptionService, plan.ID, methodId)
+	assert.Equal(t, domain.SubscriptionPastDue, subscription.Status)
+
+	stub.setDecline(false)
+	billed, err := subscriptionService.BillDue(context.Background(), subscription.NextBillingAt.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, billed)
+
+	recovered, _ := subscriptionService.FindById(context.Background(), subscription.ID.String())
+	assert.Equal(t, domain.SubscriptionActive, recovered.Status)
+	assert.Equal(t, 0, recovered.RetryCount)
+
+	charges := findCharges(t, subscriptionService, subscription.ID)
+	assert.Len(t, charges, 1)
+	assert.Equal(t, domain.ChargePaid, charges[0].Status)
+	assert.Equal(t, 2, charges[0].Attempts)
+	// The cycle still covers the period it was first billed for.
+	assert.True(t, charges[0].PeriodEnd.Equal(*recovered.CurrentPeriodEnd))
+}
+
+func TestSubscriptionPauseResumeCancel(t *testing.T) {
+	_, subscriptionService, _, methodId := setupSubscriptions(t, time.Hour)
+	plan := createPlan(t, subscriptionService, "Pro", 99_000, domain.IntervalMonth)
+	subscription := subscribe(t, subscriptionService, plan.ID, methodId)
+
+	app := fiber.New()
+	routes.SubscriptionRoutes(app, controller.NewSubscriptionController(subscriptionService))
+	put := func(path string) (int, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodPut, "/subscriptions/"+subscription.ID.String()+path, nil))
+		assert.NoError(t, err)
+		var response web.WebResponse
+		json.NewDecoder(resp.Body).Decode(&response)
+		data, _ := response.Data.(map[string]interface{})
+		return resp.StatusCode, data
+	}
+
+	status, data := put("/pause")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, domain.SubscriptionPaused, data["status"])
+
+	billed, err := subscriptionService.BillDue(context.Background(), time.Now().AddDate(1, 0, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, billed)
+
+	status, data = put("/resume")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, domain.SubscriptionActive, data["status"])
+	assert.Equal(t, data["current_period_end"], data["next_billing_at"])
+
+	status, data = put("/cancel")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, domain.SubscriptionCancelled, data["status"])
+
+	status, _ = put("/cancel")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = put("/resume")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPut, "/subscriptions/"+uuid.NewString()+"/pause", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestSubscriptionPlanChangeProrates(t *testing.T) {
+	db, subscriptionService, _, methodId := setupSubscriptions(t, time.Hour)
+	basic := createPlan(t, subscriptionService, "Basic", 30_000, domain.IntervalMonth)
+	pro := createPlan(t, subscriptionService, "Pro", 90_000, domain.IntervalMonth)
+	yearly := createPlan(t, subscriptionService, "Yearly", 900_000, domain.IntervalYear)
+	subscription := subscribe(t, subscriptionService, basic.ID, methodId)
+
+	// Half of a 30 day cycle is left.
+	periodEnd := time.Now().Add(15 * 24 * time.Hour)
+	assert.NoError(t, db.Model(&domain.Subscription{}).Where("id = ?", subscription.ID).Updates(map[string]interface{}{
+		"current_period_start": periodEnd.Add(-30 * 24 * time.Hour),
+		"current_period_end":   periodEnd,
+		"next_billing_at":      periodEnd,
+	}).Error)
+
+	_, err := subscriptionService.ChangePlan(context.Background(), subscription.ID.String(), web.SubscriptionPlanChangeRequest{PlanID: yearly.ID.String()})
+	assert.ErrorContains(t, err, "same billing interval")
+
+	upgraded, err := subscriptionService.ChangePlan(context.Background(), subscription.ID.String(), web.SubscriptionPlanChangeRequest{PlanID: pro.ID.String()})
+	assert.NoError(t, err)
+	assert.Equal(t, pro.ID, upgraded.PlanID)
+
+	charges := findCharges(t, subscriptionService, subscription.ID)
+	assert.Len(t, charges, 2)
+	assert.Equal(t, domain.ChargeProration, charges[1].Kind)
+	assert.Equal(t, domain.ChargePaid, charges[1].Status)
+	assert.InDelta(t, 30_000, charges[1].Amount, 1)
+
+	downgraded, err := subscriptionService.ChangePlan(context.Background(), subscription.ID.String(), web.SubscriptionPlanChangeRequest{PlanID: basic.ID.String()})
+	assert.NoError(t, err)
+	assert.InDelta(t, 30_000, downgraded.Credit, 1)
+
+	// The credit pays for the next cycle of the cheaper plan.
+	_, err = subscriptionService.BillDue(context.Background(), periodEnd.Add(time.Second))
+	assert.NoError(t, err)
+	charges = findCharges(t, subscriptionService, subscription.ID)
+	renewal := charges[len(charges)-1]
+	assert.Equal(t, domain.ChargePaid, renewal.Status)
+	assert.Equal(t, int64(30_000), renewal.Amount+renewal.CreditApplied)
+	assert.InDelta(t, 30_000, renewal.CreditApplied, 1)
+}
@@ -0,0 +1,213 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"payment-service/config"
+	"payment-service/controller"
+	"payment-service/models/domain"
+	"payment-service/models/web"
+	"payment-service/repository"
+	"payment-service/routes"
+	"payment-service/service"
+
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type walletFixture struct {
+	db             *gorm.DB
+	paymentService service.PaymentService
+	balanceService service.BalanceService
+	stub           *orderStub
+	callbacks      *[]web.PaymentCallbackRequest
+	app            *fiber.App
+}
+
+// setupWallets wires wallets holding at most 500.000 with top-ups of at least 10.000, and records
+// every callback sent to the order service.
+func setupWallets(t *testing.T) walletFixture {
+	stub := serveOrderStub(t)
+
+	callbacks := []web.PaymentCallbackRequest{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload web.PaymentCallbackRequest
+		json.NewDecoder(r.Body).Decode(&payload)
+		callbacks = append(callbacks, payload)
+	}))
+	t.Cleanup(srv.Close)
+	os.Setenv("ORDER_CALLBACK_URL", srv.URL)
+	t.Cleanup(func() { os.Setenv("ORDER_CALLBACK_URL", "") })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, config.Migrate(db))
+
+	validate := validator.New()
+	balanceConfig := config.BalanceConfig{WalletMinTopUp: 10_000, WalletMaxBalance: 500_000}
+	balanceService := service.NewBalanceService(repository.NewBalanceRepository(db), balanceConfig, db, validate)
+	deps := newTestPaymentDeps(db)
+	deps.BalanceService = balanceService
+	paymentService := service.NewPaymentService(deps)
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), time.Hour, db)
+
+	app := fiber.New()
+	routes.PaymentRoutes(app, controller.NewPaymentController(paymentService), idempotencyService)
+	routes.WalletRoutes(app, controller.NewWalletController(balanceService))
+
+	return walletFixture{db, paymentService, balanceService, stub, &callbacks, app}
+}
+
+func (fixture walletFixture) placeOrder(total int64) uuid.UUID {
+	orderId := uuid.New()
+	fixture.stub.orders[orderId.String()] = map[string]interface{}{"Price": float64(total), "Currency": "IDR"}
+	return orderId
+}
+
+// topUp opens a top-up of amount and pays it with a card.
+func (fixture walletFixture) topUp(t *testing.T, customerId string, amount int64) domain.Payment {
+	topUp, err := fixture.balanceService.CreateTopUp(context.Background(), customerId, web.WalletTopUpRequest{Amount: amount})
+	assert.NoError(t, err)
+
+	payment, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: topUp.ID, Amount: amount, Provider: "stripe", TopUp: true})
+	assert.NoError(t, err)
+	paid, err := fixture.paymentService.MarkAsSuccess(context.Background(), payment.ID.String())
+	assert.NoError(t, err)
+	return paid
+}
+
+func (fixture walletFixture) walletBalance(t *testing.T, customerId string) int64 {
+	wallet, err := fixture.balanceService.FindWallet(context.Background(), customerId)
+	assert.NoError(t, err)
+	return wallet.Balance
+}
+
+func TestWalletTopUpSpendAndRefund(t *testing.T) {
+	fixture := setupWallets(t)
+
+	resp, _ := fixture.app.Test(httptest.NewRequest(http.MethodGet, "/wallets/cust-1", nil), -1)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	status, response := postJSON(t, fixture.app, "/wallets/cust-1/top-ups", map[string]interface{}{"amount": 200_000})
+	assert.Equal(t, http.StatusOK, status)
+	topUp := response.Data.(map[string]interface{})
+	assert.Equal(t, domain.TopUpPending, topUp["status"])
+	assert.Equal(t, "IDR", topUp["currency"])
+	assert.Equal(t, int64(0), fixture.walletBalance(t, "cust-1"))
+
+	// The top-up is paid like an order, with its id as the order id.
+	status, response = postJSON(t, fixture.app, "/payments", map[string]interface{}{"order_id": topUp["id"], "amount": 150_000, "provider": "stripe", "top_up": true})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "payment amount 150000 does not match order total amount 200000", response.Data)
+
+	status, response = postJSON(t, fixture.app, "/payments", map[string]interface{}{"order_id": topUp["id"], "amount": 200_000, "provider": "stripe", "top_up": true})
+	assert.Equal(t, http.StatusOK, status)
+	paidTopUp := response.Data.(map[string]interface{})
+	assert.Equal(t, "success", paidTopUp["status"])
+	assert.Equal(t, true, paidTopUp["top_up"])
+	assert.Empty(t, *fixture.callbacks)
+	assert.Equal(t, int64(200_000), fixture.walletBalance(t, "cust-1"))
+
+	_, err := fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: uuid.MustParse(topUp["id"].(string)), Amount: 200_000, Provider: "stripe", TopUp: true})
+	assert.EqualError(t, err, "top-up is already completed")
+
+	status, response = postJSON(t, fixture.app, "/payments", map[string]interface{}{"order_id": fixture.placeOrder(150_000), "amount": 150_000, "provider": "wallet", "customer_id": "cust-1"})
+	assert.Equal(t, http.StatusOK, status)
+	spent := response.Data.(map[string]interface{})
+	assert.Equal(t, "success", spent["status"])
+	assert.Equal(t, domain.BalanceWallet, spent["method"])
+	assert.Equal(t, "success", (*fixture.callbacks)[0].PaymentStatus)
+	assert.Equal(t, int64(50_000), fixture.walletBalance(t, "cust-1"))
+
+	_, err = fixture.paymentService.Create(context.Background(), web.PaymentCreateRequest{OrderID: fixture.placeOrder(60_000), Amount: 60_000, Provider: "wallet", CustomerID: "cust-1"})
+	assert.EqualError(t, err, "insufficient balance: 50000 IDR available")
+
+	// Money spent from the wallet cannot be refunded to the card that topped it up.
+	_, err = fixture.paymentService.Refund(context.Background(), paidTopUp["id"].(string))
+	assert.EqualError(t, err, "insufficient balance: 50000 IDR available")
+
+	_, err = fixture.paymentService.Refund(context.Background(), spent["id"].(string))
+	assert.NoError(t, err)
+
+	resp, _ = fixture.app.Test(httptest.NewRequest(http.MethodGet, "/wallets/cust-1", nil), -1)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var found struct {
+		Data web.WalletResponse `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&found)
+	assert.Equal(t, int64(200_000), found.Data.Balance)
+	assert.Equal(t, "cust-1", found.Data.CustomerID)
+	types := []string{}
+	for _, transaction := range found.Data.Transactions {
+		types = append(types, transaction.Type)
+	}
+	assert.Equal(t, []string{domain.BalanceTopUp, domain.BalanceRedeem, domain.BalanceReversal}, types)
+
+	// With the balance back, the top-up itself can be refunded.
+	refunded, err := fixture.paymentService.Refund(context.Background(), paidTopUp["id"].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, "refunded", refunded.Status)
+	assert.Equal(t, int64(0), fixture.walletBalance(t, "cust-1"))
+}
+
+func TestWalletRules(t *testing.T) {
+	fixture := setupWallets(t)
+	ctx := context.Background()
+
+	_, err := fixture.balanceService.CreateTopUp(ctx, "cust-1", web.WalletTopUpRequest{Amount: 5_000})
+	assert.EqualError(t, err, "top-up amount must be at least 10000")
+
+	fixture.topUp(t, "cust-1", 400_000)
+	_, err = fixture.balanceService.CreateTopUp(ctx, "cust-1", web.WalletTopUpRequest{Amount: 150_000})
+	assert.EqualError(t, err, "top-up would take the wallet balance above 500000 IDR")
+	_, err = fixture.balanceService.CreateTopUp(ctx, "cust-1", web.WalletTopUpRequest{Amount: 50_000, Currency: "USD"})
+	assert.EqualError(t, err, "wallet is in IDR")
+
+	// Top-ups opened before the wallet filled up are checked again when they are paid.
+	first, err := fixture.balanceService.CreateTopUp(ctx, "cust-1", web.WalletTopUpRequest{Amount: 60_000})
+	assert.NoError(t, err)
+	second, err := fixture.balanceService.CreateTopUp(ctx, "cust-1", web.WalletTopUpRequest{Amount: 60_000})
+	assert.NoError(t, err)
+	payment, err := fixture.paymentService.Create(ctx, web.PaymentCreateRequest{OrderID: first.ID, Amount: 60_000, Provider: "stripe", TopUp: true})
+	assert.NoError(t, err)
+	_, err = fixture.paymentService.MarkAsSuccess(ctx, payment.ID.String())
+	assert.NoError(t, err)
+	_, err = fixture.paymentService.Create(ctx, web.PaymentCreateRequest{OrderID: second.ID, Amount: 60_000, Provider: "stripe", TopUp: true})
+	assert.EqualError(t, err, "top-up would take the wallet balance above 500000 IDR")
+
+	_, err = fixture.paymentService.Create(ctx, web.PaymentCreateRequest{OrderID: second.ID, Amount: 60_000, Provider: "wallet", CustomerID: "cust-1", TopUp: true})
+	assert.EqualError(t, err, "a wallet cannot be topped up from itself")
+	_, err = fixture.paymentService.Create(ctx, web.PaymentCreateRequest{OrderID: uuid.New(), Amount: 60_000, Provider: "stripe", TopUp: true})
+	assert.EqualError(t, err, "top-up not found")
+
+	orderId := fixture.placeOrder(10_000)
+	pay := func(customerId string, currency string) error {
+		_, err := fixture.paymentService.Create(ctx, web.PaymentCreateRequest{OrderID: orderId, Amount: 10_000, Currency: currency, Provider: "wallet", CustomerID: customerId})
+		return err
+	}
+	assert.EqualError(t, pay("", ""), "customer_id is required for wallet payments")
+	assert.EqualError(t, pay("cust-3", ""), "customer has no wallet")
+
+	fixture.topUp(t, "cust-2", 10_000)
+	wallet, err := fixture.balanceService.FindWallet(ctx, "cust-2")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^WL-`, wallet.Code)
+	_, err = fixture.paymentService.Create(ctx, web.PaymentCreateRequest{OrderID: orderId, Amount: 10_000, Provider: "balance", BalanceCode: wallet.Code})
+	assert.EqualError(t, err, "wallets are paid with provider wallet")
+
+	// The database refuses a negative balance even if a debit slipped past the service checks.
+	err = fixture.db.Model(&domain.BalanceAccount{}).Where("id = ?", wallet.ID).Update("balance", -1).Error
+	assert.Error(t, err)
+	assert.NoError(t, pay("cust-2", ""))
+	assert.Equal(t, int64(0), fixture.walletBalance(t, "cust-2"))
+}
@@ -0,0 +1,22 @@
+// Package views holds the server-rendered pages of the hosted checkout.
+package views
+
+import (
+	"embed"
+	"html/template"
+)
+
+//go:embed *.html
+var files embed.FS
+
+// Templates are parsed once at startup; every page extends layout.html.
+var Templates = template.Must(template.ParseFS(files, "*.html"))
+
+// ResultPage is rendered after a checkout attempt.
+type ResultPage struct {
+	Title     string
+	Success   bool
+	Message   string
+	PaymentID string
+	Token     string
+}